@@ -0,0 +1,130 @@
+package metricsx
+
+import (
+	"context"
+	"sync"
+)
+
+// Accumulator buffers the metric writes of one unit of work, such as a
+// request, and applies them together with Flush. Middleware places it in the
+// context so handlers only count work that completed:
+//
+//	metricsx.AccumulatorFromContext(ctx).Inc(ordersPlaced, "eu")
+//
+// A nil *Accumulator writes straight through, so code recording through
+// AccumulatorFromContext works with or without the middleware.
+type Accumulator struct {
+	mu      sync.Mutex
+	entries []accumulated
+}
+
+// accumulated is one buffered write; exactly one of its metrics is set
+type accumulated struct {
+	counter  Counter
+	gauge    Gauge
+	observer interface{ Observe(float64, ...string) }
+	value    float64
+	labels   []string
+}
+
+// accumulatorKey is the context key of the Accumulator
+type accumulatorKey struct{}
+
+// NewAccumulator creates an empty Accumulator
+func NewAccumulator() *Accumulator {
+	return &Accumulator{}
+}
+
+// ContextWithAccumulator returns a copy of ctx carrying a
+func ContextWithAccumulator(ctx context.Context, a *Accumulator) context.Context {
+	return context.WithValue(ctx, accumulatorKey{}, a)
+}
+
+// AccumulatorFromContext returns the Accumulator of ctx, or nil when there is none
+func AccumulatorFromContext(ctx context.Context) *Accumulator {
+	a, _ := ctx.Value(accumulatorKey{}).(*Accumulator)
+	return a
+}
+
+// Inc buffers an increment of c by 1
+func (a *Accumulator) Inc(c Counter, labels ...string) {
+	a.Add(c, 1, labels...)
+}
+
+// Add buffers an increment of c by value
+func (a *Accumulator) Add(c Counter, value float64, labels ...string) {
+	if a == nil {
+		c.Add(value, labels...)
+		return
+	}
+	a.buffer(accumulated{counter: c, value: value, labels: labels})
+}
+
+// Set buffers setting g to value; the last Set of a series wins on Flush
+func (a *Accumulator) Set(g Gauge, value float64, labels ...string) {
+	if a == nil {
+		g.Set(value, labels...)
+		return
+	}
+	a.buffer(accumulated{gauge: g, value: value, labels: labels})
+}
+
+// Observe buffers an observation of a Histogram or Summary
+func (a *Accumulator) Observe(o interface{ Observe(float64, ...string) }, value float64, labels ...string) {
+	if a == nil {
+		o.Observe(value, labels...)
+		return
+	}
+	a.buffer(accumulated{observer: o, value: value, labels: labels})
+}
+
+// buffer appends a write
+func (a *Accumulator) buffer(e accumulated) {
+	a.mu.Lock()
+	a.entries = append(a.entries, e)
+	a.mu.Unlock()
+}
+
+// Len returns the number of buffered writes
+func (a *Accumulator) Len() int {
+	if a == nil {
+		return 0
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.entries)
+}
+
+// Flush applies the buffered writes in order and empties the Accumulator
+func (a *Accumulator) Flush() {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	entries := a.entries
+	a.entries = nil
+	a.mu.Unlock()
+
+	for _, e := range entries {
+		switch {
+		case e.counter != nil:
+			e.counter.Add(e.value, e.labels...)
+		case e.gauge != nil:
+			e.gauge.Set(e.value, e.labels...)
+		default:
+			e.observer.Observe(e.value, e.labels...)
+		}
+	}
+}
+
+// Discard drops the buffered writes
+func (a *Accumulator) Discard() {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	a.entries = nil
+	a.mu.Unlock()
+}
@@ -0,0 +1,89 @@
+package metricsx
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccumulator(t *testing.T) {
+	newMetrics := func(t *testing.T) (Metrics, Provider) {
+		res, err := NewMetrics(Params{Config: Config{Provider: "prometheus"}, Logger: getTestLogger()})
+		require.NoError(t, err)
+		return res.Metrics, res.Provider
+	}
+
+	t.Run("applies buffered writes on flush", func(t *testing.T) {
+		m, provider := newMetrics(t)
+		orders := m.Counter("orders_total", WithLabels("region"))
+		basket := m.Gauge("basket_items")
+		latency := m.Histogram("checkout_seconds")
+
+		acc := NewAccumulator()
+		acc.Inc(orders, "eu")
+		acc.Add(orders, 2, "eu")
+		acc.Set(basket, 3)
+		acc.Set(basket, 5)
+		acc.Observe(latency, 0.2)
+		assert.Equal(t, 5, acc.Len())
+		assert.NotContains(t, scrape(t, provider), `orders_total{region="eu"}`)
+
+		acc.Flush()
+		body := scrape(t, provider)
+		assert.Contains(t, body, `orders_total{region="eu"} 3`)
+		assert.Contains(t, body, "basket_items 5")
+		assert.Contains(t, body, "checkout_seconds_count 1")
+		assert.Zero(t, acc.Len())
+	})
+
+	t.Run("drops discarded writes", func(t *testing.T) {
+		m, provider := newMetrics(t)
+		orders := m.Counter("orders_total")
+
+		acc := NewAccumulator()
+		acc.Inc(orders)
+		acc.Discard()
+		acc.Flush()
+		assert.NotContains(t, scrape(t, provider), "orders_total ")
+	})
+
+	t.Run("writes through without an accumulator in context", func(t *testing.T) {
+		m, provider := newMetrics(t)
+		orders := m.Counter("orders_total")
+
+		acc := AccumulatorFromContext(context.Background())
+		assert.Nil(t, acc)
+		acc.Inc(orders)
+		acc.Flush()
+		acc.Discard()
+		assert.Zero(t, acc.Len())
+		assert.Contains(t, scrape(t, provider), "orders_total 1")
+	})
+
+	t.Run("travels in the context", func(t *testing.T) {
+		acc := NewAccumulator()
+		ctx := ContextWithAccumulator(context.Background(), acc)
+		assert.Same(t, acc, AccumulatorFromContext(ctx))
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		m, provider := newMetrics(t)
+		orders := m.Counter("orders_total")
+		acc := NewAccumulator()
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				for range 100 {
+					acc.Inc(orders)
+				}
+			})
+		}
+		wg.Wait()
+		acc.Flush()
+		assert.Contains(t, scrape(t, provider), "orders_total 800")
+	})
+}
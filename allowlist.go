@@ -0,0 +1,74 @@
+package metricsx
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+)
+
+// networkAllowlist is a compiled list of allowed client networks. A nil
+// allowlist allows every client and an empty one allows none.
+type networkAllowlist []netip.Prefix
+
+// denyAllClients is the allowlist refusing every client, used in place of an
+// invalid one so a configuration error never exposes the server
+var denyAllClients = networkAllowlist{}
+
+// newNetworkAllowlist parses CIDRs and single addresses, returning nil when
+// networks is empty
+func newNetworkAllowlist(networks []string) (networkAllowlist, error) {
+	if len(networks) == 0 {
+		return nil, nil
+	}
+
+	allowlist := make(networkAllowlist, 0, len(networks))
+	for _, network := range networks {
+		if prefix, err := netip.ParsePrefix(network); err == nil {
+			allowlist = append(allowlist, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(network)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metrics allowed network %q", network)
+		}
+		allowlist = append(allowlist, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return allowlist, nil
+}
+
+// allowed reports whether the client at remoteAddr is in the allowlist
+func (l networkAllowlist) allowed(remoteAddr string) bool {
+	if l == nil {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+	for _, prefix := range l {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// withAllowlist wraps next so only clients in the allowlist returned by
+// allowlist are served, read per request so reloads take effect immediately
+func withAllowlist(next http.Handler, allowlist func() networkAllowlist) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowlist().allowed(r.RemoteAddr) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
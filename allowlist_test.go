@@ -0,0 +1,86 @@
+package metricsx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkAllowlist(t *testing.T) {
+	t.Run("matches CIDRs and addresses", func(t *testing.T) {
+		allowlist, err := newNetworkAllowlist([]string{"10.0.0.0/8", "192.168.1.7", "fd00::/8"})
+		require.NoError(t, err)
+
+		assert.True(t, allowlist.allowed("10.1.2.3:4567"))
+		assert.True(t, allowlist.allowed("192.168.1.7:80"))
+		assert.True(t, allowlist.allowed("[::ffff:10.0.0.1]:80"))
+		assert.True(t, allowlist.allowed("[fd00::1]:80"))
+		assert.False(t, allowlist.allowed("192.168.1.8:80"))
+		assert.False(t, allowlist.allowed("garbage"))
+
+		var empty networkAllowlist
+		assert.True(t, empty.allowed("203.0.113.1:80"))
+
+		_, err = newNetworkAllowlist([]string{"10.0.0.0/33"})
+		assert.Error(t, err)
+	})
+
+	t.Run("forbids clients outside the allowlist", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{
+			Host:            "127.0.0.1",
+			Port:            RandomPort,
+			Path:            "/metrics",
+			HealthPath:      "/healthz",
+			AllowedNetworks: []string{"10.0.0.0/8"},
+		}, getTestLogger())
+
+		ctx := context.Background()
+		require.NoError(t, provider.Start(ctx))
+		defer provider.Stop(ctx)
+
+		get := func(path string) int {
+			resp, err := http.Get("http://" + provider.Addr() + path)
+			require.NoError(t, err)
+			resp.Body.Close()
+			return resp.StatusCode
+		}
+
+		assert.Equal(t, http.StatusForbidden, get("/metrics"))
+		assert.Equal(t, http.StatusOK, get("/healthz"))
+
+		cfg := Config{Provider: "prometheus", Prometheus: provider.(*prometheusProvider).config}
+		cfg.Prometheus.AllowedNetworks = []string{"127.0.0.0/8"}
+		require.NoError(t, provider.(Reloadable).Reload(cfg))
+		assert.Equal(t, http.StatusOK, get("/metrics"))
+	})
+
+	t.Run("applies to requests by remote address", func(t *testing.T) {
+		allowlist, err := newNetworkAllowlist([]string{"10.0.0.0/8"})
+		require.NoError(t, err)
+		handler := withAllowlist(http.NotFoundHandler(), func() networkAllowlist { return allowlist })
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.RemoteAddr = "10.0.0.5:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("refuses every client when the allowlist is invalid", func(t *testing.T) {
+		_, err := newPrometheusProviderFromConfig(Config{
+			Prometheus: PrometheusConfig{AllowedNetworks: []string{"not-a-network"}},
+		}, getTestLogger())
+		assert.ErrorContains(t, err, "not-a-network")
+
+		provider := newPrometheusProvider(PrometheusConfig{
+			AllowedNetworks: []string{"not-a-network"},
+		}, getTestLogger()).(*prometheusProvider)
+		allowlist := *provider.allowlist.Load()
+		assert.False(t, allowlist.allowed("127.0.0.1:80"))
+		assert.False(t, allowlist.allowed("10.0.0.5:80"))
+	})
+}
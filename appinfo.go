@@ -0,0 +1,29 @@
+package metricsx
+
+import (
+	"maps"
+	"runtime"
+)
+
+// AppInfo exposes the conventional build_info gauge, qualified with the
+// configured namespace, with value 1 and the build of the service as labels:
+// version, commit, build_date, goversion and every entry of extra. Empty
+// values are kept so the label set stays stable across builds; extra cannot
+// override the fixed labels.
+//
+// Join it in PromQL to annotate other series with the running version:
+//
+//	rate(http_requests_total[5m]) * on(instance) group_left(version) myapp_build_info
+func AppInfo(m Metrics, version, commit, buildDate string, extra map[string]string) {
+	labels := make(map[string]string, len(extra)+4)
+	maps.Copy(labels, extra)
+	labels["version"] = version
+	labels["commit"] = commit
+	labels["build_date"] = buildDate
+	labels["goversion"] = runtime.Version()
+
+	m.GaugeFunc("build_info", func() float64 { return 1 },
+		WithHelp("Build of the running service, exposed as labels."),
+		WithConstLabels(labels),
+	)
+}
@@ -0,0 +1,36 @@
+package metricsx
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppInfo(t *testing.T) {
+	t.Run("exposes build info under the namespace", func(t *testing.T) {
+		res, err := NewMetrics(Params{
+			Config: Config{Provider: "prometheus", Prometheus: PrometheusConfig{Namespace: "myapp"}},
+			Logger: getTestLogger(),
+		})
+		require.NoError(t, err)
+
+		AppInfo(res.Metrics, "1.4.2", "3f9c1e0", "2024-05-01T10:00:00Z", map[string]string{"branch": "main"})
+
+		assert.Contains(t, scrape(t, res.Provider),
+			`myapp_build_info{branch="main",build_date="2024-05-01T10:00:00Z",commit="3f9c1e0",goversion="`+runtime.Version()+`",version="1.4.2"} 1`)
+	})
+
+	t.Run("keeps fixed labels over extra", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+		m := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		AppInfo(m, "1.0.0", "", "", map[string]string{"version": "ignored"})
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `version="1.0.0"`)
+		assert.Contains(t, out, `commit=""`)
+		assert.NotContains(t, out, "ignored")
+	})
+}
@@ -0,0 +1,89 @@
+package metricsx
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// AuthConfig protects the metrics endpoint. When both basic auth and a bearer
+// token are configured, either is accepted.
+type AuthConfig struct {
+	// Username for HTTP basic auth
+	Username string `mapstructure:"username" default:""`
+
+	// Password for HTTP basic auth
+	Password string `mapstructure:"password" default:""`
+
+	// BearerToken accepted in the Authorization header
+	BearerToken string `mapstructure:"bearer_token" default:""`
+}
+
+// Enabled reports whether any authentication is configured
+func (c AuthConfig) Enabled() bool {
+	return c.basic() || c.BearerToken != ""
+}
+
+// basic reports whether basic auth is configured
+func (c AuthConfig) basic() bool {
+	return c.Username != "" || c.Password != ""
+}
+
+// sanitize returns a copy with the password and token redacted, keeping
+// whether they are set
+func (c AuthConfig) sanitize() AuthConfig {
+	if c.Password != "" {
+		c.Password = "[redacted]"
+	}
+	if c.BearerToken != "" {
+		c.BearerToken = "[redacted]"
+	}
+	return c
+}
+
+// withAuth wraps next so requests must satisfy the AuthConfig returned by
+// config, which is read per request so reloads take effect immediately
+func withAuth(next http.Handler, config func() AuthConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := config()
+		if !cfg.Enabled() || cfg.authorized(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if cfg.basic() {
+			w.Header().Set("WWW-Authenticate", `Basic realm="metrics"`)
+		} else {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	})
+}
+
+// authorized reports whether r carries valid credentials
+func (c AuthConfig) authorized(r *http.Request) bool {
+	if c.basic() {
+		if username, password, ok := r.BasicAuth(); ok &&
+			secureEqual(username, c.Username)&secureEqual(password, c.Password) == 1 {
+			return true
+		}
+	}
+
+	if c.BearerToken != "" {
+		header := r.Header.Get("Authorization")
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok && secureEqual(token, c.BearerToken) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// secureEqual compares a and b in constant time, returning 1 when they are equal.
+// Hashing first keeps the comparison independent of the secret length.
+func secureEqual(a, b string) int {
+	ha := sha256.Sum256([]byte(a))
+	hb := sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:])
+}
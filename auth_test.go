@@ -0,0 +1,55 @@
+package metricsx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuth(t *testing.T) {
+	serve := func(cfg AuthConfig, setup func(r *http.Request)) *httptest.ResponseRecorder {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics", Auth: cfg}, getTestLogger())
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if setup != nil {
+			setup(req)
+		}
+		rec := httptest.NewRecorder()
+		provider.(*prometheusProvider).Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("allows requests when auth is not configured", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(AuthConfig{}, nil).Code)
+	})
+
+	t.Run("basic auth", func(t *testing.T) {
+		cfg := AuthConfig{Username: "prom", Password: "secret"}
+
+		rec := serve(cfg, nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, `Basic realm="metrics"`, rec.Header().Get("WWW-Authenticate"))
+
+		assert.Equal(t, http.StatusUnauthorized, serve(cfg, func(r *http.Request) { r.SetBasicAuth("prom", "wrong") }).Code)
+		assert.Equal(t, http.StatusOK, serve(cfg, func(r *http.Request) { r.SetBasicAuth("prom", "secret") }).Code)
+	})
+
+	t.Run("bearer token", func(t *testing.T) {
+		cfg := AuthConfig{BearerToken: "token"}
+
+		rec := serve(cfg, func(r *http.Request) { r.Header.Set("Authorization", "Bearer other") })
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+
+		assert.Equal(t, http.StatusOK, serve(cfg, func(r *http.Request) { r.Header.Set("Authorization", "Bearer token") }).Code)
+	})
+
+	t.Run("accepts either method when both are configured", func(t *testing.T) {
+		cfg := AuthConfig{Username: "prom", Password: "secret", BearerToken: "token"}
+
+		assert.Equal(t, http.StatusOK, serve(cfg, func(r *http.Request) { r.SetBasicAuth("prom", "secret") }).Code)
+		assert.Equal(t, http.StatusOK, serve(cfg, func(r *http.Request) { r.Header.Set("Authorization", "Bearer token") }).Code)
+		assert.Equal(t, http.StatusUnauthorized, serve(cfg, func(r *http.Request) { r.SetBasicAuth("prom", "token") }).Code)
+	})
+}
@@ -0,0 +1,127 @@
+// Package awsmetrics provides an aws-sdk-go-v2 middleware that records API call metrics through metricsx.
+//
+// Register it once on the shared aws.Config and every client created from it is instrumented:
+//
+//	cfg, err := config.LoadDefaultConfig(ctx)
+//	awsmetrics.New(metrics).Register(&cfg)
+//
+// It records:
+//
+//	aws_sdk_call_duration_seconds{service, operation, status}
+//	aws_sdk_retries_total{service, operation}
+//	aws_sdk_throttles_total{service, operation}
+//
+// Call duration covers the whole operation including retries and backoff.
+package awsmetrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/gostratum/metricsx"
+)
+
+// MiddlewareID is the ID of the metrics middleware in the initialize step
+const MiddlewareID = "MetricsxCallMetrics"
+
+// Option configures the AWS SDK metrics
+type Option func(*options)
+
+// options contains configuration for the AWS SDK metrics
+type options struct {
+	buckets   []float64
+	throttles []retry.IsErrorThrottle
+}
+
+// WithBuckets sets the buckets for the call duration histogram
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// WithThrottles sets the checks used to classify attempt errors as throttling
+// (default: retry.DefaultThrottles)
+func WithThrottles(throttles ...retry.IsErrorThrottle) Option {
+	return func(o *options) {
+		o.throttles = throttles
+	}
+}
+
+// Metrics records AWS SDK call metrics
+type Metrics struct {
+	duration   metricsx.Histogram
+	retries    metricsx.Counter
+	throttles  metricsx.Counter
+	isThrottle retry.IsErrorThrottles
+}
+
+// New creates the AWS SDK metrics
+func New(m metricsx.Metrics, opts ...Option) *Metrics {
+	o := &options{
+		buckets:   metricsx.DefaultBuckets,
+		throttles: retry.DefaultThrottles,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Metrics{
+		duration: m.Histogram("aws_sdk_call_duration_seconds",
+			metricsx.WithHelp("Duration of AWS API calls including retries, in seconds."),
+			metricsx.WithLabels("service", "operation", "status"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+		retries: m.Counter("aws_sdk_retries_total",
+			metricsx.WithHelp("Total number of retried AWS API call attempts."),
+			metricsx.WithLabels("service", "operation"),
+		),
+		throttles: m.Counter("aws_sdk_throttles_total",
+			metricsx.WithHelp("Total number of AWS API call attempts rejected by throttling."),
+			metricsx.WithLabels("service", "operation"),
+		),
+		isThrottle: retry.IsErrorThrottles(o.throttles),
+	}
+}
+
+// Register adds the middleware to every client created from cfg
+func (m *Metrics) Register(cfg *aws.Config) {
+	cfg.APIOptions = append(cfg.APIOptions, m.AddMiddleware)
+}
+
+// AddMiddleware adds the metrics middleware to stack. It can be passed to a
+// single client via its APIOptions.
+func (m *Metrics) AddMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc(MiddlewareID, m.handleInitialize), middleware.After)
+}
+
+// handleInitialize times the operation and inspects its attempt results
+func (m *Metrics) handleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	service, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+	start := time.Now()
+
+	out, metadata, err := next.HandleInitialize(ctx, in)
+
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	m.duration.Observe(time.Since(start).Seconds(), service, operation, status)
+
+	if results, ok := retry.GetAttemptResults(metadata); ok {
+		if n := len(results.Results); n > 1 {
+			m.retries.Add(float64(n-1), service, operation)
+		}
+		for _, result := range results.Results {
+			if result.Err != nil && m.isThrottle.IsErrorThrottle(result.Err) == aws.TrueTernary {
+				m.throttles.Inc(service, operation)
+			}
+		}
+	}
+
+	return out, metadata, err
+}
@@ -0,0 +1,98 @@
+package awsmetrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// invoke runs a fake operation through a stack with the SDK retry middleware
+// and the metrics middleware; errs are returned by successive attempts
+func invoke(t *testing.T, am *Metrics, errs ...error) error {
+	t.Helper()
+
+	stack := middleware.NewStack("test", smithyhttp.NewStackRequest)
+	require.NoError(t, stack.Initialize.Add(middleware.InitializeMiddlewareFunc("ServiceMetadata",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			ctx = awsmiddleware.SetServiceID(ctx, "SQS")
+			ctx = awsmiddleware.SetOperationName(ctx, "SendMessage")
+			return next.HandleInitialize(ctx, in)
+		}), middleware.Before))
+	retryer := retry.NewStandard(func(o *retry.StandardOptions) {
+		o.MaxAttempts = 5
+		o.RateLimiter = ratelimit.None
+		o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+	})
+	require.NoError(t, stack.Finalize.Add(retry.NewAttemptMiddleware(retryer, smithyhttp.RequestCloner), middleware.After))
+	require.NoError(t, am.AddMiddleware(stack))
+
+	attempt := 0
+	handler := middleware.DecorateHandler(middleware.HandlerFunc(func(ctx context.Context, in any) (any, middleware.Metadata, error) {
+		var err error
+		if attempt < len(errs) {
+			err = errs[attempt]
+		}
+		attempt++
+		return nil, middleware.Metadata{}, err
+	}), stack)
+
+	_, _, err := handler.Handle(context.Background(), struct{}{})
+	return err
+}
+
+func TestMiddleware(t *testing.T) {
+	throttled := &smithy.GenericAPIError{Code: "Throttling", Message: "Rate exceeded"}
+
+	t.Run("records successful calls", func(t *testing.T) {
+		m := metricstest.New()
+		require.NoError(t, invoke(t, New(m)))
+
+		out := m.String()
+		assert.Contains(t, out, `aws_sdk_call_duration_seconds_count{operation="SendMessage",service="SQS",status="ok"} 1`)
+		assert.NotContains(t, out, "aws_sdk_retries_total{")
+	})
+
+	t.Run("records retries and throttles", func(t *testing.T) {
+		m := metricstest.New()
+		require.NoError(t, invoke(t, New(m), throttled, throttled))
+
+		out := m.String()
+		assert.Contains(t, out, `aws_sdk_call_duration_seconds_count{operation="SendMessage",service="SQS",status="ok"} 1`)
+		assert.Contains(t, out, `aws_sdk_retries_total{operation="SendMessage",service="SQS"} 2`)
+		assert.Contains(t, out, `aws_sdk_throttles_total{operation="SendMessage",service="SQS"} 2`)
+	})
+
+	t.Run("records failed calls", func(t *testing.T) {
+		m := metricstest.New()
+		require.Error(t, invoke(t, New(m), errors.New("access denied")))
+
+		out := m.String()
+		assert.Contains(t, out, `aws_sdk_call_duration_seconds_count{operation="SendMessage",service="SQS",status="error"} 1`)
+		assert.NotContains(t, out, "aws_sdk_throttles_total{")
+	})
+}
+
+func TestRegister(t *testing.T) {
+	m := metricstest.New()
+
+	cfg := aws.Config{}
+	New(m).Register(&cfg)
+
+	require.Len(t, cfg.APIOptions, 1)
+	stack := middleware.NewStack("test", smithyhttp.NewStackRequest)
+	require.NoError(t, cfg.APIOptions[0](stack))
+	_, ok := stack.Initialize.Get(MiddlewareID)
+	assert.True(t, ok)
+}
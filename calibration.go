@@ -0,0 +1,239 @@
+package metricsx
+
+import (
+	"cmp"
+	"encoding/json"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beorn7/perks/quantile"
+	"github.com/gostratum/core/logx"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultCalibrationWindow is the calibration window used when
+// BucketCalibrationConfig.Window is zero
+const DefaultCalibrationWindow = 10 * time.Minute
+
+// calibrationQuantiles are the quantiles of the observed distribution that
+// become suggested bucket boundaries
+var calibrationQuantiles = []float64{0.1, 0.25, 0.5, 0.75, 0.9, 0.95, 0.99, 0.999}
+
+// BucketCalibrationConfig records the distribution of every histogram for a
+// window after start and suggests bucket boundaries from it
+type BucketCalibrationConfig struct {
+	// Enabled turns calibration on; it costs a quantile stream update per
+	// observation until the window ends
+	Enabled bool `mapstructure:"enabled" default:"false"`
+
+	// Window is how long observations are recorded before the suggestions are logged
+	Window time.Duration `mapstructure:"window" default:"10m"`
+
+	// Path serves the current suggestions as JSON, e.g. /metrics/buckets.
+	// Empty disables it.
+	Path string `mapstructure:"path" default:""`
+}
+
+// BucketSuggestion is the suggested buckets of one histogram
+type BucketSuggestion struct {
+	// Metric is the fully qualified name of the histogram
+	Metric string `json:"metric"`
+
+	// Samples is the number of observations recorded
+	Samples int `json:"samples"`
+
+	// Current are the configured buckets
+	Current []float64 `json:"current"`
+
+	// Suggested are boundaries at the 10th to 99.9th percentiles of the
+	// observations, rounded to two significant digits
+	Suggested []float64 `json:"suggested"`
+}
+
+// bucketCalibrator records the observations of every histogram during the
+// calibration window
+type bucketCalibrator struct {
+	logger logx.Logger
+	done   atomic.Bool
+
+	mu         sync.Mutex
+	histograms map[string]*histogramCalibration
+}
+
+// histogramCalibration is the observed distribution of one histogram
+type histogramCalibration struct {
+	metric  string
+	current []float64
+
+	mu     sync.Mutex
+	stream *quantile.Stream
+}
+
+// newBucketCalibrator starts a calibrator that logs its suggestions when the
+// window ends, or returns nil when calibration is disabled
+func newBucketCalibrator(cfg BucketCalibrationConfig, logger logx.Logger) *bucketCalibrator {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	c := &bucketCalibrator{
+		logger:     logger,
+		histograms: make(map[string]*histogramCalibration),
+	}
+	time.AfterFunc(cmp.Or(cfg.Window, DefaultCalibrationWindow), c.finish)
+	return c
+}
+
+// track returns the calibration of the histogram metric with buckets, or nil
+// when c is nil
+func (c *bucketCalibrator) track(metric string, buckets []float64) *histogramCalibration {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h, ok := c.histograms[metric]
+	if !ok {
+		h = &histogramCalibration{
+			metric:  metric,
+			current: buckets,
+			stream:  quantile.NewTargeted(calibrationTargets()),
+		}
+		c.histograms[metric] = h
+	}
+	return h
+}
+
+// calibrationTargets returns the CKMS objectives for calibrationQuantiles
+func calibrationTargets() map[float64]float64 {
+	targets := make(map[float64]float64, len(calibrationQuantiles))
+	for _, q := range calibrationQuantiles {
+		targets[q] = (1 - q) / 10
+	}
+	return targets
+}
+
+// observer wraps o so its observations are recorded until the window ends
+func (c *bucketCalibrator) observer(h *histogramCalibration, o prometheus.Observer) prometheus.Observer {
+	return &calibratingObserver{Observer: o, calibrator: c, calibration: h}
+}
+
+// finish stops recording and logs the suggestions
+func (c *bucketCalibrator) finish() {
+	c.done.Store(true)
+	for _, s := range c.suggestions() {
+		c.logger.Info("suggested histogram buckets",
+			logx.String("metric", s.Metric),
+			logx.Int("samples", s.Samples),
+			logx.Any("current", s.Current),
+			logx.Any("suggested", s.Suggested),
+		)
+	}
+}
+
+// suggestions returns the suggested buckets of every histogram with
+// observations, ordered by metric name
+func (c *bucketCalibrator) suggestions() []BucketSuggestion {
+	c.mu.Lock()
+	histograms := make([]*histogramCalibration, 0, len(c.histograms))
+	for _, h := range c.histograms {
+		histograms = append(histograms, h)
+	}
+	c.mu.Unlock()
+
+	suggestions := make([]BucketSuggestion, 0, len(histograms))
+	for _, h := range histograms {
+		if s, ok := h.suggest(); ok {
+			suggestions = append(suggestions, s)
+		}
+	}
+	slices.SortFunc(suggestions, func(a, b BucketSuggestion) int {
+		return cmp.Compare(a.Metric, b.Metric)
+	})
+	return suggestions
+}
+
+// handler serves the suggestions as JSON
+func (c *bucketCalibrator) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(c.suggestions())
+	})
+}
+
+// observe records value
+func (h *histogramCalibration) observe(value float64) {
+	h.mu.Lock()
+	h.stream.Insert(value)
+	h.mu.Unlock()
+}
+
+// suggest returns the suggestion for the histogram, or false without observations
+func (h *histogramCalibration) suggest() (BucketSuggestion, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := h.stream.Count()
+	if n == 0 {
+		return BucketSuggestion{}, false
+	}
+
+	suggested := make([]float64, 0, len(calibrationQuantiles))
+	for _, q := range calibrationQuantiles {
+		suggested = append(suggested, roundSignificant(h.stream.Query(q), 2))
+	}
+	slices.Sort(suggested)
+
+	current := h.current
+	if len(current) == 0 {
+		current = prometheus.DefBuckets
+	}
+	return BucketSuggestion{
+		Metric:    h.metric,
+		Samples:   n,
+		Current:   current,
+		Suggested: slices.Compact(suggested),
+	}, true
+}
+
+// roundSignificant rounds v to digits significant digits
+func roundSignificant(v float64, digits int) float64 {
+	if v == 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return v
+	}
+	scale := math.Pow(10, float64(digits)-math.Ceil(math.Log10(math.Abs(v))))
+	return math.Round(v*scale) / scale
+}
+
+// calibratingObserver records observations in a calibration before
+// forwarding them to the histogram
+type calibratingObserver struct {
+	prometheus.Observer
+	calibrator  *bucketCalibrator
+	calibration *histogramCalibration
+}
+
+func (o *calibratingObserver) Observe(value float64) {
+	if !o.calibrator.done.Load() {
+		o.calibration.observe(value)
+	}
+	o.Observer.Observe(value)
+}
+
+// ObserveWithExemplar keeps exemplars working while calibrating
+func (o *calibratingObserver) ObserveWithExemplar(value float64, exemplar prometheus.Labels) {
+	if !o.calibrator.done.Load() {
+		o.calibration.observe(value)
+	}
+	if e, ok := o.Observer.(prometheus.ExemplarObserver); ok {
+		e.ObserveWithExemplar(value, exemplar)
+		return
+	}
+	o.Observer.Observe(value)
+}
@@ -0,0 +1,88 @@
+package metricsx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketCalibration(t *testing.T) {
+	newProvider := func(t *testing.T, cfg BucketCalibrationConfig) *prometheusProvider {
+		res, err := NewMetrics(Params{
+			Config: Config{Provider: "prometheus", Prometheus: PrometheusConfig{Path: "/metrics", BucketCalibration: cfg}},
+			Logger: getTestLogger(),
+		})
+		require.NoError(t, err)
+		return res.Provider.(*prometheusProvider)
+	}
+
+	t.Run("suggests buckets from observed values", func(t *testing.T) {
+		provider := newProvider(t, BucketCalibrationConfig{Enabled: true, Window: time.Hour})
+
+		histogram := provider.Histogram("request_duration_seconds", applyOptions(WithLabels("method")))
+		for i := 1; i <= 1000; i++ {
+			histogram.Observe(float64(i)/1000, "GET")
+		}
+		timer := histogram.Timer("POST")
+		timer.ObserveDuration()
+
+		suggestions := provider.calibrator.suggestions()
+		require.Len(t, suggestions, 1)
+		s := suggestions[0]
+		assert.Equal(t, "request_duration_seconds", s.Metric)
+		assert.Equal(t, 1001, s.Samples)
+		assert.Equal(t, DefaultBuckets, s.Current)
+		assert.IsIncreasing(t, s.Suggested)
+		assert.InDelta(t, 0.5, s.Suggested[2], 0.05)
+		assert.Contains(t, scrape(t, provider), `request_duration_seconds_count{method="GET"} 1000`)
+	})
+
+	t.Run("stops recording when the window ends", func(t *testing.T) {
+		provider := newProvider(t, BucketCalibrationConfig{Enabled: true, Window: time.Hour})
+		histogram := provider.Histogram("job_duration_seconds", applyOptions())
+
+		histogram.Observe(1)
+		provider.calibrator.finish()
+		histogram.Observe(2)
+
+		suggestions := provider.calibrator.suggestions()
+		require.Len(t, suggestions, 1)
+		assert.Equal(t, 1, suggestions[0].Samples)
+		assert.Equal(t, []float64{1}, suggestions[0].Suggested)
+	})
+
+	t.Run("serves suggestions as json", func(t *testing.T) {
+		provider := newProvider(t, BucketCalibrationConfig{Enabled: true, Window: time.Hour, Path: "/metrics/buckets"})
+		provider.Histogram("job_duration_seconds", applyOptions()).Observe(0.3)
+
+		handler, ok := provider.Handlers()["/metrics/buckets"]
+		require.True(t, ok)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics/buckets", nil))
+		var suggestions []BucketSuggestion
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &suggestions))
+		require.Len(t, suggestions, 1)
+		assert.Equal(t, []float64{0.3}, suggestions[0].Suggested)
+	})
+
+	t.Run("leaves histograms untouched when disabled", func(t *testing.T) {
+		provider := newProvider(t, BucketCalibrationConfig{})
+		assert.Nil(t, provider.calibrator)
+
+		histogram := provider.Histogram("job_duration_seconds", applyOptions())
+		histogram.Observe(1)
+		assert.Contains(t, scrape(t, provider), "job_duration_seconds_count 1")
+	})
+}
+
+func TestRoundSignificant(t *testing.T) {
+	assert.Equal(t, 0.0012, roundSignificant(0.0012345, 2))
+	assert.Equal(t, 250.0, roundSignificant(247, 2))
+	assert.Equal(t, 0.0, roundSignificant(0, 2))
+}
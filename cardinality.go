@@ -0,0 +1,95 @@
+package metricsx
+
+import (
+	"sync"
+)
+
+// OverflowLabel is added to metrics with a series limit. Series created after
+// the limit is reached are recorded with OverflowLabel="true" and every other
+// label empty.
+const OverflowLabel = "other"
+
+// CardinalityConfig limits the number of label combinations per metric
+type CardinalityConfig struct {
+	// MaxSeries is the default limit of label combinations per metric (0 = unlimited)
+	MaxSeries int `mapstructure:"max_series" default:"0"`
+
+	// Limits overrides MaxSeries for individual metric names
+	Limits map[string]int `mapstructure:"limits"`
+}
+
+// limit returns the series limit for the metric name, preferring the per-metric option
+func (c CardinalityConfig) limit(name string, options *Options) int {
+	if options.MaxSeries > 0 {
+		return options.MaxSeries
+	}
+	if limit, ok := c.Limits[name]; ok {
+		return limit
+	}
+	return c.MaxSeries
+}
+
+// seriesLimiter admits up to max label combinations and routes the rest to the overflow series
+type seriesLimiter struct {
+	metric   string
+	max      int
+	inputs   int
+	overflow []string
+	dropped  Counter
+
+	mu   sync.RWMutex
+	seen map[string]struct{}
+}
+
+// newSeriesLimiter creates a limiter for a metric with the given number of labels
+func newSeriesLimiter(metric string, max, inputs int, dropped Counter) *seriesLimiter {
+	overflow := make([]string, inputs+1)
+	overflow[inputs] = "true"
+
+	return &seriesLimiter{
+		metric:   metric,
+		max:      max,
+		inputs:   inputs,
+		overflow: overflow,
+		dropped:  dropped,
+		seen:     make(map[string]struct{}),
+	}
+}
+
+// apply returns the provider label values, including the overflow label. Values
+// of the wrong length are passed through so the provider reports the mismatch.
+func (l *seriesLimiter) apply(values []string) []string {
+	if len(values) != l.inputs {
+		return values
+	}
+
+	var buf [128]byte
+	if l.admit(appendChildKey(buf[:0], values)) {
+		return append(values[:len(values):len(values)], "")
+	}
+
+	l.dropped.Inc(l.metric)
+	return l.overflow
+}
+
+// admit reports whether the series key is or can become one of the tracked series
+func (l *seriesLimiter) admit(key []byte) bool {
+	l.mu.RLock()
+	_, ok := l.seen[string(key)]
+	l.mu.RUnlock()
+	if ok {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.seen[string(key)]; ok {
+		return true
+	}
+	if len(l.seen) >= l.max {
+		return false
+	}
+	l.seen[string(key)] = struct{}{}
+	return true
+}
@@ -0,0 +1,105 @@
+package metricsx
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardinalityLimits(t *testing.T) {
+	newMetrics := func(t *testing.T, cfg CardinalityConfig, rules ...LabelRule) (Metrics, Provider) {
+		t.Helper()
+
+		res, err := NewMetrics(Params{
+			Config: Config{
+				Enabled:     true,
+				Provider:    "prometheus",
+				LabelRules:  rules,
+				Cardinality: cfg,
+				Prometheus:  PrometheusConfig{Path: "/metrics"},
+			},
+			Logger: getTestLogger(),
+		})
+		require.NoError(t, err)
+		return res.Metrics, res.Provider
+	}
+
+	t.Run("records new series past the limit in the overflow series", func(t *testing.T) {
+		m, provider := newMetrics(t, CardinalityConfig{MaxSeries: 2})
+
+		counter := m.Counter("requests_total", WithLabels("path"))
+		for i := range 5 {
+			counter.Inc(fmt.Sprintf("/item/%d", i))
+		}
+		counter.Inc("/item/0")
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `requests_total{other="",path="/item/0"} 2`)
+		assert.Contains(t, out, `requests_total{other="",path="/item/1"} 1`)
+		assert.Contains(t, out, `requests_total{other="true",path=""} 3`)
+		assert.NotContains(t, out, `path="/item/2"`)
+		assert.Contains(t, out, `metricsx_series_overflow_total{metric="requests_total"} 3`)
+	})
+
+	t.Run("shares the limit between handles of the same metric", func(t *testing.T) {
+		m, provider := newMetrics(t, CardinalityConfig{MaxSeries: 1})
+
+		m.Gauge("workers", WithLabels("pool")).Set(1, "a")
+		m.Gauge("workers", WithLabels("pool")).Set(2, "b")
+
+		assert.Contains(t, scrape(t, provider), `workers{other="true",pool=""} 2`)
+	})
+
+	t.Run("prefers per-metric limits", func(t *testing.T) {
+		m, provider := newMetrics(t, CardinalityConfig{MaxSeries: 1, Limits: map[string]int{"latency_seconds": 0}})
+
+		histogram := m.Histogram("latency_seconds", WithLabels("route"))
+		histogram.Observe(1, "/a")
+		histogram.Observe(1, "/b")
+
+		summary := m.Summary("size_bytes", WithLabels("route"), WithMaxSeries(2))
+		summary.Observe(1, "/a")
+		summary.Observe(1, "/b")
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `latency_seconds_count{route="/b"} 1`)
+		assert.Contains(t, out, `size_bytes_count{other="",route="/b"} 1`)
+		assert.NotContains(t, out, "metricsx_series_overflow_total{")
+	})
+
+	t.Run("limits series after label rules", func(t *testing.T) {
+		m, provider := newMetrics(t, CardinalityConfig{MaxSeries: 1}, LabelRule{Label: "user_id"})
+
+		counter := m.Counter("logins_total", WithLabels("user_id", "method"))
+		counter.Inc("u1", "password")
+		counter.Inc("u2", "password")
+
+		assert.Contains(t, scrape(t, provider), `logins_total{method="password",other=""} 2`)
+	})
+
+	t.Run("limits metrics by fully qualified name", func(t *testing.T) {
+		res, err := NewMetrics(Params{
+			Config: Config{
+				Enabled:          true,
+				Provider:         "prometheus",
+				Cardinality:      CardinalityConfig{MaxSeries: 1},
+				AddInstanceLabel: true,
+				InstanceID:       "pod-1",
+				Prometheus:       PrometheusConfig{Path: "/metrics", Namespace: "shop"},
+			},
+			Logger: getTestLogger(),
+		})
+		require.NoError(t, err)
+
+		res.Metrics.Counter("jobs_total", WithLabels("queue")).Inc("a")
+		res.Metrics.Counter("jobs_total", WithLabels("queue"), WithNamespace("batch")).Inc("b")
+		res.Metrics.Counter("jobs_total", WithLabels("queue"), WithNamespace("shop")).Inc("c")
+
+		out := scrape(t, res.Provider)
+		assert.Contains(t, out, `shop_jobs_total{instance="pod-1",other="",queue="a"} 1`)
+		assert.Contains(t, out, `batch_jobs_total{instance="pod-1",other="",queue="b"} 1`)
+		assert.Contains(t, out, `shop_metricsx_series_overflow_total{instance="pod-1",metric="shop_jobs_total"} 1`)
+	})
+}
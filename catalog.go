@@ -0,0 +1,109 @@
+package metricsx
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Cataloger is implemented by providers that can list their declared metrics
+type Cataloger interface {
+	// Catalog returns every declared metric, sorted by name and type
+	Catalog() []MetricSchema
+}
+
+// Catalog returns every metric declared with the provider, including metrics
+// that have no series yet
+func (p *prometheusProvider) Catalog() []MetricSchema {
+	return sortCatalog(p.metrics.schemas())
+}
+
+// Catalog returns every metric registered in dry-run mode
+func (p *dryRunProvider) Catalog() []MetricSchema {
+	return DryRunReport(p)
+}
+
+// Catalog returns the metrics declared with every member that can list them
+func (p *fanoutProvider) Catalog() []MetricSchema {
+	var schemas []MetricSchema
+	for _, provider := range p.providers {
+		if c, ok := provider.(Cataloger); ok {
+			schemas = append(schemas, c.Catalog()...)
+		}
+	}
+	return slices.CompactFunc(sortCatalog(schemas), func(a, b MetricSchema) bool {
+		return a.Name == b.Name && a.Type == b.Type
+	})
+}
+
+// sortCatalog sorts schemas by name and type
+func sortCatalog(schemas []MetricSchema) []MetricSchema {
+	slices.SortFunc(schemas, func(a, b MetricSchema) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Type, b.Type))
+	})
+	return schemas
+}
+
+// WriteCatalogJSON writes schemas as an indented JSON array
+func WriteCatalogJSON(w io.Writer, schemas []MetricSchema) error {
+	if schemas == nil {
+		schemas = []MetricSchema{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schemas)
+}
+
+// WriteCatalogMarkdown writes schemas as a Markdown table for a metrics
+// reference page
+func WriteCatalogMarkdown(w io.Writer, schemas []MetricSchema) error {
+	var b strings.Builder
+	b.WriteString("| Name | Type | Help | Labels | Buckets |\n")
+	b.WriteString("|------|------|------|--------|---------|\n")
+	for _, s := range schemas {
+		labels := make([]string, 0, len(s.Labels)+len(s.ConstLabels))
+		for _, label := range s.Labels {
+			labels = append(labels, "`"+label+"`")
+		}
+		for _, name := range slices.Sorted(maps.Keys(s.ConstLabels)) {
+			labels = append(labels, fmt.Sprintf("`%s=%q`", name, s.ConstLabels[name]))
+		}
+
+		buckets := make([]string, 0, len(s.Buckets))
+		for _, bucket := range s.Buckets {
+			buckets = append(buckets, strconv.FormatFloat(bucket, 'g', -1, 64))
+		}
+
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n",
+			s.Name, s.Type, markdownCell(s.Help), strings.Join(labels, ", "), strings.Join(buckets, ", "))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes text for a Markdown table cell
+func markdownCell(text string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(text)
+}
+
+// catalogHandler serves the catalog of provider as JSON, or as Markdown for
+// ?format=markdown
+func catalogHandler(provider Cataloger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		schemas := provider.Catalog()
+		if r.URL.Query().Get("format") == "markdown" {
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			WriteCatalogMarkdown(w, schemas)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		WriteCatalogJSON(w, schemas)
+	})
+}
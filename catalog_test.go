@@ -0,0 +1,80 @@
+package metricsx
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog(t *testing.T) {
+	declare := func(m Metrics) {
+		m.Counter("orders_total", WithHelp("Total orders | all regions."), WithLabels("region"))
+		m.Histogram("order_duration_seconds", WithHelp("Order duration."), WithBuckets(0.1, 1))
+		m.GaugeFunc("queue_depth", func() float64 { return 0 }, WithConstLabels(map[string]string{"queue": "orders"}))
+	}
+
+	t.Run("lists declared metrics of the prometheus provider", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: Config{Provider: "prometheus", Prometheus: PrometheusConfig{Namespace: "shop"}}, Logger: getTestLogger()})
+		require.NoError(t, err)
+		declare(res.Metrics)
+
+		catalog := res.Provider.(Cataloger).Catalog()
+		require.Len(t, catalog, 3)
+		assert.Equal(t, "shop_order_duration_seconds", catalog[0].Name)
+		assert.Equal(t, []float64{0.1, 1}, catalog[0].Buckets)
+		assert.Equal(t, "shop_orders_total", catalog[1].Name)
+		assert.Equal(t, []string{"region"}, catalog[1].Labels)
+		assert.Equal(t, "shop_queue_depth", catalog[2].Name)
+	})
+
+	t.Run("lists metrics of dry-run and fanout providers", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: Config{Provider: "prometheus", DryRun: true}, Logger: getTestLogger()})
+		require.NoError(t, err)
+		declare(res.Metrics)
+		assert.Len(t, res.Provider.(Cataloger).Catalog(), 3)
+
+		res, err = NewMetrics(Params{Config: Config{Provider: "fanout", Fanout: FanoutConfig{Providers: []string{"prometheus", "noop"}}}, Logger: getTestLogger()})
+		require.NoError(t, err)
+		declare(res.Metrics)
+		assert.Len(t, res.Provider.(Cataloger).Catalog(), 3)
+	})
+
+	t.Run("writes markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteCatalogMarkdown(&buf, []MetricSchema{
+			{Name: "orders_total", Type: "counter", Help: "Total orders | all regions.", Labels: []string{"region"}},
+			{Name: "queue_depth", Type: "gauge", ConstLabels: map[string]string{"queue": "orders"}},
+			{Name: "order_duration_seconds", Type: "histogram", Buckets: []float64{0.1, 1}},
+		}))
+
+		assert.Equal(t, "| Name | Type | Help | Labels | Buckets |\n"+
+			"|------|------|------|--------|---------|\n"+
+			"| `orders_total` | counter | Total orders \\| all regions. | `region` |  |\n"+
+			"| `queue_depth` | gauge |  | `queue=\"orders\"` |  |\n"+
+			"| `order_duration_seconds` | histogram |  |  | 0.1, 1 |\n", buf.String())
+	})
+
+	t.Run("serves the catalog", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: Config{Provider: "prometheus", Prometheus: PrometheusConfig{Path: "/metrics", CatalogPath: "/metrics/catalog"}}, Logger: getTestLogger()})
+		require.NoError(t, err)
+		declare(res.Metrics)
+		handler := res.Provider.(multiHandler).Handlers()["/metrics/catalog"]
+		require.NotNil(t, handler)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics/catalog", nil))
+		var catalog []MetricSchema
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &catalog))
+		assert.Len(t, catalog, 3)
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics/catalog?format=markdown", nil))
+		assert.Equal(t, "text/markdown; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Body.String(), "| `orders_total` | counter |")
+	})
+}
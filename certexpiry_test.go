@@ -0,0 +1,104 @@
+package metricsx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// certificateExpiries gathers tls_certificate_expiry_seconds from p keyed by
+// source and subject
+func certificateExpiries(t *testing.T, p *prometheusProvider) map[string]float64 {
+	t.Helper()
+
+	mfs, err := p.registry.Gather()
+	require.NoError(t, err)
+
+	values := make(map[string]float64)
+	for _, mf := range mfs {
+		if mf.GetName() != "tls_certificate_expiry_seconds" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			values[labels["source"]+" "+labels["subject"]] = m.GetGauge().GetValue()
+		}
+	}
+	return values
+}
+
+func TestRegisterCertificateExpiry(t *testing.T) {
+	ca := newTestCA(t)
+
+	t.Run("exports every certificate of the files", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger()).(*prometheusProvider)
+		metrics := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		certFile, _ := writeKeyPair(t, ca.issue(t, x509.ExtKeyUsageServerAuth, "localhost"))
+		chain, err := os.ReadFile(certFile)
+		require.NoError(t, err)
+		chainFile := filepath.Join(t.TempDir(), "chain.pem")
+		require.NoError(t, os.WriteFile(chainFile, append(chain, ca.pem...), 0o600))
+
+		require.NoError(t, RegisterCertificateExpiry(metrics, certFile, chainFile))
+
+		values := certificateExpiries(t, provider)
+		assert.Len(t, values, 3)
+		assert.InDelta(t, 3600, values[certFile+" CN=metricsx test"], 60)
+		assert.InDelta(t, 3600, values[chainFile+" CN=metricsx test"], 60)
+		assert.InDelta(t, 3600, values[chainFile+" CN=metricsx test CA"], 60)
+		assert.Contains(t, scrape(t, provider), `tls_certificate_expiry_seconds{serial="`)
+	})
+
+	t.Run("picks up rotated files", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger()).(*prometheusProvider)
+		metrics := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		certFile := filepath.Join(t.TempDir(), "cert.pem")
+		require.NoError(t, os.WriteFile(certFile, ca.pem, 0o600))
+		require.NoError(t, RegisterCertificateExpiry(metrics, certFile))
+		assert.Contains(t, certificateExpiries(t, provider), certFile+" CN=metricsx test CA")
+
+		leafFile, _ := writeKeyPair(t, ca.issue(t, x509.ExtKeyUsageServerAuth, "localhost"))
+		leaf, err := os.ReadFile(leafFile)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(certFile, leaf, 0o600))
+
+		values := certificateExpiries(t, provider)
+		assert.Len(t, values, 1)
+		assert.Contains(t, values, certFile+" CN=metricsx test")
+	})
+
+	t.Run("rejects files without certificates", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger()).(*prometheusProvider)
+		metrics := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		_, keyFile := writeKeyPair(t, ca.issue(t, x509.ExtKeyUsageServerAuth, "localhost"))
+		assert.ErrorContains(t, RegisterCertificateExpiry(metrics, keyFile), "no certificates found")
+		assert.Error(t, RegisterCertificateExpiry(metrics, filepath.Join(t.TempDir(), "missing.pem")))
+		assert.Empty(t, certificateExpiries(t, provider))
+	})
+}
+
+func TestRegisterTLSConfigExpiry(t *testing.T) {
+	ca := newTestCA(t)
+	provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger()).(*prometheusProvider)
+	metrics := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+	cert := ca.issue(t, x509.ExtKeyUsageServerAuth, "localhost")
+	cert.Certificate = append(cert.Certificate, ca.cert.Raw)
+	RegisterTLSConfigExpiry(metrics, "api", &tls.Config{Certificates: []tls.Certificate{cert}})
+
+	values := certificateExpiries(t, provider)
+	assert.Len(t, values, 2)
+	assert.InDelta(t, 3600, values["api CN=metricsx test"], 60)
+	assert.InDelta(t, 3600, values["api CN=metricsx test CA"], 60)
+}
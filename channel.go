@@ -0,0 +1,14 @@
+package metricsx
+
+// ChannelGauge exports the current length and capacity of ch as <name>_length
+// and <name>_capacity gauges, read at collection time. Use it for backpressure
+// visibility on internal queues.
+func ChannelGauge[T any](m Metrics, name string, ch chan T, opts ...Option) {
+	m.GaugeFunc(name+"_length", func() float64 {
+		return float64(len(ch))
+	}, append([]Option{WithHelp("Number of elements queued in the channel.")}, opts...)...)
+
+	m.GaugeFunc(name+"_capacity", func() float64 {
+		return float64(cap(ch))
+	}, append([]Option{WithHelp("Capacity of the channel buffer.")}, opts...)...)
+}
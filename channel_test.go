@@ -0,0 +1,42 @@
+package metricsx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelGauge(t *testing.T) {
+	t.Run("reports length and capacity at collection time", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		metrics := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		ch := make(chan int, 10)
+		ChannelGauge(metrics, "jobs_queue", ch)
+
+		ch <- 1
+		ch <- 2
+		ch <- 3
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, "jobs_queue_length 3")
+		assert.Contains(t, out, "jobs_queue_capacity 10")
+
+		<-ch
+		assert.Contains(t, scrape(t, provider), "jobs_queue_length 2")
+	})
+
+	t.Run("applies options", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		metrics := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		ChannelGauge(metrics, "events", make(chan struct{}, 4),
+			WithHelp("Pending events"),
+			WithConstLabels(map[string]string{"queue": "audit"}),
+		)
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, "# HELP events_length Pending events")
+		assert.Contains(t, out, `events_capacity{queue="audit"} 4`)
+	})
+}
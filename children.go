@@ -0,0 +1,74 @@
+package metricsx
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+// maxCachedChildren bounds the children cached per metric. Label combinations
+// beyond it are still recorded, through the uncached vector lookup.
+const maxCachedChildren = 10000
+
+// childCache caches the children of a metric vector by label values, so hot
+// series skip the label hashing of WithLabelValues
+type childCache[T any] struct {
+	with func(labels ...string) T
+
+	// expiry tracks writes for metrics with a TTL, nil otherwise
+	expiry *seriesExpiry
+
+	mu       sync.RWMutex
+	children map[string]T
+}
+
+// newChildCache creates a cache resolving misses with with
+func newChildCache[T any](with func(labels ...string) T) *childCache[T] {
+	return &childCache[T]{with: with, children: make(map[string]T)}
+}
+
+// get returns the child for labels
+func (c *childCache[T]) get(labels []string) T {
+	var buf [128]byte
+	key := appendChildKey(buf[:0], labels)
+
+	c.mu.RLock()
+	child, ok := c.children[string(key)]
+	c.mu.RUnlock()
+	if ok {
+		if c.expiry != nil {
+			c.expiry.touch(key, labels)
+		}
+		return child
+	}
+
+	// with panics on a wrong number of labels, so only valid keys are cached
+	child = c.with(labels...)
+
+	c.mu.Lock()
+	if len(c.children) < maxCachedChildren {
+		c.children[string(key)] = child
+	}
+	c.mu.Unlock()
+
+	if c.expiry != nil {
+		c.expiry.touch(key, labels)
+	}
+	return child
+}
+
+// remove drops the cached child with the given key
+func (c *childCache[T]) remove(key string) {
+	c.mu.Lock()
+	delete(c.children, key)
+	c.mu.Unlock()
+}
+
+// appendChildKey appends the length-prefixed label values to b, so distinct
+// label sets never share a key
+func appendChildKey(b []byte, labels []string) []byte {
+	for _, label := range labels {
+		b = binary.AppendUvarint(b, uint64(len(label)))
+		b = append(b, label...)
+	}
+	return b
+}
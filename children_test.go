@@ -0,0 +1,67 @@
+package metricsx
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChildCache(t *testing.T) {
+	t.Run("caches children by label values", func(t *testing.T) {
+		vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "orders_total"}, []string{"method", "status"})
+		cache := newChildCache(vec.WithLabelValues)
+
+		a := cache.get([]string{"GET", "200"})
+		assert.Same(t, a, cache.get([]string{"GET", "200"}))
+		assert.NotSame(t, a, cache.get([]string{"GET2", "00"}))
+		assert.Len(t, cache.children, 2)
+	})
+
+	t.Run("does not cache invalid label sets", func(t *testing.T) {
+		vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "orders_total"}, []string{"method"})
+		cache := newChildCache(vec.WithLabelValues)
+
+		assert.Panics(t, func() { cache.get([]string{"GET", "200"}) })
+		assert.Empty(t, cache.children)
+	})
+
+	t.Run("bounds the number of cached children", func(t *testing.T) {
+		vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "orders_total"}, []string{"id"})
+		cache := newChildCache(vec.WithLabelValues)
+
+		for i := range maxCachedChildren + 10 {
+			cache.get([]string{strconv.Itoa(i)}).Inc()
+		}
+		assert.Len(t, cache.children, maxCachedChildren)
+		assert.Equal(t, maxCachedChildren+10, testutilCount(vec))
+	})
+
+	t.Run("records cached series without allocating", func(t *testing.T) {
+		vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, []string{"method", "status"})
+		cache := newChildCache(vec.WithLabelValues)
+		labels := []string{"GET", "200"}
+		cache.get(labels)
+
+		allocs := testing.AllocsPerRun(100, func() {
+			cache.get(labels).Inc()
+		})
+		assert.Zero(t, allocs)
+	})
+}
+
+// testutilCount returns the number of series in c
+func testutilCount(c prometheus.Collector) int {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	n := 0
+	for range ch {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,31 @@
+// Package chimid provides chi middleware recording the same RED metric set as httpmid.
+//
+//	r := chi.NewRouter()
+//	r.Use(chimid.New(metrics))
+//
+// Requests are labeled with the chi route pattern (e.g. "/users/{id}"), so the
+// cardinality protection of httpmid applies to chi routers without configuration.
+package chimid
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gostratum/metricsx"
+	"github.com/gostratum/metricsx/httpmid"
+)
+
+// New creates chi middleware recording HTTP server metrics
+func New(m metricsx.Metrics, opts ...httpmid.Option) func(http.Handler) http.Handler {
+	opts = append([]httpmid.Option{httpmid.WithRouteFunc(routePattern)}, opts...)
+	return httpmid.New(m, opts...).Middleware
+}
+
+// routePattern returns the chi route pattern matched by r
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return ""
+	}
+	return rctx.RoutePattern()
+}
@@ -0,0 +1,43 @@
+package chimid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := metricstest.New()
+
+	r := chi.NewRouter()
+	r.Use(New(m))
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	r.Route("/orgs/{org}", func(r chi.Router) {
+		r.Delete("/members/{member}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+
+	requests := []struct{ method, path string }{
+		{http.MethodGet, "/users/1"},
+		{http.MethodGet, "/users/2"},
+		{http.MethodDelete, "/orgs/acme/members/7"},
+		{http.MethodGet, "/missing/123"},
+		{http.MethodGet, "/missing/456"},
+	}
+	for _, req := range requests {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	out := m.String()
+	assert.Contains(t, out, `http_requests_total{method="GET",path="/users/{id}",status="200"} 2`)
+	assert.Contains(t, out, `http_requests_total{method="DELETE",path="/orgs/{org}/members/{member}",status="204"} 1`)
+	assert.Contains(t, out, `http_requests_total{method="GET",path="unmatched",status="404"} 2`)
+	assert.NotContains(t, out, "/users/1")
+}
@@ -0,0 +1,69 @@
+package metricsx
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterFunc registers fn to emit a batch of gauges whenever metrics are
+// collected. Every Sample sent on ch becomes one gauge series: Name is
+// qualified with the configured namespace and subsystem, Labels become
+// variable labels and a non-zero Time is exposed as the sample timestamp.
+// Samples of the same name must carry the same label names. The collector is
+// unchecked, so the registry cannot reject conflicting names at registration;
+// they surface as gather errors instead.
+func (p *prometheusProvider) RegisterFunc(fn func(ch chan<- Sample), options *Options) {
+	p.register(&funcCollector{
+		fn:          fn,
+		namespace:   p.namespace(options),
+		subsystem:   p.subsystem(options),
+		help:        options.Help,
+		constLabels: options.ConstLabels,
+	})
+}
+
+// funcCollector adapts a RegisterFunc function to prometheus.Collector
+type funcCollector struct {
+	fn          func(ch chan<- Sample)
+	namespace   string
+	subsystem   string
+	help        string
+	constLabels map[string]string
+}
+
+// Describe sends no descriptors, which makes the collector unchecked: the
+// metrics it emits are only known once fn runs
+func (c *funcCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c *funcCollector) Collect(ch chan<- prometheus.Metric) {
+	samples := make(chan Sample)
+	go func() {
+		defer close(samples)
+		c.fn(samples)
+	}()
+
+	for s := range samples {
+		ch <- c.metric(s)
+	}
+}
+
+// metric converts s into a constant gauge
+func (c *funcCollector) metric(s Sample) prometheus.Metric {
+	names := slices.Sorted(maps.Keys(s.Labels))
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = s.Labels[name]
+	}
+
+	desc := prometheus.NewDesc(prometheus.BuildFQName(c.namespace, c.subsystem, s.Name), c.help, names, c.constLabels)
+	m, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, s.Value, values...)
+	if err != nil {
+		return prometheus.NewInvalidMetric(desc, err)
+	}
+	if !s.Time.IsZero() {
+		m = prometheus.NewMetricWithTimestamp(s.Time, m)
+	}
+	return m
+}
@@ -0,0 +1,45 @@
+package metricsx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterFunc(t *testing.T) {
+	t.Run("emits a batch of gauges at collection time", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: Config{Provider: "prometheus", Prometheus: PrometheusConfig{Namespace: "app"}}, Logger: getTestLogger()})
+		require.NoError(t, err)
+
+		collections := 0
+		res.Metrics.RegisterFunc(func(ch chan<- Sample) {
+			collections++
+			for _, level := range []string{"0", "1"} {
+				ch <- Sample{Name: "lsm_level_files", Labels: map[string]string{"level": level}, Value: float64(collections)}
+			}
+			ch <- Sample{Name: "lsm_memtable_bytes", Value: 4096}
+		}, WithHelp("Storage engine statistics."), WithConstLabels(map[string]string{"engine": "pebble"}))
+		assert.Zero(t, collections)
+
+		body := scrape(t, res.Provider.(*prometheusProvider))
+		assert.Equal(t, 1, collections)
+		assert.Contains(t, body, "# HELP app_lsm_level_files Storage engine statistics.")
+		assert.Contains(t, body, "# TYPE app_lsm_level_files gauge")
+		assert.Contains(t, body, `app_lsm_level_files{engine="pebble",level="0"} 1`)
+		assert.Contains(t, body, `app_lsm_level_files{engine="pebble",level="1"} 1`)
+		assert.Contains(t, body, `app_lsm_memtable_bytes{engine="pebble"} 4096`)
+
+		assert.Contains(t, scrape(t, res.Provider.(*prometheusProvider)), `app_lsm_level_files{engine="pebble",level="0"} 2`)
+	})
+
+	t.Run("exposes sample timestamps", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+		provider.RegisterFunc(func(ch chan<- Sample) {
+			ch <- Sample{Name: "replica_lag_seconds", Value: 2, Time: time.UnixMilli(1_700_000_000_000)}
+		}, applyOptions())
+
+		assert.Contains(t, scrape(t, provider), "replica_lag_seconds 2 1700000000000")
+	})
+}
@@ -1,19 +1,91 @@
 package metricsx
 
 import (
+	"time"
+
 	"github.com/gostratum/core/configx"
 )
 
+// Default timeouts for the standalone metrics server, used when the
+// corresponding PrometheusConfig field is zero
+const (
+	DefaultReadTimeout       = 10 * time.Second
+	DefaultReadHeaderTimeout = 5 * time.Second
+	DefaultWriteTimeout      = 30 * time.Second
+	DefaultIdleTimeout       = 60 * time.Second
+)
+
+// RandomPort makes the metrics HTTP server listen on a port assigned by the
+// operating system; use Provider.Addr to discover it
+const RandomPort = -1
+
 // Config contains configuration for the metrics module
 type Config struct {
 	// Enabled determines if metrics collection is enabled
 	Enabled bool `mapstructure:"enabled" default:"true"`
 
-	// Provider specifies which metrics provider to use (prometheus, noop)
+	// Provider specifies which metrics provider to use (prometheus, pushgateway, fanout, noop)
 	Provider string `mapstructure:"provider" default:"prometheus"`
 
+	// DryRun logs every metric registration and collects it into a report
+	// (see DryRunReport) instead of exporting anything
+	DryRun bool `mapstructure:"dry_run" default:"false"`
+
+	// Lint checks registered metrics against the Prometheus naming conventions
+	// on start: "warn" logs every problem, "fail" also aborts the start
+	Lint string `mapstructure:"lint" default:""`
+
+	// LabelsFromEnv maps label names to environment variables whose values are
+	// attached to every metric, e.g. {pod: POD_NAME} with the Kubernetes downward API
+	LabelsFromEnv map[string]string `mapstructure:"labels_from_env"`
+
+	// AddInstanceLabel attaches an instance label with InstanceID, or the
+	// hostname when InstanceID is empty, to every metric
+	AddInstanceLabel bool `mapstructure:"add_instance_label" default:"false"`
+
+	// InstanceID is the value of the instance label
+	InstanceID string `mapstructure:"instance_id" default:""`
+
+	// Resource controls detection of the cloud and Kubernetes environment
+	Resource ResourceConfig `mapstructure:"resource"`
+
+	// DiskUsagePaths lists paths, such as data directories, whose filesystem
+	// free and used bytes and inodes are exported
+	DiskUsagePaths []string `mapstructure:"disk_usage_paths"`
+
+	// Expected lists critical metrics, by the name passed to Metrics, that must
+	// be registered; readiness fails while any is missing
+	Expected []string `mapstructure:"expected"`
+
+	// Filter selects which metrics are exported
+	Filter FilterConfig `mapstructure:"filter"`
+
+	// LabelRules drop or mask labels on matching metrics for every provider
+	LabelRules []LabelRule `mapstructure:"label_rules"`
+
+	// Overrides replace the buckets or objectives of metrics by name
+	Overrides map[string]MetricOverride `mapstructure:"overrides"`
+
+	// Events map business event names to the attributes counted as labels
+	Events map[string]EventConfig `mapstructure:"events"`
+
+	// WatchInterval is how often the predicates of Metrics.Watch are evaluated
+	WatchInterval time.Duration `mapstructure:"watch_interval" default:"10s"`
+
+	// Cardinality limits the number of series per metric
+	Cardinality CardinalityConfig `mapstructure:"cardinality"`
+
 	// Prometheus configuration
 	Prometheus PrometheusConfig `mapstructure:"prometheus"`
+
+	// Push configures interval, timeout and flushing for push providers
+	Push PushConfig `mapstructure:"push"`
+
+	// Pushgateway configuration for the pushgateway provider
+	Pushgateway PushgatewayConfig `mapstructure:"pushgateway"`
+
+	// Fanout configuration for the fanout provider
+	Fanout FanoutConfig `mapstructure:"fanout"`
 }
 
 // Prefix enables configx.Bind
@@ -30,15 +102,141 @@ type PrometheusConfig struct {
 	// Path where metrics are exposed (default: /metrics)
 	Path string `mapstructure:"path" default:"/metrics"`
 
+	// Endpoints are additional paths serving filtered subsets of the metrics
+	Endpoints []EndpointConfig `mapstructure:"endpoints"`
+
+	// JSONPath serves the current metric values as JSON, e.g. /metrics/json.
+	// Empty disables it.
+	JSONPath string `mapstructure:"json_path" default:""`
+
+	// QueryPath serves the current value of a single metric, selected with
+	// ?name= and optionally ?labels=k=v,..., e.g. /metrics/query. Empty
+	// disables it.
+	QueryPath string `mapstructure:"query_path" default:""`
+
+	// CatalogPath serves the declared metrics as JSON, or as Markdown with
+	// ?format=markdown, e.g. /metrics/catalog. Empty disables it.
+	CatalogPath string `mapstructure:"catalog_path" default:""`
+
+	// HealthPath serves a liveness endpoint, e.g. /healthz, on the standalone
+	// server. Empty disables it.
+	HealthPath string `mapstructure:"health_path" default:""`
+
+	// EnablePprof serves the net/http/pprof handlers under /debug/pprof/ on
+	// the standalone server, protected by Auth. Without Auth, profiles are
+	// only served to loopback clients.
+	EnablePprof bool `mapstructure:"enable_pprof" default:"false"`
+
+	// Compression lists the encodings offered to scrapers: identity, gzip and
+	// zstd. Empty offers all of them.
+	Compression []string `mapstructure:"compression"`
+
+	// DisableCompression always serves uncompressed responses
+	DisableCompression bool `mapstructure:"disable_compression" default:"false"`
+
+	// EnableOpenMetrics serves the OpenMetrics format to scrapers that ask for
+	// it. The classic text and protobuf formats are always negotiated.
+	EnableOpenMetrics bool `mapstructure:"enable_open_metrics" default:"true"`
+
+	// MaxRequestsInFlight limits concurrent scrapes; additional scrapes get
+	// 503 Service Unavailable. 0 means no limit.
+	MaxRequestsInFlight int `mapstructure:"max_requests_in_flight" default:"0"`
+
+	// HandlerTimeout answers scrapes taking longer with 503 Service
+	// Unavailable. 0 means no timeout.
+	HandlerTimeout time.Duration `mapstructure:"handler_timeout" default:"0s"`
+
+	// ScrapeHookTimeout bounds the OnScrape hooks run before every scrape and
+	// push. Hooks still running at the deadline are counted as failed and the
+	// export goes ahead without them.
+	ScrapeHookTimeout time.Duration `mapstructure:"scrape_hook_timeout" default:"5s"`
+
+	// ErrorHandling controls scrapes when gathering fails: http_error answers
+	// 500, continue serves the metrics gathered anyway, panic panics
+	ErrorHandling string `mapstructure:"error_handling" default:"http_error"`
+
+	// Host is the interface the metrics HTTP server binds to, e.g. 127.0.0.1.
+	// Empty binds to all interfaces.
+	Host string `mapstructure:"host" default:""`
+
 	// Port for the metrics HTTP server (if separate from main app)
-	// If 0, metrics will be exposed on the main HTTP server
+	// If 0, no server is started and the handler is mounted on the main HTTP
+	// server, by hand or through a Router provided to the fx graph;
+	// RandomPort (-1) binds to an OS-assigned port
 	Port int `mapstructure:"port" default:"0"`
 
+	// ReadTimeout is the maximum duration for reading a scrape request
+	ReadTimeout time.Duration `mapstructure:"read_timeout" default:"10s"`
+
+	// ReadHeaderTimeout is the maximum duration for reading request headers
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout" default:"5s"`
+
+	// WriteTimeout is the maximum duration for writing a scrape response
+	WriteTimeout time.Duration `mapstructure:"write_timeout" default:"30s"`
+
+	// IdleTimeout is the maximum time to keep an idle keep-alive connection open
+	IdleTimeout time.Duration `mapstructure:"idle_timeout" default:"60s"`
+
+	// DisableKeepAlives closes the connection after every scrape
+	DisableKeepAlives bool `mapstructure:"disable_keep_alives" default:"false"`
+
+	// MaxHeaderBytes limits the size of request headers. 0 uses the net/http
+	// default of 1 MB.
+	MaxHeaderBytes int `mapstructure:"max_header_bytes" default:"0"`
+
+	// DisableHTTP2 serves HTTP/1.1 only, even over TLS
+	DisableHTTP2 bool `mapstructure:"disable_http2" default:"false"`
+
 	// EnableProcessMetrics enables Go process metrics
 	EnableProcessMetrics bool `mapstructure:"enable_process_metrics" default:"true"`
 
 	// EnableGoMetrics enables Go runtime metrics
 	EnableGoMetrics bool `mapstructure:"enable_go_metrics" default:"true"`
+
+	// GoMetricsRules are regular expressions of runtime/metrics names, such as
+	// "/sched/latencies:seconds", exposed in addition to the default Go metrics
+	GoMetricsRules []string `mapstructure:"go_metrics_rules"`
+
+	// EnableAllGoMetrics exposes the complete runtime/metrics set, including
+	// scheduler latency and GC pause histograms and the memory classes, on top
+	// of the default Go metrics. GoMetricsRules is ignored when it is set.
+	EnableAllGoMetrics bool `mapstructure:"enable_all_go_metrics" default:"false"`
+
+	// EnableBuildInfoMetrics exposes go_build_info with the main module path,
+	// version and checksum
+	EnableBuildInfoMetrics bool `mapstructure:"enable_build_info_metrics" default:"false"`
+
+	// EnableHandlerMetrics instruments the scrape endpoints with request,
+	// in-flight and duration metrics
+	EnableHandlerMetrics bool `mapstructure:"enable_handler_metrics" default:"true"`
+
+	// NativeHistograms exposes histograms as Prometheus native histograms
+	NativeHistograms NativeHistogramConfig `mapstructure:"native_histograms"`
+
+	// BucketCalibration suggests histogram buckets from the observed values
+	BucketCalibration BucketCalibrationConfig `mapstructure:"bucket_calibration"`
+
+	// History keeps recent samples of every series in memory, queryable
+	// with ?range= on QueryPath
+	History HistoryConfig `mapstructure:"history"`
+
+	// SubscriptionInterval is how often the series streamed to Subscribe
+	// channels are compared
+	SubscriptionInterval time.Duration `mapstructure:"subscription_interval" default:"1s"`
+
+	// EnableSelfMetrics exposes the health of the metrics pipeline: rejected
+	// registrations, expired series, series per metric and pushes
+	EnableSelfMetrics bool `mapstructure:"enable_self_metrics" default:"true"`
+
+	// Auth protects the metrics endpoint with basic auth or a bearer token
+	Auth AuthConfig `mapstructure:"auth"`
+
+	// AllowedNetworks restricts the standalone server to clients in these
+	// CIDRs or addresses, independent of Auth. Empty allows every client.
+	AllowedNetworks []string `mapstructure:"allowed_networks"`
+
+	// TLS serves the standalone metrics server over TLS, with optional client certificate verification
+	TLS TLSConfig `mapstructure:"tls"`
 }
 
 // NewConfig creates a new Config from the configuration loader
@@ -50,12 +248,26 @@ func NewConfig(loader configx.Loader) (Config, error) {
 	return cfg, nil
 }
 
+// Sanitize returns a copy of the configuration with the endpoint credentials
+// redacted, for logging and ConfigHash
+func (c Config) Sanitize() any {
+	safe := c
+	safe.Prometheus.Auth = c.Prometheus.Auth.sanitize()
+	return safe
+}
+
 // ConfigSummary returns a small diagnostic map safe for logging.
 func (c *Config) ConfigSummary() map[string]any {
 	return map[string]any{
 		"enabled":   c.Enabled,
+		"dry_run":   c.DryRun,
+		"lint":      c.Lint,
 		"provider":  c.Provider,
 		"prom_path": c.Prometheus.Path,
+		"prom_host": c.Prometheus.Host,
 		"prom_port": c.Prometheus.Port,
+		"prom_auth": c.Prometheus.Auth.Enabled(),
+		"prom_tls":  c.Prometheus.TLS.Enabled(),
+		"prom_mtls": c.Prometheus.TLS.ClientCAFile != "",
 	}
 }
@@ -0,0 +1,58 @@
+package metricsx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gostratum/core/configx"
+	"github.com/gostratum/core/logx"
+)
+
+// ConfigHash exposes config_info with value 1 for every configuration bound
+// through configx, labeled with its prefix as section and a hash of its
+// values, so replicas running divergent configuration stand out:
+//
+//	count by (section) (count by (section, hash) (config_info)) > 1
+//
+// The hash covers the exported fields as encoded to JSON and is truncated to
+// 16 hex digits. Sections implementing logx.Sanitizable are hashed from their
+// sanitized copy, so secrets such as passwords and tokens never feed the hash
+// and cannot be recovered from it by guessing. Call
+// ConfigHash once per process with the bound structs, typically after the fx
+// graph has provided them. It returns an error, registering nothing, when a
+// configuration cannot be encoded.
+func ConfigHash(m Metrics, configs ...configx.Configurable) error {
+	hashes := make([]string, len(configs))
+	for i, c := range configs {
+		hash, err := hashConfig(c)
+		if err != nil {
+			return fmt.Errorf("hash %s config: %w", c.Prefix(), err)
+		}
+		hashes[i] = hash
+	}
+
+	for i, c := range configs {
+		m.GaugeFunc("config_info", func() float64 { return 1 },
+			WithHelp("Hash of the loaded configuration per section, exposed as labels."),
+			WithConstLabels(map[string]string{"section": c.Prefix(), "hash": hashes[i]}),
+		)
+	}
+	return nil
+}
+
+// hashConfig returns the truncated SHA-256 of the JSON encoding of c, or of
+// its sanitized copy when it implements logx.Sanitizable
+func hashConfig(c configx.Configurable) (string, error) {
+	var v any = c
+	if s, ok := c.(logx.Sanitizable); ok {
+		v = s.Sanitize()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
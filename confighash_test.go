@@ -0,0 +1,97 @@
+package metricsx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cacheConfig is an application configuration section
+type cacheConfig struct {
+	Size int `mapstructure:"size"`
+}
+
+func (cacheConfig) Prefix() string { return "cache" }
+
+// unencodableConfig cannot be encoded to JSON
+type unencodableConfig struct {
+	Notify chan struct{}
+}
+
+func (unencodableConfig) Prefix() string { return "notify" }
+
+// dbConfig holds a secret and redacts it when sanitized
+type dbConfig struct {
+	Host     string `mapstructure:"host"`
+	Password string `mapstructure:"password"`
+}
+
+func (dbConfig) Prefix() string { return "db" }
+
+func (c dbConfig) Sanitize() any {
+	c.Password = "[redacted]"
+	return c
+}
+
+func TestConfigHash(t *testing.T) {
+	t.Run("exposes a hash per section", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+		m := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		metricsConfig := Config{Provider: "prometheus"}
+		require.NoError(t, ConfigHash(m, metricsConfig, cacheConfig{Size: 128}))
+
+		metricsHash, err := hashConfig(metricsConfig)
+		require.NoError(t, err)
+		out := scrape(t, provider)
+		assert.Contains(t, out, `config_info{hash="`+metricsHash+`",section="metrics"} 1`)
+		assert.Contains(t, out, `section="cache"} 1`)
+		assert.Len(t, metricsHash, 16)
+	})
+
+	t.Run("hash follows the values", func(t *testing.T) {
+		a, err := hashConfig(Config{Provider: "prometheus"})
+		require.NoError(t, err)
+		b, err := hashConfig(Config{Provider: "prometheus"})
+		require.NoError(t, err)
+		c, err := hashConfig(Config{Provider: "pushgateway"})
+		require.NoError(t, err)
+
+		assert.Equal(t, a, b)
+		assert.NotEqual(t, a, c)
+	})
+
+	t.Run("ignores sanitized secrets", func(t *testing.T) {
+		a, err := hashConfig(dbConfig{Host: "db1", Password: "hunter2"})
+		require.NoError(t, err)
+		b, err := hashConfig(dbConfig{Host: "db1", Password: "letmein"})
+		require.NoError(t, err)
+		c, err := hashConfig(dbConfig{Host: "db2", Password: "hunter2"})
+		require.NoError(t, err)
+
+		assert.Equal(t, a, b)
+		assert.NotEqual(t, a, c)
+
+		withToken := Config{Prometheus: PrometheusConfig{Auth: AuthConfig{BearerToken: "s3cret"}}}
+		otherToken := Config{Prometheus: PrometheusConfig{Auth: AuthConfig{BearerToken: "t0ken"}}}
+		a, err = hashConfig(withToken)
+		require.NoError(t, err)
+		b, err = hashConfig(otherToken)
+		require.NoError(t, err)
+		c, err = hashConfig(Config{})
+		require.NoError(t, err)
+
+		assert.Equal(t, a, b)
+		assert.NotEqual(t, a, c)
+	})
+
+	t.Run("rejects configurations that cannot be encoded", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+		m := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		err := ConfigHash(m, Config{}, unencodableConfig{})
+		assert.ErrorContains(t, err, "hash notify config")
+		assert.NotContains(t, scrape(t, provider), "config_info")
+	})
+}
@@ -0,0 +1,136 @@
+// Package cronmetrics instruments github.com/robfig/cron/v3 schedules through metricsx.
+//
+//	c := cron.New()
+//	s := cronmetrics.New(c, metrics)
+//	s.AddFunc("nightly_report", "0 2 * * *", generateReport)
+//	c.Start()
+//
+// It records:
+//
+//	cron_job_schedule_drift_seconds{job}
+//	cron_job_duration_seconds{job}
+//	cron_job_skipped_total{job}
+//
+// Drift is the delay between the time a run was scheduled for and the time it
+// actually started. A run that fires while the previous run of the same job is
+// still in progress is skipped and counted instead of overlapping it.
+package cronmetrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gostratum/metricsx"
+	"github.com/robfig/cron/v3"
+)
+
+// Option configures the scheduler metrics
+type Option func(*options)
+
+// options contains configuration for the scheduler metrics
+type options struct {
+	buckets      []float64
+	driftBuckets []float64
+}
+
+// WithBuckets sets the buckets for the job duration histogram
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// WithDriftBuckets sets the buckets for the schedule drift histogram
+func WithDriftBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.driftBuckets = buckets
+	}
+}
+
+// Scheduler adds instrumented jobs to a *cron.Cron
+type Scheduler struct {
+	cron *cron.Cron
+
+	drift    metricsx.Histogram
+	duration metricsx.Histogram
+	skipped  metricsx.Counter
+}
+
+// New creates a Scheduler that registers jobs on c
+func New(c *cron.Cron, m metricsx.Metrics, opts ...Option) *Scheduler {
+	o := &options{
+		buckets:      metricsx.DefaultBuckets,
+		driftBuckets: metricsx.DefaultBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Scheduler{
+		cron: c,
+		drift: m.Histogram("cron_job_schedule_drift_seconds",
+			metricsx.WithHelp("Delay between the scheduled and actual start of a cron job in seconds."),
+			metricsx.WithLabels("job"),
+			metricsx.WithBuckets(o.driftBuckets...),
+		),
+		duration: m.Histogram("cron_job_duration_seconds",
+			metricsx.WithHelp("Duration of cron job runs in seconds."),
+			metricsx.WithLabels("job"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+		skipped: m.Counter("cron_job_skipped_total",
+			metricsx.WithHelp("Total number of cron job runs skipped because the previous run was still in progress."),
+			metricsx.WithLabels("job"),
+		),
+	}
+}
+
+// AddJob schedules job under the given name using the cron spec
+func (s *Scheduler) AddJob(name, spec string, job cron.Job) (cron.EntryID, error) {
+	j := &instrumentedJob{scheduler: s, name: name, job: job}
+	id, err := s.cron.AddJob(spec, j)
+	if err != nil {
+		return 0, err
+	}
+	j.id.Store(int64(id))
+	return id, nil
+}
+
+// AddFunc schedules fn under the given name using the cron spec
+func (s *Scheduler) AddFunc(name, spec string, fn func()) (cron.EntryID, error) {
+	return s.AddJob(name, spec, cron.FuncJob(fn))
+}
+
+// instrumentedJob records drift, duration and skips around a cron.Job
+type instrumentedJob struct {
+	scheduler *Scheduler
+	name      string
+	job       cron.Job
+	id        atomic.Int64
+	running   sync.Mutex
+}
+
+// Run implements cron.Job
+func (j *instrumentedJob) Run() {
+	start := time.Now()
+
+	if !j.running.TryLock() {
+		j.scheduler.skipped.Inc(j.name)
+		return
+	}
+	defer j.running.Unlock()
+
+	// The cron run loop sets Prev to the scheduled time of the run it just
+	// started before it serves the entry snapshot
+	if id := j.id.Load(); id != 0 {
+		if scheduled := j.scheduler.cron.Entry(cron.EntryID(id)).Prev; !scheduled.IsZero() {
+			j.scheduler.drift.Observe(max(start.Sub(scheduled), 0).Seconds(), j.name)
+		}
+	}
+
+	defer func() {
+		j.scheduler.duration.Observe(time.Since(start).Seconds(), j.name)
+	}()
+	j.job.Run()
+}
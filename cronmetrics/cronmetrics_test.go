@@ -0,0 +1,86 @@
+package cronmetrics
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler(t *testing.T) {
+	t.Run("records drift and duration of scheduled runs", func(t *testing.T) {
+		m := metricstest.New()
+		c := cron.New()
+		s := New(c, m)
+
+		ran := make(chan struct{}, 1)
+		_, err := s.AddFunc("tick", "@every 1s", func() {
+			select {
+			case ran <- struct{}{}:
+			default:
+			}
+		})
+		require.NoError(t, err)
+
+		c.Start()
+		defer c.Stop()
+
+		select {
+		case <-ran:
+		case <-time.After(3 * time.Second):
+			t.Fatal("job did not run")
+		}
+
+		assert.Eventually(t, func() bool {
+			out := m.String()
+			return strings.Contains(out, `cron_job_duration_seconds_count{job="tick"} 1`) &&
+				strings.Contains(out, `cron_job_schedule_drift_seconds_count{job="tick"} 1`)
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("skips overlapping runs", func(t *testing.T) {
+		m := metricstest.New()
+		c := cron.New()
+		s := New(c, m)
+
+		release := make(chan struct{})
+		started := make(chan struct{})
+		id, err := s.AddFunc("slow", "@hourly", func() {
+			close(started)
+			<-release
+		})
+		require.NoError(t, err)
+
+		job := c.Entry(id).Job
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job.Run()
+		}()
+		<-started
+
+		job.Run()
+		close(release)
+		wg.Wait()
+
+		out := m.String()
+		assert.Contains(t, out, `cron_job_skipped_total{job="slow"} 1`)
+		assert.Contains(t, out, `cron_job_duration_seconds_count{job="slow"} 1`)
+		assert.NotContains(t, out, `cron_job_schedule_drift_seconds_count{job="slow"}`)
+	})
+
+	t.Run("rejects invalid specs", func(t *testing.T) {
+		m := metricstest.New()
+		s := New(cron.New(), m)
+
+		_, err := s.AddFunc("broken", "not a spec", func() {})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,35 @@
+package metricsx
+
+import (
+	"database/sql"
+)
+
+// RegisterDBStats exports the connection pool statistics of db as metrics.
+// Values are read from db.Stats() at collection time and labeled with db_name.
+func RegisterDBStats(m Metrics, db *sql.DB, name string) {
+	labels := WithConstLabels(map[string]string{"db_name": name})
+
+	m.GaugeFunc("go_sql_max_open_connections", func() float64 {
+		return float64(db.Stats().MaxOpenConnections)
+	}, WithHelp("Maximum number of open connections to the database."), labels)
+
+	m.GaugeFunc("go_sql_open_connections", func() float64 {
+		return float64(db.Stats().OpenConnections)
+	}, WithHelp("The number of established connections both in use and idle."), labels)
+
+	m.GaugeFunc("go_sql_in_use_connections", func() float64 {
+		return float64(db.Stats().InUse)
+	}, WithHelp("The number of connections currently in use."), labels)
+
+	m.GaugeFunc("go_sql_idle_connections", func() float64 {
+		return float64(db.Stats().Idle)
+	}, WithHelp("The number of idle connections."), labels)
+
+	m.CounterFunc("go_sql_wait_count_total", func() float64 {
+		return float64(db.Stats().WaitCount)
+	}, WithHelp("The total number of connections waited for."), labels)
+
+	m.CounterFunc("go_sql_wait_duration_seconds_total", func() float64 {
+		return db.Stats().WaitDuration.Seconds()
+	}, WithHelp("The total time blocked waiting for a new connection."), labels)
+}
@@ -0,0 +1,86 @@
+package metricsx
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubDriver is a database/sql driver that never connects
+type stubDriver struct{}
+
+func (stubDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("stub driver cannot connect")
+}
+
+func init() {
+	sql.Register("metricsx_stub", stubDriver{})
+}
+
+func TestRegisterDBStats(t *testing.T) {
+	t.Run("exports pool statistics", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		metrics := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		db, err := sql.Open("metricsx_stub", "")
+		require.NoError(t, err)
+		defer db.Close()
+		db.SetMaxOpenConns(7)
+
+		RegisterDBStats(metrics, db, "primary")
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `go_sql_max_open_connections{db_name="primary"} 7`)
+		assert.Contains(t, out, `go_sql_open_connections{db_name="primary"} 0`)
+		assert.Contains(t, out, `go_sql_in_use_connections{db_name="primary"} 0`)
+		assert.Contains(t, out, `go_sql_idle_connections{db_name="primary"} 0`)
+		assert.Contains(t, out, `go_sql_wait_count_total{db_name="primary"} 0`)
+		assert.Contains(t, out, `go_sql_wait_duration_seconds_total{db_name="primary"} 0`)
+	})
+
+	t.Run("supports multiple databases", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		metrics := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		primary, err := sql.Open("metricsx_stub", "")
+		require.NoError(t, err)
+		defer primary.Close()
+		primary.SetMaxOpenConns(10)
+
+		replica, err := sql.Open("metricsx_stub", "")
+		require.NoError(t, err)
+		defer replica.Close()
+		replica.SetMaxOpenConns(20)
+
+		RegisterDBStats(metrics, primary, "primary")
+		RegisterDBStats(metrics, replica, "replica")
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `go_sql_max_open_connections{db_name="primary"} 10`)
+		assert.Contains(t, out, `go_sql_max_open_connections{db_name="replica"} 20`)
+	})
+
+	t.Run("re-registering replaces the database", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		metrics := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		old, err := sql.Open("metricsx_stub", "")
+		require.NoError(t, err)
+		defer old.Close()
+		old.SetMaxOpenConns(1)
+
+		current, err := sql.Open("metricsx_stub", "")
+		require.NoError(t, err)
+		defer current.Close()
+		current.SetMaxOpenConns(2)
+
+		RegisterDBStats(metrics, old, "main")
+		RegisterDBStats(metrics, current, "main")
+
+		assert.Contains(t, scrape(t, provider), `go_sql_max_open_connections{db_name="main"} 2`)
+	})
+}
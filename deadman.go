@@ -0,0 +1,96 @@
+package metricsx
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// DeadmanSwitch reports whether a loop is still running: callers Ping it on
+// every iteration and it turns overdue when no ping arrived for the expected
+// interval
+type DeadmanSwitch struct {
+	name     string
+	interval time.Duration
+	created  time.Time
+	lastPing atomic.Int64
+	now      func() time.Time
+}
+
+// Deadman creates a deadman switch for name and exposes
+// deadman_last_ping_timestamp_seconds, deadman_overdue and
+// deadman_expected_interval_seconds, labeled with deadman. The values are
+// computed at collection time, so deadman_overdue turns 1 even when the loop
+// is stuck and never pings again:
+//
+//	reconciler := metricsx.Deadman(metrics, "reconciler", time.Minute)
+//	for range ticker.C {
+//		reconcile(ctx)
+//		reconciler.Ping()
+//	}
+//
+// The switch is overdue when expectedInterval passed since the last ping, or
+// since its creation before the first ping; the last ping timestamp is 0 until
+// then. It panics when expectedInterval is not positive.
+func Deadman(m Metrics, name string, expectedInterval time.Duration) *DeadmanSwitch {
+	if expectedInterval <= 0 {
+		panic(fmt.Sprintf("metricsx: deadman %s expected interval %v must be positive", name, expectedInterval))
+	}
+
+	d := &DeadmanSwitch{name: name, interval: expectedInterval, created: time.Now(), now: time.Now}
+	d.register(m)
+	return d
+}
+
+// register exposes the collection-time gauges of d
+func (d *DeadmanSwitch) register(m Metrics) {
+	constLabels := WithConstLabels(map[string]string{"deadman": d.name})
+	m.GaugeFunc("deadman_last_ping_timestamp_seconds", func() float64 {
+		last, ok := d.LastPing()
+		if !ok {
+			return 0
+		}
+		return unixSeconds(last)
+	},
+		WithHelp("Unix timestamp of the last ping of the deadman switch."),
+		constLabels,
+	)
+	m.GaugeFunc("deadman_overdue", func() float64 {
+		if d.Overdue() {
+			return 1
+		}
+		return 0
+	},
+		WithHelp("Whether the deadman switch missed its expected ping (1) or not (0)."),
+		constLabels,
+	)
+	m.GaugeFunc("deadman_expected_interval_seconds", func() float64 {
+		return d.interval.Seconds()
+	},
+		WithHelp("Expected interval between pings of the deadman switch in seconds."),
+		constLabels,
+	)
+}
+
+// Ping records that the watched loop is still running
+func (d *DeadmanSwitch) Ping() {
+	d.lastPing.Store(d.now().UnixNano())
+}
+
+// LastPing returns the time of the last ping, or false before the first one
+func (d *DeadmanSwitch) LastPing() (time.Time, bool) {
+	last := d.lastPing.Load()
+	if last == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, last), true
+}
+
+// Overdue reports whether the expected interval passed without a ping
+func (d *DeadmanSwitch) Overdue() bool {
+	since, ok := d.LastPing()
+	if !ok {
+		since = d.created
+	}
+	return d.now().Sub(since) > d.interval
+}
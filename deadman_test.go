@@ -0,0 +1,70 @@
+package metricsx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadman(t *testing.T) {
+	newTestDeadman := func(t *testing.T, name string) (*prometheusProvider, *DeadmanSwitch, *fakeClock) {
+		t.Helper()
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger()).(*prometheusProvider)
+		m := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+		d := Deadman(m, name, time.Minute)
+		d.created, d.now = clock.Now(), clock.Now
+		return provider, d, clock
+	}
+
+	t.Run("turns overdue without pings", func(t *testing.T) {
+		provider, d, clock := newTestDeadman(t, "reconciler")
+
+		body := scrape(t, provider)
+		assert.Contains(t, body, `deadman_overdue{deadman="reconciler"} 0`)
+		assert.Contains(t, body, `deadman_last_ping_timestamp_seconds{deadman="reconciler"} 0`)
+		assert.Contains(t, body, `deadman_expected_interval_seconds{deadman="reconciler"} 60`)
+
+		clock.Advance(2 * time.Minute)
+		assert.True(t, d.Overdue())
+		assert.Contains(t, scrape(t, provider), `deadman_overdue{deadman="reconciler"} 1`)
+	})
+
+	t.Run("recovers on ping", func(t *testing.T) {
+		provider, d, clock := newTestDeadman(t, "outbox")
+		clock.Advance(2 * time.Minute)
+		require.True(t, d.Overdue())
+
+		d.Ping()
+		assert.False(t, d.Overdue())
+		last, ok := d.LastPing()
+		require.True(t, ok)
+		assert.Equal(t, clock.Now(), last)
+
+		body := scrape(t, provider)
+		assert.Contains(t, body, `deadman_overdue{deadman="outbox"} 0`)
+		assert.Contains(t, body, `deadman_last_ping_timestamp_seconds{deadman="outbox"} 1.70000012e+09`)
+
+		clock.Advance(61 * time.Second)
+		assert.True(t, d.Overdue())
+	})
+
+	t.Run("exposes several switches", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+		m := &metricsImpl{provider: provider, logger: getTestLogger()}
+		Deadman(m, "reconciler", time.Minute)
+		Deadman(m, "outbox", time.Minute)
+
+		body := scrape(t, provider)
+		assert.Contains(t, body, `deadman_overdue{deadman="reconciler"} 0`)
+		assert.Contains(t, body, `deadman_overdue{deadman="outbox"} 0`)
+	})
+
+	t.Run("rejects non-positive intervals", func(t *testing.T) {
+		m := &metricsImpl{provider: newNoopProvider(), logger: getTestLogger()}
+		assert.Panics(t, func() { Deadman(m, "loop", 0) })
+	})
+}
@@ -0,0 +1,44 @@
+package metricsx
+
+import (
+	"fmt"
+)
+
+// diskUsage is the capacity of the filesystem holding a path
+type diskUsage struct {
+	sizeBytes  uint64
+	freeBytes  uint64
+	usedBytes  uint64
+	freeInodes uint64
+	usedInodes uint64
+}
+
+// RegisterDiskUsage exports the free and used bytes and inodes of the
+// filesystems holding paths, such as data directories. Values are read with
+// statfs at collection time and labeled with path. Free bytes are those
+// available to unprivileged users, like the Avail column of df. It returns an
+// error, registering nothing, when a path cannot be read or the platform is
+// not supported.
+func RegisterDiskUsage(m Metrics, paths ...string) error {
+	for _, path := range paths {
+		if _, err := statDisk(path); err != nil {
+			return fmt.Errorf("disk usage of %s: %w", path, err)
+		}
+	}
+
+	m.RegisterFunc(func(ch chan<- Sample) {
+		for _, path := range paths {
+			usage, err := statDisk(path)
+			if err != nil {
+				continue
+			}
+			labels := map[string]string{"path": path}
+			ch <- Sample{Name: "disk_size_bytes", Labels: labels, Value: float64(usage.sizeBytes)}
+			ch <- Sample{Name: "disk_free_bytes", Labels: labels, Value: float64(usage.freeBytes)}
+			ch <- Sample{Name: "disk_used_bytes", Labels: labels, Value: float64(usage.usedBytes)}
+			ch <- Sample{Name: "disk_inodes_free", Labels: labels, Value: float64(usage.freeInodes)}
+			ch <- Sample{Name: "disk_inodes_used", Labels: labels, Value: float64(usage.usedInodes)}
+		}
+	}, WithHelp("Capacity of the filesystem holding a configured path."))
+	return nil
+}
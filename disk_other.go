@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd)
+
+package metricsx
+
+import (
+	"errors"
+)
+
+// statDisk is not supported on this platform
+func statDisk(path string) (diskUsage, error) {
+	return diskUsage{}, errors.ErrUnsupported
+}
@@ -0,0 +1,49 @@
+package metricsx
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterDiskUsage(t *testing.T) {
+	t.Run("exports filesystem capacity per path", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		metrics := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		dir := t.TempDir()
+		require.NoError(t, RegisterDiskUsage(metrics, dir))
+
+		usage, err := statDisk(dir)
+		require.NoError(t, err)
+		assert.Positive(t, usage.sizeBytes)
+		assert.LessOrEqual(t, usage.freeBytes+usage.usedBytes, usage.sizeBytes)
+
+		out := scrape(t, provider)
+		for _, name := range []string{"disk_size_bytes", "disk_free_bytes", "disk_used_bytes", "disk_inodes_free", "disk_inodes_used"} {
+			assert.Contains(t, out, name+`{path="`+dir+`"}`)
+		}
+	})
+
+	t.Run("rejects unreadable paths", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		metrics := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		err := RegisterDiskUsage(metrics, t.TempDir(), filepath.Join(t.TempDir(), "missing"))
+		assert.ErrorContains(t, err, "missing")
+		assert.NotContains(t, scrape(t, provider), "disk_")
+	})
+
+	t.Run("registers configured paths", func(t *testing.T) {
+		dir := t.TempDir()
+		result, err := NewMetrics(Params{
+			Config: Config{Provider: "prometheus", DiskUsagePaths: []string{dir}},
+			Logger: getTestLogger(),
+		})
+		require.NoError(t, err)
+
+		assert.Contains(t, scrape(t, result.Provider), `disk_free_bytes{path="`+dir+`"}`)
+	})
+}
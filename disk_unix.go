@@ -0,0 +1,24 @@
+//go:build linux || darwin || freebsd
+
+package metricsx
+
+import (
+	"syscall"
+)
+
+// statDisk reads the usage of the filesystem holding path
+func statDisk(path string) (diskUsage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return diskUsage{}, err
+	}
+
+	bsize := uint64(st.Bsize)
+	return diskUsage{
+		sizeBytes:  uint64(st.Blocks) * bsize,
+		freeBytes:  uint64(st.Bavail) * bsize,
+		usedBytes:  (uint64(st.Blocks) - uint64(st.Bfree)) * bsize,
+		freeInodes: uint64(st.Ffree),
+		usedInodes: uint64(st.Files) - uint64(st.Ffree),
+	}, nil
+}
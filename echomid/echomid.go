@@ -0,0 +1,63 @@
+// Package echomid provides Echo middleware recording the same RED metric set as httpmid.
+//
+//	e := echo.New()
+//	e.Use(echomid.New(metrics))
+//
+// Requests are labeled with the registered route path (e.g. "/users/:id") so
+// dynamic segments do not explode label cardinality. Requests whose handler
+// panicked are recorded under status="500" before the panic is re-raised.
+package echomid
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gostratum/metricsx"
+	"github.com/gostratum/metricsx/httpmid"
+	"github.com/labstack/echo/v4"
+)
+
+// New creates Echo middleware recording HTTP server metrics
+func New(m metricsx.Metrics, opts ...httpmid.Option) echo.MiddlewareFunc {
+	hm := httpmid.New(m, opts...)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			end := hm.Begin(c.Request().Method)
+
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				// A panicking handler is recorded as a server error and the
+				// panic passed on to Echo's Recover middleware or net/http
+				end(c.Path(), http.StatusInternalServerError)
+				if p := recover(); p != nil {
+					panic(p)
+				}
+			}()
+
+			err := next(c)
+			completed = true
+
+			end(c.Path(), status(c, err))
+			return err
+		}
+	}
+}
+
+// status returns the status code the response will be sent with. Errors are
+// rendered by Echo's error handler after the middleware returns, so their code
+// is derived from the error itself.
+func status(c echo.Context, err error) int {
+	if err == nil || c.Response().Committed {
+		return c.Response().Status
+	}
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return he.Code
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,59 @@
+package echomid
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := metricstest.New()
+
+	e := echo.New()
+	e.Use(New(m))
+	e.GET("/users/:id", func(c echo.Context) error {
+		switch c.Param("id") {
+		case "0":
+			return echo.NewHTTPError(http.StatusNotFound, "no such user")
+		case "boom":
+			return errors.New("database down")
+		}
+		return c.String(http.StatusOK, "ok")
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/0", "/users/boom", "/nowhere"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	out := m.String()
+	assert.Contains(t, out, `http_requests_total{method="GET",path="/users/:id",status="200"} 2`)
+	assert.Contains(t, out, `http_requests_total{method="GET",path="/users/:id",status="404"} 1`)
+	assert.Contains(t, out, `http_requests_total{method="GET",path="/users/:id",status="500"} 1`)
+	assert.Contains(t, out, `http_request_duration_seconds_count{method="GET",path="/users/:id",status="200"} 2`)
+	assert.Contains(t, out, `http_requests_in_flight{method="GET"} 0`)
+	assert.NotContains(t, out, `path="/nowhere"`)
+}
+
+func TestMiddlewarePanic(t *testing.T) {
+	m := metricstest.New()
+
+	e := echo.New()
+	e.Use(middleware.Recover())
+	e.Use(New(m))
+	e.POST("/orders", func(c echo.Context) error {
+		panic("handler failed")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, 1.0, m.CounterValue("http_requests_total", "POST", "/orders", "500"))
+	assert.Equal(t, 0.0, m.GaugeValue("http_requests_in_flight", "POST"))
+}
@@ -0,0 +1,96 @@
+package metricsx
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// EndpointConfig is an additional exposition path serving a subset of the
+// metrics, e.g. a minimal endpoint for a scraper with a tighter cost budget
+type EndpointConfig struct {
+	// Path of the endpoint, e.g. /metrics/minimal
+	Path string `mapstructure:"path"`
+
+	// Filter selects the metrics served, on top of the module-wide filter
+	Filter FilterConfig `mapstructure:"filter"`
+}
+
+// endpoint is a compiled EndpointConfig
+type endpoint struct {
+	path   string
+	filter *metricFilter
+}
+
+// multiHandler is implemented by providers serving several endpoints
+type multiHandler interface {
+	Handlers() map[string]http.Handler
+}
+
+// compileEndpoints validates and compiles the additional endpoints of cfg
+func compileEndpoints(cfg PrometheusConfig) ([]endpoint, error) {
+	seen := map[string]bool{cfg.Path: true}
+	for _, path := range []string{cfg.HealthPath, cfg.JSONPath, cfg.QueryPath, cfg.CatalogPath, cfg.BucketCalibration.Path} {
+		if path == "" {
+			continue
+		}
+		if seen[path] {
+			return nil, fmt.Errorf("metrics path %q is already served", path)
+		}
+		seen[path] = true
+	}
+	endpoints := make([]endpoint, 0, len(cfg.Endpoints))
+	for i, e := range cfg.Endpoints {
+		if e.Path == "" {
+			return nil, fmt.Errorf("metrics endpoint %d: path is required", i)
+		}
+		if seen[e.Path] {
+			return nil, fmt.Errorf("metrics endpoint %d: path %q is already served", i, e.Path)
+		}
+		seen[e.Path] = true
+
+		filter, err := newMetricFilter(e.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("metrics endpoint %s: %w", e.Path, err)
+		}
+		endpoints = append(endpoints, endpoint{path: e.Path, filter: filter})
+	}
+	return endpoints, nil
+}
+
+// Handlers returns the handler for the configured path, every additional
+// endpoint and the JSON endpoint, keyed by path
+func (p *prometheusProvider) Handlers() map[string]http.Handler {
+	handlers := map[string]http.Handler{p.config.Path: p.Handler()}
+	for _, e := range p.endpoints {
+		gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			mfs, err := p.exportGatherer().Gather()
+			return e.filter.apply(mfs), err
+		})
+		handlers[e.path] = p.handlerFor(e.path, gatherer)
+	}
+	if p.config.JSONPath != "" {
+		handlers[p.config.JSONPath] = p.scrapeCollector.instrument(p.config.JSONPath, withAuth(p.jsonHandler(), func() AuthConfig {
+			return *p.auth.Load()
+		}))
+	}
+	if p.config.QueryPath != "" {
+		handlers[p.config.QueryPath] = p.scrapeCollector.instrument(p.config.QueryPath, withAuth(queryHandler(p, p.historyReader()), func() AuthConfig {
+			return *p.auth.Load()
+		}))
+	}
+	if p.config.CatalogPath != "" {
+		handlers[p.config.CatalogPath] = p.scrapeCollector.instrument(p.config.CatalogPath, withAuth(catalogHandler(p), func() AuthConfig {
+			return *p.auth.Load()
+		}))
+	}
+	if p.calibrator != nil && p.config.BucketCalibration.Path != "" {
+		path := p.config.BucketCalibration.Path
+		handlers[path] = p.scrapeCollector.instrument(path, withAuth(p.calibrator.handler(), func() AuthConfig {
+			return *p.auth.Load()
+		}))
+	}
+	return handlers
+}
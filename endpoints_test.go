@@ -0,0 +1,94 @@
+package metricsx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpoints(t *testing.T) {
+	cfg := Config{
+		Provider: "prometheus",
+		Prometheus: PrometheusConfig{
+			Path: "/metrics",
+			Endpoints: []EndpointConfig{
+				{Path: "/metrics/minimal", Filter: FilterConfig{Allow: []string{"orders_*"}}},
+			},
+		},
+	}
+
+	t.Run("serves filtered subsets on additional paths", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: cfg, Logger: getTestLogger()})
+		require.NoError(t, err)
+
+		res.Metrics.Counter("orders_total").Inc()
+		res.Metrics.Counter("cache_hits_total").Inc()
+
+		handlers := res.Provider.(*prometheusProvider).Handlers()
+		require.Len(t, handlers, 2)
+
+		get := func(path string) string {
+			rec := httptest.NewRecorder()
+			handlers[path].ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			return rec.Body.String()
+		}
+
+		full := get("/metrics")
+		assert.Contains(t, full, "orders_total 1")
+		assert.Contains(t, full, "cache_hits_total 1")
+
+		minimal := get("/metrics/minimal")
+		assert.Contains(t, minimal, "orders_total 1")
+		assert.NotContains(t, minimal, "cache_hits_total")
+	})
+
+	t.Run("exposes every endpoint on the metrics server", func(t *testing.T) {
+		serverCfg := cfg
+		serverCfg.Prometheus.Host = "127.0.0.1"
+		serverCfg.Prometheus.Port = RandomPort
+
+		res, err := NewMetrics(Params{Config: serverCfg, Logger: getTestLogger()})
+		require.NoError(t, err)
+		res.Metrics.Counter("orders_total").Inc()
+
+		ctx := context.Background()
+		require.NoError(t, res.Provider.Start(ctx))
+		defer res.Provider.Stop(ctx)
+
+		resp, err := http.Get("http://" + res.Provider.Addr() + "/metrics/minimal")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "orders_total 1")
+	})
+
+	t.Run("rejects invalid endpoints", func(t *testing.T) {
+		for _, endpoints := range [][]EndpointConfig{
+			{{Filter: FilterConfig{Allow: []string{"orders_*"}}}},
+			{{Path: "/metrics"}},
+			{{Path: "/a"}, {Path: "/a"}},
+			{{Path: "/a", Filter: FilterConfig{Deny: []string{"re:("}}}},
+		} {
+			bad := cfg
+			bad.Prometheus.Endpoints = endpoints
+
+			_, err := NewMetrics(Params{Config: bad, Logger: getTestLogger()})
+			assert.Error(t, err, "%+v", endpoints)
+		}
+	})
+
+	t.Run("requires a restart to change endpoints", func(t *testing.T) {
+		provider, err := newPrometheusProviderFromConfig(cfg, getTestLogger())
+		require.NoError(t, err)
+
+		next := cfg
+		next.Prometheus.Endpoints = nil
+		assert.ErrorIs(t, provider.(Reloadable).Reload(next), ErrRestartRequired)
+	})
+}
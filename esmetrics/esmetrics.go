@@ -0,0 +1,161 @@
+// Package esmetrics provides an http.RoundTripper that records Elasticsearch and
+// OpenSearch client requests through metricsx.
+//
+// The transport works with both official Go clients since they accept a custom
+// http.RoundTripper:
+//
+//	es, err := elasticsearch.NewClient(elasticsearch.Config{
+//		Transport: esmetrics.NewTransport(http.DefaultTransport, metrics),
+//	})
+//
+// It records:
+//
+//	elasticsearch_request_duration_seconds{method, endpoint, index, status_class}
+//	elasticsearch_request_retries_total{method, endpoint, index}
+package esmetrics
+
+import (
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"weak"
+
+	"github.com/gostratum/metricsx"
+)
+
+// Option configures the transport
+type Option func(*options)
+
+// options contains configuration for the transport
+type options struct {
+	prefix      string
+	buckets     []float64
+	indexMapper func(string) string
+}
+
+// WithPrefix sets the metric name prefix (default: "elasticsearch")
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithBuckets sets the buckets for the request duration histogram
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// WithIndexMapper sets a function that normalizes index names before they are
+// used as label values, e.g. to collapse date-suffixed indices into one series
+func WithIndexMapper(fn func(index string) string) Option {
+	return func(o *options) {
+		o.indexMapper = fn
+	}
+}
+
+// Transport is an http.RoundTripper that records request metrics
+type Transport struct {
+	next        http.RoundTripper
+	indexMapper func(string) string
+
+	duration metricsx.Histogram
+	retries  metricsx.Counter
+
+	// seen tracks requests already sent once. The clients retry by sending the
+	// same *http.Request again, so a repeated pointer identifies a retry.
+	seen sync.Map
+}
+
+// NewTransport wraps next (http.DefaultTransport if nil) with request metrics
+func NewTransport(next http.RoundTripper, m metricsx.Metrics, opts ...Option) *Transport {
+	o := &options{
+		prefix:  "elasticsearch",
+		buckets: metricsx.DefaultBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Transport{
+		next:        next,
+		indexMapper: o.indexMapper,
+		duration: m.Histogram(o.prefix+"_request_duration_seconds",
+			metricsx.WithHelp("Duration of search engine client requests in seconds."),
+			metricsx.WithLabels("method", "endpoint", "index", "status_class"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+		retries: m.Counter(o.prefix+"_request_retries_total",
+			metricsx.WithHelp("Total number of retried search engine client requests."),
+			metricsx.WithLabels("method", "endpoint", "index"),
+		),
+	}
+}
+
+// RoundTrip executes a single HTTP transaction and records its metrics
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	index, endpoint := parsePath(req.URL.Path)
+	if t.indexMapper != nil && index != "" {
+		index = t.indexMapper(index)
+	}
+
+	if t.isRetry(req) {
+		t.retries.Inc(req.Method, endpoint, index)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	t.duration.Observe(time.Since(start).Seconds(), req.Method, endpoint, index, statusClass(resp, err))
+	return resp, err
+}
+
+// isRetry reports whether req has been sent through the transport before
+func (t *Transport) isRetry(req *http.Request) bool {
+	key := weak.Make(req)
+	if _, loaded := t.seen.LoadOrStore(key, struct{}{}); loaded {
+		return true
+	}
+
+	runtime.AddCleanup(req, func(key weak.Pointer[http.Request]) {
+		t.seen.Delete(key)
+	}, key)
+	return false
+}
+
+// parsePath extracts the index and API endpoint from a request path.
+// "/logs/_search" yields ("logs", "_search"), "/_bulk" yields ("", "_bulk")
+// and "/logs" yields ("logs", "index").
+func parsePath(path string) (string, string) {
+	var index string
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		switch {
+		case segment == "":
+		case strings.HasPrefix(segment, "_"):
+			return index, segment
+		case index == "":
+			index = segment
+		}
+	}
+
+	if index != "" {
+		return index, "index"
+	}
+	return "", "root"
+}
+
+// statusClass returns the status class label ("2xx", "4xx", ...) for a response
+func statusClass(resp *http.Response, err error) string {
+	if err != nil || resp == nil {
+		return "error"
+	}
+	return strconv.Itoa(resp.StatusCode/100) + "xx"
+}
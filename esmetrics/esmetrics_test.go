@@ -0,0 +1,110 @@
+package esmetrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		index    string
+		endpoint string
+	}{
+		{"/logs/_search", "logs", "_search"},
+		{"/logs/_doc/42", "logs", "_doc"},
+		{"/_bulk", "", "_bulk"},
+		{"/_cluster/health", "", "_cluster"},
+		{"/logs", "logs", "index"},
+		{"/", "", "root"},
+	}
+
+	for _, tt := range tests {
+		index, endpoint := parsePath(tt.path)
+		assert.Equal(t, tt.index, index, tt.path)
+		assert.Equal(t, tt.endpoint, endpoint, tt.path)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	t.Run("records request duration by status class", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.Contains(r.URL.Path, "missing") {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		m := metricstest.New()
+		client := &http.Client{Transport: NewTransport(nil, m)}
+
+		for _, path := range []string{"/logs/_search", "/logs/_search", "/missing/_doc/1"} {
+			resp, err := client.Get(server.URL + path)
+			require.NoError(t, err)
+			resp.Body.Close()
+		}
+
+		out := m.String()
+		assert.Contains(t, out, `elasticsearch_request_duration_seconds_count{endpoint="_search",index="logs",method="GET",status_class="2xx"} 2`)
+		assert.Contains(t, out, `elasticsearch_request_duration_seconds_count{endpoint="_doc",index="missing",method="GET",status_class="4xx"} 1`)
+	})
+
+	t.Run("records transport errors", func(t *testing.T) {
+		m := metricstest.New()
+		transport := NewTransport(roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		}), m, WithPrefix("opensearch"))
+
+		req := httptest.NewRequest(http.MethodPost, "http://search/_bulk", nil)
+		_, err := transport.RoundTrip(req)
+		require.Error(t, err)
+
+		assert.Contains(t, m.String(), `opensearch_request_duration_seconds_count{endpoint="_bulk",index="",method="POST",status_class="error"} 1`)
+	})
+
+	t.Run("counts retries of the same request", func(t *testing.T) {
+		m := metricstest.New()
+		transport := NewTransport(roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+		}), m)
+
+		req := httptest.NewRequest(http.MethodGet, "http://search/logs/_search", nil)
+		for range 3 {
+			_, err := transport.RoundTrip(req)
+			require.NoError(t, err)
+		}
+
+		other := httptest.NewRequest(http.MethodGet, "http://search/logs/_search", nil)
+		_, err := transport.RoundTrip(other)
+		require.NoError(t, err)
+
+		out := m.String()
+		assert.Contains(t, out, `elasticsearch_request_retries_total{endpoint="_search",index="logs",method="GET"} 2`)
+		assert.Contains(t, out, `elasticsearch_request_duration_seconds_count{endpoint="_search",index="logs",method="GET",status_class="5xx"} 4`)
+	})
+
+	t.Run("normalizes index names", func(t *testing.T) {
+		m := metricstest.New()
+		transport := NewTransport(roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}), m, WithIndexMapper(func(index string) string {
+			return strings.SplitN(index, "-", 2)[0]
+		}))
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://search/logs-2026.10.18/_search", nil))
+		require.NoError(t, err)
+
+		assert.Contains(t, m.String(), `index="logs"`)
+	})
+}
@@ -0,0 +1,72 @@
+package metricsx
+
+import "strings"
+
+// EventConfig controls how an event maps to its counter
+type EventConfig struct {
+	// Labels are the attributes kept as labels; other attributes are dropped
+	Labels []string `mapstructure:"labels"`
+
+	// Help text of the event counter
+	Help string `mapstructure:"help"`
+}
+
+// eventCounter is the counter of an event and the attributes it keeps
+type eventCounter struct {
+	counter Counter
+	labels  []string
+}
+
+// eventMetricName returns the counter name of the event name
+func eventMetricName(name string) string {
+	if strings.HasSuffix(name, "_total") {
+		return name
+	}
+	return name + "_total"
+}
+
+// Event counts an occurrence of the business event name. The event is the
+// counter name with a _total suffix; only the attributes listed in the
+// event's configuration become labels, missing ones are recorded empty, so
+// product code can pass whatever it knows without exploding cardinality.
+// Events without configuration are counted without labels.
+func (m *metricsImpl) Event(name string, attrs map[string]string) {
+	e := m.event(name)
+
+	values := make([]string, len(e.labels))
+	for i, label := range e.labels {
+		values[i] = attrs[label]
+	}
+	e.counter.Inc(values...)
+}
+
+// event returns the counter of the event name, creating it on first use
+func (m *metricsImpl) event(name string) *eventCounter {
+	m.mu.Lock()
+	e, ok := m.events[name]
+	m.mu.Unlock()
+	if ok {
+		return e
+	}
+
+	cfg := m.settings().events[name]
+	help := cfg.Help
+	if help == "" {
+		help = "Total number of " + strings.ReplaceAll(strings.TrimSuffix(name, "_total"), "_", " ") + " events."
+	}
+	e = &eventCounter{
+		counter: m.Counter(eventMetricName(name), WithHelp(help), WithLabels(cfg.Labels...)),
+		labels:  cfg.Labels,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.events[name]; ok {
+		return existing
+	}
+	if m.events == nil {
+		m.events = make(map[string]*eventCounter)
+	}
+	m.events[name] = e
+	return e
+}
@@ -0,0 +1,50 @@
+package metricsx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvent(t *testing.T) {
+	newMetrics := func(t *testing.T, events map[string]EventConfig) (Metrics, Provider) {
+		res, err := NewMetrics(Params{Config: Config{Provider: "prometheus", Events: events}, Logger: getTestLogger()})
+		require.NoError(t, err)
+		return res.Metrics, res.Provider
+	}
+
+	t.Run("keeps configured attributes as labels", func(t *testing.T) {
+		m, provider := newMetrics(t, map[string]EventConfig{
+			"signup": {Labels: []string{"plan", "source"}, Help: "Completed signups."},
+		})
+
+		m.Event("signup", map[string]string{"plan": "pro", "source": "ads", "user_id": "42"})
+		m.Event("signup", map[string]string{"plan": "pro", "source": "ads", "user_id": "43"})
+		m.Event("signup", map[string]string{"plan": "free"})
+
+		body := scrape(t, provider)
+		assert.Contains(t, body, "# HELP signup_total Completed signups.")
+		assert.Contains(t, body, `signup_total{plan="pro",source="ads"} 2`)
+		assert.Contains(t, body, `signup_total{plan="free",source=""} 1`)
+		assert.NotContains(t, body, "user_id")
+	})
+
+	t.Run("counts unconfigured events without labels", func(t *testing.T) {
+		m, provider := newMetrics(t, nil)
+
+		m.Event("cart_abandoned", map[string]string{"user_id": "42"})
+		m.Event("cart_abandoned_total", nil)
+
+		body := scrape(t, provider)
+		assert.Contains(t, body, "# HELP cart_abandoned_total Total number of cart abandoned events.")
+		assert.Contains(t, body, "cart_abandoned_total 2")
+	})
+
+	t.Run("registers the event counter for MustHave", func(t *testing.T) {
+		m, _ := newMetrics(t, nil)
+
+		m.Event("checkout", nil)
+		assert.NoError(t, m.MustHave("checkout_total"))
+	})
+}
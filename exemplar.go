@@ -0,0 +1,51 @@
+package metricsx
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exemplar label names attached by the context-aware recording methods
+const (
+	ExemplarTraceID = "trace_id"
+	ExemplarSpanID  = "span_id"
+)
+
+// exemplarLabels returns the trace and span IDs of the sampled span in ctx,
+// or nil when ctx carries none
+func exemplarLabels(ctx context.Context) prometheus.Labels {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() || !sc.IsSampled() {
+		return nil
+	}
+	return prometheus.Labels{
+		ExemplarTraceID: sc.TraceID().String(),
+		ExemplarSpanID:  sc.SpanID().String(),
+	}
+}
+
+// addWithExemplar adds value to c, attaching the span in ctx as an exemplar
+// when there is one and c supports exemplars
+func addWithExemplar(ctx context.Context, c prometheus.Counter, value float64) {
+	if exemplar := exemplarLabels(ctx); exemplar != nil {
+		if adder, ok := c.(prometheus.ExemplarAdder); ok {
+			adder.AddWithExemplar(value, exemplar)
+			return
+		}
+	}
+	c.Add(value)
+}
+
+// observeWithExemplar observes value into o, attaching the span in ctx as an
+// exemplar when there is one and o supports exemplars
+func observeWithExemplar(ctx context.Context, o prometheus.Observer, value float64) {
+	if exemplar := exemplarLabels(ctx); exemplar != nil {
+		if observer, ok := o.(prometheus.ExemplarObserver); ok {
+			observer.ObserveWithExemplar(value, exemplar)
+			return
+		}
+	}
+	o.Observe(value)
+}
@@ -0,0 +1,111 @@
+package metricsx
+
+import (
+	"context"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedContext returns a context carrying a span with the given sampling decision
+func tracedContext(sampled bool) context.Context {
+	cfg := trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	}
+	if sampled {
+		cfg.TraceFlags = trace.FlagsSampled
+	}
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(cfg))
+}
+
+// exemplarOf returns the exemplar of the first series of the family name
+func exemplarOf(t *testing.T, provider Provider, name string) *dto.Exemplar {
+	t.Helper()
+
+	mfs, err := provider.(Gatherable).Gatherer().Gather()
+	require.NoError(t, err)
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+		}
+		m := mf.GetMetric()[0]
+		if m.GetCounter() != nil {
+			return m.GetCounter().GetExemplar()
+		}
+		for _, bucket := range m.GetHistogram().GetBucket() {
+			if bucket.GetExemplar() != nil {
+				return bucket.GetExemplar()
+			}
+		}
+		return nil
+	}
+	t.Fatalf("metric %s not found", name)
+	return nil
+}
+
+// exemplarLabelMap returns the labels of exemplar as a map
+func exemplarLabelMap(exemplar *dto.Exemplar) map[string]string {
+	labels := make(map[string]string)
+	for _, pair := range exemplar.GetLabel() {
+		labels[pair.GetName()] = pair.GetValue()
+	}
+	return labels
+}
+
+func TestExemplars(t *testing.T) {
+	wantLabels := map[string]string{
+		ExemplarTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		ExemplarSpanID:  "00f067aa0ba902b7",
+	}
+
+	t.Run("attaches the sampled span to counters", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+		counter := provider.Counter("requests_total", &Options{Labels: []string{"method"}})
+		counter.IncCtx(tracedContext(true), "GET")
+		counter.AddCtx(tracedContext(true), 2, "GET")
+
+		exemplar := exemplarOf(t, provider, "requests_total")
+		require.NotNil(t, exemplar)
+		assert.Equal(t, wantLabels, exemplarLabelMap(exemplar))
+		assert.Equal(t, 2.0, exemplar.GetValue())
+	})
+
+	t.Run("attaches the sampled span to histograms", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+		histogram := provider.Histogram("request_duration_seconds", &Options{Buckets: DefaultBuckets})
+		histogram.ObserveCtx(tracedContext(true), 0.42)
+
+		exemplar := exemplarOf(t, provider, "request_duration_seconds")
+		require.NotNil(t, exemplar)
+		assert.Equal(t, wantLabels, exemplarLabelMap(exemplar))
+		assert.Equal(t, 0.42, exemplar.GetValue())
+	})
+
+	t.Run("records without exemplar when the span is not sampled", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+		counter := provider.Counter("requests_total", &Options{})
+		counter.IncCtx(tracedContext(false))
+		counter.IncCtx(context.Background())
+
+		assert.Nil(t, exemplarOf(t, provider, "requests_total"))
+		assert.Contains(t, scrape(t, provider), "requests_total 2")
+	})
+
+	t.Run("forwards through label rules", func(t *testing.T) {
+		res, err := NewMetrics(Params{
+			Config: Config{
+				Provider:   "prometheus",
+				LabelRules: []LabelRule{{Label: "user", Action: "drop"}},
+			},
+			Logger: getTestLogger(),
+		})
+		require.NoError(t, err)
+
+		res.Metrics.Counter("logins_total", WithLabels("user")).IncCtx(tracedContext(true), "alice")
+		assert.NotNil(t, exemplarOf(t, res.Provider, "logins_total"))
+	})
+}
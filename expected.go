@@ -0,0 +1,96 @@
+package metricsx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gostratum/core"
+	"github.com/gostratum/core/logx"
+	"go.uber.org/fx"
+)
+
+// ErrMissingMetrics is returned by MustHave when expected metrics were never
+// registered
+var ErrMissingMetrics = errors.New("metrics never registered")
+
+// MustHave returns ErrMissingMetrics naming every metric in names that was
+// never registered. Names are those passed to Metrics, without namespace or
+// subsystem.
+func (m *metricsImpl) MustHave(names ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var missing []string
+	for _, name := range names {
+		if _, ok := m.registered[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingMetrics, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// register records that name was registered
+func (m *metricsImpl) register(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.registered == nil {
+		m.registered = make(map[string]struct{})
+	}
+	m.registered[name] = struct{}{}
+}
+
+// expectedCheck is a readiness check failing while expected metrics are missing
+type expectedCheck struct {
+	metrics Metrics
+	names   []string
+}
+
+func (c *expectedCheck) Name() string {
+	return "metrics"
+}
+
+func (c *expectedCheck) Kind() core.Kind {
+	return core.Readiness
+}
+
+func (c *expectedCheck) Check(ctx context.Context) error {
+	return c.metrics.MustHave(c.names...)
+}
+
+// expectedParams contains the dependencies for checking expected metrics
+type expectedParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Config    Config
+	Metrics   Metrics
+	Logger    logx.Logger
+	Registry  core.Registry `optional:"true"`
+}
+
+// registerExpected checks the configured expected metrics after start, and on
+// every readiness probe when a health registry is available
+func registerExpected(p expectedParams) {
+	if len(p.Config.Expected) == 0 {
+		return
+	}
+
+	check := &expectedCheck{metrics: p.Metrics, names: p.Config.Expected}
+	if p.Registry != nil {
+		p.Registry.Register(check)
+	}
+
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := check.Check(ctx); err != nil {
+				p.Logger.Error("expected metrics are missing", logx.Err(err))
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,51 @@
+package metricsx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gostratum/core"
+	"github.com/gostratum/core/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+)
+
+func TestMustHave(t *testing.T) {
+	m := &metricsImpl{provider: newNoopProvider(), logger: getTestLogger()}
+	m.Counter("orders_total")
+	m.GaugeFunc("queue_depth", func() float64 { return 0 })
+
+	assert.NoError(t, m.MustHave("orders_total", "queue_depth"))
+
+	err := m.MustHave("orders_total", "payments_total", "refunds_total")
+	require.ErrorIs(t, err, ErrMissingMetrics)
+	assert.Contains(t, err.Error(), "payments_total, refunds_total")
+}
+
+func TestExpectedReadiness(t *testing.T) {
+	registry := core.NewHealthRegistry()
+	var metrics Metrics
+
+	app := fx.New(
+		fx.NopLogger,
+		fx.Supply(Config{Provider: "noop", Expected: []string{"orders_total"}}),
+		fx.Provide(
+			func() logx.Logger { return getTestLogger() },
+			func() core.Registry { return registry },
+			NewMetrics,
+		),
+		fx.Invoke(registerExpected),
+		fx.Populate(&metrics),
+	)
+	require.NoError(t, app.Err())
+
+	ctx := context.Background()
+	require.NoError(t, app.Start(ctx))
+	defer app.Stop(ctx)
+
+	assert.False(t, registry.Aggregate(ctx, core.Readiness).OK)
+
+	metrics.Counter("orders_total")
+	assert.True(t, registry.Aggregate(ctx, core.Readiness).OK)
+}
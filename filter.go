@@ -0,0 +1,105 @@
+package metricsx
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// FilterConfig selects which metrics are exported. Patterns are globs matched
+// against the full metric name (e.g. "go_gc_*"); a "re:" prefix makes a
+// pattern a regular expression anchored to the whole name (e.g. "re:http_.+_bytes").
+type FilterConfig struct {
+	// Allow exports only metrics matching at least one pattern. Empty allows all.
+	Allow []string `mapstructure:"allow"`
+
+	// Deny suppresses metrics matching any pattern, after Allow is applied
+	Deny []string `mapstructure:"deny"`
+}
+
+// metricFilter is a compiled FilterConfig
+type metricFilter struct {
+	allow []func(string) bool
+	deny  []func(string) bool
+}
+
+// newMetricFilter compiles cfg, returning nil when no patterns are configured
+func newMetricFilter(cfg FilterConfig) (*metricFilter, error) {
+	if len(cfg.Allow) == 0 && len(cfg.Deny) == 0 {
+		return nil, nil
+	}
+
+	allow, err := compilePatterns(cfg.Allow)
+	if err != nil {
+		return nil, fmt.Errorf("metrics filter allow: %w", err)
+	}
+	deny, err := compilePatterns(cfg.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("metrics filter deny: %w", err)
+	}
+
+	return &metricFilter{allow: allow, deny: deny}, nil
+}
+
+// compilePatterns compiles glob and "re:" patterns into matchers
+func compilePatterns(patterns []string) ([]func(string) bool, error) {
+	matchers := make([]func(string) bool, 0, len(patterns))
+	for _, pattern := range patterns {
+		if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+			re, err := regexp.Compile("^(?:" + expr + ")$")
+			if err != nil {
+				return nil, err
+			}
+			matchers = append(matchers, re.MatchString)
+			continue
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+		matchers = append(matchers, func(name string) bool {
+			ok, _ := path.Match(pattern, name)
+			return ok
+		})
+	}
+	return matchers, nil
+}
+
+// allowed reports whether the metric name passes the filter
+func (f *metricFilter) allowed(name string) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.allow) > 0 && !matchAny(f.allow, name) {
+		return false
+	}
+	return !matchAny(f.deny, name)
+}
+
+// apply removes filtered metric families from mfs in place
+func (f *metricFilter) apply(mfs []*dto.MetricFamily) []*dto.MetricFamily {
+	if f == nil {
+		return mfs
+	}
+
+	kept := mfs[:0]
+	for _, mf := range mfs {
+		if f.allowed(mf.GetName()) {
+			kept = append(kept, mf)
+		}
+	}
+	return kept
+}
+
+// matchAny reports whether any matcher matches name
+func matchAny(matchers []func(string) bool, name string) bool {
+	for _, match := range matchers {
+		if match(name) {
+			return true
+		}
+	}
+	return false
+}
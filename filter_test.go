@@ -0,0 +1,86 @@
+package metricsx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricFilter(t *testing.T) {
+	t.Run("matches globs and regular expressions", func(t *testing.T) {
+		f, err := newMetricFilter(FilterConfig{
+			Allow: []string{"http_*", "re:go_(goroutines|threads)"},
+			Deny:  []string{"http_*_bytes"},
+		})
+		require.NoError(t, err)
+
+		assert.True(t, f.allowed("http_requests_total"))
+		assert.False(t, f.allowed("http_response_size_bytes"))
+		assert.True(t, f.allowed("go_goroutines"))
+		assert.False(t, f.allowed("go_goroutines_extra"))
+		assert.False(t, f.allowed("db_queries_total"))
+	})
+
+	t.Run("allows everything when empty", func(t *testing.T) {
+		f, err := newMetricFilter(FilterConfig{})
+		require.NoError(t, err)
+		assert.Nil(t, f)
+		assert.True(t, f.allowed("anything"))
+	})
+
+	t.Run("rejects invalid patterns", func(t *testing.T) {
+		_, err := newMetricFilter(FilterConfig{Deny: []string{"re:("}})
+		assert.Error(t, err)
+
+		_, err = newMetricFilter(FilterConfig{Allow: []string{"[a-"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestPrometheusFilter(t *testing.T) {
+	cfg := Config{
+		Enabled:  true,
+		Provider: "prometheus",
+		Filter:   FilterConfig{Deny: []string{"go_*", "debug_*"}},
+		Prometheus: PrometheusConfig{
+			Path:            "/metrics",
+			EnableGoMetrics: true,
+		},
+	}
+
+	t.Run("suppresses denied metrics at gather time", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: cfg, Logger: getTestLogger()})
+		require.NoError(t, err)
+
+		res.Metrics.Counter("debug_events_total").Inc()
+		res.Metrics.Counter("orders_total").Inc()
+
+		out := scrape(t, res.Provider)
+		assert.Contains(t, out, "orders_total 1")
+		assert.NotContains(t, out, "debug_events_total")
+		assert.NotContains(t, out, "go_goroutines")
+	})
+
+	t.Run("updates rules on reload", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: cfg, Logger: getTestLogger()})
+		require.NoError(t, err)
+		res.Metrics.Counter("debug_events_total").Inc()
+
+		next := cfg
+		next.Filter = FilterConfig{Deny: []string{"go_*"}}
+		require.NoError(t, res.Provider.(Reloadable).Reload(next))
+		assert.Contains(t, scrape(t, res.Provider), "debug_events_total 1")
+
+		next.Filter = FilterConfig{Deny: []string{"re:("}}
+		assert.Error(t, res.Provider.(Reloadable).Reload(next))
+	})
+
+	t.Run("fails on invalid patterns", func(t *testing.T) {
+		bad := cfg
+		bad.Filter = FilterConfig{Allow: []string{"re:["}}
+
+		_, err := NewMetrics(Params{Config: bad, Logger: getTestLogger()})
+		assert.Error(t, err)
+	})
+}
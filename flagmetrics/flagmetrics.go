@@ -0,0 +1,110 @@
+// Package flagmetrics records feature flag evaluations and states through
+// metricsx so incidents can be correlated with flag flips. It does not depend
+// on a flag SDK: the flag provider, or a hook registered with it, reports to
+// the Reporter interface.
+//
+//	flags := flagmetrics.New(metrics)
+//	client.OnChange(func(flag string, enabled bool) {
+//		flags.SetState(flag, enabled)
+//	})
+//	if flags.Bool("new-checkout", client.Enabled("new-checkout")) {
+//		...
+//	}
+//
+// It records:
+//
+//	feature_flag_evaluations_total{flag, variant}
+//	feature_flag_state{flag}
+//	feature_flag_last_change_timestamp_seconds{flag}
+//
+// The state is 1 while the flag is enabled and 0 otherwise. The change
+// timestamp is only updated when a reported state differs from the previous
+// one, so it marks the flips to overlay on dashboards.
+package flagmetrics
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gostratum/metricsx"
+)
+
+// Reporter is the integration point for feature flag providers
+type Reporter interface {
+	// Evaluated records one evaluation of flag resolving to variant
+	Evaluated(flag, variant string)
+
+	// SetState records whether flag is currently enabled
+	SetState(flag string, enabled bool)
+}
+
+// Metrics records feature flag evaluations and states
+type Metrics struct {
+	evaluations metricsx.Counter
+	state       metricsx.Gauge
+	changed     metricsx.Gauge
+
+	mu     sync.Mutex
+	states map[string]bool
+}
+
+var _ Reporter = (*Metrics)(nil)
+
+// New creates the feature flag metrics
+func New(m metricsx.Metrics) *Metrics {
+	return &Metrics{
+		evaluations: m.Counter("feature_flag_evaluations_total",
+			metricsx.WithHelp("Total number of feature flag evaluations by resolved variant."),
+			metricsx.WithLabels("flag", "variant"),
+		),
+		state: m.Gauge("feature_flag_state",
+			metricsx.WithHelp("Current state of the feature flag (1 enabled, 0 disabled)."),
+			metricsx.WithLabels("flag"),
+		),
+		changed: m.Gauge("feature_flag_last_change_timestamp_seconds",
+			metricsx.WithHelp("Unix timestamp of the last change of the feature flag state."),
+			metricsx.WithLabels("flag"),
+		),
+		states: make(map[string]bool),
+	}
+}
+
+// Evaluated records one evaluation of flag resolving to variant
+func (fm *Metrics) Evaluated(flag, variant string) {
+	fm.evaluations.Inc(flag, variant)
+}
+
+// Bool records an evaluation of a boolean flag, with variant "true" or
+// "false", and returns value
+func (fm *Metrics) Bool(flag string, value bool) bool {
+	fm.Evaluated(flag, strconv.FormatBool(value))
+	return value
+}
+
+// SetState records whether flag is currently enabled, updating the change
+// timestamp when the state differs from the last one reported
+func (fm *Metrics) SetState(flag string, enabled bool) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	previous, known := fm.states[flag]
+	fm.states[flag] = enabled
+
+	value := 0.0
+	if enabled {
+		value = 1
+	}
+	fm.state.Set(value, flag)
+	if !known || previous != enabled {
+		fm.changed.Set(float64(time.Now().UnixNano())/1e9, flag)
+	}
+}
+
+// SetStates records the state of every flag in states, as loaded from a
+// flag provider snapshot
+func (fm *Metrics) SetStates(states map[string]bool) {
+	for flag, enabled := range states {
+		fm.SetState(flag, enabled)
+	}
+}
@@ -0,0 +1,57 @@
+package flagmetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Run("counts evaluations by variant", func(t *testing.T) {
+		m := metricstest.New()
+		flags := New(m)
+
+		assert.True(t, flags.Bool("new-checkout", true))
+		assert.False(t, flags.Bool("new-checkout", false))
+		flags.Bool("new-checkout", true)
+		flags.Evaluated("search-ranking", "v2")
+
+		assert.Equal(t, 2.0, m.CounterValue("feature_flag_evaluations_total", "new-checkout", "true"))
+		assert.Equal(t, 1.0, m.CounterValue("feature_flag_evaluations_total", "new-checkout", "false"))
+		assert.Equal(t, 1.0, m.CounterValue("feature_flag_evaluations_total", "search-ranking", "v2"))
+	})
+
+	t.Run("tracks state and the time of the last flip", func(t *testing.T) {
+		m := metricstest.New()
+		flags := New(m)
+		changed := func() float64 {
+			return m.GaugeValue("feature_flag_last_change_timestamp_seconds", "new-checkout")
+		}
+
+		flags.SetState("new-checkout", true)
+		assert.Equal(t, 1.0, m.GaugeValue("feature_flag_state", "new-checkout"))
+		first := changed()
+		assert.InDelta(t, float64(time.Now().Unix()), first, 1)
+
+		time.Sleep(10 * time.Millisecond)
+		flags.SetState("new-checkout", true)
+		assert.Equal(t, first, changed())
+
+		flags.SetState("new-checkout", false)
+		assert.Equal(t, 0.0, m.GaugeValue("feature_flag_state", "new-checkout"))
+		assert.Greater(t, changed(), first)
+	})
+
+	t.Run("records provider snapshots", func(t *testing.T) {
+		m := metricstest.New()
+		flags := New(m)
+
+		flags.SetStates(map[string]bool{"a": true, "b": false})
+
+		assert.Equal(t, 1.0, m.GaugeValue("feature_flag_state", "a"))
+		assert.Equal(t, 0.0, m.GaugeValue("feature_flag_state", "b"))
+		assert.True(t, m.Registered("feature_flag_evaluations_total"))
+	})
+}
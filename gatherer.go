@@ -0,0 +1,26 @@
+package metricsx
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Gatherable is implemented by providers backed by a Prometheus registry. The
+// gatherer returns the exported families, after filtering, for tools such as
+// testutil or custom exporters.
+type Gatherable interface {
+	Gatherer() prometheus.Gatherer
+}
+
+// Gatherer returns the gatherer of the provider's registry
+func (p *prometheusProvider) Gatherer() prometheus.Gatherer {
+	return p.exportGatherer()
+}
+
+// Gatherer returns the gatherer of the first member backed by a registry, or
+// nil when there is none
+func (p *fanoutProvider) Gatherer() prometheus.Gatherer {
+	for _, provider := range p.providers {
+		if g, ok := provider.(Gatherable); ok {
+			return g.Gatherer()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package metricsx
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/gostratum/core/logx"
+)
+
+// InstanceLabel is the label set by Config.AddInstanceLabel
+const InstanceLabel = "instance"
+
+// labelNamePattern matches valid metric label names
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// resolveGlobalLabels builds the const labels attached to every metric from cfg
+func resolveGlobalLabels(cfg Config, logger logx.Logger) (map[string]string, error) {
+	labels := make(map[string]string)
+
+	for label, env := range cfg.LabelsFromEnv {
+		if !labelNamePattern.MatchString(label) {
+			return nil, fmt.Errorf("metrics labels_from_env: invalid label name %q", label)
+		}
+
+		value, ok := os.LookupEnv(env)
+		if !ok || value == "" {
+			logger.Warn("metrics label environment variable is not set",
+				logx.String("label", label),
+				logx.String("env", env),
+			)
+			continue
+		}
+		labels[label] = value
+	}
+
+	if cfg.AddInstanceLabel {
+		instance := cfg.InstanceID
+		if instance == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("metrics instance label: %w", err)
+			}
+			instance = hostname
+		}
+		labels[InstanceLabel] = instance
+	}
+
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	return labels, nil
+}
+
+// withGlobalLabels merges global labels into the const labels of options.
+// Labels set on the metric, as variable or const labels, take precedence.
+func withGlobalLabels(options *Options, global map[string]string) {
+	if len(global) == 0 {
+		return
+	}
+
+	merged := make(map[string]string, len(global)+len(options.ConstLabels))
+	for k, v := range global {
+		if !slices.Contains(options.Labels, k) {
+			merged[k] = v
+		}
+	}
+	maps.Copy(merged, options.ConstLabels)
+	options.ConstLabels = merged
+}
@@ -0,0 +1,104 @@
+package metricsx
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelsFromEnv(t *testing.T) {
+	newMetrics := func(t *testing.T, labels map[string]string) (Metrics, Provider, error) {
+		t.Helper()
+
+		res, err := NewMetrics(Params{
+			Config: Config{
+				Enabled:       true,
+				Provider:      "prometheus",
+				LabelsFromEnv: labels,
+				Prometheus:    PrometheusConfig{Path: "/metrics"},
+			},
+			Logger: getTestLogger(),
+		})
+		return res.Metrics, res.Provider, err
+	}
+
+	t.Run("attaches environment values to every metric", func(t *testing.T) {
+		t.Setenv("TEST_POD_NAME", "api-7d9f")
+		t.Setenv("TEST_NODE_NAME", "node-3")
+
+		m, provider, err := newMetrics(t, map[string]string{"pod": "TEST_POD_NAME", "node": "TEST_NODE_NAME"})
+		require.NoError(t, err)
+
+		m.Counter("orders_total", WithLabels("status")).Inc("paid")
+		m.GaugeFunc("queue_depth", func() float64 { return 2 })
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `orders_total{node="node-3",pod="api-7d9f",status="paid"} 1`)
+		assert.Contains(t, out, `queue_depth{node="node-3",pod="api-7d9f"} 2`)
+	})
+
+	t.Run("metric labels take precedence", func(t *testing.T) {
+		t.Setenv("TEST_POD_NAME", "api-7d9f")
+
+		m, provider, err := newMetrics(t, map[string]string{"pod": "TEST_POD_NAME"})
+		require.NoError(t, err)
+
+		m.Counter("jobs_total", WithLabels("pod")).Inc("worker-1")
+		m.Gauge("leader", WithConstLabels(map[string]string{"pod": "static"})).Set(1)
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `jobs_total{pod="worker-1"} 1`)
+		assert.Contains(t, out, `leader{pod="static"} 1`)
+	})
+
+	t.Run("skips unset variables", func(t *testing.T) {
+		m, provider, err := newMetrics(t, map[string]string{"pod": "TEST_UNSET_POD_NAME"})
+		require.NoError(t, err)
+
+		m.Counter("orders_total").Inc()
+		assert.Contains(t, scrape(t, provider), "orders_total 1")
+	})
+
+	t.Run("rejects invalid label names", func(t *testing.T) {
+		_, _, err := newMetrics(t, map[string]string{"pod-name": "TEST_POD_NAME"})
+		assert.Error(t, err)
+	})
+}
+
+func TestInstanceLabel(t *testing.T) {
+	newMetrics := func(t *testing.T, instanceID string) (Metrics, Provider) {
+		t.Helper()
+
+		res, err := NewMetrics(Params{
+			Config: Config{
+				Enabled:          true,
+				Provider:         "prometheus",
+				AddInstanceLabel: true,
+				InstanceID:       instanceID,
+				Prometheus:       PrometheusConfig{Path: "/metrics"},
+			},
+			Logger: getTestLogger(),
+		})
+		require.NoError(t, err)
+		return res.Metrics, res.Provider
+	}
+
+	t.Run("uses the hostname by default", func(t *testing.T) {
+		hostname, err := os.Hostname()
+		require.NoError(t, err)
+
+		m, provider := newMetrics(t, "")
+		m.Counter("pushes_total").Inc()
+
+		assert.Contains(t, scrape(t, provider), `pushes_total{instance="`+hostname+`"} 1`)
+	})
+
+	t.Run("uses the configured instance ID", func(t *testing.T) {
+		m, provider := newMetrics(t, "replica-2")
+		m.Counter("pushes_total").Inc()
+
+		assert.Contains(t, scrape(t, provider), `pushes_total{instance="replica-2"} 1`)
+	})
+}
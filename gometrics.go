@@ -0,0 +1,43 @@
+package metricsx
+
+import (
+	"path"
+	"runtime/metrics"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+// GoRuntimeMetricName returns the name under which the Go collector exposes
+// the runtime/metrics metric name, e.g. go_sched_latencies_seconds for
+// "/sched/latencies:seconds". It reports false when the running Go version
+// does not export the metric or its kind cannot be exposed.
+func GoRuntimeMetricName(name string) (string, bool) {
+	for _, d := range metrics.All() {
+		if d.Name == name {
+			return goRuntimeMetricName(d)
+		}
+	}
+	return "", false
+}
+
+// goRuntimeMetricName maps d the way the client_golang Go collector does:
+// the path becomes the subsystem and name, the unit is appended and
+// cumulative non-histogram metrics get a _total suffix
+func goRuntimeMetricName(d metrics.Description) (string, bool) {
+	key, unit, ok := strings.Cut(d.Name, ":")
+	if !ok || d.Kind == metrics.KindBad {
+		return "", false
+	}
+
+	replacer := strings.NewReplacer("/", "_", "-", "_")
+	subsystem := replacer.Replace(path.Dir(key[1:]))
+	name := strings.ReplaceAll(path.Base(key), "-", "_")
+	unit = strings.NewReplacer("-", "_", "*", "_", "/", "_per_").Replace(unit)
+
+	name = "go_" + subsystem + "_" + name + "_" + unit
+	if d.Cumulative && d.Kind != metrics.KindFloat64Histogram {
+		name += "_total"
+	}
+	return name, model.LegacyValidation.IsValidMetricName(name)
+}
@@ -0,0 +1,59 @@
+package metricsx
+
+import (
+	"runtime/metrics"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoRuntimeMetricName(t *testing.T) {
+	tests := []struct {
+		runtime string
+		want    string
+	}{
+		{"/sched/latencies:seconds", "go_sched_latencies_seconds"},
+		{"/gc/cycles/automatic:gc-cycles", "go_gc_cycles_automatic_gc_cycles_total"},
+		{"/memory/classes/heap/free:bytes", "go_memory_classes_heap_free_bytes"},
+		{"/gc/heap/allocs-by-size:bytes", "go_gc_heap_allocs_by_size_bytes"},
+	}
+	for _, tt := range tests {
+		name, ok := GoRuntimeMetricName(tt.runtime)
+		assert.True(t, ok, tt.runtime)
+		assert.Equal(t, tt.want, name)
+	}
+
+	_, ok := GoRuntimeMetricName("/no/such:metric")
+	assert.False(t, ok)
+}
+
+func TestEnableAllGoMetrics(t *testing.T) {
+	logger := getTestLogger()
+
+	t.Run("exposes every runtime metric", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{
+			Path:               "/metrics",
+			EnableGoMetrics:    true,
+			EnableAllGoMetrics: true,
+		}, logger)
+
+		out := scrape(t, provider)
+		for _, d := range metrics.All() {
+			name, ok := goRuntimeMetricName(d)
+			if !ok {
+				continue
+			}
+			assert.Contains(t, out, "\n# TYPE "+name+" ", d.Name)
+		}
+		assert.Contains(t, out, "go_goroutines")
+	})
+
+	t.Run("default set omits runtime histograms", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{
+			Path:            "/metrics",
+			EnableGoMetrics: true,
+		}, logger)
+
+		assert.NotContains(t, scrape(t, provider), "go_sched_latencies_seconds")
+	})
+}
@@ -0,0 +1,50 @@
+package metricsx
+
+import (
+	"sync"
+)
+
+// GoroutineTracker attributes running goroutines to the components that started
+// them, so leaks can be traced beyond the global go_goroutines value
+type GoroutineTracker struct {
+	active  Gauge
+	started Counter
+}
+
+// NewGoroutineTracker creates the goroutines_active{component} gauge and the
+// goroutines_started_total{component} counter
+func NewGoroutineTracker(m Metrics) *GoroutineTracker {
+	return &GoroutineTracker{
+		active: m.Gauge("goroutines_active",
+			WithHelp("Number of goroutines currently running per component."),
+			WithLabels("component"),
+		),
+		started: m.Counter("goroutines_started_total",
+			WithHelp("Total number of goroutines started per component."),
+			WithLabels("component"),
+		),
+	}
+}
+
+// Start records that component started a goroutine. Call the returned function
+// when the goroutine exits; extra calls are ignored.
+func (t *GoroutineTracker) Start(component string) func() {
+	t.started.Inc(component)
+	t.active.Inc(component)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.active.Dec(component)
+		})
+	}
+}
+
+// Go runs fn in a new goroutine tracked under component
+func (t *GoroutineTracker) Go(component string, fn func()) {
+	done := t.Start(component)
+	go func() {
+		defer done()
+		fn()
+	}()
+}
@@ -0,0 +1,44 @@
+package metricsx
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoroutineTracker(t *testing.T) {
+	t.Run("tracks running goroutines per component", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		tracker := NewGoroutineTracker(&metricsImpl{provider: provider, logger: getTestLogger()})
+
+		release := make(chan struct{})
+		var wg sync.WaitGroup
+		for range 3 {
+			wg.Add(1)
+			tracker.Go("consumer", func() {
+				defer wg.Done()
+				<-release
+			})
+		}
+		done := tracker.Start("flusher")
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `goroutines_active{component="consumer"} 3`)
+		assert.Contains(t, out, `goroutines_active{component="flusher"} 1`)
+
+		close(release)
+		wg.Wait()
+		done()
+		done()
+
+		assert.Eventually(t, func() bool {
+			out := scrape(t, provider)
+			return strings.Contains(out, `goroutines_active{component="consumer"} 0`) &&
+				strings.Contains(out, `goroutines_active{component="flusher"} 0`)
+		}, time.Second, 10*time.Millisecond)
+		assert.Contains(t, scrape(t, provider), `goroutines_started_total{component="consumer"} 3`)
+	})
+}
@@ -0,0 +1,116 @@
+// Package gqlmetrics provides a gqlgen server extension that records GraphQL
+// resolver and request metrics through metricsx.
+//
+//	srv := handler.New(generated.NewExecutableSchema(cfg))
+//	srv.Use(extension.FixedComplexityLimit(200))
+//	srv.Use(gqlmetrics.New(metrics))
+//
+// It records:
+//
+//	graphql_resolver_duration_seconds{object, field}
+//	graphql_resolver_errors_total{object, field}
+//	graphql_request_complexity{operation}
+//
+// Only fields backed by a user-defined resolver are measured. Request complexity
+// is reported when a complexity limit extension is installed.
+package gqlmetrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/gostratum/metricsx"
+)
+
+// Option configures the extension
+type Option func(*options)
+
+// options contains configuration for the extension
+type options struct {
+	buckets []float64
+}
+
+// WithBuckets sets the buckets for the resolver duration histogram
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// Extension is a gqlgen handler extension recording resolver metrics
+type Extension struct {
+	duration   metricsx.Histogram
+	errors     metricsx.Counter
+	complexity metricsx.Gauge
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = (*Extension)(nil)
+
+// New creates the metrics extension
+func New(m metricsx.Metrics, opts ...Option) *Extension {
+	o := &options{
+		buckets: metricsx.DefaultBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Extension{
+		duration: m.Histogram("graphql_resolver_duration_seconds",
+			metricsx.WithHelp("GraphQL resolver duration in seconds."),
+			metricsx.WithLabels("object", "field"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+		errors: m.Counter("graphql_resolver_errors_total",
+			metricsx.WithHelp("Total number of GraphQL resolvers that returned an error."),
+			metricsx.WithLabels("object", "field"),
+		),
+		complexity: m.Gauge("graphql_request_complexity",
+			metricsx.WithHelp("Calculated complexity of the most recent GraphQL request per operation."),
+			metricsx.WithLabels("operation"),
+		),
+	}
+}
+
+// ExtensionName returns the name of the extension
+func (e *Extension) ExtensionName() string {
+	return "MetricsxMetrics"
+}
+
+// Validate is called when the extension is added to the server
+func (e *Extension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse records the complexity of the operation
+func (e *Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if graphql.HasOperationContext(ctx) {
+		if stats := extension.GetComplexityStats(ctx); stats != nil {
+			e.complexity.Set(float64(stats.Complexity), graphql.GetOperationContext(ctx).OperationName)
+		}
+	}
+	return next(ctx)
+}
+
+// InterceptField records the duration and errors of resolver-backed fields
+func (e *Extension) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	start := time.Now()
+	res, err := next(ctx)
+
+	e.duration.Observe(time.Since(start).Seconds(), fc.Object, fc.Field.Name)
+	if err != nil {
+		e.errors.Inc(fc.Object, fc.Field.Name)
+	}
+	return res, err
+}
@@ -0,0 +1,80 @@
+package gqlmetrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func fieldContext(object, field string, isResolver bool) context.Context {
+	return graphql.WithFieldContext(context.Background(), &graphql.FieldContext{
+		Object:     object,
+		Field:      graphql.CollectedField{Field: &ast.Field{Name: field}},
+		IsResolver: isResolver,
+	})
+}
+
+func TestInterceptField(t *testing.T) {
+	t.Run("records resolver duration and errors", func(t *testing.T) {
+		m := metricstest.New()
+		ext := New(m)
+
+		res, err := ext.InterceptField(fieldContext("Query", "user", true), func(ctx context.Context) (any, error) {
+			return "alice", nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "alice", res)
+
+		_, err = ext.InterceptField(fieldContext("Query", "user", true), func(ctx context.Context) (any, error) {
+			return nil, errors.New("not found")
+		})
+		require.Error(t, err)
+
+		out := m.String()
+		assert.Contains(t, out, `graphql_resolver_duration_seconds_count{field="user",object="Query"} 2`)
+		assert.Contains(t, out, `graphql_resolver_errors_total{field="user",object="Query"} 1`)
+	})
+
+	t.Run("skips trivial fields", func(t *testing.T) {
+		m := metricstest.New()
+		ext := New(m)
+
+		_, err := ext.InterceptField(fieldContext("User", "name", false), func(ctx context.Context) (any, error) {
+			return "alice", nil
+		})
+		require.NoError(t, err)
+
+		assert.NotContains(t, m.String(), `object="User"`)
+	})
+}
+
+func TestInterceptResponse(t *testing.T) {
+	m := metricstest.New()
+	ext := New(m)
+
+	opCtx := &graphql.OperationContext{OperationName: "GetUser"}
+	opCtx.Stats.SetExtension("ComplexityLimit", &extension.ComplexityStats{Complexity: 12, ComplexityLimit: 200})
+	ctx := graphql.WithOperationContext(context.Background(), opCtx)
+
+	resp := ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		return &graphql.Response{}
+	})
+	require.NotNil(t, resp)
+
+	assert.Contains(t, m.String(), `graphql_request_complexity{operation="GetUser"} 12`)
+}
+
+func TestExtensionName(t *testing.T) {
+	m := metricstest.New()
+	ext := New(m)
+
+	assert.Equal(t, "MetricsxMetrics", ext.ExtensionName())
+	assert.NoError(t, ext.Validate(nil))
+}
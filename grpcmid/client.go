@@ -0,0 +1,151 @@
+package grpcmid
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gostratum/metricsx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// ClientMetrics records metrics for RPCs issued by a gRPC client
+type ClientMetrics struct {
+	started     metricsx.Counter
+	handled     metricsx.Counter
+	handling    metricsx.Histogram
+	inFlight    metricsx.Gauge
+	msgReceived metricsx.Counter
+	msgSent     metricsx.Counter
+}
+
+// NewClientMetrics creates the client-side gRPC metrics
+func NewClientMetrics(m metricsx.Metrics, opts ...Option) *ClientMetrics {
+	o := applyOptions(opts...)
+
+	return &ClientMetrics{
+		started: m.Counter("grpc_client_started_total",
+			metricsx.WithHelp("Total number of RPCs started on the client."),
+			metricsx.WithLabels("grpc_type", "grpc_service", "grpc_method"),
+		),
+		handled: m.Counter("grpc_client_handled_total",
+			metricsx.WithHelp("Total number of RPCs completed by the client, regardless of success or failure."),
+			metricsx.WithLabels("grpc_type", "grpc_service", "grpc_method", "grpc_code"),
+		),
+		handling: m.Histogram("grpc_client_handling_seconds",
+			metricsx.WithHelp("Histogram of response latency (seconds) of the gRPC until it is finished by the application."),
+			metricsx.WithLabels("grpc_type", "grpc_service", "grpc_method"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+		inFlight: m.Gauge("grpc_client_in_flight",
+			metricsx.WithHelp("Number of RPCs currently in flight on the client."),
+			metricsx.WithLabels("grpc_type", "grpc_service", "grpc_method"),
+		),
+		msgReceived: m.Counter("grpc_client_msg_received_total",
+			metricsx.WithHelp("Total number of RPC stream messages received by the client."),
+			metricsx.WithLabels("grpc_type", "grpc_service", "grpc_method"),
+		),
+		msgSent: m.Counter("grpc_client_msg_sent_total",
+			metricsx.WithHelp("Total number of gRPC stream messages sent by the client."),
+			metricsx.WithLabels("grpc_type", "grpc_service", "grpc_method"),
+		),
+	}
+}
+
+// UnaryClientInterceptor returns a unary client interceptor that records metrics
+func (c *ClientMetrics) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, fullMethod string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		service, method := splitMethodName(fullMethod)
+		start := time.Now()
+
+		c.started.Inc(Unary, service, method)
+		c.inFlight.Inc(Unary, service, method)
+		c.msgSent.Inc(Unary, service, method)
+
+		err := invoker(ctx, fullMethod, req, reply, cc, opts...)
+
+		if err == nil {
+			c.msgReceived.Inc(Unary, service, method)
+		}
+		c.inFlight.Dec(Unary, service, method)
+		c.handled.Inc(Unary, service, method, status.Code(err).String())
+		c.handling.Observe(time.Since(start).Seconds(), Unary, service, method)
+
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a stream client interceptor that records metrics.
+// The RPC is considered finished once RecvMsg returns an error (including io.EOF),
+// or after the single response of an RPC without server streaming.
+func (c *ClientMetrics) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, fullMethod string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		service, method := splitMethodName(fullMethod)
+		stream := &monitoredClientStream{
+			metrics:       c,
+			labels:        []string{streamType(desc.ClientStreams, desc.ServerStreams), service, method},
+			start:         time.Now(),
+			serverStreams: desc.ServerStreams,
+		}
+
+		c.started.Inc(stream.labels...)
+		c.inFlight.Inc(stream.labels...)
+
+		cs, err := streamer(ctx, desc, cc, fullMethod, opts...)
+		if err != nil {
+			stream.finish(err)
+			return nil, err
+		}
+
+		stream.ClientStream = cs
+		return stream, nil
+	}
+}
+
+// monitoredClientStream wraps grpc.ClientStream to count messages and record completion
+type monitoredClientStream struct {
+	grpc.ClientStream
+	metrics       *ClientMetrics
+	labels        []string
+	start         time.Time
+	serverStreams bool
+	once          sync.Once
+}
+
+func (s *monitoredClientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.metrics.msgSent.Inc(s.labels...)
+	}
+	return err
+}
+
+func (s *monitoredClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil:
+		s.metrics.msgReceived.Inc(s.labels...)
+		// Without server streaming the single response completes the RPC
+		// and callers never receive io.EOF
+		if !s.serverStreams {
+			s.finish(nil)
+		}
+	case errors.Is(err, io.EOF):
+		s.finish(nil)
+	default:
+		s.finish(err)
+	}
+	return err
+}
+
+// finish records the outcome of the stream exactly once
+func (s *monitoredClientStream) finish(err error) {
+	s.once.Do(func() {
+		s.metrics.inFlight.Dec(s.labels...)
+		s.metrics.handled.Inc(append(s.labels, status.Code(err).String())...)
+		s.metrics.handling.Observe(time.Since(s.start).Seconds(), s.labels...)
+	})
+}
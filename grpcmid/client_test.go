@@ -0,0 +1,129 @@
+package grpcmid
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeClientStream is a grpc.ClientStream that returns a fixed number of messages
+type fakeClientStream struct {
+	grpc.ClientStream
+	remaining int
+}
+
+func (s *fakeClientStream) SendMsg(m any) error { return nil }
+func (s *fakeClientStream) CloseSend() error    { return nil }
+func (s *fakeClientStream) RecvMsg(m any) error {
+	if s.remaining == 0 {
+		return io.EOF
+	}
+	s.remaining--
+	return nil
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	t.Run("records successful calls", func(t *testing.T) {
+		m := metricstest.New()
+		interceptor := NewClientMetrics(m).UnaryClientInterceptor()
+
+		err := interceptor(context.Background(), "/helloworld.Greeter/SayHello", "req", nil, nil,
+			func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				return nil
+			})
+		require.NoError(t, err)
+
+		out := m.String()
+		assert.Contains(t, out, `grpc_client_started_total{grpc_method="SayHello",grpc_service="helloworld.Greeter",grpc_type="unary"} 1`)
+		assert.Contains(t, out, `grpc_client_handled_total{grpc_code="OK",grpc_method="SayHello",grpc_service="helloworld.Greeter",grpc_type="unary"} 1`)
+		assert.Contains(t, out, `grpc_client_in_flight{grpc_method="SayHello",grpc_service="helloworld.Greeter",grpc_type="unary"} 0`)
+		assert.Contains(t, out, `grpc_client_msg_received_total{grpc_method="SayHello",grpc_service="helloworld.Greeter",grpc_type="unary"} 1`)
+	})
+
+	t.Run("records status code of failed calls", func(t *testing.T) {
+		m := metricstest.New()
+		interceptor := NewClientMetrics(m).UnaryClientInterceptor()
+
+		err := interceptor(context.Background(), "/helloworld.Greeter/SayHello", "req", nil, nil,
+			func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				return status.Error(codes.Unavailable, "down")
+			})
+		require.Error(t, err)
+
+		assert.Contains(t, m.String(), `grpc_client_handled_total{grpc_code="Unavailable",grpc_method="SayHello",grpc_service="helloworld.Greeter",grpc_type="unary"} 1`)
+	})
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	t.Run("records stream until EOF", func(t *testing.T) {
+		m := metricstest.New()
+		interceptor := NewClientMetrics(m).StreamClientInterceptor()
+
+		desc := &grpc.StreamDesc{ServerStreams: true}
+		cs, err := interceptor(context.Background(), desc, nil, "/feed.Feed/Watch",
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return &fakeClientStream{remaining: 2}, nil
+			})
+		require.NoError(t, err)
+
+		assert.Contains(t, m.String(), `grpc_client_in_flight{grpc_method="Watch",grpc_service="feed.Feed",grpc_type="server_stream"} 1`)
+
+		require.NoError(t, cs.SendMsg(nil))
+		for cs.RecvMsg(nil) == nil {
+		}
+		// Further receives after completion must not double count
+		_ = cs.RecvMsg(nil)
+
+		out := m.String()
+		assert.Contains(t, out, `grpc_client_in_flight{grpc_method="Watch",grpc_service="feed.Feed",grpc_type="server_stream"} 0`)
+		assert.Contains(t, out, `grpc_client_msg_received_total{grpc_method="Watch",grpc_service="feed.Feed",grpc_type="server_stream"} 2`)
+		assert.Contains(t, out, `grpc_client_msg_sent_total{grpc_method="Watch",grpc_service="feed.Feed",grpc_type="server_stream"} 1`)
+		assert.Contains(t, out, `grpc_client_handled_total{grpc_code="OK",grpc_method="Watch",grpc_service="feed.Feed",grpc_type="server_stream"} 1`)
+	})
+
+	t.Run("records client streams after the single response", func(t *testing.T) {
+		m := metricstest.New()
+		interceptor := NewClientMetrics(m).StreamClientInterceptor()
+
+		desc := &grpc.StreamDesc{ClientStreams: true}
+		cs, err := interceptor(context.Background(), desc, nil, "/upload.Upload/Put",
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return &fakeClientStream{remaining: 1}, nil
+			})
+		require.NoError(t, err)
+
+		require.NoError(t, cs.SendMsg(nil))
+		require.NoError(t, cs.SendMsg(nil))
+		require.NoError(t, cs.CloseSend())
+		require.NoError(t, cs.RecvMsg(nil))
+
+		out := m.String()
+		assert.Contains(t, out, `grpc_client_in_flight{grpc_method="Put",grpc_service="upload.Upload",grpc_type="client_stream"} 0`)
+		assert.Contains(t, out, `grpc_client_msg_sent_total{grpc_method="Put",grpc_service="upload.Upload",grpc_type="client_stream"} 2`)
+		assert.Contains(t, out, `grpc_client_msg_received_total{grpc_method="Put",grpc_service="upload.Upload",grpc_type="client_stream"} 1`)
+		assert.Contains(t, out, `grpc_client_handled_total{grpc_code="OK",grpc_method="Put",grpc_service="upload.Upload",grpc_type="client_stream"} 1`)
+		assert.Contains(t, out, `grpc_client_handling_seconds_count{grpc_method="Put",grpc_service="upload.Upload",grpc_type="client_stream"} 1`)
+	})
+
+	t.Run("records streams that fail to open", func(t *testing.T) {
+		m := metricstest.New()
+		interceptor := NewClientMetrics(m).StreamClientInterceptor()
+
+		_, err := interceptor(context.Background(), &grpc.StreamDesc{ClientStreams: true}, nil, "/upload.Upload/Put",
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return nil, status.Error(codes.PermissionDenied, "nope")
+			})
+		require.Error(t, err)
+
+		out := m.String()
+		assert.Contains(t, out, `grpc_client_handled_total{grpc_code="PermissionDenied",grpc_method="Put",grpc_service="upload.Upload",grpc_type="client_stream"} 1`)
+		assert.Contains(t, out, `grpc_client_in_flight{grpc_method="Put",grpc_service="upload.Upload",grpc_type="client_stream"} 0`)
+	})
+}
@@ -0,0 +1,86 @@
+// Package grpcmid provides gRPC interceptors that record RPC metrics through metricsx.
+//
+// Metric names and labels follow the scheme popularised by go-grpc-prometheus so
+// existing dashboards and alerts keep working:
+//
+//	grpc_server_started_total{grpc_type, grpc_service, grpc_method}
+//	grpc_server_handled_total{grpc_type, grpc_service, grpc_method, grpc_code}
+//	grpc_server_handling_seconds{grpc_type, grpc_service, grpc_method}
+//	grpc_server_msg_received_total{grpc_type, grpc_service, grpc_method}
+//	grpc_server_msg_sent_total{grpc_type, grpc_service, grpc_method}
+//
+// Client interceptors record the same set under the grpc_client_ prefix, plus
+// grpc_client_in_flight for RPCs that have started but not yet finished.
+package grpcmid
+
+import (
+	"strings"
+
+	"github.com/gostratum/metricsx"
+	"google.golang.org/grpc"
+)
+
+// RPC types used for the grpc_type label
+const (
+	Unary        = "unary"
+	ClientStream = "client_stream"
+	ServerStream = "server_stream"
+	BidiStream   = "bidi_stream"
+)
+
+// Option configures the interceptor metrics
+type Option func(*options)
+
+// options contains configuration for the interceptor metrics
+type options struct {
+	buckets []float64
+}
+
+// WithBuckets sets the buckets for the handling duration histogram
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// applyOptions applies the given options and returns the final options
+func applyOptions(opts ...Option) *options {
+	o := &options{
+		buckets: metricsx.DefaultBuckets,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// splitMethodName splits a full gRPC method name ("/package.Service/Method")
+// into its service and method parts
+func splitMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", "unknown"
+}
+
+// streamType returns the grpc_type label value for a stream
+func streamType(clientStreams, serverStreams bool) string {
+	switch {
+	case clientStreams && serverStreams:
+		return BidiStream
+	case clientStreams:
+		return ClientStream
+	case serverStreams:
+		return ServerStream
+	default:
+		return Unary
+	}
+}
+
+// serverStreamType returns the grpc_type label value for server stream info
+func serverStreamType(info *grpc.StreamServerInfo) string {
+	return streamType(info.IsClientStream, info.IsServerStream)
+}
@@ -0,0 +1,28 @@
+package grpcmid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitMethodName(t *testing.T) {
+	t.Run("splits service and method", func(t *testing.T) {
+		service, method := splitMethodName("/helloworld.Greeter/SayHello")
+		assert.Equal(t, "helloworld.Greeter", service)
+		assert.Equal(t, "SayHello", method)
+	})
+
+	t.Run("handles malformed names", func(t *testing.T) {
+		service, method := splitMethodName("bogus")
+		assert.Equal(t, "unknown", service)
+		assert.Equal(t, "unknown", method)
+	})
+}
+
+func TestStreamType(t *testing.T) {
+	assert.Equal(t, Unary, streamType(false, false))
+	assert.Equal(t, ClientStream, streamType(true, false))
+	assert.Equal(t, ServerStream, streamType(false, true))
+	assert.Equal(t, BidiStream, streamType(true, true))
+}
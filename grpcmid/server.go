@@ -0,0 +1,114 @@
+package grpcmid
+
+import (
+	"context"
+	"time"
+
+	"github.com/gostratum/metricsx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// ServerMetrics records metrics for RPCs handled by a gRPC server
+type ServerMetrics struct {
+	started     metricsx.Counter
+	handled     metricsx.Counter
+	handling    metricsx.Histogram
+	msgReceived metricsx.Counter
+	msgSent     metricsx.Counter
+}
+
+// NewServerMetrics creates the server-side gRPC metrics
+func NewServerMetrics(m metricsx.Metrics, opts ...Option) *ServerMetrics {
+	o := applyOptions(opts...)
+
+	return &ServerMetrics{
+		started: m.Counter("grpc_server_started_total",
+			metricsx.WithHelp("Total number of RPCs started on the server."),
+			metricsx.WithLabels("grpc_type", "grpc_service", "grpc_method"),
+		),
+		handled: m.Counter("grpc_server_handled_total",
+			metricsx.WithHelp("Total number of RPCs completed on the server, regardless of success or failure."),
+			metricsx.WithLabels("grpc_type", "grpc_service", "grpc_method", "grpc_code"),
+		),
+		handling: m.Histogram("grpc_server_handling_seconds",
+			metricsx.WithHelp("Histogram of response latency (seconds) of gRPC that had been application-level handled by the server."),
+			metricsx.WithLabels("grpc_type", "grpc_service", "grpc_method"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+		msgReceived: m.Counter("grpc_server_msg_received_total",
+			metricsx.WithHelp("Total number of RPC stream messages received on the server."),
+			metricsx.WithLabels("grpc_type", "grpc_service", "grpc_method"),
+		),
+		msgSent: m.Counter("grpc_server_msg_sent_total",
+			metricsx.WithHelp("Total number of gRPC stream messages sent by the server."),
+			metricsx.WithLabels("grpc_type", "grpc_service", "grpc_method"),
+		),
+	}
+}
+
+// UnaryServerInterceptor returns a unary server interceptor that records metrics
+func (s *ServerMetrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		service, method := splitMethodName(info.FullMethod)
+		start := time.Now()
+
+		s.started.Inc(Unary, service, method)
+		s.msgReceived.Inc(Unary, service, method)
+
+		resp, err := handler(ctx, req)
+
+		if err == nil {
+			s.msgSent.Inc(Unary, service, method)
+		}
+		s.handled.Inc(Unary, service, method, status.Code(err).String())
+		s.handling.Observe(time.Since(start).Seconds(), Unary, service, method)
+
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a stream server interceptor that records metrics
+func (s *ServerMetrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		service, method := splitMethodName(info.FullMethod)
+		rpcType := serverStreamType(info)
+		start := time.Now()
+
+		s.started.Inc(rpcType, service, method)
+
+		err := handler(srv, &monitoredServerStream{
+			ServerStream: ss,
+			metrics:      s,
+			labels:       []string{rpcType, service, method},
+		})
+
+		s.handled.Inc(rpcType, service, method, status.Code(err).String())
+		s.handling.Observe(time.Since(start).Seconds(), rpcType, service, method)
+
+		return err
+	}
+}
+
+// monitoredServerStream wraps grpc.ServerStream to count messages
+type monitoredServerStream struct {
+	grpc.ServerStream
+	metrics *ServerMetrics
+	labels  []string
+}
+
+func (s *monitoredServerStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.metrics.msgSent.Inc(s.labels...)
+	}
+	return err
+}
+
+func (s *monitoredServerStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.metrics.msgReceived.Inc(s.labels...)
+	}
+	return err
+}
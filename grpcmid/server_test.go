@@ -0,0 +1,94 @@
+package grpcmid
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeServerStream is a grpc.ServerStream that serves a fixed number of messages
+type fakeServerStream struct {
+	grpc.ServerStream
+	remaining int
+}
+
+func (s *fakeServerStream) Context() context.Context { return context.Background() }
+func (s *fakeServerStream) SendMsg(m any) error      { return nil }
+func (s *fakeServerStream) RecvMsg(m any) error {
+	if s.remaining == 0 {
+		return io.EOF
+	}
+	s.remaining--
+	return nil
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Run("records successful calls", func(t *testing.T) {
+		m := metricstest.New()
+		interceptor := NewServerMetrics(m).UnaryServerInterceptor()
+
+		info := &grpc.UnaryServerInfo{FullMethod: "/helloworld.Greeter/SayHello"}
+		resp, err := interceptor(context.Background(), "req", info, func(ctx context.Context, req any) (any, error) {
+			return "resp", nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "resp", resp)
+
+		out := m.String()
+		assert.Contains(t, out, `grpc_server_started_total{grpc_method="SayHello",grpc_service="helloworld.Greeter",grpc_type="unary"} 1`)
+		assert.Contains(t, out, `grpc_server_handled_total{grpc_code="OK",grpc_method="SayHello",grpc_service="helloworld.Greeter",grpc_type="unary"} 1`)
+		assert.Contains(t, out, `grpc_server_handling_seconds_count{grpc_method="SayHello",grpc_service="helloworld.Greeter",grpc_type="unary"} 1`)
+		assert.Contains(t, out, `grpc_server_msg_sent_total{grpc_method="SayHello",grpc_service="helloworld.Greeter",grpc_type="unary"} 1`)
+	})
+
+	t.Run("records status code of failed calls", func(t *testing.T) {
+		m := metricstest.New()
+		interceptor := NewServerMetrics(m).UnaryServerInterceptor()
+
+		info := &grpc.UnaryServerInfo{FullMethod: "/helloworld.Greeter/SayHello"}
+		_, err := interceptor(context.Background(), "req", info, func(ctx context.Context, req any) (any, error) {
+			return nil, status.Error(codes.NotFound, "missing")
+		})
+		require.Error(t, err)
+
+		out := m.String()
+		assert.Contains(t, out, `grpc_server_handled_total{grpc_code="NotFound",grpc_method="SayHello",grpc_service="helloworld.Greeter",grpc_type="unary"} 1`)
+		assert.NotContains(t, out, "grpc_server_msg_sent_total{")
+	})
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	m := metricstest.New()
+	interceptor := NewServerMetrics(m, WithBuckets(0.1, 1)).StreamServerInterceptor()
+
+	info := &grpc.StreamServerInfo{
+		FullMethod:     "/chat.Chat/Talk",
+		IsClientStream: true,
+		IsServerStream: true,
+	}
+	err := interceptor(nil, &fakeServerStream{remaining: 3}, info, func(srv any, ss grpc.ServerStream) error {
+		for {
+			if err := ss.RecvMsg(nil); err != nil {
+				break
+			}
+			if err := ss.SendMsg(nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	out := m.String()
+	assert.Contains(t, out, `grpc_server_msg_received_total{grpc_method="Talk",grpc_service="chat.Chat",grpc_type="bidi_stream"} 3`)
+	assert.Contains(t, out, `grpc_server_msg_sent_total{grpc_method="Talk",grpc_service="chat.Chat",grpc_type="bidi_stream"} 3`)
+	assert.Contains(t, out, `grpc_server_handled_total{grpc_code="OK",grpc_method="Talk",grpc_service="chat.Chat",grpc_type="bidi_stream"} 1`)
+	assert.Contains(t, out, `grpc_server_handling_seconds_bucket{grpc_method="Talk",grpc_service="chat.Chat",grpc_type="bidi_stream",le="0.1"} 1`)
+}
@@ -0,0 +1,180 @@
+package metricsx
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gostratum/core/logx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	_ "github.com/prometheus/client_golang/prometheus/promhttp/zstd" // registers the zstd encoder
+	dto "github.com/prometheus/client_model/go"
+)
+
+// handlerOpts builds the promhttp options for the scrape handler from cfg,
+// logging gathering and encoding errors to logger
+func handlerOpts(cfg PrometheusConfig, logger logx.Logger) (promhttp.HandlerOpts, error) {
+	opts := promhttp.HandlerOpts{
+		ErrorLog:            errorLog{logger},
+		EnableOpenMetrics:   cfg.EnableOpenMetrics,
+		DisableCompression:  cfg.DisableCompression,
+		MaxRequestsInFlight: cfg.MaxRequestsInFlight,
+		Timeout:             cfg.HandlerTimeout,
+	}
+
+	switch cfg.ErrorHandling {
+	case "", "http_error":
+		opts.ErrorHandling = promhttp.HTTPErrorOnError
+	case "continue":
+		opts.ErrorHandling = promhttp.ContinueOnError
+	case "panic":
+		opts.ErrorHandling = promhttp.PanicOnError
+	default:
+		return promhttp.HandlerOpts{}, fmt.Errorf("unsupported metrics error handling %q", cfg.ErrorHandling)
+	}
+
+	for _, name := range cfg.Compression {
+		switch c := promhttp.Compression(name); c {
+		case promhttp.Identity, promhttp.Gzip, promhttp.Zstd:
+			opts.OfferedCompressions = append(opts.OfferedCompressions, c)
+		default:
+			return promhttp.HandlerOpts{}, fmt.Errorf("unsupported metrics compression %q", name)
+		}
+	}
+	return opts, nil
+}
+
+// errorLog adapts a logx.Logger to promhttp.Logger
+type errorLog struct {
+	logger logx.Logger
+}
+
+func (l errorLog) Println(v ...any) {
+	l.logger.Error("metrics handler error", logx.String("error", strings.TrimSpace(fmt.Sprintln(v...))))
+}
+
+// handlerFor returns an HTTP handler exposing g at path, protected by the
+// configured auth and instrumented with the scrape metrics. Requests with
+// name[] or match[] query parameters only gather the matching families.
+func (p *prometheusProvider) handlerFor(path string, g prometheus.Gatherer) http.Handler {
+	// The limits wrap the whole handler rather than each per-request promhttp
+	// handler so they are shared by every scrape of path
+	opts := p.handlerOpts
+	opts.MaxRequestsInFlight, opts.Timeout = 0, 0
+
+	full := promhttp.HandlerFor(g, opts)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter, err := newQueryFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if filter == nil {
+			full.ServeHTTP(w, r)
+			return
+		}
+
+		promhttp.HandlerFor(prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			mfs, err := g.Gather()
+			return filter.apply(mfs), err
+		}), opts).ServeHTTP(w, r)
+	})
+
+	limited := withLimits(handler, p.handlerOpts.MaxRequestsInFlight, p.handlerOpts.Timeout)
+	return p.scrapeCollector.instrument(path, withAuth(limited, func() AuthConfig {
+		return *p.auth.Load()
+	}))
+}
+
+// newQueryFilter builds a filter from the name[] (exact family names) and
+// match[] (patterns) query parameters, returning nil when neither is set
+func newQueryFilter(query url.Values) (*metricFilter, error) {
+	names := slices.Concat(query["name[]"], query["name"])
+	patterns := slices.Concat(query["match[]"], query["match"])
+	if len(names) == 0 && len(patterns) == 0 {
+		return nil, nil
+	}
+
+	allow, err := compilePatterns(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid match parameter: %w", err)
+	}
+	for _, name := range names {
+		allow = append(allow, func(family string) bool { return family == name })
+	}
+	return &metricFilter{allow: allow}, nil
+}
+
+// withLimits bounds the concurrent requests and the duration of next, like
+// the equivalent promhttp.HandlerOpts. Zero disables a limit.
+func withLimits(next http.Handler, maxInFlight int, timeout time.Duration) http.Handler {
+	if timeout > 0 {
+		next = http.TimeoutHandler(next, timeout, fmt.Sprintf("Exceeded configured timeout of %v.\n", timeout))
+	}
+	if maxInFlight <= 0 {
+		return next
+	}
+
+	inFlight := make(chan struct{}, maxInFlight)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case inFlight <- struct{}{}:
+			defer func() { <-inFlight }()
+			next.ServeHTTP(w, r)
+		default:
+			http.Error(w, fmt.Sprintf("Limit of concurrent requests reached (%d), try again later.", maxInFlight), http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// scrapeMetrics instruments the scrape handlers. It is a single collector so
+// it can be registered and unregistered like the default collectors.
+type scrapeMetrics struct {
+	requests *prometheus.CounterVec
+	inFlight prometheus.Gauge
+	duration *prometheus.HistogramVec
+}
+
+// newScrapeMetrics creates the scrape handler metrics
+func newScrapeMetrics() *scrapeMetrics {
+	return &scrapeMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "metricsx_scrapes_total",
+			Help: "Total number of scrapes by path and HTTP status code.",
+		}, []string{"path", "code"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "metricsx_scrapes_in_flight",
+			Help: "Number of scrapes currently being served.",
+		}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "metricsx_scrape_duration_seconds",
+			Help: "Duration of scrapes by path.",
+		}, []string{"path"}),
+	}
+}
+
+// instrument wraps handler with the scrape metrics for path
+func (s *scrapeMetrics) instrument(path string, handler http.Handler) http.Handler {
+	labels := prometheus.Labels{"path": path}
+	return promhttp.InstrumentHandlerInFlight(s.inFlight,
+		promhttp.InstrumentHandlerCounter(s.requests.MustCurryWith(labels),
+			promhttp.InstrumentHandlerDuration(s.duration.MustCurryWith(labels), handler),
+		),
+	)
+}
+
+func (s *scrapeMetrics) Describe(ch chan<- *prometheus.Desc) {
+	s.requests.Describe(ch)
+	s.inFlight.Describe(ch)
+	s.duration.Describe(ch)
+}
+
+func (s *scrapeMetrics) Collect(ch chan<- prometheus.Metric) {
+	s.requests.Collect(ch)
+	s.inFlight.Collect(ch)
+	s.duration.Collect(ch)
+}
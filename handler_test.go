@@ -0,0 +1,184 @@
+package metricsx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerCompression(t *testing.T) {
+	encoding := func(cfg PrometheusConfig, accept string) string {
+		t.Helper()
+
+		cfg.Path = "/metrics"
+		provider, err := newPrometheusProviderFromConfig(Config{Prometheus: cfg}, getTestLogger())
+		require.NoError(t, err)
+		provider.Counter("orders_total", &Options{}).Inc()
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept-Encoding", accept)
+		rec := httptest.NewRecorder()
+		provider.(*prometheusProvider).Handler().ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec.Header().Get("Content-Encoding")
+	}
+
+	assert.Equal(t, "gzip", encoding(PrometheusConfig{}, "gzip"))
+	assert.Equal(t, "zstd", encoding(PrometheusConfig{}, "zstd"))
+	assert.Equal(t, "gzip", encoding(PrometheusConfig{Compression: []string{"gzip"}}, "zstd, gzip"))
+	assert.Empty(t, encoding(PrometheusConfig{Compression: []string{"identity"}}, "gzip"))
+	assert.Empty(t, encoding(PrometheusConfig{DisableCompression: true}, "gzip"))
+
+	_, err := newPrometheusProviderFromConfig(Config{Prometheus: PrometheusConfig{Compression: []string{"brotli"}}}, getTestLogger())
+	assert.Error(t, err)
+}
+
+func TestHandlerMetrics(t *testing.T) {
+	cfg := PrometheusConfig{
+		Path:                 "/metrics",
+		EnableHandlerMetrics: true,
+		Endpoints:            []EndpointConfig{{Path: "/metrics/minimal"}},
+	}
+	provider := newPrometheusProvider(cfg, getTestLogger())
+
+	handlers := provider.(*prometheusProvider).Handlers()
+	for _, path := range []string{"/metrics", "/metrics", "/metrics/minimal"} {
+		handlers[path].ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	out := scrape(t, provider)
+	assert.Contains(t, out, `metricsx_scrapes_total{code="200",path="/metrics"} 2`)
+	assert.Contains(t, out, `metricsx_scrapes_total{code="200",path="/metrics/minimal"} 1`)
+	assert.Contains(t, out, `metricsx_scrape_duration_seconds_count{path="/metrics/minimal"} 1`)
+	assert.Contains(t, out, "metricsx_scrapes_in_flight 1")
+
+	disabled := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+	assert.NotContains(t, scrape(t, disabled), "metricsx_scrapes")
+}
+
+// failingCollector reports a collection error on every gather
+type failingCollector struct{}
+
+func (failingCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (failingCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.NewInvalidMetric(prometheus.NewDesc("broken", "Broken metric.", nil, nil), errors.New("collection failed"))
+}
+
+func TestHandlerOpts(t *testing.T) {
+	t.Run("maps the configuration", func(t *testing.T) {
+		opts, err := handlerOpts(PrometheusConfig{
+			MaxRequestsInFlight: 4,
+			HandlerTimeout:      5 * time.Second,
+			ErrorHandling:       "continue",
+		}, getTestLogger())
+		require.NoError(t, err)
+
+		assert.Equal(t, 4, opts.MaxRequestsInFlight)
+		assert.Equal(t, 5*time.Second, opts.Timeout)
+		assert.Equal(t, promhttp.ContinueOnError, opts.ErrorHandling)
+		assert.NotNil(t, opts.ErrorLog)
+
+		_, err = handlerOpts(PrometheusConfig{ErrorHandling: "ignore"}, getTestLogger())
+		assert.Error(t, err)
+	})
+
+	t.Run("applies error handling to scrapes", func(t *testing.T) {
+		status := func(errorHandling string) int {
+			provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics", ErrorHandling: errorHandling}, getTestLogger())
+			provider.(*prometheusProvider).registry.MustRegister(failingCollector{})
+			provider.Counter("orders_total", &Options{}).Inc()
+
+			rec := httptest.NewRecorder()
+			provider.(*prometheusProvider).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			return rec.Code
+		}
+
+		assert.Equal(t, http.StatusInternalServerError, status(""))
+		assert.Equal(t, http.StatusOK, status("continue"))
+	})
+}
+
+func TestQueryFilter(t *testing.T) {
+	provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+	provider.Counter("orders_total", &Options{}).Inc()
+	provider.Counter("refunds_total", &Options{}).Inc()
+	provider.Gauge("queue_depth", &Options{}).Set(1)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		provider.(*prometheusProvider).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics?"+query, nil))
+		return rec
+	}
+
+	out := get("name[]=orders_total&name[]=queue_depth").Body.String()
+	assert.Contains(t, out, "orders_total 1")
+	assert.Contains(t, out, "queue_depth 1")
+	assert.NotContains(t, out, "refunds_total")
+
+	out = get("match=*_total").Body.String()
+	assert.Contains(t, out, "orders_total 1")
+	assert.Contains(t, out, "refunds_total 1")
+	assert.NotContains(t, out, "queue_depth")
+
+	assert.Contains(t, get("").Body.String(), "queue_depth 1")
+	assert.Equal(t, http.StatusBadRequest, get("match[]=re:(").Code)
+}
+
+func TestWithLimits(t *testing.T) {
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	handler := withLimits(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}), 1, 0)
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	<-entered
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	close(release)
+
+	slow := withLimits(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}), 0, 10*time.Millisecond)
+	rec = httptest.NewRecorder()
+	slow.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestContentNegotiation(t *testing.T) {
+	contentType := func(cfg PrometheusConfig, accept string) string {
+		t.Helper()
+
+		cfg.Path = "/metrics"
+		provider := newPrometheusProvider(cfg, getTestLogger())
+		provider.Counter("orders_total", &Options{}).Inc()
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		provider.(*prometheusProvider).Handler().ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec.Header().Get("Content-Type")
+	}
+
+	const (
+		protobuf    = "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited"
+		openMetrics = "application/openmetrics-text;version=1.0.0"
+	)
+
+	assert.Contains(t, contentType(PrometheusConfig{}, ""), "text/plain")
+	assert.Contains(t, contentType(PrometheusConfig{}, protobuf), "application/vnd.google.protobuf")
+	assert.Contains(t, contentType(PrometheusConfig{EnableOpenMetrics: true}, openMetrics), "application/openmetrics-text")
+	assert.Contains(t, contentType(PrometheusConfig{}, openMetrics), "text/plain")
+}
@@ -0,0 +1,133 @@
+// Package healthmetrics mirrors gostratum health check results as metrics so
+// alerting can be driven from the same probes as liveness and readiness.
+//
+//	hm := healthmetrics.New(metrics)
+//	registry := hm.WrapRegistry(core.NewHealthRegistry())
+//
+// With core.New the registry can be decorated instead:
+//
+//	fx.Decorate(func(r core.Registry, m metricsx.Metrics) core.Registry {
+//		return healthmetrics.New(m).WrapRegistry(r)
+//	})
+//
+// It records:
+//
+//	health_check_status{check, kind}
+//	health_check_duration_seconds{check, kind}
+//
+// The status is 1 when the last result of the check was healthy and 0 otherwise.
+package healthmetrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/gostratum/core"
+	"github.com/gostratum/metricsx"
+)
+
+// Option configures the health check metrics
+type Option func(*options)
+
+// options contains configuration for the health check metrics
+type options struct {
+	buckets []float64
+}
+
+// WithBuckets sets the buckets for the check duration histogram
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// Metrics records health check results
+type Metrics struct {
+	status   metricsx.Gauge
+	duration metricsx.Histogram
+}
+
+// New creates the health check metrics
+func New(m metricsx.Metrics, opts ...Option) *Metrics {
+	o := &options{
+		buckets: metricsx.DefaultBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Metrics{
+		status: m.Gauge("health_check_status",
+			metricsx.WithHelp("Result of the last health check run (1 healthy, 0 unhealthy)."),
+			metricsx.WithLabels("check", "kind"),
+		),
+		duration: m.Histogram("health_check_duration_seconds",
+			metricsx.WithHelp("Duration of health checks in seconds."),
+			metricsx.WithLabels("check", "kind"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+	}
+}
+
+// WrapCheck returns a core.Check that records every run of c
+func (hm *Metrics) WrapCheck(c core.Check) core.Check {
+	return &check{next: c, metrics: hm}
+}
+
+// WrapRegistry returns a core.Registry that wraps registered checks and
+// records statuses reported through Set
+func (hm *Metrics) WrapRegistry(r core.Registry) core.Registry {
+	return &registry{Registry: r, metrics: hm}
+}
+
+// record sets the status of a check
+func (hm *Metrics) record(kind core.Kind, name string, err error) {
+	status := 1.0
+	if err != nil {
+		status = 0
+	}
+	hm.status.Set(status, name, string(kind))
+}
+
+// check wraps a core.Check with metrics
+type check struct {
+	next    core.Check
+	metrics *Metrics
+}
+
+// Name returns the name of the wrapped check
+func (c *check) Name() string {
+	return c.next.Name()
+}
+
+// Kind returns the kind of the wrapped check
+func (c *check) Kind() core.Kind {
+	return c.next.Kind()
+}
+
+// Check runs the wrapped check and records its duration and status
+func (c *check) Check(ctx context.Context) error {
+	start := time.Now()
+	err := c.next.Check(ctx)
+
+	c.metrics.duration.Observe(time.Since(start).Seconds(), c.Name(), string(c.Kind()))
+	c.metrics.record(c.Kind(), c.Name(), err)
+	return err
+}
+
+// registry wraps a core.Registry with metrics
+type registry struct {
+	core.Registry
+	metrics *Metrics
+}
+
+// Register registers c wrapped with metrics
+func (r *registry) Register(c core.Check) {
+	r.Registry.Register(r.metrics.WrapCheck(c))
+}
+
+// Set records the reported status and forwards it
+func (r *registry) Set(kind core.Kind, name string, err error) {
+	r.metrics.record(kind, name, err)
+	r.Registry.Set(kind, name, err)
+}
@@ -0,0 +1,49 @@
+package healthmetrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gostratum/core"
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubCheck is a core.Check returning a fixed error
+type stubCheck struct {
+	name string
+	kind core.Kind
+	err  error
+}
+
+func (c stubCheck) Name() string                    { return c.name }
+func (c stubCheck) Kind() core.Kind                 { return c.kind }
+func (c stubCheck) Check(ctx context.Context) error { return c.err }
+
+func TestRegistry(t *testing.T) {
+	t.Run("records check results on aggregate", func(t *testing.T) {
+		m := metricstest.New()
+		r := New(m).WrapRegistry(core.NewHealthRegistry())
+
+		r.Register(stubCheck{name: "db", kind: core.Readiness})
+		r.Register(stubCheck{name: "cache", kind: core.Readiness, err: errors.New("timeout")})
+
+		res := r.Aggregate(context.Background(), core.Readiness)
+		assert.False(t, res.OK)
+
+		out := m.String()
+		assert.Contains(t, out, `health_check_status{check="db",kind="readiness"} 1`)
+		assert.Contains(t, out, `health_check_status{check="cache",kind="readiness"} 0`)
+		assert.Contains(t, out, `health_check_duration_seconds_count{check="db",kind="readiness"} 1`)
+	})
+
+	t.Run("records statuses reported with Set", func(t *testing.T) {
+		m := metricstest.New()
+		r := New(m).WrapRegistry(core.NewHealthRegistry())
+
+		r.Set(core.Liveness, "worker", errors.New("stalled"))
+
+		assert.Contains(t, m.String(), `health_check_status{check="worker",kind="liveness"} 0`)
+	})
+}
@@ -0,0 +1,65 @@
+package metricsx
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// healthStatus is the body of the health endpoint. It names the failing
+// checks but never their errors, as the endpoint is not protected by auth.
+type healthStatus struct {
+	Status string   `json:"status"`
+	Failed []string `json:"failed,omitempty"`
+}
+
+// healthCheck is a check reported by the health endpoint; failing reports
+// whether its last attempt failed
+type healthCheck struct {
+	name    string
+	failing func() bool
+}
+
+// healthChecker is implemented by providers with state the health endpoint
+// reports
+type healthChecker interface {
+	healthChecks() []healthCheck
+}
+
+// healthChecks returns the gather check and the checks added by
+// addHealthChecks
+func (p *prometheusProvider) healthChecks() []healthCheck {
+	return append([]healthCheck{{name: "gather", failing: p.gatherFailed.Load}}, p.extraHealthChecks...)
+}
+
+// addHealthChecks makes the health endpoint also report checks, named after
+// the provider they belong to. It must be called before Start.
+func (p *prometheusProvider) addHealthChecks(provider string, checks []healthCheck) {
+	for _, check := range checks {
+		p.extraHealthChecks = append(p.extraHealthChecks, healthCheck{
+			name:    provider + "." + check.name,
+			failing: check.failing,
+		})
+	}
+}
+
+// healthHandler reports the outcome of the last gather and, with fanout, of
+// the last push of the other providers. It does not gather itself, so probes
+// cost nothing; before the first scrape it reports ok. It is not protected by
+// auth so probes can reach it, and it exposes no metric data.
+func (p *prometheusProvider) healthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, code := healthStatus{Status: "ok"}, http.StatusOK
+		for _, check := range p.healthChecks() {
+			if check.failing() {
+				status.Failed = append(status.Failed, check.name)
+			}
+		}
+		if len(status.Failed) > 0 {
+			status.Status, code = "unhealthy", http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(status)
+	})
+}
@@ -0,0 +1,79 @@
+package metricsx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthHandler(t *testing.T) {
+	t.Run("serves the health path on the metrics server", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{
+			Host:       "127.0.0.1",
+			Port:       RandomPort,
+			Path:       "/metrics",
+			HealthPath: "/healthz",
+			Auth:       AuthConfig{BearerToken: "secret"},
+		}, getTestLogger())
+
+		ctx := context.Background()
+		require.NoError(t, provider.Start(ctx))
+		defer provider.Stop(ctx)
+
+		resp, err := http.Get("http://" + provider.Addr() + "/healthz")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var status healthStatus
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "ok", status.Status)
+	})
+
+	t.Run("reports the last gather by check name only", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger()).(*prometheusProvider)
+		provider.registry.MustRegister(failingCollector{})
+
+		probe := func() *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			provider.healthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			return rec
+		}
+		assert.Equal(t, http.StatusOK, probe().Code)
+
+		scrape(t, provider)
+		rec := probe()
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.JSONEq(t, `{"status":"unhealthy","failed":["gather"]}`, rec.Body.String())
+		assert.NotContains(t, rec.Body.String(), "collection failed")
+	})
+
+	t.Run("reports failed pushes of fanout members", func(t *testing.T) {
+		provider, err := newFanoutProvider(Config{
+			Fanout:      FanoutConfig{Providers: []string{"prometheus", "pushgateway"}},
+			Prometheus:  PrometheusConfig{Path: "/metrics"},
+			Pushgateway: PushgatewayConfig{URL: "http://127.0.0.1:1", Job: "test"},
+		}, getTestLogger())
+		require.NoError(t, err)
+		members := provider.(*fanoutProvider).providers
+		prom, gateway := members[0].(*prometheusProvider), members[1].(*pushgatewayProvider)
+
+		gateway.loop.failed.Store(true)
+		rec := httptest.NewRecorder()
+		prom.healthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.JSONEq(t, `{"status":"unhealthy","failed":["pushgateway.push"]}`, rec.Body.String())
+	})
+
+	t.Run("rejects a health path that is already served", func(t *testing.T) {
+		_, err := newPrometheusProviderFromConfig(Config{
+			Prometheus: PrometheusConfig{Path: "/metrics", HealthPath: "/metrics"},
+		}, getTestLogger())
+		assert.Error(t, err)
+	})
+}
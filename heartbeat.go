@@ -0,0 +1,66 @@
+package metricsx
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultHeartbeatInterval is the heartbeat period used when Heartbeat is
+// given a non-positive interval
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// Heartbeat exposes service_start_time_seconds, service_uptime_seconds and
+// service_heartbeat_timestamp_seconds, updating the heartbeat every interval
+// from a background goroutine until the returned stop function is called.
+//
+// A heartbeat older than a few intervals means the process is alive but no
+// longer scheduling work, so deadman alerts can be written the same way for
+// every service:
+//
+//	time() - service_heartbeat_timestamp_seconds > 60
+func Heartbeat(m Metrics, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+
+	start := time.Now()
+	m.GaugeFunc("service_start_time_seconds", func() float64 {
+		return unixSeconds(start)
+	},
+		WithHelp("Unix timestamp of the service start."),
+	)
+	m.GaugeFunc("service_uptime_seconds", func() float64 {
+		return time.Since(start).Seconds()
+	},
+		WithHelp("Seconds since the service started."),
+	)
+
+	heartbeat := m.Gauge("service_heartbeat_timestamp_seconds",
+		WithHelp("Unix timestamp of the last heartbeat."),
+	)
+	heartbeat.Set(unixSeconds(start))
+
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				heartbeat.Set(unixSeconds(now))
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// unixSeconds returns t as fractional seconds since the Unix epoch
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / 1e9
+}
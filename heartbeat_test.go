@@ -0,0 +1,41 @@
+package metricsx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeat(t *testing.T) {
+	t.Run("exposes start time, uptime and heartbeat", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: Config{Provider: "prometheus"}, Logger: getTestLogger()})
+		require.NoError(t, err)
+
+		stop := Heartbeat(res.Metrics, 10*time.Millisecond)
+		defer stop()
+
+		body := scrape(t, res.Provider)
+		assert.Contains(t, body, "# TYPE service_start_time_seconds gauge")
+		assert.Contains(t, body, "# TYPE service_uptime_seconds gauge")
+		assert.Contains(t, body, "# TYPE service_heartbeat_timestamp_seconds gauge")
+	})
+
+	t.Run("advances the heartbeat until stopped", func(t *testing.T) {
+		m := &metricsImpl{provider: newPrometheusProvider(PrometheusConfig{}, getTestLogger()), logger: getTestLogger()}
+		gauge := m.provider.Gauge("service_heartbeat_timestamp_seconds", applyOptions()).(*prometheusGaugeVec)
+		read := func() float64 {
+			return testutil.ToFloat64(gauge.vec)
+		}
+
+		stop := Heartbeat(m, 5*time.Millisecond)
+		first := read()
+		assert.InDelta(t, unixSeconds(time.Now()), first, 1)
+
+		assert.Eventually(t, func() bool { return read() > first }, time.Second, 5*time.Millisecond)
+		stop()
+		stop()
+	})
+}
@@ -0,0 +1,264 @@
+package metricsx
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHistoryInterval is the sampling interval used when
+// HistoryConfig.Interval is zero
+const DefaultHistoryInterval = 15 * time.Second
+
+// HistoryConfig keeps a ring buffer of recent samples per series in memory,
+// for local debugging without a Prometheus server
+type HistoryConfig struct {
+	// Retention is how long samples are kept; 0 disables the history
+	Retention time.Duration `mapstructure:"retention" default:"0s"`
+
+	// Interval is how often every series is sampled
+	Interval time.Duration `mapstructure:"interval" default:"15s"`
+}
+
+// enabled reports whether samples are recorded
+func (c HistoryConfig) enabled() bool {
+	return c.Retention > 0
+}
+
+// HistoryPoint is a sample of a series at Time. Value is set for counters,
+// gauges and untyped series, Count and Sum for histograms and summaries.
+type HistoryPoint struct {
+	Time  time.Time
+	Value float64
+	Count uint64
+	Sum   float64
+}
+
+// SeriesHistory is the recorded samples of one series, oldest first
+type SeriesHistory struct {
+	// Name is the fully qualified family name
+	Name string
+
+	// Type is counter, gauge, histogram, summary or untyped
+	Type string
+
+	// Labels are the label names and values of the series
+	Labels map[string]string
+
+	// Points are the samples within the requested window
+	Points []HistoryPoint
+}
+
+// HistoryReader is implemented by providers that keep a sample history
+type HistoryReader interface {
+	// History returns the samples of the family name recorded within window,
+	// for every series carrying all label values of matchers
+	History(name string, matchers map[string]string, window time.Duration) []SeriesHistory
+}
+
+// History returns the recorded samples of the family name, or nil when the
+// history is disabled
+func (p *prometheusProvider) History(name string, matchers map[string]string, window time.Duration) []SeriesHistory {
+	if p.history == nil {
+		return nil
+	}
+	return p.history.History(name, matchers, window)
+}
+
+// historyReader returns the sample history, or nil when it is disabled
+func (p *prometheusProvider) historyReader() HistoryReader {
+	if p.history == nil {
+		return nil
+	}
+	return p.history
+}
+
+// metricHistory samples a Snapshotter periodically into per-series rings
+type metricHistory struct {
+	source   Snapshotter
+	interval time.Duration
+	capacity int
+	now      func() time.Time
+
+	mu     sync.RWMutex
+	series map[string]*historyRing
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// historyRing is a fixed size ring of the samples of one series
+type historyRing struct {
+	name   string
+	kind   string
+	labels map[string]string
+	points []HistoryPoint
+	next   int
+	full   bool
+}
+
+// newMetricHistory creates a history of source, or nil when cfg disables it
+func newMetricHistory(cfg HistoryConfig, source Snapshotter) *metricHistory {
+	if !cfg.enabled() {
+		return nil
+	}
+
+	interval := cmp.Or(cfg.Interval, DefaultHistoryInterval)
+	return &metricHistory{
+		source:   source,
+		interval: interval,
+		capacity: max(int(cfg.Retention/interval), 1),
+		now:      time.Now,
+		series:   make(map[string]*historyRing),
+	}
+}
+
+// start samples on every interval until stop is called
+func (h *metricHistory) start() {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stop != nil {
+		return
+	}
+	h.stop, h.done = make(chan struct{}), make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+
+		h.sample()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				h.sample()
+			}
+		}
+	}(h.stop, h.done)
+}
+
+// stopSampling ends the sampling goroutine, keeping the recorded samples
+func (h *metricHistory) stopSampling() {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	stop, done := h.stop, h.done
+	h.stop, h.done = nil, nil
+	h.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// sample records the current value of every series and drops series that
+// have not been seen for a whole retention
+func (h *metricHistory) sample() {
+	families, _ := h.source.Snapshot()
+	now := h.now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, f := range families {
+		for _, s := range f.Samples {
+			key := historyKey(f.Name, s.Labels)
+			ring, ok := h.series[key]
+			if !ok {
+				ring = &historyRing{name: f.Name, kind: f.Type, labels: s.Labels, points: make([]HistoryPoint, h.capacity)}
+				h.series[key] = ring
+			}
+			ring.add(HistoryPoint{Time: now, Value: s.Value, Count: s.Count, Sum: s.Sum})
+		}
+	}
+
+	retention := time.Duration(h.capacity) * h.interval
+	for key, ring := range h.series {
+		if last, ok := ring.last(); ok && now.Sub(last.Time) > retention {
+			delete(h.series, key)
+		}
+	}
+}
+
+// History returns the samples of the family name within window for every
+// series matching matchers, sorted by labels
+func (h *metricHistory) History(name string, matchers map[string]string, window time.Duration) []SeriesHistory {
+	since := h.now().Add(-window)
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var history []SeriesHistory
+	for _, ring := range h.series {
+		if ring.name != name || !matchLabels(ring.labels, matchers) {
+			continue
+		}
+		points := ring.since(since)
+		if len(points) == 0 {
+			continue
+		}
+		history = append(history, SeriesHistory{Name: ring.name, Type: ring.kind, Labels: ring.labels, Points: points})
+	}
+	slices.SortFunc(history, func(a, b SeriesHistory) int {
+		return cmp.Compare(historyKey("", a.Labels), historyKey("", b.Labels))
+	})
+	return history
+}
+
+// historyKey identifies a series by family name and labels
+func historyKey(name string, labels map[string]string) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		b.WriteByte(0)
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+	}
+	return b.String()
+}
+
+// add appends p, overwriting the oldest point when the ring is full
+func (r *historyRing) add(p HistoryPoint) {
+	r.points[r.next] = p
+	r.next = (r.next + 1) % len(r.points)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// last returns the newest point
+func (r *historyRing) last() (HistoryPoint, bool) {
+	if r.next == 0 && !r.full {
+		return HistoryPoint{}, false
+	}
+	return r.points[(r.next-1+len(r.points))%len(r.points)], true
+}
+
+// since returns the points recorded at or after t, oldest first
+func (r *historyRing) since(t time.Time) []HistoryPoint {
+	ordered := r.points[:r.next]
+	if r.full {
+		ordered = append(slices.Clone(r.points[r.next:]), r.points[:r.next]...)
+	}
+
+	points := make([]HistoryPoint, 0, len(ordered))
+	for _, p := range ordered {
+		if !p.Time.Before(t) {
+			points = append(points, p)
+		}
+	}
+	return points
+}
@@ -0,0 +1,143 @@
+package metricsx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHistory(t *testing.T, history HistoryConfig) (Metrics, *prometheusProvider, *fakeClock) {
+	t.Helper()
+	res, err := NewMetrics(Params{Config: Config{Provider: "prometheus", Prometheus: PrometheusConfig{Path: "/metrics", QueryPath: "/metrics/query", History: history}}, Logger: getTestLogger()})
+	require.NoError(t, err)
+
+	p := res.Provider.(*prometheusProvider)
+	require.NotNil(t, p.history)
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	p.history.now = clock.Now
+	return res.Metrics, p, clock
+}
+
+// staticSnapshot serves a fixed snapshot
+type staticSnapshot []FamilySnapshot
+
+func (s *staticSnapshot) Snapshot() ([]FamilySnapshot, error) {
+	return *s, nil
+}
+
+func TestMetricHistory(t *testing.T) {
+	t.Run("is disabled without retention", func(t *testing.T) {
+		assert.Nil(t, newMetricHistory(HistoryConfig{}, nil))
+	})
+
+	t.Run("records samples within the window", func(t *testing.T) {
+		m, p, clock := newTestHistory(t, HistoryConfig{Retention: time.Minute, Interval: 10 * time.Second})
+		queue := m.Gauge("queue_depth", WithLabels("queue"))
+
+		for i := range 4 {
+			queue.Set(float64(i), "emails")
+			queue.Set(float64(10*i), "sms")
+			p.history.sample()
+			clock.Advance(10 * time.Second)
+		}
+
+		series := p.History("queue_depth", map[string]string{"queue": "emails"}, 25*time.Second)
+		require.Len(t, series, 1)
+		assert.Equal(t, "gauge", series[0].Type)
+		assert.Equal(t, map[string]string{"queue": "emails"}, series[0].Labels)
+		values := make([]float64, 0, len(series[0].Points))
+		for _, point := range series[0].Points {
+			values = append(values, point.Value)
+		}
+		assert.Equal(t, []float64{2, 3}, values)
+
+		assert.Len(t, p.History("queue_depth", nil, time.Minute), 2)
+	})
+
+	t.Run("keeps only the retention", func(t *testing.T) {
+		m, p, clock := newTestHistory(t, HistoryConfig{Retention: 30 * time.Second, Interval: 10 * time.Second})
+		requests := m.Counter("requests_total")
+
+		for range 5 {
+			requests.Inc()
+			p.history.sample()
+			clock.Advance(10 * time.Second)
+		}
+
+		series := p.History("requests_total", nil, time.Hour)
+		require.Len(t, series, 1)
+		require.Len(t, series[0].Points, 3)
+		assert.Equal(t, 3.0, series[0].Points[0].Value)
+		assert.Equal(t, 5.0, series[0].Points[2].Value)
+	})
+
+	t.Run("drops series that stopped reporting", func(t *testing.T) {
+		snapshot := staticSnapshot{{Name: "temperature_celsius", Type: "gauge", Samples: []SampleSnapshot{{Value: 21}}}}
+		h := newMetricHistory(HistoryConfig{Retention: 20 * time.Second, Interval: 10 * time.Second}, &snapshot)
+		clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+		h.now = clock.Now
+
+		h.sample()
+		require.Len(t, h.series, 1)
+
+		snapshot = nil
+		clock.Advance(time.Minute)
+		h.sample()
+		assert.Empty(t, h.series)
+	})
+}
+
+func TestQueryHandlerRange(t *testing.T) {
+	m, p, clock := newTestHistory(t, HistoryConfig{Retention: time.Minute, Interval: 10 * time.Second})
+	latency := m.Histogram("request_duration_seconds")
+	for range 3 {
+		latency.Observe(0.5)
+		p.history.sample()
+		clock.Advance(10 * time.Second)
+	}
+
+	handler := p.Handlers()["/metrics/query"]
+	query := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := query("/metrics/query?name=request_duration_seconds&range=10m")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body jsonHistory
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "histogram", body.Type)
+	require.Len(t, body.Series, 1)
+	require.Len(t, body.Series[0].Points, 3)
+	assert.Equal(t, uint64(3), *body.Series[0].Points[2].Count)
+	assert.Nil(t, body.Series[0].Points[2].Value)
+
+	assert.Equal(t, http.StatusBadRequest, query("/metrics/query?name=request_duration_seconds&range=soon").Code)
+	assert.Equal(t, http.StatusNotFound, query("/metrics/query?name=missing_total&range=10m").Code)
+
+	t.Run("rejects ranges when disabled", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: Config{Provider: "prometheus", Prometheus: PrometheusConfig{Path: "/metrics", QueryPath: "/metrics/query"}}, Logger: getTestLogger()})
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		res.Provider.(multiHandler).Handlers()["/metrics/query"].ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics/query?name=x&range=1m", nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestMetricHistoryStartStop(t *testing.T) {
+	m, p, _ := newTestHistory(t, HistoryConfig{Retention: time.Minute, Interval: time.Hour})
+	m.Counter("jobs_total").Inc()
+
+	require.NoError(t, p.Start(t.Context()))
+	require.NoError(t, p.Stop(t.Context()))
+
+	assert.Len(t, p.History("jobs_total", nil, time.Minute), 1, "samples once on start")
+}
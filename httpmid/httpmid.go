@@ -0,0 +1,195 @@
+// Package httpmid provides net/http middleware that records RED (rate, errors,
+// duration) metrics through metricsx.
+//
+//	hm := httpmid.New(metrics)
+//	http.ListenAndServe(":8080", hm.Middleware(mux))
+//
+// It records:
+//
+//	http_requests_total{method, path, status}
+//	http_request_duration_seconds{method, path, status}
+//	http_requests_in_flight{method}
+//
+// The path label holds the matched route pattern rather than the raw URL path,
+// so IDs and other dynamic segments cannot explode label cardinality. Requests
+// that did not match a route are recorded under path="unmatched", and requests
+// whose handler panicked under status="500" before the panic is re-raised.
+//
+// WithAccumulator gives every request a metricsx.Accumulator, so metrics that
+// handlers record through metricsx.AccumulatorFromContext only count requests
+// whose handler returned.
+package httpmid
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gostratum/metricsx"
+)
+
+// UnmatchedRoute is the path label used for requests without a route pattern
+const UnmatchedRoute = "unmatched"
+
+// Option configures the HTTP metrics
+type Option func(*options)
+
+// options contains configuration for the HTTP metrics
+type options struct {
+	buckets    []float64
+	routeFunc  func(*http.Request) string
+	accumulate bool
+}
+
+// WithBuckets sets the buckets for the request duration histogram
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// WithRouteFunc sets the function that returns the route pattern of a served
+// request (default: http.Request.Pattern set by http.ServeMux). It is called
+// after the handler returns; an empty result is recorded as UnmatchedRoute.
+func WithRouteFunc(fn func(*http.Request) string) Option {
+	return func(o *options) {
+		o.routeFunc = fn
+	}
+}
+
+// WithAccumulator places a metricsx.Accumulator in the context of every
+// request. Its writes are flushed when the handler returns and discarded
+// when it panics.
+func WithAccumulator() Option {
+	return func(o *options) {
+		o.accumulate = true
+	}
+}
+
+// Metrics records HTTP server metrics
+type Metrics struct {
+	requests   metricsx.Counter
+	duration   metricsx.Histogram
+	inFlight   metricsx.Gauge
+	routeFunc  func(*http.Request) string
+	accumulate bool
+}
+
+// New creates the HTTP server metrics
+func New(m metricsx.Metrics, opts ...Option) *Metrics {
+	o := &options{
+		buckets: metricsx.DefaultBuckets,
+		routeFunc: func(r *http.Request) string {
+			return r.Pattern
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Metrics{
+		requests: m.Counter("http_requests_total",
+			metricsx.WithHelp("Total HTTP requests."),
+			metricsx.WithLabels("method", "path", "status"),
+		),
+		duration: m.Histogram("http_request_duration_seconds",
+			metricsx.WithHelp("HTTP request duration in seconds."),
+			metricsx.WithLabels("method", "path", "status"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+		inFlight: m.Gauge("http_requests_in_flight",
+			metricsx.WithHelp("Current number of HTTP requests being served."),
+			metricsx.WithLabels("method"),
+		),
+		routeFunc:  o.routeFunc,
+		accumulate: o.accumulate,
+	}
+}
+
+// Begin marks the start of a request and returns a function that records its
+// completion. It lets framework adapters share the same metric set:
+//
+//	end := hm.Begin(req.Method)
+//	defer func() { end(route, status) }()
+func (h *Metrics) Begin(method string) func(route string, status int) {
+	start := time.Now()
+	h.inFlight.Inc(method)
+
+	return func(route string, status int) {
+		if route == "" {
+			route = UnmatchedRoute
+		}
+		code := strconv.Itoa(status)
+
+		h.inFlight.Dec(method)
+		h.requests.Inc(method, route, code)
+		h.duration.Observe(time.Since(start).Seconds(), method, route, code)
+	}
+}
+
+// Middleware wraps next and records metrics for every request it serves
+func (h *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		end := h.Begin(r.Method)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		var acc *metricsx.Accumulator
+		if h.accumulate {
+			acc = metricsx.NewAccumulator()
+			r = r.WithContext(metricsx.ContextWithAccumulator(r.Context(), acc))
+		}
+
+		completed := false
+		defer func() {
+			if completed {
+				acc.Flush()
+				end(h.routeFunc(r), rec.status)
+				return
+			}
+
+			// A panicking handler is recorded as a server error and the panic
+			// passed on to net/http or an outer recovery middleware
+			acc.Discard()
+			end(h.routeFunc(r), http.StatusInternalServerError)
+			if p := recover(); p != nil {
+				panic(p)
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+		completed = true
+	})
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher when the underlying writer supports it
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		r.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
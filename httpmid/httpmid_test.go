@@ -0,0 +1,121 @@
+package httpmid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gostratum/metricsx"
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Run("labels requests by route pattern", func(t *testing.T) {
+		m := metricstest.New()
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			if r.PathValue("id") == "0" {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+			w.Write([]byte("ok"))
+		})
+		handler := New(m).Middleware(mux)
+
+		for _, path := range []string{"/users/1", "/users/2", "/users/0"} {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+
+		out := m.String()
+		assert.Contains(t, out, `http_requests_total{method="GET",path="GET /users/{id}",status="200"} 2`)
+		assert.Contains(t, out, `http_requests_total{method="GET",path="GET /users/{id}",status="404"} 1`)
+		assert.Contains(t, out, `http_request_duration_seconds_count{method="GET",path="GET /users/{id}",status="200"} 2`)
+		assert.Contains(t, out, `http_requests_in_flight{method="GET"} 0`)
+		assert.NotContains(t, out, "/users/1")
+	})
+
+	t.Run("records unmatched requests under a single label", func(t *testing.T) {
+		m := metricstest.New()
+		handler := New(m).Middleware(http.NewServeMux())
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/random/abc", nil))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/random/def", nil))
+
+		assert.Contains(t, m.String(), `http_requests_total{method="GET",path="unmatched",status="404"} 2`)
+	})
+
+	t.Run("uses a custom route func", func(t *testing.T) {
+		m := metricstest.New()
+		handler := New(m, WithRouteFunc(func(r *http.Request) string {
+			return strings.SplitN(r.URL.Path, "/", 3)[1]
+		})).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders/42", nil))
+
+		assert.Contains(t, m.String(), `http_requests_total{method="POST",path="orders",status="201"} 1`)
+	})
+
+	t.Run("preserves flushing", func(t *testing.T) {
+		m := metricstest.New()
+		handler := New(m).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, http.NewResponseController(w).Flush())
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.True(t, rec.Flushed)
+	})
+
+	t.Run("counts accumulated metrics of completed requests", func(t *testing.T) {
+		m := metricstest.New()
+		orders := m.Counter("orders_placed_total")
+		handler := New(m, WithAccumulator()).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			metricsx.AccumulatorFromContext(r.Context()).Inc(orders)
+			if r.URL.Path == "/panic" {
+				panic("handler failed")
+			}
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", nil))
+		assert.Panics(t, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/panic", nil))
+		})
+
+		assert.Contains(t, m.String(), "orders_placed_total 1")
+	})
+
+	t.Run("records panics as server errors and re-panics", func(t *testing.T) {
+		m := metricstest.New()
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /orders", func(w http.ResponseWriter, r *http.Request) {
+			panic("handler failed")
+		})
+		handler := New(m).Middleware(mux)
+
+		assert.PanicsWithValue(t, "handler failed", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", nil))
+		})
+
+		assert.Equal(t, 1.0, m.CounterValue("http_requests_total", "POST", "POST /orders", "500"))
+		assert.Equal(t, 0.0, m.GaugeValue("http_requests_in_flight", "POST"))
+	})
+}
+
+func TestBegin(t *testing.T) {
+	m := metricstest.New()
+	hm := New(m)
+
+	end := hm.Begin(http.MethodDelete)
+	assert.Contains(t, m.String(), `http_requests_in_flight{method="DELETE"} 1`)
+	end("", http.StatusNoContent)
+
+	out := m.String()
+	assert.Contains(t, out, `http_requests_in_flight{method="DELETE"} 0`)
+	assert.Contains(t, out, `http_requests_total{method="DELETE",path="unmatched",status="204"} 1`)
+}
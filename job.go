@@ -0,0 +1,59 @@
+package metricsx
+
+import (
+	"time"
+)
+
+// Job records the standard metrics for a cron or batch job
+type Job struct {
+	name        string
+	lastSuccess Gauge
+	lastRun     Gauge
+	runs        Counter
+	failures    Counter
+}
+
+// JobMetrics creates the metrics for jobName:
+// job_last_success_timestamp_seconds, job_last_duration_seconds, job_runs_total
+// and job_failures_total, all labeled with job
+func JobMetrics(m Metrics, jobName string) *Job {
+	return &Job{
+		name: jobName,
+		lastSuccess: m.Gauge("job_last_success_timestamp_seconds",
+			WithHelp("Unix timestamp of the last successful job run."),
+			WithLabels("job"),
+		),
+		lastRun: m.Gauge("job_last_duration_seconds",
+			WithHelp("Duration of the last job run in seconds."),
+			WithLabels("job"),
+		),
+		runs: m.Counter("job_runs_total",
+			WithHelp("Total number of job runs."),
+			WithLabels("job"),
+		),
+		failures: m.Counter("job_failures_total",
+			WithHelp("Total number of failed job runs."),
+			WithLabels("job"),
+		),
+	}
+}
+
+// Run executes fn and records its duration and outcome
+func (j *Job) Run(fn func() error) error {
+	start := time.Now()
+	err := fn()
+	j.Record(time.Since(start), err)
+	return err
+}
+
+// Record records a run that took duration and finished with err
+func (j *Job) Record(duration time.Duration, err error) {
+	j.runs.Inc(j.name)
+	j.lastRun.Set(duration.Seconds(), j.name)
+
+	if err != nil {
+		j.failures.Inc(j.name)
+		return
+	}
+	j.lastSuccess.Set(float64(time.Now().UnixNano())/1e9, j.name)
+}
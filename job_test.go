@@ -0,0 +1,47 @@
+package metricsx
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobMetrics(t *testing.T) {
+	t.Run("records successful runs", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		job := JobMetrics(&metricsImpl{provider: provider, logger: getTestLogger()}, "nightly_report")
+
+		require.NoError(t, job.Run(func() error { return nil }))
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `job_runs_total{job="nightly_report"} 1`)
+		assert.Contains(t, out, `job_last_duration_seconds{job="nightly_report"}`)
+		assert.Contains(t, out, `job_last_success_timestamp_seconds{job="nightly_report"} 1.`)
+		assert.NotContains(t, out, `job_failures_total{`)
+	})
+
+	t.Run("records failures without touching last success", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		job := JobMetrics(&metricsImpl{provider: provider, logger: getTestLogger()}, "cleanup")
+
+		err := job.Run(func() error { return errors.New("disk full") })
+		require.Error(t, err)
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `job_runs_total{job="cleanup"} 1`)
+		assert.Contains(t, out, `job_failures_total{job="cleanup"} 1`)
+		assert.NotContains(t, out, `job_last_success_timestamp_seconds{`)
+	})
+
+	t.Run("records externally timed runs", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		job := JobMetrics(&metricsImpl{provider: provider, logger: getTestLogger()}, "import")
+
+		job.Record(90*time.Second, nil)
+
+		assert.Contains(t, scrape(t, provider), `job_last_duration_seconds{job="import"} 90`)
+	})
+}
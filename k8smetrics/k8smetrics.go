@@ -0,0 +1,131 @@
+// Package k8smetrics adapts client-go's metrics hooks to metricsx so API server
+// calls made by controllers and operators land in the application registry.
+//
+// The types implement the interfaces from k8s.io/client-go/tools/metrics
+// structurally, so this package does not depend on client-go:
+//
+//	a := k8smetrics.New(metrics)
+//	clientmetrics.Register(clientmetrics.RegisterOpts{
+//		RequestLatency:     a.RequestLatency,
+//		RateLimiterLatency: a.RateLimiterLatency,
+//		RequestResult:      a.RequestResult,
+//		RequestRetry:       a.RequestRetry,
+//	})
+//
+// It records the metric names used by Kubernetes components:
+//
+//	rest_client_request_duration_seconds{verb, host}
+//	rest_client_rate_limiter_duration_seconds{verb, host}
+//	rest_client_requests_total{code, method, host}
+//	rest_client_request_retries_total{code, method, host}
+package k8smetrics
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/gostratum/metricsx"
+)
+
+// DefaultBuckets are the request latency buckets used by Kubernetes components
+var DefaultBuckets = []float64{0.005, 0.025, 0.1, 0.25, 0.5, 1.0, 2.0, 4.0, 8.0, 15.0, 30.0, 60.0}
+
+// Option configures the adapter
+type Option func(*options)
+
+// options contains configuration for the adapter
+type options struct {
+	buckets []float64
+}
+
+// WithBuckets sets the buckets for the latency histograms
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// Adapter holds client-go metric implementations backed by metricsx
+type Adapter struct {
+	// RequestLatency implements metrics.LatencyMetric for request latency
+	RequestLatency *LatencyMetric
+
+	// RateLimiterLatency implements metrics.LatencyMetric for client-side throttling latency
+	RateLimiterLatency *LatencyMetric
+
+	// RequestResult implements metrics.ResultMetric for response codes
+	RequestResult *ResultMetric
+
+	// RequestRetry implements metrics.RetryMetric for retried requests
+	RequestRetry *RetryMetric
+}
+
+// New creates the client-go adapter
+func New(m metricsx.Metrics, opts ...Option) *Adapter {
+	o := &options{
+		buckets: DefaultBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Adapter{
+		RequestLatency: &LatencyMetric{
+			histogram: m.Histogram("rest_client_request_duration_seconds",
+				metricsx.WithHelp("Request latency in seconds. Broken down by verb and host."),
+				metricsx.WithLabels("verb", "host"),
+				metricsx.WithBuckets(o.buckets...),
+			),
+		},
+		RateLimiterLatency: &LatencyMetric{
+			histogram: m.Histogram("rest_client_rate_limiter_duration_seconds",
+				metricsx.WithHelp("Client side rate limiter latency in seconds. Broken down by verb and host."),
+				metricsx.WithLabels("verb", "host"),
+				metricsx.WithBuckets(o.buckets...),
+			),
+		},
+		RequestResult: &ResultMetric{
+			counter: m.Counter("rest_client_requests_total",
+				metricsx.WithHelp("Number of HTTP requests, partitioned by status code, method, and host."),
+				metricsx.WithLabels("code", "method", "host"),
+			),
+		},
+		RequestRetry: &RetryMetric{
+			counter: m.Counter("rest_client_request_retries_total",
+				metricsx.WithHelp("Number of request retries, partitioned by status code, method, and host."),
+				metricsx.WithLabels("code", "method", "host"),
+			),
+		},
+	}
+}
+
+// LatencyMetric implements client-go's metrics.LatencyMetric
+type LatencyMetric struct {
+	histogram metricsx.Histogram
+}
+
+// Observe records the latency of a request to u
+func (l *LatencyMetric) Observe(_ context.Context, verb string, u url.URL, latency time.Duration) {
+	l.histogram.Observe(latency.Seconds(), verb, u.Host)
+}
+
+// ResultMetric implements client-go's metrics.ResultMetric
+type ResultMetric struct {
+	counter metricsx.Counter
+}
+
+// Increment counts a response with the given status code
+func (r *ResultMetric) Increment(_ context.Context, code, method, host string) {
+	r.counter.Inc(code, method, host)
+}
+
+// RetryMetric implements client-go's metrics.RetryMetric
+type RetryMetric struct {
+	counter metricsx.Counter
+}
+
+// IncrementRetry counts a retried request
+func (r *RetryMetric) IncrementRetry(_ context.Context, code, method, host string) {
+	r.counter.Inc(code, method, host)
+}
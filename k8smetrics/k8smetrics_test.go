@@ -0,0 +1,43 @@
+package k8smetrics
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+)
+
+// The interfaces below mirror k8s.io/client-go/tools/metrics
+var (
+	_ interface {
+		Observe(ctx context.Context, verb string, u url.URL, latency time.Duration)
+	} = (*LatencyMetric)(nil)
+	_ interface {
+		Increment(ctx context.Context, code string, method string, host string)
+	} = (*ResultMetric)(nil)
+	_ interface {
+		IncrementRetry(ctx context.Context, code string, method string, host string)
+	} = (*RetryMetric)(nil)
+)
+
+func TestAdapter(t *testing.T) {
+	m := metricstest.New()
+	a := New(m, WithBuckets(0.1, 1))
+	ctx := context.Background()
+
+	u := url.URL{Scheme: "https", Host: "10.0.0.1:6443", Path: "/api/v1/namespaces/default/pods"}
+	a.RequestLatency.Observe(ctx, "GET", u, 50*time.Millisecond)
+	a.RateLimiterLatency.Observe(ctx, "GET", u, 2*time.Second)
+	a.RequestResult.Increment(ctx, "200", "GET", u.Host)
+	a.RequestResult.Increment(ctx, "200", "GET", u.Host)
+	a.RequestRetry.IncrementRetry(ctx, "429", "GET", u.Host)
+
+	out := m.String()
+	assert.Contains(t, out, `rest_client_request_duration_seconds_bucket{host="10.0.0.1:6443",verb="GET",le="0.1"} 1`)
+	assert.Contains(t, out, `rest_client_rate_limiter_duration_seconds_bucket{host="10.0.0.1:6443",verb="GET",le="1"} 0`)
+	assert.Contains(t, out, `rest_client_requests_total{code="200",host="10.0.0.1:6443",method="GET"} 2`)
+	assert.Contains(t, out, `rest_client_request_retries_total{code="429",host="10.0.0.1:6443",method="GET"} 1`)
+}
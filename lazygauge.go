@@ -0,0 +1,60 @@
+package metricsx
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultLazyGaugeWindow is the memoization window used when LazyGauge is
+// given a non-positive window
+const DefaultLazyGaugeWindow = 5 * time.Second
+
+// LazyGauge is a gauge computed by an expensive function only when metrics
+// are collected, at most once per memoization window
+type LazyGauge struct {
+	fn     func() float64
+	window time.Duration
+	now    func() time.Time
+
+	mu       sync.Mutex
+	value    float64
+	computed time.Time
+	valid    bool
+}
+
+// NewLazyGauge registers the gauge name whose value is fn, called when the
+// gauge is scraped or exported and reused for window afterwards. Within the
+// window, every member of a fanout provider, concurrent scrapes and pushes
+// share one call, so a computation taking seconds is not repeated per
+// collection. Concurrent collections wait for a running call instead of
+// starting another.
+func NewLazyGauge(m Metrics, name string, fn func() float64, window time.Duration, opts ...Option) *LazyGauge {
+	if window <= 0 {
+		window = DefaultLazyGaugeWindow
+	}
+
+	g := &LazyGauge{fn: fn, window: window, now: time.Now}
+	m.GaugeFunc(name, g.Value, opts...)
+	return g
+}
+
+// Value returns the memoized value, calling the function when the window
+// since the last call has passed
+func (g *LazyGauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	if !g.valid || now.Sub(g.computed) >= g.window {
+		g.value, g.computed, g.valid = g.fn(), now, true
+	}
+	return g.value
+}
+
+// Invalidate discards the memoized value, so the next collection calls the
+// function again
+func (g *LazyGauge) Invalidate() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.valid = false
+}
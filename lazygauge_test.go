@@ -0,0 +1,62 @@
+package metricsx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazyGauge(t *testing.T) {
+	newTestLazyGauge := func(t *testing.T, cfg Config) (Result, *LazyGauge, *fakeClock, *int) {
+		t.Helper()
+		res, err := NewMetrics(Params{Config: cfg, Logger: getTestLogger()})
+		require.NoError(t, err)
+
+		calls := 0
+		g := NewLazyGauge(res.Metrics, "index_size_bytes", func() float64 {
+			calls++
+			return float64(calls * 100)
+		}, time.Minute, WithHelp("Size of the search index."))
+
+		clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+		g.now = clock.Now
+		return res, g, clock, &calls
+	}
+
+	t.Run("computes only when collected", func(t *testing.T) {
+		res, _, clock, calls := newTestLazyGauge(t, Config{Provider: "prometheus"})
+		assert.Zero(t, *calls)
+
+		provider := res.Provider.(*prometheusProvider)
+		assert.Contains(t, scrape(t, provider), "index_size_bytes 100")
+		assert.Contains(t, scrape(t, provider), "index_size_bytes 100")
+		assert.Equal(t, 1, *calls)
+
+		clock.Advance(time.Minute)
+		assert.Contains(t, scrape(t, provider), "index_size_bytes 200")
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("shares one call across fanout members", func(t *testing.T) {
+		res, _, _, calls := newTestLazyGauge(t, Config{
+			Provider:    "fanout",
+			Fanout:      FanoutConfig{Providers: []string{"prometheus", "pushgateway"}},
+			Pushgateway: PushgatewayConfig{URL: "http://pushgateway:9091", Job: "indexer"},
+		})
+
+		for _, member := range res.Provider.(*fanoutProvider).providers {
+			assert.Contains(t, scrape(t, member), "index_size_bytes 100")
+		}
+		assert.Equal(t, 1, *calls)
+	})
+
+	t.Run("recomputes after Invalidate", func(t *testing.T) {
+		_, g, _, calls := newTestLazyGauge(t, Config{Provider: "noop"})
+		assert.Equal(t, 100.0, g.Value())
+		g.Invalidate()
+		assert.Equal(t, 200.0, g.Value())
+		assert.Equal(t, 2, *calls)
+	})
+}
@@ -0,0 +1,158 @@
+package metricsx
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gostratum/core/logx"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxevent"
+	"go.uber.org/zap"
+)
+
+// Lifecycle phase label values
+const (
+	LifecyclePhaseStart = "start"
+	LifecyclePhaseStop  = "stop"
+)
+
+// fxEventLogger records fx lifecycle metrics and forwards events to next
+type fxEventLogger struct {
+	next fxevent.Logger
+
+	hookDuration  Histogram
+	hookFailures  Counter
+	startDuration Gauge
+	stopDuration  Gauge
+
+	mu        sync.Mutex
+	startedAt time.Time
+	stoppedAt time.Time
+}
+
+// FxEventLogger wraps next with an fxevent.Logger that records:
+//
+//	fx_hook_duration_seconds{phase, caller, function}
+//	fx_hook_failures_total{phase, caller, function}
+//	fx_app_start_duration_seconds
+//	fx_app_stop_duration_seconds
+//
+// The caller label is the constructor that appended the hook and function the
+// hook itself, so constructors appending several hooks are told apart. Fx only
+// sends hook events to the application logger, so install it with
+// fx.WithLogger at the top level rather than inside a module. FxEventsOption
+// does so around the logx logger; wrap another logger the same way:
+//
+//	fx.WithLogger(func(m metricsx.Metrics) fxevent.Logger {
+//		return metricsx.FxEventLogger(m, myEventLogger)
+//	})
+func FxEventLogger(m Metrics, next fxevent.Logger) fxevent.Logger {
+	if next == nil {
+		next = fxevent.NopLogger
+	}
+
+	return &fxEventLogger{
+		next: next,
+		hookDuration: m.Histogram("fx_hook_duration_seconds",
+			WithHelp("Duration of fx lifecycle hooks in seconds."),
+			WithLabels("phase", "caller", "function"),
+		),
+		hookFailures: m.Counter("fx_hook_failures_total",
+			WithHelp("Total number of failed fx lifecycle hooks."),
+			WithLabels("phase", "caller", "function"),
+		),
+		startDuration: m.Gauge("fx_app_start_duration_seconds",
+			WithHelp("Time taken to run all fx start hooks in seconds."),
+		),
+		stopDuration: m.Gauge("fx_app_stop_duration_seconds",
+			WithHelp("Time taken to run all fx stop hooks in seconds."),
+		),
+	}
+}
+
+// FxEventsOption installs FxEventLogger as the application's fx event logger,
+// forwarding events to logx.FxEventLogger when the graph provides a
+// *zap.Logger, as logx.Module does, and to the console otherwise. It replaces
+// any fx.WithLogger given before it, so place it last:
+//
+//	fx.New(
+//		logx.Module(),
+//		metricsx.Module(),
+//		metricsx.FxEventsOption(),
+//	)
+//
+// The metrics graph is built when fx installs the logger, before the other
+// constructors run.
+func FxEventsOption() fx.Option {
+	return fx.WithLogger(newFxEventLogger)
+}
+
+// fxLoggerParams contains the dependencies of the fx event logger installed
+// by FxEventsOption
+type fxLoggerParams struct {
+	fx.In
+	Metrics Metrics
+	Logger  *zap.Logger `optional:"true"`
+}
+
+// newFxEventLogger returns FxEventLogger wrapping the logx event logger, or
+// the console logger fx uses by default when there is no *zap.Logger
+func newFxEventLogger(p fxLoggerParams) fxevent.Logger {
+	var next fxevent.Logger = &fxevent.ConsoleLogger{W: os.Stderr}
+	if p.Logger != nil {
+		next = logx.FxEventLogger(p.Logger)
+	}
+	return FxEventLogger(p.Metrics, next)
+}
+
+// LogEvent records metrics for lifecycle events and forwards every event
+func (l *fxEventLogger) LogEvent(event fxevent.Event) {
+	switch e := event.(type) {
+	case *fxevent.OnStartExecuting:
+		l.markStart(&l.startedAt)
+	case *fxevent.OnStartExecuted:
+		l.recordHook(LifecyclePhaseStart, e.CallerName, e.FunctionName, e.Runtime, e.Err)
+	case *fxevent.Started:
+		l.recordApp(l.startDuration, &l.startedAt)
+	case *fxevent.OnStopExecuting:
+		l.markStart(&l.stoppedAt)
+	case *fxevent.OnStopExecuted:
+		l.recordHook(LifecyclePhaseStop, e.CallerName, e.FunctionName, e.Runtime, e.Err)
+	case *fxevent.Stopped:
+		l.recordApp(l.stopDuration, &l.stoppedAt)
+	}
+
+	l.next.LogEvent(event)
+}
+
+// markStart remembers when the first hook of a phase began
+func (l *fxEventLogger) markStart(at *time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if at.IsZero() {
+		*at = time.Now()
+	}
+}
+
+// recordHook records the duration and outcome of a single hook
+func (l *fxEventLogger) recordHook(phase, caller, function string, runtime time.Duration, err error) {
+	l.hookDuration.Observe(runtime.Seconds(), phase, caller, function)
+	if err != nil {
+		l.hookFailures.Inc(phase, caller, function)
+	}
+}
+
+// recordApp records the time since the first hook of a phase began
+func (l *fxEventLogger) recordApp(gauge Gauge, at *time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if at.IsZero() {
+		gauge.Set(0)
+		return
+	}
+	gauge.Set(time.Since(*at).Seconds())
+	*at = time.Time{}
+}
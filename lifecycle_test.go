@@ -0,0 +1,118 @@
+package metricsx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gostratum/core/configx"
+	"github.com/gostratum/core/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxevent"
+	"go.uber.org/zap"
+)
+
+func TestFxEventLogger(t *testing.T) {
+	t.Run("records hook durations and app start and stop time", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		m := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+		app := fx.New(
+			fx.WithLogger(func() fxevent.Logger { return FxEventLogger(m, nil) }),
+			fx.Invoke(func(lc fx.Lifecycle) {
+				lc.Append(fx.Hook{
+					OnStart: func(context.Context) error {
+						time.Sleep(10 * time.Millisecond)
+						return nil
+					},
+					OnStop: func(context.Context) error { return nil },
+				})
+			}),
+		)
+		require.NoError(t, app.Start(context.Background()))
+		require.NoError(t, app.Stop(context.Background()))
+
+		out := scrape(t, provider)
+		assert.Regexp(t, `fx_hook_duration_seconds_count\{caller="github.com/gostratum/metricsx.TestFxEventLogger[^"]*",function="[^"]+",phase="start"\} 1`, out)
+		assert.Regexp(t, `fx_hook_duration_seconds_count\{caller="github.com/gostratum/metricsx.TestFxEventLogger[^"]*",function="[^"]+",phase="stop"\} 1`, out)
+		assert.Contains(t, out, "fx_app_start_duration_seconds 0.0")
+		assert.Contains(t, out, "fx_app_stop_duration_seconds ")
+		assert.NotContains(t, out, "fx_hook_failures_total{")
+	})
+
+	t.Run("counts failed hooks", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		logger := FxEventLogger(&metricsImpl{provider: provider, logger: getTestLogger()}, nil)
+
+		logger.LogEvent(&fxevent.OnStartExecuting{CallerName: "db.New", FunctionName: "db.(*Pool).Ping"})
+		logger.LogEvent(&fxevent.OnStartExecuted{CallerName: "db.New", FunctionName: "db.(*Pool).Ping", Runtime: time.Second, Err: errors.New("refused")})
+		logger.LogEvent(&fxevent.OnStartExecuted{CallerName: "db.New", FunctionName: "db.(*Pool).Warm", Runtime: 2 * time.Second})
+		logger.LogEvent(&fxevent.Started{Err: errors.New("refused")})
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `fx_hook_failures_total{caller="db.New",function="db.(*Pool).Ping",phase="start"} 1`)
+		assert.Contains(t, out, `fx_hook_duration_seconds_sum{caller="db.New",function="db.(*Pool).Ping",phase="start"} 1`)
+		assert.Contains(t, out, `fx_hook_duration_seconds_sum{caller="db.New",function="db.(*Pool).Warm",phase="start"} 2`)
+	})
+
+	t.Run("is installed by FxEventsOption", func(t *testing.T) {
+		var provider Provider
+		app := fx.New(
+			Module(),
+			FxEventsOption(),
+			fx.Provide(
+				func() configx.Loader {
+					return stubLoader{cfg: Config{
+						Provider:   "prometheus",
+						Prometheus: PrometheusConfig{Path: "/metrics"},
+					}}
+				},
+				func() logx.Logger { return getTestLogger() },
+				func() *zap.Logger { return zap.NewNop() },
+			),
+			fx.Populate(&provider),
+			fx.Invoke(func(lc fx.Lifecycle) {
+				lc.Append(fx.StartHook(func() {}))
+			}),
+		)
+		require.NoError(t, app.Start(context.Background()))
+		require.NoError(t, app.Stop(context.Background()))
+
+		assert.Contains(t, scrape(t, provider), "fx_app_start_duration_seconds")
+	})
+
+	t.Run("leaves the application's logger in place", func(t *testing.T) {
+		events := &recordingEventLogger{}
+		var provider Provider
+		app := fx.New(
+			fx.WithLogger(func() fxevent.Logger { return events }),
+			Module(),
+			fx.Provide(
+				func() configx.Loader {
+					return stubLoader{cfg: Config{Provider: "prometheus"}}
+				},
+				func() logx.Logger { return getTestLogger() },
+			),
+			fx.Populate(&provider),
+		)
+		require.NoError(t, app.Start(context.Background()))
+		require.NoError(t, app.Stop(context.Background()))
+
+		assert.True(t, events.started)
+		assert.NotContains(t, scrape(t, provider), "fx_app_start_duration_seconds")
+	})
+}
+
+// recordingEventLogger is an application fx event logger
+type recordingEventLogger struct {
+	started bool
+}
+
+func (l *recordingEventLogger) LogEvent(event fxevent.Event) {
+	if _, ok := event.(*fxevent.Started); ok {
+		l.started = true
+	}
+}
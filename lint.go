@@ -0,0 +1,177 @@
+package metricsx
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/gostratum/core/logx"
+	"github.com/prometheus/client_golang/prometheus/testutil/promlint"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/fx"
+)
+
+// ErrLintFailed is returned on start in the fail lint mode when registered
+// metrics violate the Prometheus naming conventions
+var ErrLintFailed = errors.New("metrics lint failed")
+
+// LintProblem is a naming or help violation of a registered metric
+type LintProblem struct {
+	// Metric is the fully qualified metric name
+	Metric string
+
+	// Text describes the violation
+	Text string
+}
+
+// Linter is implemented by providers that can check their registered metrics
+// against the Prometheus naming conventions
+type Linter interface {
+	// Lint returns the problems found in the registered metrics, sorted by metric
+	Lint() ([]LintProblem, error)
+}
+
+// Lint runs promlint over every metric registered with the provider,
+// including metrics that have no series yet
+func (p *prometheusProvider) Lint() ([]LintProblem, error) {
+	return lintSchemas(p.metrics.schemas())
+}
+
+// Lint runs promlint over every metric registered in dry-run mode
+func (p *dryRunProvider) Lint() ([]LintProblem, error) {
+	return lintSchemas(DryRunReport(p))
+}
+
+// Lint returns the problems of every member that can lint its metrics
+func (p *fanoutProvider) Lint() ([]LintProblem, error) {
+	var problems []LintProblem
+	for _, provider := range p.providers {
+		l, ok := provider.(Linter)
+		if !ok {
+			continue
+		}
+		found, err := l.Lint()
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, found...)
+	}
+	slices.SortFunc(problems, compareLintProblems)
+	return slices.Compact(problems), nil
+}
+
+// lintSchemas runs promlint over metric families built from schemas
+func lintSchemas(schemas []MetricSchema) ([]LintProblem, error) {
+	mfs := make([]*dto.MetricFamily, 0, len(schemas))
+	for _, schema := range schemas {
+		mfs = append(mfs, schemaFamily(schema))
+	}
+
+	found, err := promlint.NewWithMetricFamilies(mfs).Lint()
+	if err != nil {
+		return nil, err
+	}
+
+	problems := make([]LintProblem, 0, len(found))
+	for _, problem := range found {
+		problems = append(problems, LintProblem{Metric: problem.Metric, Text: problem.Text})
+	}
+	slices.SortFunc(problems, compareLintProblems)
+	return slices.Compact(problems), nil
+}
+
+// schemaFamily builds a metric family with a single empty series, so promlint
+// sees the metric type, help and label names of schema
+func schemaFamily(schema MetricSchema) *dto.MetricFamily {
+	metric := &dto.Metric{}
+	for _, name := range schema.Labels {
+		metric.Label = append(metric.Label, &dto.LabelPair{Name: &name, Value: new(string)})
+	}
+	for _, name := range slices.Sorted(maps.Keys(schema.ConstLabels)) {
+		value := schema.ConstLabels[name]
+		metric.Label = append(metric.Label, &dto.LabelPair{Name: &name, Value: &value})
+	}
+
+	mf := &dto.MetricFamily{Name: &schema.Name, Metric: []*dto.Metric{metric}}
+	if schema.Help != "" {
+		mf.Help = &schema.Help
+	}
+
+	var typ dto.MetricType
+	switch schema.Type {
+	case "counter":
+		typ, metric.Counter = dto.MetricType_COUNTER, &dto.Counter{}
+	case "gauge":
+		typ, metric.Gauge = dto.MetricType_GAUGE, &dto.Gauge{}
+	case "histogram":
+		typ, metric.Histogram = dto.MetricType_HISTOGRAM, &dto.Histogram{}
+	case "summary":
+		typ, metric.Summary = dto.MetricType_SUMMARY, &dto.Summary{}
+	}
+	mf.Type = &typ
+	return mf
+}
+
+func compareLintProblems(a, b LintProblem) int {
+	return cmp.Or(cmp.Compare(a.Metric, b.Metric), cmp.Compare(a.Text, b.Text))
+}
+
+// lintParams contains the dependencies for linting registered metrics
+type lintParams struct {
+	fx.In
+	Lifecycle fx.Lifecycle
+	Config    Config
+	Provider  Provider
+	Logger    logx.Logger
+}
+
+// registerLint lints the registered metrics on start in the configured mode:
+// warn logs every problem and fail also aborts the start
+func registerLint(p lintParams) error {
+	switch p.Config.Lint {
+	case "":
+		return nil
+	case "warn", "fail":
+	default:
+		return fmt.Errorf("unsupported metrics lint mode %q", p.Config.Lint)
+	}
+
+	linter, ok := p.Provider.(Linter)
+	if !ok {
+		p.Logger.Warn("metrics provider does not support linting", logx.String("provider", p.Config.Provider))
+		return nil
+	}
+
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			problems, err := linter.Lint()
+			if err != nil {
+				return fmt.Errorf("linting metrics: %w", err)
+			}
+			for _, problem := range problems {
+				p.Logger.Warn("metric violates naming conventions",
+					logx.String("metric", problem.Metric),
+					logx.String("problem", problem.Text),
+				)
+			}
+			if len(problems) > 0 && p.Config.Lint == "fail" {
+				return fmt.Errorf("%w: %s", ErrLintFailed, formatLintProblems(problems))
+			}
+			return nil
+		},
+	})
+	return nil
+}
+
+// formatLintProblems joins problems into a single line
+func formatLintProblems(problems []LintProblem) string {
+	texts := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		texts = append(texts, problem.Metric+": "+problem.Text)
+	}
+	return strings.Join(texts, "; ")
+}
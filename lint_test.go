@@ -0,0 +1,76 @@
+package metricsx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gostratum/core/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+)
+
+func TestLint(t *testing.T) {
+	t.Run("reports naming and help violations", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Namespace: "app"}, getTestLogger())
+		provider.Counter("requests", &Options{Help: "Requests.", Labels: []string{"method"}})
+		provider.Gauge("queueDepth", &Options{})
+		provider.Histogram("request_duration_seconds", &Options{Help: "Request duration.", Buckets: DefaultBuckets})
+
+		problems, err := provider.(Linter).Lint()
+		require.NoError(t, err)
+		assert.Equal(t, []LintProblem{
+			{Metric: "app_queueDepth", Text: "metric names should be written in 'snake_case' not 'camelCase'"},
+			{Metric: "app_queueDepth", Text: "no help text"},
+			{Metric: "app_requests", Text: `counter metrics should have "_total" suffix`},
+		}, problems)
+	})
+
+	t.Run("lints metrics without series", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+		provider.Summary("payload", &Options{Help: "Payload size.", Labels: []string{"contentType"}})
+		provider.CounterFunc("cache_hits_total", func() float64 { return 0 }, &Options{Help: "Cache hits."})
+
+		problems, err := provider.(Linter).Lint()
+		require.NoError(t, err)
+		assert.Equal(t, []LintProblem{
+			{Metric: "payload", Text: "label names should be written in 'snake_case' not 'camelCase'"},
+		}, problems)
+	})
+
+	t.Run("lints dry-run registrations", func(t *testing.T) {
+		provider := newDryRunProvider(Config{}, getTestLogger())
+		provider.Counter("jobs", &Options{Help: "Jobs."})
+
+		problems, err := provider.(Linter).Lint()
+		require.NoError(t, err)
+		assert.Equal(t, []LintProblem{{Metric: "jobs", Text: `counter metrics should have "_total" suffix`}}, problems)
+	})
+}
+
+func TestLintOnStart(t *testing.T) {
+	start := func(mode string) error {
+		app := fx.New(
+			fx.NopLogger,
+			fx.Supply(Config{Provider: "prometheus", Lint: mode}),
+			fx.Provide(
+				func() logx.Logger { return getTestLogger() },
+				NewMetrics,
+			),
+			fx.Invoke(registerLint),
+			fx.Invoke(func(m Metrics) { m.Counter("requests", WithHelp("Requests.")) }),
+		)
+		if err := app.Err(); err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		defer app.Stop(ctx)
+		return app.Start(ctx)
+	}
+
+	assert.NoError(t, start(""))
+	assert.NoError(t, start("warn"))
+	assert.ErrorIs(t, start("fail"), ErrLintFailed)
+	assert.ErrorContains(t, start("strict"), `unsupported metrics lint mode "strict"`)
+}
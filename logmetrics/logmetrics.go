@@ -0,0 +1,41 @@
+// Package logmetrics counts log lines written through logx so error-rate
+// alerting can be based on actual log volume.
+//
+//	logger := logmetrics.WrapLogger(zapLogger, metrics)
+//
+// With logx.Module the zap logger behind logx.Logger can be decorated instead:
+//
+//	fx.Decorate(func(l *zap.Logger, m metricsx.Metrics) *zap.Logger {
+//		return logmetrics.WrapLogger(l, m)
+//	})
+//
+// It records:
+//
+//	log_messages_total{level}
+//
+// Only entries enabled by the logger level are counted.
+package logmetrics
+
+import (
+	"github.com/gostratum/metricsx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Hook returns a zap hook that counts every entry written by its level
+func Hook(m metricsx.Metrics) func(zapcore.Entry) error {
+	messages := m.Counter("log_messages_total",
+		metricsx.WithHelp("Total number of log messages written by level."),
+		metricsx.WithLabels("level"),
+	)
+
+	return func(entry zapcore.Entry) error {
+		messages.Inc(entry.Level.String())
+		return nil
+	}
+}
+
+// WrapLogger returns a copy of l that counts every entry it writes
+func WrapLogger(l *zap.Logger, m metricsx.Metrics) *zap.Logger {
+	return l.WithOptions(zap.Hooks(Hook(m)))
+}
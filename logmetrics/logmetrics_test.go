@@ -0,0 +1,32 @@
+package logmetrics
+
+import (
+	"testing"
+
+	"github.com/gostratum/core/logx"
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWrapLogger(t *testing.T) {
+	t.Run("counts enabled entries by level", func(t *testing.T) {
+		m := metricstest.New()
+		core, logs := observer.New(zapcore.InfoLevel)
+		logger := logx.ProvideAdapter(WrapLogger(zap.New(core), m))
+
+		logger.Debug("hidden")
+		logger.Info("started")
+		logger.Error("failed")
+		logger.With(logx.String("component", "worker")).Error("failed again")
+
+		assert.Equal(t, 3, logs.Len())
+
+		out := m.String()
+		assert.Contains(t, out, `log_messages_total{level="info"} 1`)
+		assert.Contains(t, out, `log_messages_total{level="error"} 2`)
+		assert.NotContains(t, out, `log_messages_total{level="debug"}`)
+	})
+}
@@ -0,0 +1,95 @@
+package metricsx
+
+import "context"
+
+// labelMapper rewrites the label values passed by callers before they reach the provider
+type labelMapper func(values []string) []string
+
+// then returns a mapper applying m followed by next; nil mappers are skipped
+func (m labelMapper) then(next labelMapper) labelMapper {
+	switch {
+	case m == nil:
+		return next
+	case next == nil:
+		return m
+	}
+	return func(values []string) []string {
+		return next(m(values))
+	}
+}
+
+// mappedCounter rewrites label values before forwarding to a Counter
+type mappedCounter struct {
+	next      Counter
+	mapLabels labelMapper
+}
+
+func (c *mappedCounter) Inc(labels ...string) {
+	c.next.Inc(c.mapLabels(labels)...)
+}
+
+func (c *mappedCounter) Add(value float64, labels ...string) {
+	c.next.Add(value, c.mapLabels(labels)...)
+}
+
+func (c *mappedCounter) IncCtx(ctx context.Context, labels ...string) {
+	c.next.IncCtx(ctx, c.mapLabels(labels)...)
+}
+
+func (c *mappedCounter) AddCtx(ctx context.Context, value float64, labels ...string) {
+	c.next.AddCtx(ctx, value, c.mapLabels(labels)...)
+}
+
+// mappedGauge rewrites label values before forwarding to a Gauge
+type mappedGauge struct {
+	next      Gauge
+	mapLabels labelMapper
+}
+
+func (g *mappedGauge) Set(value float64, labels ...string) {
+	g.next.Set(value, g.mapLabels(labels)...)
+}
+
+func (g *mappedGauge) Inc(labels ...string) {
+	g.next.Inc(g.mapLabels(labels)...)
+}
+
+func (g *mappedGauge) Dec(labels ...string) {
+	g.next.Dec(g.mapLabels(labels)...)
+}
+
+func (g *mappedGauge) Add(value float64, labels ...string) {
+	g.next.Add(value, g.mapLabels(labels)...)
+}
+
+func (g *mappedGauge) Sub(value float64, labels ...string) {
+	g.next.Sub(value, g.mapLabels(labels)...)
+}
+
+// mappedHistogram rewrites label values before forwarding to a Histogram
+type mappedHistogram struct {
+	next      Histogram
+	mapLabels labelMapper
+}
+
+func (h *mappedHistogram) Observe(value float64, labels ...string) {
+	h.next.Observe(value, h.mapLabels(labels)...)
+}
+
+func (h *mappedHistogram) ObserveCtx(ctx context.Context, value float64, labels ...string) {
+	h.next.ObserveCtx(ctx, value, h.mapLabels(labels)...)
+}
+
+func (h *mappedHistogram) Timer(labels ...string) Timer {
+	return h.next.Timer(h.mapLabels(labels)...)
+}
+
+// mappedSummary rewrites label values before forwarding to a Summary
+type mappedSummary struct {
+	next      Summary
+	mapLabels labelMapper
+}
+
+func (s *mappedSummary) Observe(value float64, labels ...string) {
+	s.next.Observe(value, s.mapLabels(labels)...)
+}
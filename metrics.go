@@ -19,6 +19,27 @@ type Metrics interface {
 
 	// Summary creates or retrieves a summary metric
 	Summary(name string, opts ...Option) Summary
+
+	// GaugeFunc registers a gauge whose value is computed by fn at collection time
+	GaugeFunc(name string, fn func() float64, opts ...Option)
+
+	// CounterFunc registers a counter whose value is computed by fn at collection time
+	CounterFunc(name string, fn func() float64, opts ...Option)
+
+	// RegisterFunc registers fn to emit a batch of related gauges at
+	// collection time, one per Sample sent on ch
+	RegisterFunc(fn func(ch chan<- Sample), opts ...Option)
+
+	// MustHave returns ErrMissingMetrics if any of names was never registered
+	MustHave(names ...string) error
+
+	// Event counts an occurrence of a business event, keeping the configured
+	// attributes as labels
+	Event(name string, attrs map[string]string)
+
+	// Watch calls cb when predicate starts holding for a series of the
+	// metric name, evaluated every Config.WatchInterval
+	Watch(name string, predicate func(float64) bool, cb func(Sample))
 }
 
 // Counter is a monotonically increasing metric
@@ -28,6 +49,14 @@ type Counter interface {
 
 	// Add increments the counter by the given value
 	Add(value float64, labels ...string)
+
+	// IncCtx increments the counter by 1, attaching the sampled OpenTelemetry
+	// span in ctx as an exemplar
+	IncCtx(ctx context.Context, labels ...string)
+
+	// AddCtx increments the counter by the given value, attaching the sampled
+	// OpenTelemetry span in ctx as an exemplar
+	AddCtx(ctx context.Context, value float64, labels ...string)
 }
 
 // Gauge is a metric that can go up and down
@@ -53,6 +82,10 @@ type Histogram interface {
 	// Observe adds a single observation to the histogram
 	Observe(value float64, labels ...string)
 
+	// ObserveCtx adds a single observation to the histogram, attaching the
+	// sampled OpenTelemetry span in ctx as an exemplar
+	ObserveCtx(ctx context.Context, value float64, labels ...string)
+
 	// Timer creates a timer that will observe the duration when stopped
 	Timer(labels ...string) Timer
 }
@@ -83,6 +116,9 @@ type Options struct {
 	// Labels are the label names for this metric
 	Labels []string
 
+	// ConstLabels are fixed label values attached to every series of this metric
+	ConstLabels map[string]string
+
 	// Buckets for histograms (optional, uses defaults if not set)
 	Buckets []float64
 
@@ -94,6 +130,21 @@ type Options struct {
 
 	// Subsystem for the metric (optional)
 	Subsystem string
+
+	// MaxSeries limits the number of label combinations (optional, uses the configured limit if not set)
+	MaxSeries int
+
+	// Sharded spreads counter increments over per-CPU shards summed at collection time
+	Sharded bool
+
+	// TTL deletes label combinations not written for this long (optional, 0 keeps them forever)
+	TTL time.Duration
+
+	// NativeHistogram also exposes histograms as Prometheus native histograms
+	NativeHistogram bool
+
+	// NativeBucketFactor is the growth factor of native buckets (optional, uses the configured factor if not set)
+	NativeBucketFactor float64
 }
 
 // WithHelp sets the help text for the metric
@@ -110,6 +161,41 @@ func WithLabels(labels ...string) Option {
 	}
 }
 
+// WithConstLabels sets fixed label values for the metric
+func WithConstLabels(labels map[string]string) Option {
+	return func(o *Options) {
+		o.ConstLabels = labels
+	}
+}
+
+// WithMaxSeries limits the number of label combinations of the metric. Further
+// combinations are recorded under the OverflowLabel series.
+func WithMaxSeries(n int) Option {
+	return func(o *Options) {
+		o.MaxSeries = n
+	}
+}
+
+// WithTTL deletes label combinations of the metric that were not written
+// within d, so series of ephemeral entities such as pods or sessions do not
+// accumulate. Expired series are removed when the metric is collected and
+// start from zero if written again. Sharded counters ignore it, and expired
+// series keep counting against WithMaxSeries.
+func WithTTL(d time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = d
+	}
+}
+
+// WithSharding spreads the increments of a counter over per-CPU shards that
+// are summed at collection time. It avoids contention on a single atomic for
+// extremely hot counters; other metric types ignore it.
+func WithSharding() Option {
+	return func(o *Options) {
+		o.Sharded = true
+	}
+}
+
 // WithBuckets sets the buckets for histogram metrics
 func WithBuckets(buckets ...float64) Option {
 	return func(o *Options) {
@@ -117,6 +203,16 @@ func WithBuckets(buckets ...float64) Option {
 	}
 }
 
+// WithNativeHistogram also exposes a histogram as a Prometheus native
+// histogram with buckets growing by at most bucketFactor; 0 uses the
+// configured factor
+func WithNativeHistogram(bucketFactor float64) Option {
+	return func(o *Options) {
+		o.NativeHistogram = true
+		o.NativeBucketFactor = bucketFactor
+	}
+}
+
 // WithObjectives sets the objectives for summary metrics
 func WithObjectives(objectives map[float64]float64) Option {
 	return func(o *Options) {
@@ -177,9 +273,22 @@ type Provider interface {
 	// Summary creates or retrieves a summary
 	Summary(name string, options *Options) Summary
 
+	// GaugeFunc registers a gauge computed at collection time
+	GaugeFunc(name string, fn func() float64, options *Options)
+
+	// CounterFunc registers a counter computed at collection time
+	CounterFunc(name string, fn func() float64, options *Options)
+
+	// RegisterFunc registers fn to emit a batch of gauges at collection time
+	RegisterFunc(fn func(ch chan<- Sample), options *Options)
+
 	// Start starts the metrics provider (e.g., HTTP server for Prometheus)
 	Start(ctx context.Context) error
 
 	// Stop stops the metrics provider
 	Stop(ctx context.Context) error
+
+	// Addr returns the address the provider's HTTP server listens on, or an
+	// empty string when it runs no server of its own
+	Addr() string
 }
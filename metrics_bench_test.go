@@ -0,0 +1,157 @@
+package metricsx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// benchProviders are the providers every hot-path benchmark runs against
+var benchProviders = []string{"prometheus", "pushgateway", "fanout", "noop"}
+
+// newBenchMetrics creates Metrics backed by the provider registered under name
+func newBenchMetrics(tb testing.TB, name string) Metrics {
+	tb.Helper()
+
+	res, err := NewMetrics(Params{
+		Config: Config{
+			Provider:    name,
+			Prometheus:  PrometheusConfig{Namespace: "bench"},
+			Pushgateway: PushgatewayConfig{URL: "http://127.0.0.1:9091", Job: "bench"},
+			Fanout:      FanoutConfig{Providers: []string{"prometheus", "pushgateway"}},
+		},
+		Logger: getTestLogger(),
+	})
+	require.NoError(tb, err)
+	return res.Metrics
+}
+
+// benchEach runs fn as a sub-benchmark for every provider
+func benchEach(b *testing.B, fn func(b *testing.B, m Metrics)) {
+	for _, name := range benchProviders {
+		b.Run(name, func(b *testing.B) {
+			m := newBenchMetrics(b, name)
+			b.ReportAllocs()
+			fn(b, m)
+		})
+	}
+}
+
+// TestFastPathAllocations guards the documented guarantee: recording on a
+// metric obtained up front allocates at most once, for the variadic label
+// values, and not at all without labels
+func TestFastPathAllocations(t *testing.T) {
+	for _, name := range benchProviders {
+		t.Run(name, func(t *testing.T) {
+			m := newBenchMetrics(t, name)
+			counter := m.Counter("requests_total", WithLabels("method", "status"))
+			gauge := m.Gauge("queue_depth", WithLabels("queue"))
+			histogram := m.Histogram("request_duration_seconds", WithLabels("method"))
+			summary := m.Summary("payload_bytes", WithLabels("method"))
+			unlabelled := m.Counter("events_total")
+
+			record := map[string]func(){
+				"counter":   func() { counter.Inc("GET", "200") },
+				"gauge":     func() { gauge.Set(42, "orders") },
+				"histogram": func() { histogram.Observe(0.042, "GET") },
+				"summary":   func() { summary.Observe(512, "GET") },
+			}
+			for metric, fn := range record {
+				fn()
+				assert.LessOrEqual(t, testing.AllocsPerRun(100, fn), 1.0, metric)
+			}
+
+			unlabelled.Inc()
+			assert.Zero(t, testing.AllocsPerRun(100, func() { unlabelled.Inc() }))
+
+			timer := histogram.Timer("GET")
+			assert.Zero(t, testing.AllocsPerRun(100, timer.ObserveDuration))
+		})
+	}
+}
+
+func BenchmarkCounterInc(b *testing.B) {
+	benchEach(b, func(b *testing.B, m Metrics) {
+		counter := m.Counter("requests_total", WithLabels("method", "status"))
+		for b.Loop() {
+			counter.Inc("GET", "200")
+		}
+	})
+}
+
+func BenchmarkCounterIncParallel(b *testing.B) {
+	benchEach(b, func(b *testing.B, m Metrics) {
+		counter := m.Counter("requests_total", WithLabels("method", "status"))
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				counter.Inc("GET", "200")
+			}
+		})
+	})
+}
+
+func BenchmarkGaugeSet(b *testing.B) {
+	benchEach(b, func(b *testing.B, m Metrics) {
+		gauge := m.Gauge("queue_depth", WithLabels("queue"))
+		for b.Loop() {
+			gauge.Set(42, "orders")
+		}
+	})
+}
+
+func BenchmarkHistogramObserve(b *testing.B) {
+	benchEach(b, func(b *testing.B, m Metrics) {
+		histogram := m.Histogram("request_duration_seconds", WithLabels("method"))
+		for b.Loop() {
+			histogram.Observe(0.042, "GET")
+		}
+	})
+}
+
+func BenchmarkSummaryObserve(b *testing.B) {
+	benchEach(b, func(b *testing.B, m Metrics) {
+		summary := m.Summary("payload_bytes", WithLabels("method"))
+		for b.Loop() {
+			summary.Observe(512, "GET")
+		}
+	})
+}
+
+func BenchmarkTimer(b *testing.B) {
+	benchEach(b, func(b *testing.B, m Metrics) {
+		histogram := m.Histogram("request_duration_seconds", WithLabels("method"))
+		for b.Loop() {
+			histogram.Timer("GET").ObserveDuration()
+		}
+	})
+}
+
+func BenchmarkCounterIncLimited(b *testing.B) {
+	benchEach(b, func(b *testing.B, m Metrics) {
+		counter := m.Counter("requests_total", WithLabels("method", "status"), WithMaxSeries(100))
+		for b.Loop() {
+			counter.Inc("GET", "200")
+		}
+	})
+}
+
+func BenchmarkSimpleCounterInc(b *testing.B) {
+	benchEach(b, func(b *testing.B, m Metrics) {
+		counter := NewSimpleCounter(m, "events_total")
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				counter.Inc()
+			}
+		})
+	})
+}
+
+func BenchmarkSimpleGaugeSet(b *testing.B) {
+	benchEach(b, func(b *testing.B, m Metrics) {
+		gauge := NewSimpleGauge(m, "queue_depth")
+		for b.Loop() {
+			gauge.Set(42)
+		}
+	})
+}
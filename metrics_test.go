@@ -19,6 +19,12 @@ func TestOptions(t *testing.T) {
 		assert.Equal(t, []string{"label1", "label2"}, opts.Labels)
 	})
 
+	t.Run("WithConstLabels sets const labels", func(t *testing.T) {
+		opts := &Options{}
+		WithConstLabels(map[string]string{"db_name": "primary"})(opts)
+		assert.Equal(t, map[string]string{"db_name": "primary"}, opts.ConstLabels)
+	})
+
 	t.Run("WithBuckets sets buckets", func(t *testing.T) {
 		opts := &Options{}
 		buckets := []float64{0.1, 0.5, 1.0}
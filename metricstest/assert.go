@@ -0,0 +1,99 @@
+package metricstest
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Reader reads back recorded values. It is implemented by Metrics and Provider.
+type Reader interface {
+	CounterValue(name string, labels ...string) float64
+	GaugeValue(name string, labels ...string) float64
+	HistogramSampleCount(name string, labels ...string) uint64
+	HistogramBucketCounts(name string, labels ...string) map[float64]uint64
+	SummarySampleCount(name string, labels ...string) uint64
+}
+
+// AssertCounter reports a test error unless the counter name has value want
+// for labels:
+//
+//	metricstest.AssertCounter(t, m, "requests_total", 3, "GET", "200")
+func AssertCounter(t testing.TB, r Reader, name string, want float64, labels ...string) bool {
+	t.Helper()
+
+	if got := r.CounterValue(name, labels...); got != want {
+		t.Errorf("counter %s: got %v, want %v", describeSeries(name, labels), got, want)
+		return false
+	}
+	return true
+}
+
+// AssertGauge reports a test error unless the gauge name has value want for labels
+func AssertGauge(t testing.TB, r Reader, name string, want float64, labels ...string) bool {
+	t.Helper()
+
+	if got := r.GaugeValue(name, labels...); got != want {
+		t.Errorf("gauge %s: got %v, want %v", describeSeries(name, labels), got, want)
+		return false
+	}
+	return true
+}
+
+// AssertHistogramCount reports a test error unless the histogram name has
+// want observations for labels
+func AssertHistogramCount(t testing.TB, r Reader, name string, want uint64, labels ...string) bool {
+	t.Helper()
+
+	if got := r.HistogramSampleCount(name, labels...); got != want {
+		t.Errorf("histogram %s: got %d observations, want %d", describeSeries(name, labels), got, want)
+		return false
+	}
+	return true
+}
+
+// AssertHistogramBuckets reports a test error unless the cumulative bucket
+// counts of the histogram name for labels match want. Only the bounds in want
+// are compared:
+//
+//	metricstest.AssertHistogramBuckets(t, m, "request_duration_seconds",
+//		map[float64]uint64{0.1: 1, 1: 3}, "GET")
+func AssertHistogramBuckets(t testing.TB, r Reader, name string, want map[float64]uint64, labels ...string) bool {
+	t.Helper()
+
+	got := r.HistogramBucketCounts(name, labels...)
+	ok := true
+	for _, bound := range slices.Sorted(maps.Keys(want)) {
+		count, exists := got[bound]
+		switch {
+		case !exists:
+			t.Errorf("histogram %s: no bucket le=%v", describeSeries(name, labels), bound)
+			ok = false
+		case count != want[bound]:
+			t.Errorf("histogram %s: bucket le=%v has %d observations, want %d", describeSeries(name, labels), bound, count, want[bound])
+			ok = false
+		}
+	}
+	return ok
+}
+
+// AssertSummaryCount reports a test error unless the summary name has want
+// observations for labels
+func AssertSummaryCount(t testing.TB, r Reader, name string, want uint64, labels ...string) bool {
+	t.Helper()
+
+	if got := r.SummarySampleCount(name, labels...); got != want {
+		t.Errorf("summary %s: got %d observations, want %d", describeSeries(name, labels), got, want)
+		return false
+	}
+	return true
+}
+
+// describeSeries formats name and labels for error messages
+func describeSeries(name string, labels []string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	return name + "{" + strings.Join(labels, ", ") + "}"
+}
@@ -0,0 +1,59 @@
+package metricstest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gostratum/metricsx"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingT captures the errors reported by the assertion helpers
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertions(t *testing.T) {
+	m := New()
+	m.Counter("requests_total", metricsx.WithLabels("method", "status")).Add(3, "GET", "200")
+	m.Gauge("queue_depth").Set(4)
+	m.Summary("payload_bytes").Observe(512)
+
+	latency := m.Histogram("request_duration_seconds", metricsx.WithLabels("method"), metricsx.WithBuckets(0.1, 1))
+	latency.Observe(0.05, "GET")
+	latency.Observe(0.5, "GET")
+	latency.Observe(2, "GET")
+
+	t.Run("pass on matching values", func(t *testing.T) {
+		rt := &recordingT{TB: t}
+		assert.True(t, AssertCounter(rt, m, "requests_total", 3, "GET", "200"))
+		assert.True(t, AssertGauge(rt, m, "queue_depth", 4))
+		assert.True(t, AssertHistogramCount(rt, m, "request_duration_seconds", 3, "GET"))
+		assert.True(t, AssertHistogramBuckets(rt, m, "request_duration_seconds", map[float64]uint64{0.1: 1, 1: 2}, "GET"))
+		assert.True(t, AssertSummaryCount(rt, m, "payload_bytes", 1))
+		assert.Empty(t, rt.errors)
+	})
+
+	t.Run("report mismatches", func(t *testing.T) {
+		rt := &recordingT{TB: t}
+		assert.False(t, AssertCounter(rt, m, "requests_total", 2, "GET", "200"))
+		assert.False(t, AssertGauge(rt, m, "queue_depth", 1))
+		assert.False(t, AssertHistogramBuckets(rt, m, "request_duration_seconds", map[float64]uint64{0.1: 2, 5: 3}, "GET"))
+		assert.False(t, AssertSummaryCount(rt, m.Provider(), "payload_bytes", 2))
+
+		assert.Equal(t, []string{
+			"counter requests_total{GET, 200}: got 3, want 2",
+			"gauge queue_depth: got 4, want 1",
+			"histogram request_duration_seconds{GET}: bucket le=0.1 has 1 observations, want 2",
+			"histogram request_duration_seconds{GET}: no bucket le=5",
+			"summary payload_bytes: got 1 observations, want 2",
+		}, rt.errors)
+	})
+}
@@ -0,0 +1,99 @@
+package metricstest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gostratum/metricsx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/expfmt"
+)
+
+// UpdateGoldenEnv is the environment variable that makes AssertGolden rewrite
+// the golden files from the current exposition instead of comparing
+const UpdateGoldenEnv = "METRICSX_UPDATE_GOLDEN"
+
+// GatherAndCompare compares the exposition of provider with expected, in the
+// Prometheus text format. Only the families in names are compared, or every
+// family when names is empty. The provider must implement metricsx.Gatherable,
+// as the Prometheus, Pushgateway and fanout providers do.
+func GatherAndCompare(provider metricsx.Provider, expected []byte, names ...string) error {
+	g, err := gatherer(provider)
+	if err != nil {
+		return err
+	}
+	return testutil.GatherAndCompare(g, bytes.NewReader(expected), names...)
+}
+
+// AssertGolden reports a test error unless the exposition of provider matches
+// the golden file at path, limited to the families in names. Run the tests
+// with METRICSX_UPDATE_GOLDEN=1 to write the golden files instead:
+//
+//	res, _ := metricsx.NewMetrics(params)
+//	NewOrderService(res.Metrics).PlaceOrder(ctx, order)
+//	metricstest.AssertGolden(t, res.Provider, "testdata/orders.golden", "app_orders_total")
+func AssertGolden(t testing.TB, provider metricsx.Provider, path string, names ...string) bool {
+	t.Helper()
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := writeGolden(provider, path, names); err != nil {
+			t.Errorf("updating golden file %s: %v", path, err)
+			return false
+		}
+		return true
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("reading golden file: %v (set %s=1 to create it)", err, UpdateGoldenEnv)
+		return false
+	}
+	if err := GatherAndCompare(provider, expected, names...); err != nil {
+		t.Errorf("exposition differs from golden file %s:\n%v", path, err)
+		return false
+	}
+	return true
+}
+
+// writeGolden writes the exposition of provider for names to path
+func writeGolden(provider metricsx.Provider, path string, names []string) error {
+	g, err := gatherer(provider)
+	if err != nil {
+		return err
+	}
+	mfs, err := g.Gather()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	enc := expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, mf := range mfs {
+		if len(names) > 0 && !slices.Contains(names, mf.GetName()) {
+			continue
+		}
+		if err := enc.Encode(mf); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// gatherer returns the gatherer of provider
+func gatherer(provider metricsx.Provider) (prometheus.Gatherer, error) {
+	if g, ok := provider.(metricsx.Gatherable); ok {
+		if g := g.Gatherer(); g != nil {
+			return g, nil
+		}
+	}
+	return nil, fmt.Errorf("metricstest: %T is not backed by a Prometheus registry", provider)
+}
@@ -0,0 +1,64 @@
+package metricstest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gostratum/core/logx"
+	"github.com/gostratum/metricsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPrometheusMetrics returns Metrics backed by the Prometheus provider with some orders recorded
+func newPrometheusMetrics(t *testing.T) metricsx.Result {
+	t.Helper()
+
+	res, err := metricsx.NewMetrics(metricsx.Params{
+		Config: metricsx.Config{
+			Provider:   "prometheus",
+			Prometheus: metricsx.PrometheusConfig{Namespace: "app"},
+		},
+		Logger: logx.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	orders := res.Metrics.Counter("orders_total", metricsx.WithLabels("status"), metricsx.WithHelp("Total orders."))
+	orders.Add(2, "created")
+	orders.Inc("failed")
+	res.Metrics.Gauge("queue_depth", metricsx.WithHelp("Queued orders.")).Set(3)
+	return res
+}
+
+func TestAssertGolden(t *testing.T) {
+	t.Run("matches the golden file", func(t *testing.T) {
+		res := newPrometheusMetrics(t)
+		assert.True(t, AssertGolden(t, res.Provider, "testdata/orders.golden", "app_orders_total"))
+	})
+
+	t.Run("reports differences", func(t *testing.T) {
+		res := newPrometheusMetrics(t)
+		res.Metrics.Counter("orders_total", metricsx.WithLabels("status"), metricsx.WithHelp("Total orders.")).Inc("failed")
+
+		rt := &recordingT{TB: t}
+		assert.False(t, AssertGolden(rt, res.Provider, "testdata/orders.golden", "app_orders_total"))
+		require.Len(t, rt.errors, 1)
+		assert.Contains(t, rt.errors[0], `app_orders_total{status="failed"} 2`)
+	})
+
+	t.Run("writes golden files in update mode", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nested", "orders.golden")
+		res := newPrometheusMetrics(t)
+
+		t.Setenv(UpdateGoldenEnv, "1")
+		assert.True(t, AssertGolden(t, res.Provider, path, "app_orders_total", "app_queue_depth"))
+
+		t.Setenv(UpdateGoldenEnv, "")
+		assert.True(t, AssertGolden(t, res.Provider, path, "app_orders_total", "app_queue_depth"))
+	})
+
+	t.Run("requires a registry", func(t *testing.T) {
+		err := GatherAndCompare(NewProvider(), nil)
+		assert.ErrorContains(t, err, "not backed by a Prometheus registry")
+	})
+}
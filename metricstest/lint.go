@@ -0,0 +1,31 @@
+package metricstest
+
+import (
+	"testing"
+
+	"github.com/gostratum/metricsx"
+)
+
+// AssertLint reports a test error for every naming or help violation of the
+// metrics registered with provider. The provider must implement
+// metricsx.Linter, as the Prometheus, Pushgateway, fanout and dry-run
+// providers do.
+func AssertLint(t testing.TB, provider metricsx.Provider) bool {
+	t.Helper()
+
+	linter, ok := provider.(metricsx.Linter)
+	if !ok {
+		t.Errorf("metricstest: %T does not support linting", provider)
+		return false
+	}
+
+	problems, err := linter.Lint()
+	if err != nil {
+		t.Errorf("linting metrics: %v", err)
+		return false
+	}
+	for _, problem := range problems {
+		t.Errorf("metric %s: %s", problem.Metric, problem.Text)
+	}
+	return len(problems) == 0
+}
@@ -0,0 +1,22 @@
+package metricstest
+
+import (
+	"testing"
+
+	"github.com/gostratum/metricsx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertLint(t *testing.T) {
+	res := newPrometheusMetrics(t)
+	assert.True(t, AssertLint(t, res.Provider))
+
+	res.Metrics.Counter("requests", metricsx.WithHelp("Requests."))
+	rt := &recordingT{TB: t}
+	assert.False(t, AssertLint(rt, res.Provider))
+	assert.Equal(t, []string{`metric app_requests: counter metrics should have "_total" suffix`}, rt.errors)
+
+	rt = &recordingT{TB: t}
+	assert.False(t, AssertLint(rt, NewProvider()))
+	assert.Len(t, rt.errors, 1)
+}
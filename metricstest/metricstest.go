@@ -0,0 +1,576 @@
+// Package metricstest provides in-memory metricsx implementations for unit
+// tests of instrumented code. Recorded values can be read back by metric name
+// and label values:
+//
+//	m := metricstest.New()
+//	svc := NewOrderService(m)
+//	svc.PlaceOrder(ctx, order)
+//
+//	assert.Equal(t, 1.0, m.CounterValue("orders_total", "created"))
+//	assert.Equal(t, uint64(1), m.HistogramSampleCount("order_duration_seconds"))
+//
+// Metrics are keyed by the name passed to metricsx, without namespace or
+// subsystem, and series by their label values in order. Const labels are
+// accepted but not part of the series. Reading a metric or series that was
+// never recorded returns zero.
+package metricstest
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gostratum/metricsx"
+)
+
+// metricKind is the type a metric was registered as
+type metricKind string
+
+const (
+	kindCounter   metricKind = "counter"
+	kindGauge     metricKind = "gauge"
+	kindHistogram metricKind = "histogram"
+	kindSummary   metricKind = "summary"
+)
+
+// metric is a registered metric and its series
+type metric struct {
+	kind    metricKind
+	labels  []string
+	buckets []float64
+	fn      func() float64
+	series  map[string]*series
+}
+
+// series holds the recorded values of one label combination
+type series struct {
+	labels       []string
+	value        float64
+	observations []float64
+}
+
+// recorder stores every recorded value and implements the read-back methods
+// shared by Metrics and Provider
+type recorder struct {
+	mu      sync.Mutex
+	metrics map[string]*metric
+	events  map[string][]map[string]string
+	funcs   []func(ch chan<- metricsx.Sample)
+}
+
+func newRecorder() *recorder {
+	return &recorder{metrics: make(map[string]*metric)}
+}
+
+// register returns the metric name, creating it as kind on first use. Like
+// Prometheus, it panics when name was registered as another type.
+func (r *recorder) register(name string, kind metricKind, options *metricsx.Options, fn func() float64) *metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m, ok := r.metrics[name]; ok {
+		if m.kind != kind {
+			panic(fmt.Sprintf("metricstest: %s registered as %s and %s", name, m.kind, kind))
+		}
+		if fn != nil {
+			m.fn = fn
+		}
+		return m
+	}
+
+	m := &metric{kind: kind, labels: slices.Clone(options.Labels), fn: fn, series: make(map[string]*series)}
+	if kind == kindHistogram {
+		m.buckets = options.Buckets
+		if len(m.buckets) == 0 {
+			m.buckets = metricsx.DefaultBuckets
+		}
+	}
+	r.metrics[name] = m
+	return m
+}
+
+// update applies fn to the series of m for labels. It panics on a wrong number
+// of label values, like the Prometheus provider.
+func (r *recorder) update(name string, m *metric, labels []string, fn func(s *series)) {
+	if len(labels) != len(m.labels) {
+		panic(fmt.Sprintf("metricstest: %s expects %d label values but got %d", name, len(m.labels), len(labels)))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := seriesKey(labels)
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{labels: slices.Clone(labels)}
+		m.series[key] = s
+	}
+	fn(s)
+}
+
+// read returns a copy of the series of name for labels if name is a metric of kind
+func (r *recorder) read(name string, kind metricKind, labels []string) (series, bool) {
+	r.mu.Lock()
+	m, ok := r.metrics[name]
+	if !ok || m.kind != kind {
+		r.mu.Unlock()
+		return series{}, false
+	}
+	if fn := m.fn; fn != nil {
+		// fn may record metrics itself, so it runs without the lock
+		r.mu.Unlock()
+		return series{value: fn()}, true
+	}
+	defer r.mu.Unlock()
+
+	s, ok := m.series[seriesKey(labels)]
+	if !ok {
+		return series{}, false
+	}
+	return series{value: s.value, observations: slices.Clone(s.observations)}, true
+}
+
+// seriesKey identifies a label combination
+func seriesKey(labels []string) string {
+	return strings.Join(labels, "\xff")
+}
+
+// CounterValue returns the value of the counter name for labels, including
+// counters registered with CounterFunc
+func (r *recorder) CounterValue(name string, labels ...string) float64 {
+	s, _ := r.read(name, kindCounter, labels)
+	return s.value
+}
+
+// GaugeValue returns the value of the gauge name for labels, including gauges
+// registered with GaugeFunc
+func (r *recorder) GaugeValue(name string, labels ...string) float64 {
+	s, _ := r.read(name, kindGauge, labels)
+	return s.value
+}
+
+// HistogramSampleCount returns the number of observations of the histogram
+// name for labels
+func (r *recorder) HistogramSampleCount(name string, labels ...string) uint64 {
+	s, _ := r.read(name, kindHistogram, labels)
+	return uint64(len(s.observations))
+}
+
+// HistogramSampleSum returns the sum of the observations of the histogram
+// name for labels
+func (r *recorder) HistogramSampleSum(name string, labels ...string) float64 {
+	s, _ := r.read(name, kindHistogram, labels)
+	return sum(s.observations)
+}
+
+// HistogramBucketCounts returns the cumulative number of observations of the
+// histogram name for labels at or below each bucket upper bound, like the
+// Prometheus le buckets. The +Inf bucket equals HistogramSampleCount.
+func (r *recorder) HistogramBucketCounts(name string, labels ...string) map[float64]uint64 {
+	s, ok := r.read(name, kindHistogram, labels)
+	if !ok {
+		return nil
+	}
+
+	r.mu.Lock()
+	buckets := r.metrics[name].buckets
+	r.mu.Unlock()
+
+	counts := make(map[float64]uint64, len(buckets))
+	for _, bound := range buckets {
+		var n uint64
+		for _, v := range s.observations {
+			if v <= bound {
+				n++
+			}
+		}
+		counts[bound] = n
+	}
+	return counts
+}
+
+// SummarySampleCount returns the number of observations of the summary name
+// for labels
+func (r *recorder) SummarySampleCount(name string, labels ...string) uint64 {
+	s, _ := r.read(name, kindSummary, labels)
+	return uint64(len(s.observations))
+}
+
+// SummarySampleSum returns the sum of the observations of the summary name
+// for labels
+func (r *recorder) SummarySampleSum(name string, labels ...string) float64 {
+	s, _ := r.read(name, kindSummary, labels)
+	return sum(s.observations)
+}
+
+// Observations returns the values observed by the histogram or summary name
+// for labels, in order
+func (r *recorder) Observations(name string, labels ...string) []float64 {
+	if s, ok := r.read(name, kindHistogram, labels); ok {
+		return s.observations
+	}
+	s, _ := r.read(name, kindSummary, labels)
+	return s.observations
+}
+
+// Registered reports whether a metric named name was registered
+func (r *recorder) Registered(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.metrics[name]
+	return ok
+}
+
+// Collect runs every function registered with RegisterFunc and returns the
+// samples they emit, in order
+func (r *recorder) Collect() []metricsx.Sample {
+	r.mu.Lock()
+	funcs := slices.Clone(r.funcs)
+	r.mu.Unlock()
+
+	var samples []metricsx.Sample
+	for _, fn := range funcs {
+		ch := make(chan metricsx.Sample)
+		go func() {
+			defer close(ch)
+			fn(ch)
+		}()
+		for s := range ch {
+			samples = append(samples, s)
+		}
+	}
+	return samples
+}
+
+// Reset clears every recorded value, keeping the registered metrics
+func (r *recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, m := range r.metrics {
+		clear(m.series)
+	}
+	clear(r.events)
+}
+
+func sum(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// Provider is a metricsx.Provider recording every value in memory
+type Provider struct {
+	*recorder
+}
+
+var _ metricsx.Provider = (*Provider)(nil)
+
+// NewProvider creates an empty recording Provider
+func NewProvider() *Provider {
+	return &Provider{recorder: newRecorder()}
+}
+
+func (p *Provider) Counter(name string, options *metricsx.Options) metricsx.Counter {
+	return &counter{recorder: p.recorder, name: name, metric: p.register(name, kindCounter, options, nil)}
+}
+
+func (p *Provider) Gauge(name string, options *metricsx.Options) metricsx.Gauge {
+	return &gauge{recorder: p.recorder, name: name, metric: p.register(name, kindGauge, options, nil)}
+}
+
+func (p *Provider) Histogram(name string, options *metricsx.Options) metricsx.Histogram {
+	return &observer{recorder: p.recorder, name: name, metric: p.register(name, kindHistogram, options, nil)}
+}
+
+func (p *Provider) Summary(name string, options *metricsx.Options) metricsx.Summary {
+	return &observer{recorder: p.recorder, name: name, metric: p.register(name, kindSummary, options, nil)}
+}
+
+func (p *Provider) GaugeFunc(name string, fn func() float64, options *metricsx.Options) {
+	p.register(name, kindGauge, options, fn)
+}
+
+func (p *Provider) CounterFunc(name string, fn func() float64, options *metricsx.Options) {
+	p.register(name, kindCounter, options, fn)
+}
+
+// RegisterFunc keeps fn for Collect
+func (p *Provider) RegisterFunc(fn func(ch chan<- metricsx.Sample), options *metricsx.Options) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.funcs = append(p.funcs, fn)
+}
+
+func (p *Provider) Start(ctx context.Context) error {
+	return nil
+}
+
+func (p *Provider) Stop(ctx context.Context) error {
+	return nil
+}
+
+func (p *Provider) Addr() string {
+	return ""
+}
+
+// Metrics is a metricsx.Metrics recording every value in memory
+type Metrics struct {
+	*recorder
+	provider *Provider
+
+	watchMu sync.Mutex
+	watches []*watch
+}
+
+// watch is a predicate registered with Metrics.Watch
+type watch struct {
+	name      string
+	predicate func(float64) bool
+	cb        func(metricsx.Sample)
+	firing    map[string]bool
+}
+
+var _ metricsx.Metrics = (*Metrics)(nil)
+
+// New creates an empty recording Metrics
+func New() *Metrics {
+	provider := NewProvider()
+	return &Metrics{recorder: provider.recorder, provider: provider}
+}
+
+// Provider returns the Provider the metrics are recorded in
+func (m *Metrics) Provider() *Provider {
+	return m.provider
+}
+
+func (m *Metrics) Counter(name string, opts ...metricsx.Option) metricsx.Counter {
+	return m.provider.Counter(name, applyOptions(opts))
+}
+
+func (m *Metrics) Gauge(name string, opts ...metricsx.Option) metricsx.Gauge {
+	return m.provider.Gauge(name, applyOptions(opts))
+}
+
+func (m *Metrics) Histogram(name string, opts ...metricsx.Option) metricsx.Histogram {
+	return m.provider.Histogram(name, applyOptions(opts))
+}
+
+func (m *Metrics) Summary(name string, opts ...metricsx.Option) metricsx.Summary {
+	return m.provider.Summary(name, applyOptions(opts))
+}
+
+func (m *Metrics) GaugeFunc(name string, fn func() float64, opts ...metricsx.Option) {
+	m.provider.GaugeFunc(name, fn, applyOptions(opts))
+}
+
+func (m *Metrics) CounterFunc(name string, fn func() float64, opts ...metricsx.Option) {
+	m.provider.CounterFunc(name, fn, applyOptions(opts))
+}
+
+// MustHave returns metricsx.ErrMissingMetrics naming every metric in names
+// that was never registered
+func (m *Metrics) MustHave(names ...string) error {
+	var missing []string
+	for _, name := range names {
+		if !m.Registered(name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", metricsx.ErrMissingMetrics, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Event counts the event on the counter name with a _total suffix, without
+// labels, and keeps attrs for Events
+func (m *Metrics) Event(name string, attrs map[string]string) {
+	counter := name
+	if !strings.HasSuffix(counter, "_total") {
+		counter += "_total"
+	}
+	m.Counter(counter).Inc()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.events == nil {
+		m.events = make(map[string][]map[string]string)
+	}
+	m.events[name] = append(m.events[name], maps.Clone(attrs))
+}
+
+// Events returns the attributes of every occurrence of the event name, in order
+func (m *Metrics) Events(name string) []map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.events[name])
+}
+
+func (m *Metrics) RegisterFunc(fn func(ch chan<- metricsx.Sample), opts ...metricsx.Option) {
+	m.provider.RegisterFunc(fn, applyOptions(opts))
+}
+
+// Watch keeps the watch for EvaluateWatches; nothing is evaluated in the
+// background, so tests decide when watches fire
+func (m *Metrics) Watch(name string, predicate func(float64) bool, cb func(metricsx.Sample)) {
+	m.watchMu.Lock()
+	defer m.watchMu.Unlock()
+	m.watches = append(m.watches, &watch{name: name, predicate: predicate, cb: cb})
+}
+
+// EvaluateWatches evaluates every watch once against the recorded counters
+// and gauges, like one tick of the metricsx watcher: a callback runs when its
+// predicate starts holding for a series
+func (m *Metrics) EvaluateWatches() {
+	m.watchMu.Lock()
+	watches := slices.Clone(m.watches)
+	m.watchMu.Unlock()
+
+	now := time.Now()
+	for _, w := range watches {
+		next := make(map[string]bool)
+		for _, sample := range m.samples(w.name) {
+			if !w.predicate(sample.Value) {
+				continue
+			}
+			key := fmt.Sprint(sample.Labels) // maps print sorted by key
+			next[key] = true
+			if !w.firing[key] {
+				sample.Time = now
+				w.cb(sample)
+			}
+		}
+		w.firing = next
+	}
+}
+
+// samples returns the current value of every series of the counter or gauge name
+func (r *recorder) samples(name string) []metricsx.Sample {
+	r.mu.Lock()
+	m, ok := r.metrics[name]
+	if !ok || (m.kind != kindCounter && m.kind != kindGauge) {
+		r.mu.Unlock()
+		return nil
+	}
+	if fn := m.fn; fn != nil {
+		r.mu.Unlock()
+		return []metricsx.Sample{{Name: name, Value: fn()}}
+	}
+	defer r.mu.Unlock()
+
+	samples := make([]metricsx.Sample, 0, len(m.series))
+	for _, s := range m.series {
+		labels := make(map[string]string, len(m.labels))
+		for i, label := range m.labels {
+			labels[label] = s.labels[i]
+		}
+		samples = append(samples, metricsx.Sample{Name: name, Labels: labels, Value: s.value})
+	}
+	return samples
+}
+
+// applyOptions applies opts to empty Options
+func applyOptions(opts []metricsx.Option) *metricsx.Options {
+	options := &metricsx.Options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// counter implements metricsx.Counter
+type counter struct {
+	*recorder
+	name   string
+	metric *metric
+}
+
+func (c *counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+func (c *counter) IncCtx(ctx context.Context, labels ...string) {
+	c.Inc(labels...)
+}
+
+func (c *counter) AddCtx(ctx context.Context, value float64, labels ...string) {
+	c.Add(value, labels...)
+}
+
+func (c *counter) Add(value float64, labels ...string) {
+	if value < 0 {
+		panic(fmt.Sprintf("metricstest: counter %s cannot decrease in value", c.name))
+	}
+	c.update(c.name, c.metric, labels, func(s *series) { s.value += value })
+}
+
+// gauge implements metricsx.Gauge
+type gauge struct {
+	*recorder
+	name   string
+	metric *metric
+}
+
+func (g *gauge) Set(value float64, labels ...string) {
+	g.update(g.name, g.metric, labels, func(s *series) { s.value = value })
+}
+
+func (g *gauge) Inc(labels ...string) {
+	g.Add(1, labels...)
+}
+
+func (g *gauge) Dec(labels ...string) {
+	g.Add(-1, labels...)
+}
+
+func (g *gauge) Add(value float64, labels ...string) {
+	g.update(g.name, g.metric, labels, func(s *series) { s.value += value })
+}
+
+func (g *gauge) Sub(value float64, labels ...string) {
+	g.Add(-value, labels...)
+}
+
+// observer implements metricsx.Histogram and metricsx.Summary
+type observer struct {
+	*recorder
+	name   string
+	metric *metric
+}
+
+func (o *observer) Observe(value float64, labels ...string) {
+	o.update(o.name, o.metric, labels, func(s *series) { s.observations = append(s.observations, value) })
+}
+
+func (o *observer) ObserveCtx(ctx context.Context, value float64, labels ...string) {
+	o.Observe(value, labels...)
+}
+
+func (o *observer) Timer(labels ...string) metricsx.Timer {
+	return &timer{observer: o, labels: labels, start: time.Now()}
+}
+
+// timer implements metricsx.Timer
+type timer struct {
+	observer *observer
+	labels   []string
+	start    time.Time
+}
+
+func (t *timer) ObserveDuration() {
+	t.Stop()
+}
+
+func (t *timer) Stop() time.Duration {
+	duration := time.Since(t.start)
+	t.observer.Observe(duration.Seconds(), t.labels...)
+	return duration
+}
@@ -0,0 +1,156 @@
+package metricstest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gostratum/metricsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Run("reads back counters and gauges", func(t *testing.T) {
+		m := New()
+		orders := m.Counter("orders_total", metricsx.WithLabels("status"))
+		orders.Inc("created")
+		orders.Add(2, "created")
+		orders.Inc("failed")
+
+		queue := m.Gauge("queue_depth")
+		queue.Set(10)
+		queue.Inc()
+		queue.Sub(3)
+
+		assert.Equal(t, 3.0, m.CounterValue("orders_total", "created"))
+		assert.Equal(t, 1.0, m.CounterValue("orders_total", "failed"))
+		assert.Equal(t, 8.0, m.GaugeValue("queue_depth"))
+	})
+
+	t.Run("reads back histograms and summaries", func(t *testing.T) {
+		m := New()
+		latency := m.Histogram("request_duration_seconds", metricsx.WithLabels("method"))
+		latency.Observe(0.5, "GET")
+		latency.Observe(1.5, "GET")
+		latency.Timer("POST").ObserveDuration()
+
+		m.Summary("payload_bytes").Observe(512)
+
+		assert.Equal(t, uint64(2), m.HistogramSampleCount("request_duration_seconds", "GET"))
+		assert.Equal(t, 2.0, m.HistogramSampleSum("request_duration_seconds", "GET"))
+		assert.Equal(t, []float64{0.5, 1.5}, m.Observations("request_duration_seconds", "GET"))
+		assert.Equal(t, uint64(1), m.HistogramSampleCount("request_duration_seconds", "POST"))
+		assert.Equal(t, uint64(1), m.SummarySampleCount("payload_bytes"))
+		assert.Equal(t, 512.0, m.SummarySampleSum("payload_bytes"))
+	})
+
+	t.Run("evaluates collection-time metrics on read", func(t *testing.T) {
+		m := New()
+		value := 1.0
+		m.GaugeFunc("pool_size", func() float64 { return value })
+		m.CounterFunc("cache_hits_total", func() float64 { return 7 })
+
+		value = 4
+		assert.Equal(t, 4.0, m.GaugeValue("pool_size"))
+		assert.Equal(t, 7.0, m.CounterValue("cache_hits_total"))
+	})
+
+	t.Run("returns zero for unknown metrics and series", func(t *testing.T) {
+		m := New()
+		m.Counter("orders_total", metricsx.WithLabels("status")).Inc("created")
+
+		assert.Zero(t, m.CounterValue("orders_total", "failed"))
+		assert.Zero(t, m.CounterValue("missing_total"))
+		assert.Zero(t, m.GaugeValue("orders_total", "created"))
+		assert.Zero(t, m.HistogramSampleCount("missing_seconds"))
+		assert.Nil(t, m.Observations("missing_seconds"))
+	})
+
+	t.Run("panics on invalid use", func(t *testing.T) {
+		m := New()
+		orders := m.Counter("orders_total", metricsx.WithLabels("status"))
+
+		assert.Panics(t, func() { orders.Inc() })
+		assert.Panics(t, func() { orders.Add(-1, "created") })
+		assert.Panics(t, func() { m.Gauge("orders_total") })
+	})
+
+	t.Run("reports missing metrics", func(t *testing.T) {
+		m := New()
+		m.Counter("orders_total")
+
+		assert.NoError(t, m.MustHave("orders_total"))
+		err := m.MustHave("orders_total", "payments_total")
+		assert.True(t, errors.Is(err, metricsx.ErrMissingMetrics))
+		assert.ErrorContains(t, err, "payments_total")
+	})
+
+	t.Run("reset clears values", func(t *testing.T) {
+		m := New()
+		orders := m.Counter("orders_total")
+		orders.Inc()
+		m.Reset()
+
+		assert.Zero(t, m.CounterValue("orders_total"))
+		assert.True(t, m.Registered("orders_total"))
+		orders.Inc()
+		assert.Equal(t, 1.0, m.CounterValue("orders_total"))
+	})
+
+	t.Run("records events", func(t *testing.T) {
+		m := New()
+		m.Event("signup", map[string]string{"plan": "pro"})
+		m.Event("signup", map[string]string{"plan": "free"})
+
+		assert.Equal(t, 2.0, m.CounterValue("signup_total"))
+		assert.Equal(t, []map[string]string{{"plan": "pro"}, {"plan": "free"}}, m.Events("signup"))
+
+		m.Reset()
+		assert.Empty(t, m.Events("signup"))
+	})
+
+	t.Run("collects registered functions", func(t *testing.T) {
+		m := New()
+		m.RegisterFunc(func(ch chan<- metricsx.Sample) {
+			ch <- metricsx.Sample{Name: "lsm_memtable_bytes", Value: 4096}
+		})
+
+		assert.Equal(t, []metricsx.Sample{{Name: "lsm_memtable_bytes", Value: 4096}}, m.Collect())
+	})
+
+	t.Run("evaluates watches on demand", func(t *testing.T) {
+		m := New()
+		queue := m.Gauge("queue_depth", metricsx.WithLabels("queue"))
+
+		var fired []metricsx.Sample
+		m.Watch("queue_depth", func(v float64) bool { return v > 100 }, func(s metricsx.Sample) {
+			fired = append(fired, s)
+		})
+
+		queue.Set(150, "emails")
+		queue.Set(5, "sms")
+		m.EvaluateWatches()
+		m.EvaluateWatches()
+		require.Len(t, fired, 1)
+		assert.Equal(t, map[string]string{"queue": "emails"}, fired[0].Labels)
+		assert.Equal(t, 150.0, fired[0].Value)
+
+		queue.Set(50, "emails")
+		m.EvaluateWatches()
+		queue.Set(120, "emails")
+		m.EvaluateWatches()
+		assert.Len(t, fired, 2)
+	})
+}
+
+func TestProvider(t *testing.T) {
+	p := NewProvider()
+	p.Counter("orders_total", &metricsx.Options{Labels: []string{"status"}}).Inc("created")
+	p.Histogram("request_duration_seconds", &metricsx.Options{}).Observe(0.25)
+
+	assert.Equal(t, 1.0, p.CounterValue("orders_total", "created"))
+	assert.Equal(t, uint64(1), p.HistogramSampleCount("request_duration_seconds"))
+	assert.NoError(t, p.Start(t.Context()))
+	assert.Empty(t, p.Addr())
+	assert.NoError(t, p.Stop(t.Context()))
+}
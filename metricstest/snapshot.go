@@ -0,0 +1,136 @@
+package metricstest
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gostratum/metricsx"
+)
+
+var _ metricsx.Snapshotter = (*Provider)(nil)
+
+// Snapshot returns every recorded metric sorted by name, with its series
+// sorted by label values, so output built from it is stable across runs.
+// Names are those passed to metricsx, without namespace or subsystem.
+func (r *recorder) Snapshot() ([]metricsx.FamilySnapshot, error) {
+	type pending struct {
+		family int
+		fn     func() float64
+	}
+
+	r.mu.Lock()
+	families := make([]metricsx.FamilySnapshot, 0, len(r.metrics))
+	var fns []pending
+	for _, name := range slices.Sorted(maps.Keys(r.metrics)) {
+		m := r.metrics[name]
+		family := metricsx.FamilySnapshot{Name: name, Type: string(m.kind)}
+		if m.fn != nil {
+			fns = append(fns, pending{family: len(families), fn: m.fn})
+		}
+
+		series := slices.SortedFunc(maps.Values(m.series), func(a, b *series) int {
+			return slices.Compare(a.labels, b.labels)
+		})
+		for _, s := range series {
+			family.Samples = append(family.Samples, m.sample(s))
+		}
+		families = append(families, family)
+	}
+	r.mu.Unlock()
+
+	// Value functions may record metrics themselves, so they run without the lock
+	for _, p := range fns {
+		families[p.family].Samples = []metricsx.SampleSnapshot{{Value: p.fn()}}
+	}
+	return families, nil
+}
+
+// sample converts a series of m
+func (m *metric) sample(s *series) metricsx.SampleSnapshot {
+	sample := metricsx.SampleSnapshot{Value: s.value}
+	if len(m.labels) > 0 {
+		sample.Labels = make(map[string]string, len(m.labels))
+		for i, name := range m.labels {
+			sample.Labels[name] = s.labels[i]
+		}
+	}
+
+	switch m.kind {
+	case kindHistogram, kindSummary:
+		sample.Value = 0
+		sample.Count = uint64(len(s.observations))
+		sample.Sum = sum(s.observations)
+	}
+	for _, bound := range m.buckets {
+		var n uint64
+		for _, v := range s.observations {
+			if v <= bound {
+				n++
+			}
+		}
+		sample.Buckets = append(sample.Buckets, metricsx.BucketSnapshot{UpperBound: bound, Count: n})
+	}
+	return sample
+}
+
+// String formats every recorded series in a text format similar to the
+// Prometheus exposition, one line per value in a deterministic order:
+//
+//	orders_total{status="created"} 3
+//	request_duration_seconds_bucket{method="GET",le="0.1"} 1
+//	request_duration_seconds_count{method="GET"} 2
+func (r *recorder) String() string {
+	families, _ := r.Snapshot()
+
+	var b strings.Builder
+	for _, family := range families {
+		for _, sample := range family.Samples {
+			if family.Type != string(kindHistogram) && family.Type != string(kindSummary) {
+				writeLine(&b, family.Name, sample.Labels, "", sample.Value)
+				continue
+			}
+
+			for _, bucket := range sample.Buckets {
+				writeLine(&b, family.Name+"_bucket", sample.Labels, formatFloat(bucket.UpperBound), float64(bucket.Count))
+			}
+			if family.Type == string(kindHistogram) {
+				writeLine(&b, family.Name+"_bucket", sample.Labels, "+Inf", float64(sample.Count))
+			}
+			writeLine(&b, family.Name+"_count", sample.Labels, "", float64(sample.Count))
+			writeLine(&b, family.Name+"_sum", sample.Labels, "", sample.Sum)
+		}
+	}
+	return b.String()
+}
+
+// writeLine writes a series line for name with labels sorted by name and an
+// le label when le is set
+func writeLine(b *strings.Builder, name string, labels map[string]string, le string, value float64) {
+	pairs := make([]string, 0, len(labels)+1)
+	for _, label := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, label+"="+strconv.Quote(labels[label]))
+	}
+	if le != "" {
+		pairs = append(pairs, "le="+strconv.Quote(le))
+	}
+
+	b.WriteString(name)
+	if len(pairs) > 0 {
+		b.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	b.WriteString(" " + formatFloat(value) + "\n")
+}
+
+// formatFloat formats v like the Prometheus text format
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,65 @@
+package metricstest
+
+import (
+	"testing"
+
+	"github.com/gostratum/metricsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	record := func() *Metrics {
+		m := New()
+		m.GaugeFunc("workers", func() float64 { return 4 })
+		for _, status := range []string{"failed", "created", "cancelled", "created"} {
+			m.Counter("orders_total", metricsx.WithLabels("status", "region")).Inc(status, "eu")
+		}
+		latency := m.Histogram("request_duration_seconds", metricsx.WithLabels("method"), metricsx.WithBuckets(0.1, 1))
+		latency.Observe(0.5, "POST")
+		latency.Observe(0.05, "GET")
+		m.Summary("payload_bytes").Observe(512)
+		return m
+	}
+
+	t.Run("sorts by name and label values", func(t *testing.T) {
+		families, err := record().Snapshot()
+		require.NoError(t, err)
+
+		names := make([]string, 0, len(families))
+		for _, family := range families {
+			names = append(names, family.Name)
+		}
+		assert.Equal(t, []string{"orders_total", "payload_bytes", "request_duration_seconds", "workers"}, names)
+
+		orders := families[0]
+		assert.Equal(t, "counter", orders.Type)
+		require.Len(t, orders.Samples, 3)
+		assert.Equal(t, map[string]string{"status": "cancelled", "region": "eu"}, orders.Samples[0].Labels)
+		assert.Equal(t, 2.0, orders.Samples[1].Value)
+		assert.Equal(t, []metricsx.SampleSnapshot{{Value: 4}}, families[3].Samples)
+	})
+
+	t.Run("formats stable text", func(t *testing.T) {
+		want := `orders_total{region="eu",status="cancelled"} 1
+orders_total{region="eu",status="created"} 2
+orders_total{region="eu",status="failed"} 1
+payload_bytes_count 1
+payload_bytes_sum 512
+request_duration_seconds_bucket{method="GET",le="0.1"} 1
+request_duration_seconds_bucket{method="GET",le="1"} 1
+request_duration_seconds_bucket{method="GET",le="+Inf"} 1
+request_duration_seconds_count{method="GET"} 1
+request_duration_seconds_sum{method="GET"} 0.05
+request_duration_seconds_bucket{method="POST",le="0.1"} 0
+request_duration_seconds_bucket{method="POST",le="1"} 1
+request_duration_seconds_bucket{method="POST",le="+Inf"} 1
+request_duration_seconds_count{method="POST"} 1
+request_duration_seconds_sum{method="POST"} 0.5
+workers 4
+`
+		for range 10 {
+			assert.Equal(t, want, record().String())
+		}
+	})
+}
@@ -0,0 +1,179 @@
+package metricstest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gostratum/metricsx"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// StressConfig configures Stress
+type StressConfig struct {
+	// Goroutines recording concurrently (default 8)
+	Goroutines int
+
+	// Iterations per goroutine (default 1000)
+	Iterations int
+
+	// Metrics is the number of distinct names per metric type (default 4)
+	Metrics int
+}
+
+// withDefaults fills the unset fields of c
+func (c StressConfig) withDefaults() StressConfig {
+	if c.Goroutines <= 0 {
+		c.Goroutines = 8
+	}
+	if c.Iterations <= 0 {
+		c.Iterations = 1000
+	}
+	if c.Metrics <= 0 {
+		c.Metrics = 4
+	}
+	return c
+}
+
+// stressKinds are the metric types Stress records, with the value each one
+// is read back as
+var stressKinds = []string{"counter", "gauge", "histogram", "summary"}
+
+// Stress hammers provider with concurrent registrations and recordings of
+// every metric type from many goroutines, then checks that no update was
+// lost. Run it with -race to also catch data races:
+//
+//	metricstest.Stress(t, provider, metricstest.StressConfig{Goroutines: 32})
+//
+// Final values are read back from providers implementing Reader, such as
+// NewProvider, or metricsx.Gatherable, such as the Prometheus provider. Other
+// providers are only checked for panics and races. The metrics are named
+// stress_<type>_<n> and must not be filtered out by the provider.
+func Stress(t testing.TB, provider metricsx.Provider, cfg StressConfig) bool {
+	t.Helper()
+	cfg = cfg.withDefaults()
+
+	var wg sync.WaitGroup
+	for range cfg.Goroutines {
+		wg.Go(func() {
+			for i := range cfg.Iterations {
+				name, parity := stressSeries(i, cfg.Metrics)
+				provider.Counter("stress_counter_"+name+"_total", stressOptions()).Inc(parity)
+				provider.Gauge("stress_gauge_"+name, stressOptions()).Inc(parity)
+				provider.Histogram("stress_histogram_"+name, stressOptions()).Observe(1, parity)
+				provider.Summary("stress_summary_"+name, stressOptions()).Observe(1, parity)
+			}
+		})
+	}
+	wg.Wait()
+
+	read, ok := stressReader(t, provider)
+	if !ok {
+		return true
+	}
+
+	want := make(map[[2]string]float64)
+	for i := range cfg.Iterations {
+		name, parity := stressSeries(i, cfg.Metrics)
+		want[[2]string{name, parity}] += float64(cfg.Goroutines)
+	}
+
+	passed := true
+	for series, count := range want {
+		name, parity := series[0], series[1]
+		for _, kind := range stressKinds {
+			metric := "stress_" + kind + "_" + name
+			if kind == "counter" {
+				metric += "_total"
+			}
+			if got := read(kind, metric, parity); got != count {
+				t.Errorf("%s %s{parity=%s}: got %v, want %v", kind, metric, parity, got, count)
+				passed = false
+			}
+		}
+	}
+	return passed
+}
+
+// stressOptions returns fresh options for a stress metric, as providers may
+// modify them
+func stressOptions() *metricsx.Options {
+	return &metricsx.Options{
+		Help:       "Stress test metric.",
+		Labels:     []string{"parity"},
+		Buckets:    metricsx.DefaultBuckets,
+		Objectives: metricsx.DefaultObjectives,
+	}
+}
+
+// stressSeries returns the metric name suffix and label value recorded in iteration i
+func stressSeries(i, metrics int) (name, parity string) {
+	parity = "even"
+	if (i/metrics)%2 == 1 {
+		parity = "odd"
+	}
+	return fmt.Sprint(i % metrics), parity
+}
+
+// stressReader returns a function reading the final value of a stress series
+// from provider: the counter or gauge value, or the observation count
+func stressReader(t testing.TB, provider metricsx.Provider) (func(kind, name, parity string) float64, bool) {
+	if r, ok := provider.(Reader); ok {
+		return func(kind, name, parity string) float64 {
+			switch kind {
+			case "counter":
+				return r.CounterValue(name, parity)
+			case "gauge":
+				return r.GaugeValue(name, parity)
+			case "histogram":
+				return float64(r.HistogramSampleCount(name, parity))
+			}
+			return float64(r.SummarySampleCount(name, parity))
+		}, true
+	}
+
+	g, err := gatherer(provider)
+	if err != nil {
+		return nil, false
+	}
+	mfs, err := g.Gather()
+	if err != nil {
+		t.Errorf("gathering metrics: %v", err)
+		return nil, false
+	}
+
+	return func(kind, name, parity string) float64 {
+		for _, mf := range mfs {
+			// Namespace and subsystem come from the provider configuration
+			if mf.GetName() != name && !strings.HasSuffix(mf.GetName(), "_"+name) {
+				continue
+			}
+			for _, m := range mf.GetMetric() {
+				if !hasLabel(m, "parity", parity) {
+					continue
+				}
+				switch kind {
+				case "counter":
+					return m.GetCounter().GetValue()
+				case "gauge":
+					return m.GetGauge().GetValue()
+				case "histogram":
+					return float64(m.GetHistogram().GetSampleCount())
+				}
+				return float64(m.GetSummary().GetSampleCount())
+			}
+		}
+		return 0
+	}, true
+}
+
+// hasLabel reports whether m has the label name with value
+func hasLabel(m *dto.Metric, name, value string) bool {
+	for _, pair := range m.GetLabel() {
+		if pair.GetName() == name {
+			return pair.GetValue() == value
+		}
+	}
+	return false
+}
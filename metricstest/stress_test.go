@@ -0,0 +1,50 @@
+package metricstest
+
+import (
+	"testing"
+
+	"github.com/gostratum/core/logx"
+	"github.com/gostratum/metricsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStress(t *testing.T) {
+	cfg := StressConfig{Goroutines: 8, Iterations: 200}
+
+	t.Run("recording provider", func(t *testing.T) {
+		assert.True(t, Stress(t, NewProvider(), cfg))
+	})
+
+	for _, name := range []string{"prometheus", "fanout", "noop"} {
+		t.Run(name, func(t *testing.T) {
+			res, err := metricsx.NewMetrics(metricsx.Params{
+				Config: metricsx.Config{
+					Provider:    name,
+					Prometheus:  metricsx.PrometheusConfig{Namespace: "app"},
+					Pushgateway: metricsx.PushgatewayConfig{URL: "http://127.0.0.1:9091", Job: "stress"},
+					Fanout:      metricsx.FanoutConfig{Providers: []string{"prometheus", "pushgateway"}},
+				},
+				Logger: logx.NewNoopLogger(),
+			})
+			require.NoError(t, err)
+			assert.True(t, Stress(t, res.Provider, cfg))
+		})
+	}
+
+	t.Run("reports lost updates", func(t *testing.T) {
+		rt := &recordingT{TB: t}
+		assert.False(t, Stress(rt, lossyProvider{NewProvider()}, StressConfig{Goroutines: 2, Iterations: 4, Metrics: 1}))
+		assert.Contains(t, rt.errors, "counter stress_counter_0_total{parity=even}: got 0, want 4")
+	})
+}
+
+// lossyProvider drops every counter increment
+type lossyProvider struct {
+	*Provider
+}
+
+func (p lossyProvider) Counter(name string, options *metricsx.Options) metricsx.Counter {
+	p.Provider.Counter(name, options)
+	return NewProvider().Counter(name, options)
+}
@@ -1,9 +1,15 @@
 package metricsx
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 
 	"github.com/gostratum/core/logx"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/fx"
 )
 
@@ -21,34 +27,67 @@ type Result struct {
 	Provider Provider
 }
 
-// Module provides the metrics module for fx
+// Module provides the metrics module for fx. It leaves the application's fx
+// event logger alone; add FxEventsOption to record lifecycle metrics.
 func Module() fx.Option {
 	return fx.Module("metricsx",
 		fx.Provide(
 			NewConfig,
 			NewMetrics,
 		),
-		fx.Invoke(registerLifecycle),
+		fx.Invoke(registerLifecycle, mountHandler, registerExpected, registerLint, registerWatches),
 	)
 }
 
 // NewMetrics creates a new Metrics instance based on configuration
 func NewMetrics(p Params) (Result, error) {
-	var provider Provider
-
-	switch p.Config.Provider {
-	case "prometheus":
-		provider = newPrometheusProvider(p.Config.Prometheus, p.Logger)
-	case "noop":
-		provider = newNoopProvider()
-	default:
+	provider, err := newProvider(p.Config.Provider, p.Config, p.Logger)
+	if p.Config.DryRun {
+		provider, err = newDryRunProvider(p.Config, p.Logger), nil
+	}
+	if errors.Is(err, errUnknownProvider) {
 		p.Logger.Warn("unknown metrics provider, using noop", logx.String("provider", p.Config.Provider))
-		provider = newNoopProvider()
+		provider, err = newNoopProvider(), nil
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	settings, err := newModuleSettings(p.Config)
+	if err != nil {
+		return Result{}, err
+	}
+
+	globalLabels, err := resolveGlobalLabels(p.Config, p.Logger)
+	if err != nil {
+		return Result{}, err
 	}
 
 	metrics := &metricsImpl{
-		provider: provider,
-		logger:   p.Logger,
+		provider:     provider,
+		logger:       p.Logger,
+		config:       p.Config,
+		globalLabels: globalLabels,
+		watcher:      newWatcher(provider, p.Config, p.Logger),
+	}
+	metrics.live.Store(settings)
+	if r, ok := provider.(moduleReloadable); ok {
+		r.setModule(metrics)
+	}
+
+	if p.Config.Resource.Detect {
+		ctx, cancel := context.WithTimeout(context.Background(), cmp.Or(p.Config.Resource.Timeout, DefaultResourceTimeout))
+		resource := DetectResource(ctx)
+		cancel()
+
+		p.Logger.Info("detected metrics resource", logx.Any("resource", resource))
+		registerResourceInfo(metrics, resource)
+	}
+
+	if len(p.Config.DiskUsagePaths) > 0 {
+		if err := RegisterDiskUsage(metrics, p.Config.DiskUsagePaths...); err != nil {
+			p.Logger.Warn("ignoring metrics disk usage paths", logx.Err(err))
+		}
 	}
 
 	return Result{
@@ -57,6 +96,24 @@ func NewMetrics(p Params) (Result, error) {
 	}, nil
 }
 
+// errUnknownProvider is returned by newProvider for unsupported provider names
+var errUnknownProvider = errors.New("unknown metrics provider")
+
+// newProvider creates the provider registered under name
+func newProvider(name string, cfg Config, logger logx.Logger) (Provider, error) {
+	switch name {
+	case "prometheus":
+		return newPrometheusProviderFromConfig(cfg, logger)
+	case "pushgateway":
+		return newPushgatewayProvider(cfg, logger)
+	case "fanout":
+		return newFanoutProvider(cfg, logger)
+	case "noop":
+		return newNoopProvider(), nil
+	}
+	return nil, fmt.Errorf("%w %q", errUnknownProvider, name)
+}
+
 // registerLifecycle registers the metrics lifecycle hooks
 func registerLifecycle(lc fx.Lifecycle, provider Provider, logger logx.Logger) {
 	lc.Append(fx.Hook{
@@ -73,26 +130,133 @@ func registerLifecycle(lc fx.Lifecycle, provider Provider, logger logx.Logger) {
 
 // metricsImpl implements the Metrics interface
 type metricsImpl struct {
-	provider Provider
-	logger   logx.Logger
+	provider     Provider
+	logger       logx.Logger
+	globalLabels map[string]string
+	watcher      *watcher
+
+	// live holds the settings swapped by Reload; config is the module
+	// configuration they were built from
+	live atomic.Pointer[moduleSettings]
+
+	mu         sync.Mutex
+	config     Config
+	limiters   map[string]*seriesLimiter
+	registered map[string]struct{}
+	overflow   Counter
+	events     map[string]*eventCounter
 }
 
 func (m *metricsImpl) Counter(name string, opts ...Option) Counter {
-	options := applyOptions(opts...)
+	options := m.options(name, opts...)
+	if mapLabels := m.mapLabels(name, options); mapLabels != nil {
+		return &mappedCounter{next: m.provider.Counter(name, options), mapLabels: mapLabels}
+	}
 	return m.provider.Counter(name, options)
 }
 
 func (m *metricsImpl) Gauge(name string, opts ...Option) Gauge {
-	options := applyOptions(opts...)
+	options := m.options(name, opts...)
+	if mapLabels := m.mapLabels(name, options); mapLabels != nil {
+		return &mappedGauge{next: m.provider.Gauge(name, options), mapLabels: mapLabels}
+	}
 	return m.provider.Gauge(name, options)
 }
 
 func (m *metricsImpl) Histogram(name string, opts ...Option) Histogram {
-	options := applyOptions(opts...)
+	options := m.options(name, opts...)
+	if mapLabels := m.mapLabels(name, options); mapLabels != nil {
+		return &mappedHistogram{next: m.provider.Histogram(name, options), mapLabels: mapLabels}
+	}
 	return m.provider.Histogram(name, options)
 }
 
 func (m *metricsImpl) Summary(name string, opts ...Option) Summary {
-	options := applyOptions(opts...)
+	options := m.options(name, opts...)
+	if mapLabels := m.mapLabels(name, options); mapLabels != nil {
+		return &mappedSummary{next: m.provider.Summary(name, options), mapLabels: mapLabels}
+	}
 	return m.provider.Summary(name, options)
 }
+
+func (m *metricsImpl) GaugeFunc(name string, fn func() float64, opts ...Option) {
+	options := m.options(name, opts...)
+	rewriteLabels(m.settings().labelRules, name, options)
+	m.provider.GaugeFunc(name, fn, options)
+}
+
+func (m *metricsImpl) CounterFunc(name string, fn func() float64, opts ...Option) {
+	options := m.options(name, opts...)
+	rewriteLabels(m.settings().labelRules, name, options)
+	m.provider.CounterFunc(name, fn, options)
+}
+
+func (m *metricsImpl) RegisterFunc(fn func(ch chan<- Sample), opts ...Option) {
+	options := applyOptions(opts...)
+	withGlobalLabels(options, m.globalLabels)
+	m.provider.RegisterFunc(fn, options)
+}
+
+// options records the registration of name and applies opts, the configured
+// override for name and the global labels
+func (m *metricsImpl) options(name string, opts ...Option) *Options {
+	m.register(name)
+
+	options := applyOptions(opts...)
+	if override, ok := m.settings().overrides[name]; ok {
+		override.apply(options)
+	}
+	withGlobalLabels(options, m.globalLabels)
+	return options
+}
+
+// mapLabels applies label rules and the series limit to options and returns
+// the mapper for label values, or nil when values pass through unchanged
+func (m *metricsImpl) mapLabels(name string, options *Options) labelMapper {
+	mapLabels := rewriteLabels(m.settings().labelRules, name, options)
+	if limiter := m.limiter(name, options); limiter != nil {
+		mapLabels = mapLabels.then(limiter.apply)
+	}
+	return mapLabels
+}
+
+// limiter returns the shared series limiter for the metric, adding the
+// overflow label to options, or nil when the metric is not limited. Limiters
+// are shared by fully qualified name, so metrics with the same name in
+// different namespaces are limited separately.
+func (m *metricsImpl) limiter(name string, options *Options) *seriesLimiter {
+	limit := m.settings().cardinality.limit(name, options)
+	if limit <= 0 || len(options.Labels) == 0 {
+		return nil
+	}
+
+	inputs := len(options.Labels)
+	options.Labels = append(options.Labels[:inputs:inputs], OverflowLabel)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.overflow == nil {
+		overflow := &Options{
+			Help:   "Total number of observations recorded in the overflow series because the metric reached its series limit.",
+			Labels: []string{"metric"},
+		}
+		withGlobalLabels(overflow, m.globalLabels)
+		m.overflow = m.provider.Counter("metricsx_series_overflow_total", overflow)
+	}
+	if m.limiters == nil {
+		m.limiters = make(map[string]*seriesLimiter)
+	}
+
+	fqName := prometheus.BuildFQName(
+		cmp.Or(options.Namespace, m.config.Prometheus.Namespace),
+		cmp.Or(options.Subsystem, m.config.Prometheus.Subsystem),
+		name,
+	)
+	if l, ok := m.limiters[fqName]; ok {
+		return l
+	}
+	l := newSeriesLimiter(fqName, limit, inputs, m.overflow)
+	m.limiters[fqName] = l
+	return l
+}
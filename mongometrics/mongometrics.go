@@ -0,0 +1,117 @@
+// Package mongometrics provides a MongoDB driver command monitor that records metrics through metricsx.
+//
+// Every command issued by a client configured with the monitor records:
+//
+//	mongodb_command_duration_seconds{command, collection}
+//	mongodb_command_failures_total{command, collection}
+package mongometrics
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/gostratum/metricsx"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/event"
+)
+
+// Option configures the command monitor
+type Option func(*options)
+
+// options contains configuration for the command monitor
+type options struct {
+	buckets []float64
+}
+
+// WithBuckets sets the buckets for the command duration histogram
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// monitor records command metrics and tracks in-flight commands
+type monitor struct {
+	duration metricsx.Histogram
+	failures metricsx.Counter
+
+	// collections maps in-flight requests to the collection they target,
+	// since finished events do not carry the command document
+	collections sync.Map
+}
+
+// NewCommandMonitor creates a command monitor to pass to options.Client().SetMonitor
+func NewCommandMonitor(m metricsx.Metrics, opts ...Option) *event.CommandMonitor {
+	o := &options{
+		buckets: metricsx.DefaultBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	mon := &monitor{
+		duration: m.Histogram("mongodb_command_duration_seconds",
+			metricsx.WithHelp("MongoDB command duration in seconds."),
+			metricsx.WithLabels("command", "collection"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+		failures: m.Counter("mongodb_command_failures_total",
+			metricsx.WithHelp("Total number of failed MongoDB commands."),
+			metricsx.WithLabels("command", "collection"),
+		),
+	}
+
+	return &event.CommandMonitor{
+		Started:   mon.started,
+		Succeeded: mon.succeeded,
+		Failed:    mon.failed,
+	}
+}
+
+func (m *monitor) started(_ context.Context, evt *event.CommandStartedEvent) {
+	m.collections.Store(requestKey(evt.ConnectionID, evt.RequestID), collectionName(evt.Command))
+}
+
+func (m *monitor) succeeded(_ context.Context, evt *event.CommandSucceededEvent) {
+	collection := m.finish(&evt.CommandFinishedEvent)
+	m.duration.Observe(evt.Duration.Seconds(), evt.CommandName, collection)
+}
+
+func (m *monitor) failed(_ context.Context, evt *event.CommandFailedEvent) {
+	collection := m.finish(&evt.CommandFinishedEvent)
+	m.duration.Observe(evt.Duration.Seconds(), evt.CommandName, collection)
+	m.failures.Inc(evt.CommandName, collection)
+}
+
+// finish removes a request from the in-flight set and returns its collection
+func (m *monitor) finish(evt *event.CommandFinishedEvent) string {
+	collection, ok := m.collections.LoadAndDelete(requestKey(evt.ConnectionID, evt.RequestID))
+	if !ok {
+		return ""
+	}
+	return collection.(string)
+}
+
+// requestKey identifies a command across its started and finished events
+func requestKey(connectionID string, requestID int64) string {
+	return connectionID + "/" + strconv.FormatInt(requestID, 10)
+}
+
+// collectionName extracts the target collection from a command document.
+// Most commands carry it as the value of their first element ({"find": "users"});
+// cursor commands such as getMore use a separate "collection" field.
+func collectionName(cmd bson.Raw) string {
+	elems, err := cmd.Elements()
+	if err != nil || len(elems) == 0 {
+		return ""
+	}
+
+	if name, ok := elems[0].Value().StringValueOK(); ok {
+		return name
+	}
+	if name, ok := cmd.Lookup("collection").StringValueOK(); ok {
+		return name
+	}
+	return ""
+}
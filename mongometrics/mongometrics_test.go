@@ -0,0 +1,84 @@
+package mongometrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/event"
+)
+
+func mustMarshal(t *testing.T, doc bson.D) bson.Raw {
+	t.Helper()
+	raw, err := bson.Marshal(doc)
+	require.NoError(t, err)
+	return raw
+}
+
+func TestCollectionName(t *testing.T) {
+	assert.Equal(t, "users", collectionName(mustMarshal(t, bson.D{{Key: "find", Value: "users"}})))
+	assert.Equal(t, "orders", collectionName(mustMarshal(t, bson.D{
+		{Key: "getMore", Value: int64(42)},
+		{Key: "collection", Value: "orders"},
+	})))
+	assert.Empty(t, collectionName(mustMarshal(t, bson.D{{Key: "ping", Value: 1}})))
+	assert.Empty(t, collectionName(nil))
+}
+
+func TestCommandMonitor(t *testing.T) {
+	t.Run("records successful commands", func(t *testing.T) {
+		m := metricstest.New()
+		mon := NewCommandMonitor(m, WithBuckets(0.01, 1))
+		ctx := context.Background()
+
+		mon.Started(ctx, &event.CommandStartedEvent{
+			Command:      mustMarshal(t, bson.D{{Key: "find", Value: "users"}}),
+			CommandName:  "find",
+			RequestID:    1,
+			ConnectionID: "conn-1",
+		})
+		mon.Succeeded(ctx, &event.CommandSucceededEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{
+				Duration:     5 * time.Millisecond,
+				CommandName:  "find",
+				RequestID:    1,
+				ConnectionID: "conn-1",
+			},
+		})
+
+		out := m.String()
+		assert.Contains(t, out, `mongodb_command_duration_seconds_bucket{collection="users",command="find",le="0.01"} 1`)
+		assert.NotContains(t, out, "mongodb_command_failures_total{")
+	})
+
+	t.Run("records failed commands", func(t *testing.T) {
+		m := metricstest.New()
+		mon := NewCommandMonitor(m)
+		ctx := context.Background()
+
+		mon.Started(ctx, &event.CommandStartedEvent{
+			Command:      mustMarshal(t, bson.D{{Key: "insert", Value: "orders"}}),
+			CommandName:  "insert",
+			RequestID:    7,
+			ConnectionID: "conn-2",
+		})
+		mon.Failed(ctx, &event.CommandFailedEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{
+				Duration:     time.Millisecond,
+				CommandName:  "insert",
+				RequestID:    7,
+				ConnectionID: "conn-2",
+			},
+			Failure: errors.New("duplicate key"),
+		})
+
+		out := m.String()
+		assert.Contains(t, out, `mongodb_command_failures_total{collection="orders",command="insert"} 1`)
+		assert.Contains(t, out, `mongodb_command_duration_seconds_count{collection="orders",command="insert"} 1`)
+	})
+}
@@ -0,0 +1,55 @@
+package metricsx
+
+import (
+	"cmp"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Default native histogram settings, used when the corresponding
+// NativeHistogramConfig field is zero
+const (
+	DefaultNativeBucketFactor     = 1.1
+	DefaultNativeMaxBuckets       = 160
+	DefaultNativeMinResetDuration = time.Hour
+)
+
+// NativeHistogramConfig exposes histograms as Prometheus native histograms.
+// Native buckets are only served in the protobuf format, which scrapers
+// negotiate through the Accept header; the classic buckets stay available in
+// every format.
+type NativeHistogramConfig struct {
+	// Enabled adds native buckets to every histogram; WithNativeHistogram
+	// enables them per metric
+	Enabled bool `mapstructure:"enabled" default:"false"`
+
+	// BucketFactor is the maximum growth between neighbouring native buckets,
+	// e.g. 1.1 for buckets at most 10% wider than the previous one
+	BucketFactor float64 `mapstructure:"bucket_factor" default:"1.1"`
+
+	// MaxBuckets caps the native buckets per series; the resolution is
+	// lowered when more would be needed
+	MaxBuckets uint32 `mapstructure:"max_buckets" default:"160"`
+
+	// MinResetDuration is the minimum time between resets of a series that
+	// hit MaxBuckets, which restore the full resolution
+	MinResetDuration time.Duration `mapstructure:"min_reset_duration" default:"1h"`
+}
+
+// applyNativeHistogram sets the native histogram fields of opts when options
+// or cfg enable them. Histograms without explicit buckets keep the default
+// classic buckets, which the client library drops for native histograms, so
+// text format scrapers see no change.
+func applyNativeHistogram(opts *prometheus.HistogramOpts, options *Options, cfg NativeHistogramConfig) {
+	if !options.NativeHistogram && !cfg.Enabled {
+		return
+	}
+
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = prometheus.DefBuckets
+	}
+	opts.NativeHistogramBucketFactor = cmp.Or(options.NativeBucketFactor, cfg.BucketFactor, DefaultNativeBucketFactor)
+	opts.NativeHistogramMaxBucketNumber = cmp.Or(cfg.MaxBuckets, DefaultNativeMaxBuckets)
+	opts.NativeHistogramMinResetDuration = cmp.Or(cfg.MinResetDuration, DefaultNativeMinResetDuration)
+}
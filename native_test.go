@@ -0,0 +1,61 @@
+package metricsx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNativeHistograms(t *testing.T) {
+	// scrapeProtobuf negotiates the protobuf format and returns the family name
+	scrapeProtobuf := func(t *testing.T, p *prometheusProvider, name string) *dto.MetricFamily {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", string(expfmt.NewFormat(expfmt.TypeProtoDelim)))
+		rec := httptest.NewRecorder()
+		p.Handler().ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, rec.Header().Get("Content-Type"), "application/vnd.google.protobuf")
+
+		decoder := expfmt.NewDecoder(rec.Body, expfmt.ResponseFormat(rec.Header()))
+		for {
+			var mf dto.MetricFamily
+			if err := decoder.Decode(&mf); err != nil {
+				t.Fatalf("metric family %s not found: %v", name, err)
+			}
+			if mf.GetName() == name {
+				return &mf
+			}
+		}
+	}
+
+	t.Run("exposes native buckets per metric", func(t *testing.T) {
+		p := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger()).(*prometheusProvider)
+		p.Histogram("request_duration_seconds", applyOptions(WithNativeHistogram(0))).Observe(0.3)
+		p.Histogram("payload_bytes", applyOptions()).Observe(512)
+
+		h := scrapeProtobuf(t, p, "request_duration_seconds").GetMetric()[0].GetHistogram()
+		assert.Equal(t, int32(3), h.GetSchema(), "factor 1.1 maps to schema 3")
+		assert.NotEmpty(t, h.GetPositiveSpan())
+		assert.NotEmpty(t, h.GetBucket(), "classic buckets are kept")
+
+		classic := scrapeProtobuf(t, p, "payload_bytes").GetMetric()[0].GetHistogram()
+		assert.Empty(t, classic.GetPositiveSpan())
+		assert.Contains(t, scrape(t, p), `request_duration_seconds_bucket{le="0.5"} 1`)
+	})
+
+	t.Run("enables native buckets for every histogram", func(t *testing.T) {
+		p := newPrometheusProvider(PrometheusConfig{Path: "/metrics", NativeHistograms: NativeHistogramConfig{Enabled: true, BucketFactor: 2}}, getTestLogger()).(*prometheusProvider)
+		p.Histogram("payload_bytes", applyOptions(WithBuckets(100, 1000))).Observe(512)
+
+		h := scrapeProtobuf(t, p, "payload_bytes").GetMetric()[0].GetHistogram()
+		assert.Equal(t, int32(0), h.GetSchema(), "factor 2 maps to schema 0")
+		assert.NotEmpty(t, h.GetPositiveSpan())
+		assert.Len(t, h.GetBucket(), 2)
+	})
+}
@@ -0,0 +1,67 @@
+package metricsx
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// MetricOverride replaces the buckets or objectives set in code for one metric
+type MetricOverride struct {
+	// Buckets replace the histogram buckets
+	Buckets []float64 `mapstructure:"buckets"`
+
+	// Objectives replace the summary objectives, keyed by quantile (e.g. "0.99": 0.001)
+	Objectives map[string]float64 `mapstructure:"objectives"`
+}
+
+// metricOverride is a validated MetricOverride
+type metricOverride struct {
+	buckets    []float64
+	objectives map[float64]float64
+}
+
+// compileOverrides validates overrides and parses objective quantiles
+func compileOverrides(overrides map[string]MetricOverride) (map[string]metricOverride, error) {
+	if len(overrides) == 0 {
+		return nil, nil
+	}
+
+	compiled := make(map[string]metricOverride, len(overrides))
+	for name, override := range overrides {
+		if !slices.IsSorted(override.Buckets) || len(slices.Compact(slices.Clone(override.Buckets))) != len(override.Buckets) {
+			return nil, fmt.Errorf("metrics override %s: buckets must be strictly increasing", name)
+		}
+
+		var objectives map[float64]float64
+		for q, e := range override.Objectives {
+			quantile, err := strconv.ParseFloat(q, 64)
+			if err != nil || quantile < 0 || quantile > 1 {
+				return nil, fmt.Errorf("metrics override %s: invalid quantile %q", name, q)
+			}
+			if objectives == nil {
+				objectives = make(map[float64]float64, len(override.Objectives))
+			}
+			objectives[quantile] = e
+		}
+
+		compiled[name] = metricOverride{buckets: override.Buckets, objectives: objectives}
+	}
+	return compiled, nil
+}
+
+// apply replaces the buckets and objectives of options with the configured ones
+func (o metricOverride) apply(options *Options) {
+	if len(o.buckets) > 0 {
+		options.Buckets = o.buckets
+	}
+	if len(o.objectives) > 0 {
+		options.Objectives = o.objectives
+	}
+}
+
+// equal reports whether o and other set the same buckets and objectives
+func (o metricOverride) equal(other metricOverride) bool {
+	return slices.Equal(o.buckets, other.buckets) && maps.Equal(o.objectives, other.objectives)
+}
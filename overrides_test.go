@@ -0,0 +1,55 @@
+package metricsx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricOverrides(t *testing.T) {
+	newMetrics := func(overrides map[string]MetricOverride) (Metrics, Provider, error) {
+		res, err := NewMetrics(Params{
+			Config: Config{
+				Enabled:    true,
+				Provider:   "prometheus",
+				Overrides:  overrides,
+				Prometheus: PrometheusConfig{Path: "/metrics"},
+			},
+			Logger: getTestLogger(),
+		})
+		return res.Metrics, res.Provider, err
+	}
+
+	t.Run("replaces buckets and objectives set in code", func(t *testing.T) {
+		m, provider, err := newMetrics(map[string]MetricOverride{
+			"checkout_seconds": {Buckets: []float64{0.25, 2.5}},
+			"payload_bytes":    {Objectives: map[string]float64{"0.999": 0.0001}},
+		})
+		require.NoError(t, err)
+
+		m.Histogram("checkout_seconds", WithBuckets(1, 5, 10)).Observe(1)
+		m.Summary("payload_bytes").Observe(512)
+		m.Histogram("other_seconds", WithBuckets(7)).Observe(1)
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `checkout_seconds_bucket{le="0.25"} 0`)
+		assert.Contains(t, out, `checkout_seconds_bucket{le="2.5"} 1`)
+		assert.NotContains(t, out, `checkout_seconds_bucket{le="5"}`)
+		assert.Contains(t, out, `payload_bytes{quantile="0.999"} 512`)
+		assert.NotContains(t, out, `payload_bytes{quantile="0.5"}`)
+		assert.Contains(t, out, `other_seconds_bucket{le="7"} 1`)
+	})
+
+	t.Run("rejects invalid overrides", func(t *testing.T) {
+		for _, override := range []MetricOverride{
+			{Buckets: []float64{1, 0.5}},
+			{Buckets: []float64{1, 1}},
+			{Objectives: map[string]float64{"p99": 0.001}},
+			{Objectives: map[string]float64{"1.5": 0.001}},
+		} {
+			_, _, err := newMetrics(map[string]MetricOverride{"latency_seconds": override})
+			assert.Error(t, err)
+		}
+	})
+}
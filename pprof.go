@@ -0,0 +1,57 @@
+package metricsx
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"net/netip"
+)
+
+// pprofPrefix is the path the profiling handlers are mounted under
+const pprofPrefix = "/debug/pprof/"
+
+// handlePprof mounts the net/http/pprof handlers on mux, protected by the
+// configured auth. Without auth, profiles are only served to loopback
+// clients.
+func (p *prometheusProvider) handlePprof(mux *http.ServeMux) {
+	if !p.auth.Load().Enabled() {
+		p.logger.Warn("metrics pprof enabled without auth, serving profiles to loopback clients only")
+	}
+
+	handlers := map[string]http.HandlerFunc{
+		pprofPrefix:             pprof.Index,
+		pprofPrefix + "cmdline": pprof.Cmdline,
+		pprofPrefix + "profile": pprof.Profile,
+		pprofPrefix + "symbol":  pprof.Symbol,
+		pprofPrefix + "trace":   pprof.Trace,
+	}
+	for path, handler := range handlers {
+		mux.Handle(path, p.withPprofAuth(handler))
+	}
+}
+
+// withPprofAuth wraps next with the configured auth, refusing clients other
+// than loopback ones while auth is disabled. Auth is read per request so
+// reloads take effect immediately.
+func (p *prometheusProvider) withPprofAuth(next http.Handler) http.Handler {
+	authed := withAuth(next, func() AuthConfig {
+		return *p.auth.Load()
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !p.auth.Load().Enabled() && !loopback(r.RemoteAddr) {
+			http.Error(w, "pprof requires metrics auth for non-local clients", http.StatusForbidden)
+			return
+		}
+		authed.ServeHTTP(w, r)
+	})
+}
+
+// loopback reports whether remoteAddr is a loopback address
+func loopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.Unmap().IsLoopback()
+}
@@ -0,0 +1,68 @@
+package metricsx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPprof(t *testing.T) {
+	get := func(t *testing.T, cfg PrometheusConfig, path string, token string) int {
+		t.Helper()
+
+		cfg.Host = "127.0.0.1"
+		cfg.Port = RandomPort
+		cfg.Path = "/metrics"
+		provider := newPrometheusProvider(cfg, getTestLogger())
+
+		ctx := context.Background()
+		require.NoError(t, provider.Start(ctx))
+		defer provider.Stop(ctx)
+
+		req, err := http.NewRequest(http.MethodGet, "http://"+provider.Addr()+path, nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("mounts pprof when enabled", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get(t, PrometheusConfig{EnablePprof: true}, "/debug/pprof/", ""))
+		assert.Equal(t, http.StatusOK, get(t, PrometheusConfig{EnablePprof: true}, "/debug/pprof/goroutine?debug=1", ""))
+		assert.Equal(t, http.StatusNotFound, get(t, PrometheusConfig{}, "/debug/pprof/", ""))
+	})
+
+	t.Run("protects pprof with auth", func(t *testing.T) {
+		cfg := PrometheusConfig{EnablePprof: true, Auth: AuthConfig{BearerToken: "secret"}}
+		assert.Equal(t, http.StatusUnauthorized, get(t, cfg, "/debug/pprof/", ""))
+		assert.Equal(t, http.StatusOK, get(t, cfg, "/debug/pprof/", "secret"))
+	})
+
+	t.Run("serves only loopback clients without auth", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{EnablePprof: true}, getTestLogger()).(*prometheusProvider)
+		mux := http.NewServeMux()
+		provider.handlePprof(mux)
+
+		serve := func(remoteAddr string) int {
+			req := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+			req.RemoteAddr = remoteAddr
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			return rec.Code
+		}
+		assert.Equal(t, http.StatusForbidden, serve("203.0.113.7:51000"))
+		assert.Equal(t, http.StatusOK, serve("127.0.0.1:51000"))
+		assert.Equal(t, http.StatusOK, serve("[::1]:51000"))
+
+		provider.auth.Store(&AuthConfig{BearerToken: "secret"})
+		assert.Equal(t, http.StatusUnauthorized, serve("203.0.113.7:51000"))
+	})
+}
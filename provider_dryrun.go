@@ -0,0 +1,145 @@
+package metricsx
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/gostratum/core/logx"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricSchema describes a metric registration recorded in dry-run mode
+type MetricSchema struct {
+	// Name is the fully qualified name, including namespace and subsystem
+	Name        string              `json:"name"`
+	Type        string              `json:"type"`
+	Help        string              `json:"help,omitempty"`
+	Labels      []string            `json:"labels,omitempty"`
+	ConstLabels map[string]string   `json:"const_labels,omitempty"`
+	Buckets     []float64           `json:"buckets,omitempty"`
+	Objectives  map[float64]float64 `json:"objectives,omitempty"`
+}
+
+// newMetricSchema describes a metric of kind registered with options
+func newMetricSchema(kind, namespace, subsystem, name string, options *Options) MetricSchema {
+	schema := MetricSchema{
+		Name:        prometheus.BuildFQName(namespace, subsystem, name),
+		Type:        kind,
+		Help:        options.Help,
+		ConstLabels: options.ConstLabels,
+	}
+	if len(options.Labels) > 0 {
+		schema.Labels = options.Labels
+	}
+	switch kind {
+	case "histogram":
+		schema.Buckets = options.Buckets
+		if len(schema.Buckets) == 0 {
+			schema.Buckets = prometheus.DefBuckets
+		}
+	case "summary":
+		schema.Objectives = options.Objectives
+	}
+	return schema
+}
+
+// dryRunProvider logs and records every registration without exporting anything
+type dryRunProvider struct {
+	noopProvider
+	config PrometheusConfig
+	logger logx.Logger
+
+	mu      sync.Mutex
+	schemas map[string]MetricSchema
+}
+
+// newDryRunProvider creates a provider for dry-run mode
+func newDryRunProvider(cfg Config, logger logx.Logger) Provider {
+	return &dryRunProvider{
+		config:  cfg.Prometheus,
+		logger:  logger,
+		schemas: make(map[string]MetricSchema),
+	}
+}
+
+// DryRunReport returns the metrics registered with a provider in dry-run mode,
+// sorted by name, or nil for any other provider
+func DryRunReport(provider Provider) []MetricSchema {
+	p, ok := provider.(*dryRunProvider)
+	if !ok {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.SortedFunc(maps.Values(p.schemas), func(a, b MetricSchema) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Type, b.Type))
+	})
+}
+
+func (p *dryRunProvider) Counter(name string, options *Options) Counter {
+	p.record("counter", name, options)
+	return p.noopProvider.Counter(name, options)
+}
+
+func (p *dryRunProvider) Gauge(name string, options *Options) Gauge {
+	p.record("gauge", name, options)
+	return p.noopProvider.Gauge(name, options)
+}
+
+func (p *dryRunProvider) Histogram(name string, options *Options) Histogram {
+	p.record("histogram", name, options)
+	return p.noopProvider.Histogram(name, options)
+}
+
+func (p *dryRunProvider) Summary(name string, options *Options) Summary {
+	p.record("summary", name, options)
+	return p.noopProvider.Summary(name, options)
+}
+
+func (p *dryRunProvider) GaugeFunc(name string, fn func() float64, options *Options) {
+	p.record("gauge", name, options)
+}
+
+func (p *dryRunProvider) CounterFunc(name string, fn func() float64, options *Options) {
+	p.record("counter", name, options)
+}
+
+func (p *dryRunProvider) Start(ctx context.Context) error {
+	p.logger.Info("metrics dry run enabled, nothing is exported")
+	return nil
+}
+
+func (p *dryRunProvider) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.logger.Info("metrics dry run finished", logx.Int("metrics", len(p.schemas)))
+	return nil
+}
+
+// record logs the first registration of a metric and adds it to the report
+func (p *dryRunProvider) record(kind, name string, options *Options) {
+	schema := newMetricSchema(kind, cmp.Or(options.Namespace, p.config.Namespace), cmp.Or(options.Subsystem, p.config.Subsystem), name, options)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := kind + " " + schema.Name
+	if _, exists := p.schemas[key]; exists {
+		return
+	}
+	p.schemas[key] = schema
+
+	p.logger.Info("metrics dry run registration",
+		logx.String("name", schema.Name),
+		logx.String("type", schema.Type),
+		logx.Any("labels", schema.Labels),
+		logx.Any("const_labels", schema.ConstLabels),
+		logx.Any("buckets", schema.Buckets),
+		logx.Any("objectives", schema.Objectives),
+	)
+}
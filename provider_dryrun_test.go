@@ -0,0 +1,40 @@
+package metricsx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunProvider(t *testing.T) {
+	cfg := Config{
+		Provider:   "prometheus",
+		DryRun:     true,
+		Prometheus: PrometheusConfig{Namespace: "shop", Path: "/metrics", Port: 19096},
+	}
+
+	res, err := NewMetrics(Params{Config: cfg, Logger: getTestLogger()})
+	require.NoError(t, err)
+
+	res.Metrics.Counter("orders_total", WithHelp("Orders placed"), WithLabels("status")).Inc("ok")
+	res.Metrics.Counter("orders_total", WithHelp("Orders placed"), WithLabels("status")).Inc("failed")
+	res.Metrics.Histogram("checkout_seconds", WithSubsystem("web"), WithBuckets(0.1, 1))
+	res.Metrics.Summary("payload_bytes", WithObjectives(map[float64]float64{0.5: 0.05}))
+	res.Metrics.GaugeFunc("queue_depth", func() float64 { return 1 })
+
+	ctx := context.Background()
+	require.NoError(t, res.Provider.Start(ctx))
+	assert.Empty(t, res.Provider.Addr())
+	require.NoError(t, res.Provider.Stop(ctx))
+
+	assert.Equal(t, []MetricSchema{
+		{Name: "shop_orders_total", Type: "counter", Help: "Orders placed", Labels: []string{"status"}},
+		{Name: "shop_payload_bytes", Type: "summary", Objectives: map[float64]float64{0.5: 0.05}},
+		{Name: "shop_queue_depth", Type: "gauge"},
+		{Name: "shop_web_checkout_seconds", Type: "histogram", Buckets: []float64{0.1, 1}},
+	}, DryRunReport(res.Provider))
+
+	assert.Nil(t, DryRunReport(newNoopProvider()))
+}
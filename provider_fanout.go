@@ -0,0 +1,503 @@
+package metricsx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gostratum/core/logx"
+)
+
+// FanoutConfig contains configuration for the fanout provider
+type FanoutConfig struct {
+	// Providers are the providers that receive metrics, e.g. [prometheus, pushgateway]
+	Providers []string `mapstructure:"providers"`
+
+	// Routes send matching metrics to a subset of Providers. The first
+	// matching route wins; metrics matching no route go to every provider.
+	Routes []RouteConfig `mapstructure:"routes"`
+}
+
+// RouteConfig sends metrics matching all of its patterns to Providers. Patterns
+// use the same syntax as FilterConfig; an empty pattern matches anything.
+type RouteConfig struct {
+	// Namespace pattern matched against the metric namespace
+	Namespace string `mapstructure:"namespace"`
+
+	// Subsystem pattern matched against the metric subsystem
+	Subsystem string `mapstructure:"subsystem"`
+
+	// Name pattern matched against the metric name
+	Name string `mapstructure:"name"`
+
+	// Providers receiving matching metrics
+	Providers []string `mapstructure:"providers"`
+}
+
+// route is a compiled RouteConfig
+type route struct {
+	namespace []func(string) bool
+	subsystem []func(string) bool
+	name      []func(string) bool
+	providers []Provider
+}
+
+// matches reports whether the metric matches every pattern of the route
+func (r route) matches(namespace, subsystem, name string) bool {
+	return matchPattern(r.namespace, namespace) &&
+		matchPattern(r.subsystem, subsystem) &&
+		matchPattern(r.name, name)
+}
+
+// matchPattern reports whether name matches the optional pattern
+func matchPattern(matchers []func(string) bool, name string) bool {
+	return len(matchers) == 0 || matchAny(matchers, name)
+}
+
+// fanoutProvider sends metrics to several providers
+type fanoutProvider struct {
+	config    FanoutConfig
+	namespace string
+	subsystem string
+	names     []string
+	providers []Provider
+	routes    []route
+	logger    logx.Logger
+	module    *metricsImpl
+}
+
+// newFanoutProvider creates the configured member providers and routes
+func newFanoutProvider(cfg Config, logger logx.Logger) (Provider, error) {
+	if len(cfg.Fanout.Providers) == 0 {
+		return nil, errors.New("metrics fanout requires at least one provider")
+	}
+
+	p := &fanoutProvider{
+		config:    cfg.Fanout,
+		namespace: cfg.Prometheus.Namespace,
+		subsystem: cfg.Prometheus.Subsystem,
+		logger:    logger,
+	}
+
+	members := make(map[string]Provider, len(cfg.Fanout.Providers))
+	for _, name := range cfg.Fanout.Providers {
+		if name == "fanout" {
+			return nil, errors.New("metrics fanout cannot contain itself")
+		}
+		if _, ok := members[name]; ok {
+			return nil, fmt.Errorf("metrics fanout provider %q listed twice", name)
+		}
+
+		member, err := newProvider(name, cfg, logger)
+		if err != nil {
+			return nil, fmt.Errorf("metrics fanout: %v", err)
+		}
+		members[name] = member
+		p.names = append(p.names, name)
+		p.providers = append(p.providers, member)
+	}
+
+	for i, rc := range cfg.Fanout.Routes {
+		r := route{}
+		for _, pattern := range []struct {
+			value  string
+			target *[]func(string) bool
+		}{
+			{rc.Namespace, &r.namespace},
+			{rc.Subsystem, &r.subsystem},
+			{rc.Name, &r.name},
+		} {
+			if pattern.value == "" {
+				continue
+			}
+			matchers, err := compilePatterns([]string{pattern.value})
+			if err != nil {
+				return nil, fmt.Errorf("metrics fanout route %d: %w", i, err)
+			}
+			*pattern.target = matchers
+		}
+
+		if len(rc.Providers) == 0 {
+			return nil, fmt.Errorf("metrics fanout route %d: providers are required", i)
+		}
+		for _, name := range rc.Providers {
+			member, ok := members[name]
+			if !ok {
+				return nil, fmt.Errorf("metrics fanout route %d: provider %q is not in fanout providers", i, name)
+			}
+			r.providers = append(r.providers, member)
+		}
+		p.routes = append(p.routes, r)
+	}
+
+	p.shareHealthChecks()
+	return p, nil
+}
+
+// shareHealthChecks makes the health endpoint of the Prometheus member also
+// report the state of the other members, such as the last push
+func (p *fanoutProvider) shareHealthChecks() {
+	var server *prometheusProvider
+	for _, member := range p.providers {
+		if prom, ok := member.(*prometheusProvider); ok {
+			server = prom
+			break
+		}
+	}
+	if server == nil {
+		return
+	}
+
+	for i, member := range p.providers {
+		if checker, ok := member.(healthChecker); ok && member != Provider(server) {
+			server.addHealthChecks(p.names[i], checker.healthChecks())
+		}
+	}
+}
+
+// targets returns the providers that receive the metric
+func (p *fanoutProvider) targets(name string, options *Options) []Provider {
+	namespace := options.Namespace
+	if namespace == "" {
+		namespace = p.namespace
+	}
+	subsystem := options.Subsystem
+	if subsystem == "" {
+		subsystem = p.subsystem
+	}
+
+	for _, r := range p.routes {
+		if r.matches(namespace, subsystem, name) {
+			return r.providers
+		}
+	}
+	return p.providers
+}
+
+// Counter creates the counter on every target provider
+func (p *fanoutProvider) Counter(name string, options *Options) Counter {
+	targets := p.targets(name, options)
+	if len(targets) == 1 {
+		return targets[0].Counter(name, options)
+	}
+
+	counters := make(fanoutCounter, len(targets))
+	for i, target := range targets {
+		counters[i] = target.Counter(name, options)
+	}
+	return counters
+}
+
+// Gauge creates the gauge on every target provider
+func (p *fanoutProvider) Gauge(name string, options *Options) Gauge {
+	targets := p.targets(name, options)
+	if len(targets) == 1 {
+		return targets[0].Gauge(name, options)
+	}
+
+	gauges := make(fanoutGauge, len(targets))
+	for i, target := range targets {
+		gauges[i] = target.Gauge(name, options)
+	}
+	return gauges
+}
+
+// Histogram creates the histogram on every target provider
+func (p *fanoutProvider) Histogram(name string, options *Options) Histogram {
+	targets := p.targets(name, options)
+	if len(targets) == 1 {
+		return targets[0].Histogram(name, options)
+	}
+
+	histograms := make(fanoutHistogram, len(targets))
+	for i, target := range targets {
+		histograms[i] = target.Histogram(name, options)
+	}
+	return histograms
+}
+
+// Summary creates the summary on every target provider
+func (p *fanoutProvider) Summary(name string, options *Options) Summary {
+	targets := p.targets(name, options)
+	if len(targets) == 1 {
+		return targets[0].Summary(name, options)
+	}
+
+	summaries := make(fanoutSummary, len(targets))
+	for i, target := range targets {
+		summaries[i] = target.Summary(name, options)
+	}
+	return summaries
+}
+
+// GaugeFunc registers the gauge on every target provider
+func (p *fanoutProvider) GaugeFunc(name string, fn func() float64, options *Options) {
+	for _, target := range p.targets(name, options) {
+		target.GaugeFunc(name, fn, options)
+	}
+}
+
+// CounterFunc registers the counter on every target provider
+func (p *fanoutProvider) CounterFunc(name string, fn func() float64, options *Options) {
+	for _, target := range p.targets(name, options) {
+		target.CounterFunc(name, fn, options)
+	}
+}
+
+// RegisterFunc registers fn on every provider
+func (p *fanoutProvider) RegisterFunc(fn func(ch chan<- Sample), options *Options) {
+	for _, provider := range p.providers {
+		provider.RegisterFunc(fn, options)
+	}
+}
+
+// OnScrape registers fn with every member that supports scrape hooks, so it
+// runs before each gather of any of them
+func (p *fanoutProvider) OnScrape(fn func(ctx context.Context) error) {
+	for _, provider := range p.providers {
+		if h, ok := provider.(ScrapeHooker); ok {
+			h.OnScrape(fn)
+		}
+	}
+}
+
+// Start starts every provider, stopping the ones already started on failure
+func (p *fanoutProvider) Start(ctx context.Context) error {
+	for i, provider := range p.providers {
+		if err := provider.Start(ctx); err != nil {
+			for _, started := range slices.Backward(p.providers[:i]) {
+				started.Stop(ctx)
+			}
+			return fmt.Errorf("start metrics provider %s: %w", p.names[i], err)
+		}
+	}
+	return nil
+}
+
+// Stop stops every provider in reverse order
+func (p *fanoutProvider) Stop(ctx context.Context) error {
+	var errs []error
+	for i, provider := range slices.Backward(p.providers) {
+		if err := provider.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stop metrics provider %s: %w", p.names[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Addr returns the first listen address among the members
+func (p *fanoutProvider) Addr() string {
+	for _, provider := range p.providers {
+		if addr := provider.Addr(); addr != "" {
+			return addr
+		}
+	}
+	return ""
+}
+
+// Handler returns the scrape handler of the first member that serves one
+func (p *fanoutProvider) Handler() http.Handler {
+	for _, provider := range p.providers {
+		if h, ok := provider.(interface{ Handler() http.Handler }); ok {
+			return h.Handler()
+		}
+	}
+	return http.NotFoundHandler()
+}
+
+// Handlers returns the handlers of the first member that serves them
+func (p *fanoutProvider) Handlers() map[string]http.Handler {
+	for _, provider := range p.providers {
+		if h, ok := provider.(multiHandler); ok {
+			return h.Handlers()
+		}
+	}
+	return nil
+}
+
+// Snapshot returns the snapshot of the first member that supports it
+func (p *fanoutProvider) Snapshot() ([]FamilySnapshot, error) {
+	for _, provider := range p.providers {
+		if s, ok := provider.(Snapshotter); ok {
+			return s.Snapshot()
+		}
+	}
+	return nil, nil
+}
+
+// Subscribe streams the changes of the first member that supports it; the
+// channel is closed right away when no member does
+func (p *fanoutProvider) Subscribe(filter FilterConfig) <-chan MetricUpdate {
+	if s, ok := p.subscriber(); ok {
+		return s.Subscribe(filter)
+	}
+	ch := make(chan MetricUpdate)
+	close(ch)
+	return ch
+}
+
+// Unsubscribe stops the updates of ch and closes it
+func (p *fanoutProvider) Unsubscribe(ch <-chan MetricUpdate) {
+	if s, ok := p.subscriber(); ok {
+		s.Unsubscribe(ch)
+	}
+}
+
+// subscriber returns the first member that streams metric changes
+func (p *fanoutProvider) subscriber() (Subscriber, bool) {
+	for _, provider := range p.providers {
+		if s, ok := provider.(Subscriber); ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// Reload applies cfg to every member that supports reloading, then the
+// module-level settings
+func (p *fanoutProvider) Reload(cfg Config) error {
+	if cfg.Provider != "fanout" {
+		return fmt.Errorf("%w: provider", ErrRestartRequired)
+	}
+	if !reflect.DeepEqual(cfg.Fanout, p.config) {
+		return fmt.Errorf("%w: fanout", ErrRestartRequired)
+	}
+
+	fields, applyModule, err := p.module.prepareReload(cfg)
+	if err != nil {
+		return err
+	}
+	if len(fields) > 0 {
+		return fmt.Errorf("%w: %s", ErrRestartRequired, strings.Join(fields, ", "))
+	}
+
+	for i, provider := range p.providers {
+		r, ok := provider.(Reloadable)
+		if !ok {
+			continue
+		}
+
+		member := cfg
+		member.Provider = p.names[i]
+		if err := r.Reload(member); err != nil {
+			return fmt.Errorf("reload metrics provider %s: %w", p.names[i], err)
+		}
+	}
+	applyModule()
+	return nil
+}
+
+// setModule makes Reload apply the module-level settings of m
+func (p *fanoutProvider) setModule(m *metricsImpl) {
+	p.module = m
+}
+
+// fanoutCounter forwards to several counters
+type fanoutCounter []Counter
+
+func (c fanoutCounter) Inc(labels ...string) {
+	for _, counter := range c {
+		counter.Inc(labels...)
+	}
+}
+
+func (c fanoutCounter) Add(value float64, labels ...string) {
+	for _, counter := range c {
+		counter.Add(value, labels...)
+	}
+}
+
+func (c fanoutCounter) IncCtx(ctx context.Context, labels ...string) {
+	for _, counter := range c {
+		counter.IncCtx(ctx, labels...)
+	}
+}
+
+func (c fanoutCounter) AddCtx(ctx context.Context, value float64, labels ...string) {
+	for _, counter := range c {
+		counter.AddCtx(ctx, value, labels...)
+	}
+}
+
+// fanoutGauge forwards to several gauges
+type fanoutGauge []Gauge
+
+func (g fanoutGauge) Set(value float64, labels ...string) {
+	for _, gauge := range g {
+		gauge.Set(value, labels...)
+	}
+}
+
+func (g fanoutGauge) Inc(labels ...string) {
+	for _, gauge := range g {
+		gauge.Inc(labels...)
+	}
+}
+
+func (g fanoutGauge) Dec(labels ...string) {
+	for _, gauge := range g {
+		gauge.Dec(labels...)
+	}
+}
+
+func (g fanoutGauge) Add(value float64, labels ...string) {
+	for _, gauge := range g {
+		gauge.Add(value, labels...)
+	}
+}
+
+func (g fanoutGauge) Sub(value float64, labels ...string) {
+	for _, gauge := range g {
+		gauge.Sub(value, labels...)
+	}
+}
+
+// fanoutHistogram forwards to several histograms
+type fanoutHistogram []Histogram
+
+func (h fanoutHistogram) Observe(value float64, labels ...string) {
+	for _, histogram := range h {
+		histogram.Observe(value, labels...)
+	}
+}
+
+func (h fanoutHistogram) ObserveCtx(ctx context.Context, value float64, labels ...string) {
+	for _, histogram := range h {
+		histogram.ObserveCtx(ctx, value, labels...)
+	}
+}
+
+func (h fanoutHistogram) Timer(labels ...string) Timer {
+	return &fanoutTimer{histogram: h, labels: labels, start: time.Now()}
+}
+
+// fanoutSummary forwards to several summaries
+type fanoutSummary []Summary
+
+func (s fanoutSummary) Observe(value float64, labels ...string) {
+	for _, summary := range s {
+		summary.Observe(value, labels...)
+	}
+}
+
+// fanoutTimer observes one duration into every histogram
+type fanoutTimer struct {
+	histogram fanoutHistogram
+	labels    []string
+	start     time.Time
+}
+
+func (t *fanoutTimer) ObserveDuration() {
+	t.Stop()
+}
+
+func (t *fanoutTimer) Stop() time.Duration {
+	duration := time.Since(t.start)
+	t.histogram.Observe(duration.Seconds(), t.labels...)
+	return duration
+}
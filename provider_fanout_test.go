@@ -0,0 +1,90 @@
+package metricsx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFanoutProvider(t *testing.T) {
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer gateway.Close()
+
+	cfg := Config{
+		Enabled:  true,
+		Provider: "fanout",
+		Fanout: FanoutConfig{
+			Providers: []string{"prometheus", "pushgateway"},
+			Routes: []RouteConfig{
+				{Subsystem: "billing", Providers: []string{"pushgateway"}},
+				{Name: "re:debug_.+", Providers: []string{"prometheus"}},
+			},
+		},
+		Prometheus:  PrometheusConfig{Path: "/metrics"},
+		Push:        PushConfig{Interval: time.Hour},
+		Pushgateway: PushgatewayConfig{URL: gateway.URL, Job: "test"},
+	}
+
+	t.Run("routes metrics to member providers", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: cfg, Logger: getTestLogger()})
+		require.NoError(t, err)
+
+		res.Metrics.Counter("invoices_total", WithSubsystem("billing")).Inc()
+		res.Metrics.Gauge("debug_queue_depth").Set(3)
+		res.Metrics.Histogram("request_seconds").Timer().ObserveDuration()
+
+		fanout := res.Provider.(*fanoutProvider)
+		prom := scrape(t, fanout.providers[0])
+		push := scrape(t, fanout.providers[1])
+
+		assert.NotContains(t, prom, "billing_invoices_total")
+		assert.Contains(t, push, "billing_invoices_total 1")
+		assert.Contains(t, prom, "debug_queue_depth 3")
+		assert.NotContains(t, push, "debug_queue_depth")
+		assert.Contains(t, prom, "request_seconds_count 1")
+		assert.Contains(t, push, "request_seconds_count 1")
+
+		assert.Contains(t, scrape(t, res.Provider), "debug_queue_depth 3")
+	})
+
+	t.Run("starts, reloads and stops every member", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: cfg, Logger: getTestLogger()})
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		require.NoError(t, res.Provider.Start(ctx))
+
+		next := cfg
+		next.Push.Interval = time.Minute
+		require.NoError(t, res.Provider.(Reloadable).Reload(next))
+		assert.Equal(t, time.Minute, res.Provider.(*fanoutProvider).providers[1].(*pushgatewayProvider).loop.config.Load().Interval)
+
+		next.Fanout.Routes = nil
+		assert.ErrorIs(t, res.Provider.(Reloadable).Reload(next), ErrRestartRequired)
+
+		require.NoError(t, res.Provider.Stop(ctx))
+	})
+
+	t.Run("rejects invalid configuration", func(t *testing.T) {
+		for _, fanout := range []FanoutConfig{
+			{},
+			{Providers: []string{"prometheus", "statsd"}},
+			{Providers: []string{"fanout"}},
+			{Providers: []string{"prometheus", "prometheus"}},
+			{Providers: []string{"prometheus"}, Routes: []RouteConfig{{Name: "x_*", Providers: []string{"noop"}}}},
+			{Providers: []string{"prometheus"}, Routes: []RouteConfig{{Name: "x_*"}}},
+			{Providers: []string{"prometheus"}, Routes: []RouteConfig{{Name: "re:(", Providers: []string{"prometheus"}}}},
+		} {
+			bad := cfg
+			bad.Fanout = fanout
+
+			_, err := NewMetrics(Params{Config: bad, Logger: getTestLogger()})
+			assert.Error(t, err, "%+v", fanout)
+		}
+	})
+}
@@ -2,11 +2,15 @@ package metricsx
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 )
 
 // noopProvider implements a no-op metrics provider for testing
-type noopProvider struct{}
+type noopProvider struct {
+	module *metricsImpl
+}
 
 // newNoopProvider creates a new no-op provider
 func newNoopProvider() Provider {
@@ -29,6 +33,29 @@ func (p *noopProvider) Summary(name string, options *Options) Summary {
 	return &noopSummary{}
 }
 
+func (p *noopProvider) GaugeFunc(name string, fn func() float64, options *Options) {}
+
+func (p *noopProvider) CounterFunc(name string, fn func() float64, options *Options) {}
+
+func (p *noopProvider) RegisterFunc(fn func(ch chan<- Sample), options *Options) {}
+
+func (p *noopProvider) Reload(cfg Config) error {
+	fields, applyModule, err := p.module.prepareReload(cfg)
+	if err != nil {
+		return err
+	}
+	if len(fields) > 0 {
+		return fmt.Errorf("%w: %s", ErrRestartRequired, strings.Join(fields, ", "))
+	}
+	applyModule()
+	return nil
+}
+
+// setModule makes Reload apply the module-level settings of m
+func (p *noopProvider) setModule(m *metricsImpl) {
+	p.module = m
+}
+
 func (p *noopProvider) Start(ctx context.Context) error {
 	return nil
 }
@@ -37,10 +64,16 @@ func (p *noopProvider) Stop(ctx context.Context) error {
 	return nil
 }
 
+func (p *noopProvider) Addr() string {
+	return ""
+}
+
 type noopCounter struct{}
 
-func (c *noopCounter) Inc(labels ...string)                {}
-func (c *noopCounter) Add(value float64, labels ...string) {}
+func (c *noopCounter) Inc(labels ...string)                                        {}
+func (c *noopCounter) Add(value float64, labels ...string)                         {}
+func (c *noopCounter) IncCtx(ctx context.Context, labels ...string)                {}
+func (c *noopCounter) AddCtx(ctx context.Context, value float64, labels ...string) {}
 
 type noopGauge struct{}
 
@@ -52,18 +85,20 @@ func (g *noopGauge) Sub(value float64, labels ...string) {}
 
 type noopHistogram struct{}
 
-func (h *noopHistogram) Observe(value float64, labels ...string) {}
+func (h *noopHistogram) Observe(value float64, labels ...string)                         {}
+func (h *noopHistogram) ObserveCtx(ctx context.Context, value float64, labels ...string) {}
 func (h *noopHistogram) Timer(labels ...string) Timer {
-	return &noopTimer{}
+	return sharedNoopTimer
 }
 
 type noopSummary struct{}
 
 func (s *noopSummary) Observe(value float64, labels ...string) {}
 
-type noopTimer struct {
-	start time.Time
-}
+type noopTimer struct{}
+
+// sharedNoopTimer is returned by every noop histogram, as noop timers hold no state
+var sharedNoopTimer = &noopTimer{}
 
 func (t *noopTimer) ObserveDuration()    {}
 func (t *noopTimer) Stop() time.Duration { return 0 }
@@ -66,6 +66,14 @@ func TestNoopProvider(t *testing.T) {
 		summary.Observe(1.5, "label1")
 	})
 
+	t.Run("noop value funcs", func(t *testing.T) {
+		provider := newNoopProvider()
+
+		// Should not panic or call the functions
+		provider.GaugeFunc("test_gauge_func", func() float64 { panic("called") }, &Options{})
+		provider.CounterFunc("test_counter_func", func() float64 { panic("called") }, &Options{})
+	})
+
 	t.Run("noop timer", func(t *testing.T) {
 		provider := newNoopProvider()
 		histogram := provider.Histogram("test", &Options{})
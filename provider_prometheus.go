@@ -1,200 +1,434 @@
 package metricsx
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gostratum/core/logx"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 )
 
 // prometheusProvider implements the Provider interface for Prometheus
 type prometheusProvider struct {
-	config   PrometheusConfig
-	logger   logx.Logger
-	registry *prometheus.Registry
-	server   *http.Server
-
-	mu         sync.RWMutex
-	counters   map[string]*prometheusCounterVec
-	gauges     map[string]*prometheusGaugeVec
-	histograms map[string]*prometheusHistogramVec
-	summaries  map[string]*prometheusSummaryVec
+	config    PrometheusConfig
+	logger    logx.Logger
+	registry  *prometheus.Registry
+	server    *http.Server
+	listener  net.Listener
+	auth      atomic.Pointer[AuthConfig]
+	allowlist atomic.Pointer[networkAllowlist]
+	filter    atomic.Pointer[metricFilter]
+
+	endpoints   []endpoint
+	handlerOpts promhttp.HandlerOpts
+
+	processCollector   prometheus.Collector
+	goCollector        prometheus.Collector
+	buildInfoCollector prometheus.Collector
+	scrapeCollector    *scrapeMetrics
+	selfCollector      *selfMetrics
+	calibrator         *bucketCalibrator
+	history            *metricHistory
+	subscriptions      *subscriptions
+	scrapeHooks        scrapeHooks
+	module             *metricsImpl
+
+	// gatherFailed records whether the last gather failed and
+	// extraHealthChecks the checks of other fanout members, for the health
+	// endpoint
+	gatherFailed      atomic.Bool
+	extraHealthChecks []healthCheck
+
+	mu      sync.RWMutex
+	metrics *metricShards
 }
 
 // newPrometheusProvider creates a new Prometheus provider
 func newPrometheusProvider(config PrometheusConfig, logger logx.Logger) Provider {
-	registry := prometheus.NewRegistry()
+	p := &prometheusProvider{
+		config:             config,
+		logger:             logger,
+		registry:           prometheus.NewRegistry(),
+		processCollector:   prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		goCollector:        newGoCollectorOrDefault(config.GoMetricsRules, config.EnableAllGoMetrics, logger),
+		buildInfoCollector: collectors.NewBuildInfoCollector(),
+		scrapeCollector:    newScrapeMetrics(),
+		selfCollector:      newSelfMetrics(),
+		calibrator:         newBucketCalibrator(config.BucketCalibration, logger),
+		metrics:            newMetricShards(),
+	}
+	p.history = newMetricHistory(config.History, p)
+	p.subscriptions = newSubscriptions(p, config.SubscriptionInterval)
+	p.auth.Store(&config.Auth)
+	p.scrapeHooks.setTimeout(config.ScrapeHookTimeout)
+
+	allowlist, err := newNetworkAllowlist(config.AllowedNetworks)
+	if err != nil {
+		logger.Error("refusing every metrics client", logx.Err(err))
+		allowlist = denyAllClients
+	}
+	p.allowlist.Store(&allowlist)
 
-	// Register default collectors if enabled
-	if config.EnableProcessMetrics {
-		registry.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	endpoints, err := compileEndpoints(config)
+	if err != nil {
+		logger.Warn("ignoring metrics endpoints", logx.Err(err))
 	}
-	if config.EnableGoMetrics {
-		registry.MustRegister(prometheus.NewGoCollector())
+	p.endpoints = endpoints
+
+	opts, err := handlerOpts(config, logger)
+	if err != nil {
+		logger.Warn("ignoring metrics handler options", logx.Err(err))
 	}
+	p.handlerOpts = opts
+
+	// Register default collectors if enabled
+	p.toggleCollector(p.processCollector, false, config.EnableProcessMetrics)
+	p.toggleCollector(p.goCollector, false, config.EnableGoMetrics)
+	p.toggleCollector(p.buildInfoCollector, false, config.EnableBuildInfoMetrics)
+	p.toggleCollector(p.scrapeCollector, false, config.EnableHandlerMetrics)
+	p.toggleCollector(p.selfCollector, false, config.EnableSelfMetrics)
+
+	return p
+}
 
-	return &prometheusProvider{
-		config:     config,
-		logger:     logger,
-		registry:   registry,
-		counters:   make(map[string]*prometheusCounterVec),
-		gauges:     make(map[string]*prometheusGaugeVec),
-		histograms: make(map[string]*prometheusHistogramVec),
-		summaries:  make(map[string]*prometheusSummaryVec),
+// newPrometheusProviderFromConfig creates a Prometheus provider and applies the
+// module-wide settings in cfg
+func newPrometheusProviderFromConfig(cfg Config, logger logx.Logger) (Provider, error) {
+	filter, err := newMetricFilter(cfg.Filter)
+	if err != nil {
+		return nil, err
 	}
+	if _, err := newGoCollector(cfg.Prometheus.GoMetricsRules, cfg.Prometheus.EnableAllGoMetrics); err != nil {
+		return nil, err
+	}
+	if _, err := compileEndpoints(cfg.Prometheus); err != nil {
+		return nil, err
+	}
+	if _, err := handlerOpts(cfg.Prometheus, logger); err != nil {
+		return nil, err
+	}
+	if _, err := newNetworkAllowlist(cfg.Prometheus.AllowedNetworks); err != nil {
+		return nil, err
+	}
+
+	p := newPrometheusProvider(cfg.Prometheus, logger).(*prometheusProvider)
+	p.filter.Store(filter)
+	return p, nil
 }
 
 // Counter creates or retrieves a counter metric
 func (p *prometheusProvider) Counter(name string, options *Options) Counter {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	key := p.metricKey(name, options)
-	if c, exists := p.counters[key]; exists {
+	var buf [metricKeySize]byte
+	key := p.appendMetricKey(buf[:0], name, options)
+	shard := p.metrics.get(key)
+	if c, exists := lookup(shard, shard.counters, key); exists {
 		return c
 	}
 
-	counterVec := prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Namespace: p.namespace(options),
-			Subsystem: p.subsystem(options),
-			Name:      name,
-			Help:      options.Help,
-		},
-		options.Labels,
-	)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
-	p.registry.MustRegister(counterVec)
+	if c, exists := shard.counters[string(key)]; exists {
+		return c
+	}
+	p.checkSchema("counter", name, options)
+
+	opts := prometheus.CounterOpts{
+		Namespace:   p.namespace(options),
+		Subsystem:   p.subsystem(options),
+		Name:        name,
+		Help:        options.Help,
+		ConstLabels: options.ConstLabels,
+	}
 
-	counter := &prometheusCounterVec{
-		vec:    counterVec,
-		labels: options.Labels,
+	var counter Counter
+	if options.Sharded {
+		vec := newShardedCounterVec(opts, options.Labels)
+		p.register(vec)
+		counter = &prometheusShardedCounterVec{vec: vec}
+	} else {
+		counterVec := prometheus.NewCounterVec(opts, options.Labels)
+		children := newChildCache(counterVec.WithLabelValues)
+		p.register(expiring(counterVec, children, options.TTL, p.expiredCounter(name, options)))
+		counter = &prometheusCounterVec{
+			vec:      counterVec,
+			labels:   options.Labels,
+			children: children,
+		}
 	}
 
-	p.counters[key] = counter
+	p.recordSchema(shard, key, "counter", name, options)
+	shard.counters[string(key)] = counter
 	return counter
 }
 
 // Gauge creates or retrieves a gauge metric
 func (p *prometheusProvider) Gauge(name string, options *Options) Gauge {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	var buf [metricKeySize]byte
+	key := p.appendMetricKey(buf[:0], name, options)
+	shard := p.metrics.get(key)
+	if g, exists := lookup(shard, shard.gauges, key); exists {
+		return g
+	}
 
-	key := p.metricKey(name, options)
-	if g, exists := p.gauges[key]; exists {
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if g, exists := shard.gauges[string(key)]; exists {
 		return g
 	}
+	p.checkSchema("gauge", name, options)
 
 	gaugeVec := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Namespace: p.namespace(options),
-			Subsystem: p.subsystem(options),
-			Name:      name,
-			Help:      options.Help,
+			Namespace:   p.namespace(options),
+			Subsystem:   p.subsystem(options),
+			Name:        name,
+			Help:        options.Help,
+			ConstLabels: options.ConstLabels,
 		},
 		options.Labels,
 	)
 
-	p.registry.MustRegister(gaugeVec)
+	children := newChildCache(gaugeVec.WithLabelValues)
+	p.register(expiring(gaugeVec, children, options.TTL, p.expiredCounter(name, options)))
 
 	gauge := &prometheusGaugeVec{
-		vec:    gaugeVec,
-		labels: options.Labels,
+		vec:      gaugeVec,
+		labels:   options.Labels,
+		children: children,
 	}
 
-	p.gauges[key] = gauge
+	p.recordSchema(shard, key, "gauge", name, options)
+	shard.gauges[string(key)] = gauge
 	return gauge
 }
 
 // Histogram creates or retrieves a histogram metric
 func (p *prometheusProvider) Histogram(name string, options *Options) Histogram {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	var buf [metricKeySize]byte
+	key := p.appendMetricKey(buf[:0], name, options)
+	shard := p.metrics.get(key)
+	if h, exists := lookup(shard, shard.histograms, key); exists {
+		return h
+	}
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
-	key := p.metricKey(name, options)
-	if h, exists := p.histograms[key]; exists {
+	if h, exists := shard.histograms[string(key)]; exists {
 		return h
 	}
+	p.checkSchema("histogram", name, options)
+
+	opts := prometheus.HistogramOpts{
+		Namespace:   p.namespace(options),
+		Subsystem:   p.subsystem(options),
+		Name:        name,
+		Help:        options.Help,
+		ConstLabels: options.ConstLabels,
+		Buckets:     options.Buckets,
+	}
+	applyNativeHistogram(&opts, options, p.config.NativeHistograms)
+	histogramVec := prometheus.NewHistogramVec(opts, options.Labels)
 
-	histogramVec := prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Namespace: p.namespace(options),
-			Subsystem: p.subsystem(options),
-			Name:      name,
-			Help:      options.Help,
-			Buckets:   options.Buckets,
-		},
-		options.Labels,
-	)
+	with := histogramVec.WithLabelValues
+	if calibration := p.calibrator.track(prometheus.BuildFQName(p.namespace(options), p.subsystem(options), name), options.Buckets); calibration != nil {
+		with = func(labels ...string) prometheus.Observer {
+			return p.calibrator.observer(calibration, histogramVec.WithLabelValues(labels...))
+		}
+	}
 
-	p.registry.MustRegister(histogramVec)
+	children := newChildCache(with)
+	p.register(expiring(histogramVec, children, options.TTL, p.expiredCounter(name, options)))
 
 	histogram := &prometheusHistogramVec{
-		vec:    histogramVec,
-		labels: options.Labels,
+		vec:      histogramVec,
+		labels:   options.Labels,
+		children: children,
 	}
 
-	p.histograms[key] = histogram
+	p.recordSchema(shard, key, "histogram", name, options)
+	shard.histograms[string(key)] = histogram
 	return histogram
 }
 
 // Summary creates or retrieves a summary metric
 func (p *prometheusProvider) Summary(name string, options *Options) Summary {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	var buf [metricKeySize]byte
+	key := p.appendMetricKey(buf[:0], name, options)
+	shard := p.metrics.get(key)
+	if s, exists := lookup(shard, shard.summaries, key); exists {
+		return s
+	}
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
-	key := p.metricKey(name, options)
-	if s, exists := p.summaries[key]; exists {
+	if s, exists := shard.summaries[string(key)]; exists {
 		return s
 	}
+	p.checkSchema("summary", name, options)
 
 	summaryVec := prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
-			Namespace:  p.namespace(options),
-			Subsystem:  p.subsystem(options),
-			Name:       name,
-			Help:       options.Help,
-			Objectives: options.Objectives,
+			Namespace:   p.namespace(options),
+			Subsystem:   p.subsystem(options),
+			Name:        name,
+			Help:        options.Help,
+			ConstLabels: options.ConstLabels,
+			Objectives:  options.Objectives,
 		},
 		options.Labels,
 	)
 
-	p.registry.MustRegister(summaryVec)
+	children := newChildCache(summaryVec.WithLabelValues)
+	p.register(expiring(summaryVec, children, options.TTL, p.expiredCounter(name, options)))
 
 	summary := &prometheusSummaryVec{
-		vec:    summaryVec,
-		labels: options.Labels,
+		vec:      summaryVec,
+		labels:   options.Labels,
+		children: children,
 	}
 
-	p.summaries[key] = summary
+	p.recordSchema(shard, key, "summary", name, options)
+	shard.summaries[string(key)] = summary
 	return summary
 }
 
+// GaugeFunc registers a gauge whose value is computed at collection time.
+// Registering the same gauge again replaces the value function.
+func (p *prometheusProvider) GaugeFunc(name string, fn func() float64, options *Options) {
+	p.valueFunc("gauge", name, fn, options, func(opts prometheus.Opts, f func() float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts(opts), f)
+	})
+}
+
+// CounterFunc registers a counter whose value is computed at collection time.
+// Registering the same counter again replaces the value function.
+func (p *prometheusProvider) CounterFunc(name string, fn func() float64, options *Options) {
+	p.valueFunc("counter", name, fn, options, func(opts prometheus.Opts, f func() float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts(opts), f)
+	})
+}
+
+// valueFunc registers a collection-time metric of kind built by newCollector
+func (p *prometheusProvider) valueFunc(kind, name string, fn func() float64, options *Options, newCollector func(prometheus.Opts, func() float64) prometheus.Collector) {
+	var buf [metricKeySize]byte
+	key := p.appendMetricKey(buf[:0], name, options)
+	shard := p.metrics.get(key)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if f, exists := shard.funcs[string(key)]; exists {
+		f.fn.Store(&fn)
+		return
+	}
+	p.checkSchema(kind, name, options)
+
+	f := &prometheusValueFunc{}
+	f.fn.Store(&fn)
+
+	p.register(newCollector(prometheus.Opts{
+		Namespace:   p.namespace(options),
+		Subsystem:   p.subsystem(options),
+		Name:        name,
+		Help:        options.Help,
+		ConstLabels: options.ConstLabels,
+	}, f.value))
+
+	p.recordSchema(shard, key, kind, name, options)
+	shard.funcs[string(key)] = f
+}
+
 // Start starts the Prometheus HTTP server if a port is configured
 func (p *prometheusProvider) Start(ctx context.Context) error {
+	p.history.start()
+
 	if p.config.Port == 0 {
-		p.logger.Info("metrics will be exposed on main HTTP server", logx.String("path", p.config.Path))
+		p.logger.Info("metrics server disabled, mount the handler on the main HTTP server", logx.String("path", p.config.Path))
 		return nil
 	}
 
-	addr := fmt.Sprintf(":%d", p.config.Port)
+	port := p.config.Port
+	if port == RandomPort {
+		port = 0
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(p.config.Host, strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("listen for metrics: %w", err)
+	}
+	addr := listener.Addr().String()
 	p.logger.Info("starting metrics HTTP server", logx.String("addr", addr), logx.String("path", p.config.Path))
 
 	mux := http.NewServeMux()
-	mux.Handle(p.config.Path, promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{}))
+	for path, handler := range p.Handlers() {
+		mux.Handle(path, handler)
+	}
+	if p.config.EnablePprof {
+		p.handlePprof(mux)
+	}
+
+	// The health endpoint is exempt from the allowlist so probes from the node
+	// still reach it
+	handler := withAllowlist(mux, func() networkAllowlist {
+		return *p.allowlist.Load()
+	})
+	if p.config.HealthPath != "" {
+		root := http.NewServeMux()
+		root.Handle(p.config.HealthPath, p.healthHandler())
+		root.Handle("/", handler)
+		handler = root
+	}
 
 	p.server = &http.Server{
-		Addr:    addr,
-		Handler: mux,
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       cmp.Or(p.config.ReadTimeout, DefaultReadTimeout),
+		ReadHeaderTimeout: cmp.Or(p.config.ReadHeaderTimeout, DefaultReadHeaderTimeout),
+		WriteTimeout:      cmp.Or(p.config.WriteTimeout, DefaultWriteTimeout),
+		IdleTimeout:       cmp.Or(p.config.IdleTimeout, DefaultIdleTimeout),
+		MaxHeaderBytes:    p.config.MaxHeaderBytes,
+	}
+	p.server.SetKeepAlivesEnabled(!p.config.DisableKeepAlives)
+	if p.config.DisableHTTP2 {
+		p.server.Protocols = new(http.Protocols)
+		p.server.Protocols.SetHTTP1(true)
+	}
+
+	if p.config.TLS.Enabled() {
+		tlsConfig, err := p.config.TLS.serverConfig()
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		p.server.TLSConfig = tlsConfig
 	}
+	p.listener = listener
 
 	go func() {
-		if err := p.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if p.server.TLSConfig != nil {
+			err = p.server.ServeTLS(listener, "", "")
+		} else {
+			err = p.server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			p.logger.Error("metrics HTTP server error", logx.Err(err))
 		}
 	}()
@@ -204,6 +438,9 @@ func (p *prometheusProvider) Start(ctx context.Context) error {
 
 // Stop stops the Prometheus HTTP server
 func (p *prometheusProvider) Stop(ctx context.Context) error {
+	p.history.stopSampling()
+	p.subscriptions.close()
+
 	if p.server == nil {
 		return nil
 	}
@@ -212,14 +449,219 @@ func (p *prometheusProvider) Stop(ctx context.Context) error {
 	return p.server.Shutdown(ctx)
 }
 
-// Handler returns the HTTP handler for metrics
+// Addr returns the listen address of the metrics HTTP server, or an empty
+// string when metrics are exposed on the main HTTP server
+func (p *prometheusProvider) Addr() string {
+	if p.listener == nil {
+		return ""
+	}
+	return p.listener.Addr().String()
+}
+
+// Handler returns the HTTP handler for metrics, protected by the configured auth
 func (p *prometheusProvider) Handler() http.Handler {
-	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+	return p.handlerFor(p.config.Path, p.exportGatherer())
+}
+
+// exportGatherer is gatherer running the scrape hooks first, for scrapes and
+// pushes
+func (p *prometheusProvider) exportGatherer() prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		p.runScrapeHooks()
+		return p.gatherer().Gather()
+	})
+}
+
+// gatherer returns the registry with the metric filter applied at gather time
+func (p *prometheusProvider) gatherer() prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := p.registry.Gather()
+		p.gatherFailed.Store(err != nil)
+		p.selfCollector.observeSeries(mfs)
+		return p.filter.Load().apply(mfs), err
+	})
+}
+
+// Reload applies cfg without restarting: default collectors are registered or
+// unregistered, and endpoint auth, allowed networks, the metric filter, the
+// scrape hook timeout and the module-level settings are swapped. Changes to settings that need a restart
+// are rejected with ErrRestartRequired and nothing is applied.
+func (p *prometheusProvider) Reload(cfg Config) error {
+	return p.reload(cfg, "prometheus")
+}
+
+// setModule makes Reload apply the module-level settings of m
+func (p *prometheusProvider) setModule(m *metricsImpl) {
+	p.module = m
+}
+
+// reload applies cfg for a provider registered under the given name
+func (p *prometheusProvider) reload(cfg Config, provider string) error {
+	next := cfg.Prometheus
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	moduleFields, applyModule, err := p.module.prepareReload(cfg)
+	if err != nil {
+		return err
+	}
+
+	fields := restartFields(p.config, next)
+	if cfg.Provider != provider {
+		fields = append([]string{"provider"}, fields...)
+	}
+	fields = append(fields, moduleFields...)
+	if len(fields) > 0 {
+		return fmt.Errorf("%w: %s", ErrRestartRequired, strings.Join(fields, ", "))
+	}
+
+	filter, err := newMetricFilter(cfg.Filter)
+	if err != nil {
+		return err
+	}
+	allowlist, err := newNetworkAllowlist(next.AllowedNetworks)
+	if err != nil {
+		return err
+	}
+
+	p.toggleCollector(p.processCollector, p.config.EnableProcessMetrics, next.EnableProcessMetrics)
+	p.toggleCollector(p.goCollector, p.config.EnableGoMetrics, next.EnableGoMetrics)
+	p.toggleCollector(p.buildInfoCollector, p.config.EnableBuildInfoMetrics, next.EnableBuildInfoMetrics)
+	p.toggleCollector(p.scrapeCollector, p.config.EnableHandlerMetrics, next.EnableHandlerMetrics)
+	p.toggleCollector(p.selfCollector, p.config.EnableSelfMetrics, next.EnableSelfMetrics)
+	p.auth.Store(&next.Auth)
+	p.allowlist.Store(&allowlist)
+	p.filter.Store(filter)
+	p.scrapeHooks.setTimeout(next.ScrapeHookTimeout)
+
+	p.config.EnableProcessMetrics = next.EnableProcessMetrics
+	p.config.EnableGoMetrics = next.EnableGoMetrics
+	p.config.EnableBuildInfoMetrics = next.EnableBuildInfoMetrics
+	p.config.EnableHandlerMetrics = next.EnableHandlerMetrics
+	p.config.EnableSelfMetrics = next.EnableSelfMetrics
+	p.config.Auth = next.Auth
+	p.config.AllowedNetworks = next.AllowedNetworks
+	p.config.ScrapeHookTimeout = next.ScrapeHookTimeout
+	applyModule()
+
+	p.logger.Info("metrics configuration reloaded")
+	return nil
+}
+
+// newGoCollector creates the Go collector, additionally exposing every
+// runtime/metrics metric when all is set or those whose names match one of
+// rules otherwise
+func newGoCollector(rules []string, all bool) (prometheus.Collector, error) {
+	matchers := make([]collectors.GoRuntimeMetricsRule, 0, len(rules))
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid go metrics rule %q: %w", rule, err)
+		}
+		matchers = append(matchers, collectors.GoRuntimeMetricsRule{Matcher: re})
+	}
+	if all {
+		matchers = []collectors.GoRuntimeMetricsRule{collectors.MetricsAll}
+	}
+	if len(matchers) == 0 {
+		return collectors.NewGoCollector(), nil
+	}
+	return collectors.NewGoCollector(collectors.WithGoCollectorRuntimeMetrics(matchers...)), nil
+}
+
+// newGoCollectorOrDefault is newGoCollector falling back to the default Go
+// collector when rules are invalid
+func newGoCollectorOrDefault(rules []string, all bool, logger logx.Logger) prometheus.Collector {
+	c, err := newGoCollector(rules, all)
+	if err != nil {
+		logger.Warn("ignoring go metrics rules", logx.Err(err))
+		return collectors.NewGoCollector()
+	}
+	return c
+}
+
+// register registers c, counting and panicking on failure like MustRegister
+func (p *prometheusProvider) register(c prometheus.Collector) {
+	if err := p.registry.Register(c); err != nil {
+		p.selfCollector.registrationFailed(err)
+		panic(err)
+	}
+}
+
+// checkSchema panics, like register, when the fully qualified name of the
+// metric is already registered with another type or other label names.
+// Metrics are keyed by their label names, so without it such a conflict would
+// surface as a less specific duplicate registration error from the registry.
+func (p *prometheusProvider) checkSchema(kind, name string, options *Options) {
+	fqName := prometheus.BuildFQName(p.namespace(options), p.subsystem(options), name)
+	if err := p.metrics.conflict(fqName, kind, options); err != nil {
+		p.selfCollector.registrationFailed(err)
+		panic(err)
+	}
+}
+
+// recordSchema records the schema of a registered metric under key in shard
+// and claims its name for checkSchema
+func (p *prometheusProvider) recordSchema(shard *metricShard, key []byte, kind, name string, options *Options) {
+	shard.schemas[string(key)] = newMetricSchema(kind, p.namespace(options), p.subsystem(options), name, options)
+	p.metrics.claim(prometheus.BuildFQName(p.namespace(options), p.subsystem(options), name), kind, options)
+}
+
+// expiredCounter returns the self metric counting expired series of the
+// metric, or nil when it has no TTL
+func (p *prometheusProvider) expiredCounter(name string, options *Options) prometheus.Counter {
+	if options.TTL <= 0 {
+		return nil
+	}
+	return p.selfCollector.expired.WithLabelValues(prometheus.BuildFQName(p.namespace(options), p.subsystem(options), name))
+}
+
+// toggleCollector registers or unregisters c when its enabled state changes
+func (p *prometheusProvider) toggleCollector(c prometheus.Collector, enabled, enable bool) {
+	switch {
+	case enable && !enabled:
+		p.registry.MustRegister(c)
+	case !enable && enabled:
+		p.registry.Unregister(c)
+	}
 }
 
-// metricKey generates a unique key for a metric
-func (p *prometheusProvider) metricKey(name string, options *Options) string {
-	return fmt.Sprintf("%s_%s_%s", p.namespace(options), p.subsystem(options), name)
+// appendMetricKey appends the key identifying a metric to dst: its fully
+// qualified name, label names and const labels. Callers pass a stack buffer
+// so looking up an existing metric does not allocate.
+func (p *prometheusProvider) appendMetricKey(dst []byte, name string, options *Options) []byte {
+	dst = append(dst, p.namespace(options)...)
+	dst = append(dst, '_')
+	dst = append(dst, p.subsystem(options)...)
+	dst = append(dst, '_')
+	dst = append(dst, name...)
+
+	dst = append(dst, '{')
+	for i, label := range options.Labels {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, label...)
+	}
+	dst = append(dst, '}')
+
+	// Const labels are appended in name order, sorting the names in a stack
+	// buffer large enough for the usual handful of labels
+	var buf [8]string
+	names := buf[:0]
+	for k := range options.ConstLabels {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+
+	for _, k := range names {
+		dst = append(dst, ',')
+		dst = append(dst, k...)
+		dst = append(dst, '=')
+		dst = strconv.AppendQuote(dst, options.ConstLabels[k])
+	}
+	return dst
 }
 
 // namespace returns the namespace to use for metrics
@@ -240,86 +682,109 @@ func (p *prometheusProvider) subsystem(options *Options) string {
 
 // prometheusCounterVec implements Counter
 type prometheusCounterVec struct {
-	vec    *prometheus.CounterVec
-	labels []string
+	vec      *prometheus.CounterVec
+	labels   []string
+	children *childCache[prometheus.Counter]
 }
 
 func (c *prometheusCounterVec) Inc(labels ...string) {
-	c.vec.WithLabelValues(labels...).Inc()
+	c.children.get(labels).Inc()
 }
 
 func (c *prometheusCounterVec) Add(value float64, labels ...string) {
-	c.vec.WithLabelValues(labels...).Add(value)
+	c.children.get(labels).Add(value)
+}
+
+func (c *prometheusCounterVec) IncCtx(ctx context.Context, labels ...string) {
+	addWithExemplar(ctx, c.children.get(labels), 1)
+}
+
+func (c *prometheusCounterVec) AddCtx(ctx context.Context, value float64, labels ...string) {
+	addWithExemplar(ctx, c.children.get(labels), value)
 }
 
 // prometheusGaugeVec implements Gauge
 type prometheusGaugeVec struct {
-	vec    *prometheus.GaugeVec
-	labels []string
+	vec      *prometheus.GaugeVec
+	labels   []string
+	children *childCache[prometheus.Gauge]
 }
 
 func (g *prometheusGaugeVec) Set(value float64, labels ...string) {
-	g.vec.WithLabelValues(labels...).Set(value)
+	g.children.get(labels).Set(value)
 }
 
 func (g *prometheusGaugeVec) Inc(labels ...string) {
-	g.vec.WithLabelValues(labels...).Inc()
+	g.children.get(labels).Inc()
 }
 
 func (g *prometheusGaugeVec) Dec(labels ...string) {
-	g.vec.WithLabelValues(labels...).Dec()
+	g.children.get(labels).Dec()
 }
 
 func (g *prometheusGaugeVec) Add(value float64, labels ...string) {
-	g.vec.WithLabelValues(labels...).Add(value)
+	g.children.get(labels).Add(value)
 }
 
 func (g *prometheusGaugeVec) Sub(value float64, labels ...string) {
-	g.vec.WithLabelValues(labels...).Sub(value)
+	g.children.get(labels).Sub(value)
 }
 
 // prometheusHistogramVec implements Histogram
 type prometheusHistogramVec struct {
-	vec    *prometheus.HistogramVec
-	labels []string
+	vec      *prometheus.HistogramVec
+	labels   []string
+	children *childCache[prometheus.Observer]
 }
 
 func (h *prometheusHistogramVec) Observe(value float64, labels ...string) {
-	h.vec.WithLabelValues(labels...).Observe(value)
+	h.children.get(labels).Observe(value)
+}
+
+func (h *prometheusHistogramVec) ObserveCtx(ctx context.Context, value float64, labels ...string) {
+	observeWithExemplar(ctx, h.children.get(labels), value)
 }
 
 func (h *prometheusHistogramVec) Timer(labels ...string) Timer {
 	return &prometheusTimer{
-		histogram: h,
-		labels:    labels,
-		start:     time.Now(),
+		observer: h.children.get(labels),
+		start:    time.Now(),
 	}
 }
 
 // prometheusSummaryVec implements Summary
 type prometheusSummaryVec struct {
-	vec    *prometheus.SummaryVec
-	labels []string
+	vec      *prometheus.SummaryVec
+	labels   []string
+	children *childCache[prometheus.Observer]
 }
 
 func (s *prometheusSummaryVec) Observe(value float64, labels ...string) {
-	s.vec.WithLabelValues(labels...).Observe(value)
+	s.children.get(labels).Observe(value)
+}
+
+// prometheusValueFunc holds the swappable value function of a GaugeFunc or CounterFunc
+type prometheusValueFunc struct {
+	fn atomic.Pointer[func() float64]
+}
+
+func (f *prometheusValueFunc) value() float64 {
+	return (*f.fn.Load())()
 }
 
-// prometheusTimer implements Timer
+// prometheusTimer implements Timer. It binds the histogram child when
+// started so observing the duration does not look up the labels again.
 type prometheusTimer struct {
-	histogram *prometheusHistogramVec
-	labels    []string
-	start     time.Time
+	observer prometheus.Observer
+	start    time.Time
 }
 
 func (t *prometheusTimer) ObserveDuration() {
-	duration := time.Since(t.start).Seconds()
-	t.histogram.Observe(duration, t.labels...)
+	t.observer.Observe(time.Since(t.start).Seconds())
 }
 
 func (t *prometheusTimer) Stop() time.Duration {
 	duration := time.Since(t.start)
-	t.histogram.Observe(duration.Seconds(), t.labels...)
+	t.observer.Observe(duration.Seconds())
 	return duration
 }
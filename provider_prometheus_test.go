@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -16,6 +17,17 @@ func getTestLogger() logx.Logger {
 	return logx.NewNoopLogger()
 }
 
+// scrape returns the text exposition of a provider serving an HTTP handler
+func scrape(t *testing.T, provider Provider) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	provider.(interface{ Handler() http.Handler }).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
 func TestPrometheusProvider(t *testing.T) {
 	logger := getTestLogger()
 
@@ -139,6 +151,20 @@ func TestPrometheusProvider(t *testing.T) {
 		assert.Equal(t, counter1, counter2)
 	})
 
+	t.Run("gauge and counter funcs are evaluated at collection time", func(t *testing.T) {
+		config := PrometheusConfig{Port: 0, Path: "/metrics"}
+		provider := newPrometheusProvider(config, logger)
+
+		value := 1.0
+		provider.GaugeFunc("queue_depth", func() float64 { return value }, &Options{Help: "Queue depth"})
+		provider.CounterFunc("events_total", func() float64 { return value * 10 }, &Options{Help: "Events"})
+
+		value = 3
+		out := scrape(t, provider)
+		assert.Contains(t, out, "queue_depth 3")
+		assert.Contains(t, out, "events_total 30")
+	})
+
 	t.Run("applies namespace and subsystem", func(t *testing.T) {
 		config := PrometheusConfig{Port: 0, Path: "/metrics"}
 		provider := newPrometheusProvider(config, logger)
@@ -187,6 +213,98 @@ func TestPrometheusLifecycle(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("binds to the configured host", func(t *testing.T) {
+		config := PrometheusConfig{
+			Host: "127.0.0.1",
+			Port: 19094,
+			Path: "/metrics",
+		}
+
+		provider := newPrometheusProvider(config, logger)
+
+		ctx := context.Background()
+		require.NoError(t, provider.Start(ctx))
+		defer provider.Stop(ctx)
+
+		assert.Equal(t, "127.0.0.1:19094", provider.(*prometheusProvider).server.Addr)
+
+		time.Sleep(100 * time.Millisecond)
+		resp, err := http.Get("http://127.0.0.1:19094/metrics")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("listens on a random port", func(t *testing.T) {
+		config := PrometheusConfig{
+			Host: "127.0.0.1",
+			Port: RandomPort,
+			Path: "/metrics",
+		}
+
+		provider := newPrometheusProvider(config, logger)
+		assert.Empty(t, provider.Addr())
+
+		ctx := context.Background()
+		require.NoError(t, provider.Start(ctx))
+		defer provider.Stop(ctx)
+
+		addr := provider.Addr()
+		assert.NotEqual(t, "127.0.0.1:0", addr)
+
+		resp, err := http.Get("http://" + addr + "/metrics")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("applies server timeouts", func(t *testing.T) {
+		config := PrometheusConfig{
+			Port:         19095,
+			Path:         "/metrics",
+			WriteTimeout: 5 * time.Second,
+		}
+
+		provider := newPrometheusProvider(config, logger)
+
+		ctx := context.Background()
+		require.NoError(t, provider.Start(ctx))
+		defer provider.Stop(ctx)
+
+		server := provider.(*prometheusProvider).server
+		assert.Equal(t, DefaultReadTimeout, server.ReadTimeout)
+		assert.Equal(t, DefaultReadHeaderTimeout, server.ReadHeaderTimeout)
+		assert.Equal(t, 5*time.Second, server.WriteTimeout)
+		assert.Equal(t, DefaultIdleTimeout, server.IdleTimeout)
+	})
+
+	t.Run("applies keep-alive and protocol settings", func(t *testing.T) {
+		config := PrometheusConfig{
+			Host:              "127.0.0.1",
+			Port:              RandomPort,
+			Path:              "/metrics",
+			DisableKeepAlives: true,
+			MaxHeaderBytes:    4096,
+			DisableHTTP2:      true,
+		}
+
+		provider := newPrometheusProvider(config, logger)
+
+		ctx := context.Background()
+		require.NoError(t, provider.Start(ctx))
+		defer provider.Stop(ctx)
+
+		server := provider.(*prometheusProvider).server
+		assert.Equal(t, 4096, server.MaxHeaderBytes)
+		assert.True(t, server.Protocols.HTTP1())
+		assert.False(t, server.Protocols.HTTP2())
+
+		resp, err := http.Get("http://" + provider.Addr() + "/metrics")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.True(t, resp.Close)
+	})
+
 	t.Run("skip server start when port is 0", func(t *testing.T) {
 		config := PrometheusConfig{
 			Port: 0,
@@ -198,6 +316,7 @@ func TestPrometheusLifecycle(t *testing.T) {
 		ctx := context.Background()
 		err := provider.Start(ctx)
 		assert.NoError(t, err)
+		assert.Empty(t, provider.Addr())
 
 		// Stop should be no-op
 		err = provider.Stop(ctx)
@@ -281,6 +400,33 @@ func TestPrometheusConfig(t *testing.T) {
 		assert.NotNil(t, provider)
 	})
 
+	t.Run("enables build info metrics", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{
+			Path:                   "/metrics",
+			EnableBuildInfoMetrics: true,
+		}, logger)
+
+		assert.Contains(t, scrape(t, provider), "go_build_info{")
+		assert.NotContains(t, scrape(t, newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, logger)), "go_build_info")
+	})
+
+	t.Run("exposes runtime metrics matching go metrics rules", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{
+			Path:            "/metrics",
+			EnableGoMetrics: true,
+			GoMetricsRules:  []string{"^/sched/latencies:seconds$"},
+		}, logger)
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, "go_sched_latencies_seconds_bucket")
+		assert.Contains(t, out, "go_goroutines")
+
+		_, err := newPrometheusProviderFromConfig(Config{
+			Prometheus: PrometheusConfig{GoMetricsRules: []string{"("}},
+		}, logger)
+		assert.Error(t, err)
+	})
+
 	t.Run("enables both process and go metrics", func(t *testing.T) {
 		config := PrometheusConfig{
 			Port:                 0,
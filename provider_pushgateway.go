@@ -0,0 +1,108 @@
+package metricsx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+
+	"github.com/gostratum/core/logx"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// PushgatewayConfig contains Prometheus Pushgateway configuration
+type PushgatewayConfig struct {
+	// URL of the Pushgateway, e.g. http://pushgateway:9091
+	URL string `mapstructure:"url" default:""`
+
+	// Job is the job label of the pushed group
+	Job string `mapstructure:"job" default:""`
+
+	// Grouping adds labels to the grouping key of the pushed group
+	Grouping map[string]string `mapstructure:"grouping"`
+}
+
+// pushgatewayProvider collects metrics in a Prometheus registry and pushes
+// them to a Pushgateway
+type pushgatewayProvider struct {
+	*prometheusProvider
+	config PushgatewayConfig
+	loop   *pushLoop
+}
+
+// newPushgatewayProvider creates a provider that pushes to the configured Pushgateway
+func newPushgatewayProvider(cfg Config, logger logx.Logger) (Provider, error) {
+	if cfg.Pushgateway.URL == "" || cfg.Pushgateway.Job == "" {
+		return nil, errors.New("metrics pushgateway requires url and job")
+	}
+
+	prom, err := newPrometheusProviderFromConfig(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pushgatewayProvider{
+		prometheusProvider: prom.(*prometheusProvider),
+		config:             cfg.Pushgateway,
+	}
+
+	pusher := push.New(cfg.Pushgateway.URL, cfg.Pushgateway.Job).
+		Gatherer(p.exportGatherer()).
+		Client(statusClient{client: http.DefaultClient})
+	for name, value := range cfg.Pushgateway.Grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+	p.loop = newPushLoop(cfg.Push, p.selfCollector.instrumentPush(pusher.PushContext), logger)
+	p.loop.retried = p.selfCollector.pushRetries.Inc
+
+	return p, nil
+}
+
+// Start begins pushing on the configured interval and recording the history
+func (p *pushgatewayProvider) Start(ctx context.Context) error {
+	p.history.start()
+	p.logger.Info("starting metrics push to pushgateway",
+		logx.String("url", p.config.URL),
+		logx.String("job", p.config.Job),
+	)
+	p.loop.Start()
+	return nil
+}
+
+// healthChecks returns the gather check and whether the last push failed
+func (p *pushgatewayProvider) healthChecks() []healthCheck {
+	return append(p.prometheusProvider.healthChecks(), healthCheck{name: "push", failing: p.loop.failed.Load})
+}
+
+// Stop stops pushing, flushing once more when configured
+func (p *pushgatewayProvider) Stop(ctx context.Context) error {
+	p.logger.Info("stopping metrics push to pushgateway")
+	p.history.stopSampling()
+	p.subscriptions.close()
+	return p.loop.Stop(ctx)
+}
+
+// Reload applies cfg, including new push settings
+func (p *pushgatewayProvider) Reload(cfg Config) error {
+	var fields []string
+	if cfg.Pushgateway.URL != p.config.URL {
+		fields = append(fields, "pushgateway.url")
+	}
+	if cfg.Pushgateway.Job != p.config.Job {
+		fields = append(fields, "pushgateway.job")
+	}
+	if !maps.Equal(cfg.Pushgateway.Grouping, p.config.Grouping) {
+		fields = append(fields, "pushgateway.grouping")
+	}
+	if len(fields) > 0 {
+		return fmt.Errorf("%w: %s", ErrRestartRequired, strings.Join(fields, ", "))
+	}
+
+	if err := p.reload(cfg, "pushgateway"); err != nil {
+		return err
+	}
+	p.loop.SetConfig(cfg.Push)
+	return nil
+}
@@ -0,0 +1,99 @@
+package metricsx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushgatewayProvider(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		paths  []string
+		bodies []string
+	)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gateway.Close()
+
+	cfg := Config{
+		Enabled:  true,
+		Provider: "pushgateway",
+		Push:     PushConfig{Interval: time.Hour, FlushOnShutdown: true},
+		Pushgateway: PushgatewayConfig{
+			URL:      gateway.URL,
+			Job:      "nightly_import",
+			Grouping: map[string]string{"shard": "3"},
+		},
+	}
+
+	t.Run("records the history while running", func(t *testing.T) {
+		withHistory := cfg
+		withHistory.Push.FlushOnShutdown = false
+		withHistory.Prometheus.History = HistoryConfig{Retention: time.Minute, Interval: time.Hour}
+		provider, err := newPushgatewayProvider(withHistory, getTestLogger())
+		require.NoError(t, err)
+		history := provider.(*pushgatewayProvider).history
+
+		provider.Counter("rows_imported_total", applyOptions()).Add(42)
+		ctx := context.Background()
+		require.NoError(t, provider.Start(ctx))
+		assert.Eventually(t, func() bool {
+			return len(history.History("rows_imported_total", nil, time.Minute)) > 0
+		}, time.Second, 10*time.Millisecond)
+
+		require.NoError(t, provider.Stop(ctx))
+		assert.Nil(t, history.stop)
+	})
+
+	t.Run("pushes the registry to the gateway", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: cfg, Logger: getTestLogger()})
+		require.NoError(t, err)
+
+		res.Metrics.Counter("rows_imported_total").Add(42)
+
+		ctx := context.Background()
+		require.NoError(t, res.Provider.Start(ctx))
+		require.NoError(t, res.Provider.Stop(ctx))
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, paths, 1)
+		assert.Equal(t, "PUT /metrics/job/nightly_import/shard/3", paths[0])
+		assert.NotEmpty(t, bodies[0])
+	})
+
+	t.Run("reloads push settings", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: cfg, Logger: getTestLogger()})
+		require.NoError(t, err)
+
+		next := cfg
+		next.Push.Interval = time.Minute
+		require.NoError(t, res.Provider.(Reloadable).Reload(next))
+		assert.Equal(t, time.Minute, res.Provider.(*pushgatewayProvider).loop.config.Load().Interval)
+
+		next.Pushgateway.Job = "other"
+		assert.ErrorIs(t, res.Provider.(Reloadable).Reload(next), ErrRestartRequired)
+	})
+
+	t.Run("requires url and job", func(t *testing.T) {
+		bad := cfg
+		bad.Pushgateway = PushgatewayConfig{URL: gateway.URL}
+
+		_, err := NewMetrics(Params{Config: bad, Logger: getTestLogger()})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,140 @@
+// Package pubsubmetrics records Google Cloud Pub/Sub publish and receive metrics through metricsx.
+//
+// The helpers are written against small interfaces satisfied by
+// *pubsub.PublishResult and *pubsub.Message from cloud.google.com/go/pubsub,
+// so this package does not depend on the client library:
+//
+//	res := topic.Publish(ctx, msg)
+//	pm.TrackPublish("orders", res)
+//
+//	err := sub.Receive(ctx, pubsubmetrics.Handler(pm, "orders-worker",
+//		func(ctx context.Context, msg *pubsub.Message) error {
+//			return process(ctx, msg.Data)
+//		}))
+//
+// It records:
+//
+//	pubsub_publish_duration_seconds{topic, status}
+//	pubsub_messages_received_total{subscription}
+//	pubsub_ack_duration_seconds{subscription}
+//	pubsub_outstanding_messages{subscription}
+//	pubsub_nacks_total{subscription}
+package pubsubmetrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/gostratum/metricsx"
+)
+
+// PublishResult is the subset of *pubsub.PublishResult used to observe publishes
+type PublishResult interface {
+	// Ready returns a channel that is closed when the result is available
+	Ready() <-chan struct{}
+
+	// Get returns the server-generated message ID or the publish error
+	Get(ctx context.Context) (string, error)
+}
+
+// Message is the subset of *pubsub.Message used to acknowledge deliveries
+type Message interface {
+	Ack()
+	Nack()
+}
+
+// Option configures the Pub/Sub metrics
+type Option func(*options)
+
+// options contains configuration for the Pub/Sub metrics
+type options struct {
+	buckets []float64
+}
+
+// WithBuckets sets the buckets for the publish and ack duration histograms
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// Metrics records Pub/Sub publish and receive metrics
+type Metrics struct {
+	publishDuration metricsx.Histogram
+	received        metricsx.Counter
+	ackDuration     metricsx.Histogram
+	outstanding     metricsx.Gauge
+	nacks           metricsx.Counter
+}
+
+// New creates the Pub/Sub metrics
+func New(m metricsx.Metrics, opts ...Option) *Metrics {
+	o := &options{
+		buckets: metricsx.DefaultBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Metrics{
+		publishDuration: m.Histogram("pubsub_publish_duration_seconds",
+			metricsx.WithHelp("Time from publishing a message until the server acknowledged it, in seconds."),
+			metricsx.WithLabels("topic", "status"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+		received: m.Counter("pubsub_messages_received_total",
+			metricsx.WithHelp("Total number of messages delivered to the subscriber."),
+			metricsx.WithLabels("subscription"),
+		),
+		ackDuration: m.Histogram("pubsub_ack_duration_seconds",
+			metricsx.WithHelp("Time from message delivery until it was acknowledged, in seconds."),
+			metricsx.WithLabels("subscription"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+		outstanding: m.Gauge("pubsub_outstanding_messages",
+			metricsx.WithHelp("Number of delivered messages that have not been acknowledged yet."),
+			metricsx.WithLabels("subscription"),
+		),
+		nacks: m.Counter("pubsub_nacks_total",
+			metricsx.WithHelp("Total number of messages negatively acknowledged."),
+			metricsx.WithLabels("subscription"),
+		),
+	}
+}
+
+// TrackPublish records the latency and outcome of res once it becomes ready.
+// It returns immediately; the observation happens in the background.
+func (m *Metrics) TrackPublish(topic string, res PublishResult) {
+	start := time.Now()
+
+	go func() {
+		<-res.Ready()
+
+		status := "ok"
+		if _, err := res.Get(context.Background()); err != nil {
+			status = "error"
+		}
+		m.publishDuration.Observe(time.Since(start).Seconds(), topic, status)
+	}()
+}
+
+// Handler wraps a message handler for subscription.Receive. The message is
+// acknowledged when handler returns nil and negatively acknowledged otherwise.
+func Handler[M Message](m *Metrics, subscription string, handler func(ctx context.Context, msg M) error) func(context.Context, M) {
+	return func(ctx context.Context, msg M) {
+		start := time.Now()
+
+		m.received.Inc(subscription)
+		m.outstanding.Inc(subscription)
+		defer m.outstanding.Dec(subscription)
+
+		if err := handler(ctx, msg); err != nil {
+			msg.Nack()
+			m.nacks.Inc(subscription)
+			return
+		}
+
+		msg.Ack()
+		m.ackDuration.Observe(time.Since(start).Seconds(), subscription)
+	}
+}
@@ -0,0 +1,96 @@
+package pubsubmetrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeResult is a PublishResult that becomes ready when resolve is called
+type fakeResult struct {
+	ready chan struct{}
+	err   error
+}
+
+func newFakeResult() *fakeResult { return &fakeResult{ready: make(chan struct{})} }
+
+func (r *fakeResult) Ready() <-chan struct{} { return r.ready }
+func (r *fakeResult) Get(ctx context.Context) (string, error) {
+	<-r.ready
+	return "id", r.err
+}
+func (r *fakeResult) resolve(err error) {
+	r.err = err
+	close(r.ready)
+}
+
+// fakeMessage records how it was acknowledged
+type fakeMessage struct {
+	acked, nacked bool
+}
+
+func (m *fakeMessage) Ack()  { m.acked = true }
+func (m *fakeMessage) Nack() { m.nacked = true }
+
+func TestTrackPublish(t *testing.T) {
+	m := metricstest.New()
+	pm := New(m)
+
+	ok, failed := newFakeResult(), newFakeResult()
+	pm.TrackPublish("orders", ok)
+	pm.TrackPublish("orders", failed)
+
+	ok.resolve(nil)
+	failed.resolve(errors.New("deadline exceeded"))
+
+	assert.Eventually(t, func() bool {
+		out := m.String()
+		return strings.Contains(out, `pubsub_publish_duration_seconds_count{status="ok",topic="orders"} 1`) &&
+			strings.Contains(out, `pubsub_publish_duration_seconds_count{status="error",topic="orders"} 1`)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("acks successfully handled messages", func(t *testing.T) {
+		m := metricstest.New()
+		pm := New(m)
+
+		var outstanding string
+		handler := Handler(pm, "worker", func(ctx context.Context, msg *fakeMessage) error {
+			outstanding = m.String()
+			return nil
+		})
+
+		msg := &fakeMessage{}
+		handler(context.Background(), msg)
+
+		assert.True(t, msg.acked)
+		assert.Contains(t, outstanding, `pubsub_outstanding_messages{subscription="worker"} 1`)
+
+		out := m.String()
+		assert.Contains(t, out, `pubsub_messages_received_total{subscription="worker"} 1`)
+		assert.Contains(t, out, `pubsub_ack_duration_seconds_count{subscription="worker"} 1`)
+		assert.Contains(t, out, `pubsub_outstanding_messages{subscription="worker"} 0`)
+	})
+
+	t.Run("nacks failed messages", func(t *testing.T) {
+		m := metricstest.New()
+		pm := New(m)
+
+		handler := Handler(pm, "worker", func(ctx context.Context, msg *fakeMessage) error {
+			return errors.New("boom")
+		})
+
+		msg := &fakeMessage{}
+		handler(context.Background(), msg)
+
+		assert.True(t, msg.nacked)
+		assert.False(t, msg.acked)
+		assert.Contains(t, m.String(), `pubsub_nacks_total{subscription="worker"} 1`)
+	})
+}
@@ -0,0 +1,198 @@
+package metricsx
+
+import (
+	"cmp"
+	"context"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gostratum/core/logx"
+)
+
+// Default push settings, used when the corresponding PushConfig field is zero
+const (
+	DefaultPushInterval = 15 * time.Second
+	DefaultPushTimeout  = 10 * time.Second
+
+	DefaultPushShutdownTimeout = 5 * time.Second
+)
+
+// Delays between failed flush attempts on shutdown
+const (
+	minFlushBackoff = 100 * time.Millisecond
+	maxFlushBackoff = time.Second
+)
+
+// PushConfig controls how push providers send metrics
+type PushConfig struct {
+	// Interval between pushes
+	Interval time.Duration `mapstructure:"interval" default:"15s"`
+
+	// Timeout bounds a single push
+	Timeout time.Duration `mapstructure:"timeout" default:"10s"`
+
+	// Jitter adds a random delay up to this duration to every interval so
+	// replicas do not push in lockstep
+	Jitter time.Duration `mapstructure:"jitter" default:"0s"`
+
+	// FlushOnShutdown pushes once more when the provider stops so the last
+	// interval is not lost
+	FlushOnShutdown bool `mapstructure:"flush_on_shutdown" default:"true"`
+
+	// ShutdownTimeout bounds the final flush, which is retried until it
+	// succeeds or the timeout (or the Stop context) expires
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" default:"5s"`
+
+	// Retry controls how a failed push is retried within an interval
+	Retry PushRetryConfig `mapstructure:"retry"`
+}
+
+// interval returns the push interval including a random jitter
+func (c PushConfig) interval() time.Duration {
+	interval := cmp.Or(c.Interval, DefaultPushInterval)
+	if c.Jitter > 0 {
+		interval += rand.N(c.Jitter)
+	}
+	return interval
+}
+
+// pushLoop calls push periodically according to a PushConfig
+type pushLoop struct {
+	push   func(ctx context.Context) error
+	logger logx.Logger
+	config atomic.Pointer[PushConfig]
+
+	// retried is called before every retry of a failed push
+	retried func()
+
+	// failed records whether the last push failed after its retries
+	failed atomic.Bool
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newPushLoop creates a loop that is not yet running
+func newPushLoop(cfg PushConfig, push func(ctx context.Context) error, logger logx.Logger) *pushLoop {
+	l := &pushLoop{push: push, logger: logger, retried: func() {}}
+	l.config.Store(&cfg)
+	return l
+}
+
+// SetConfig replaces the push settings; the new interval applies from the next push
+func (l *pushLoop) SetConfig(cfg PushConfig) {
+	l.config.Store(&cfg)
+}
+
+// Start runs the loop in the background until Stop is called
+func (l *pushLoop) Start() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+	l.done = make(chan struct{})
+
+	go l.run(ctx, l.done)
+}
+
+// run pushes on every interval until ctx is cancelled
+func (l *pushLoop) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	timer := time.NewTimer(l.config.Load().interval())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			l.failed.Store(l.pushWithRetry(ctx) != nil)
+			timer.Reset(l.config.Load().interval())
+		}
+	}
+}
+
+// Stop ends the loop and, when configured, flushes once more within ctx
+func (l *pushLoop) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	cancel, done := l.cancel, l.done
+	l.cancel, l.done = nil, nil
+	l.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if !l.config.Load().FlushOnShutdown {
+		return nil
+	}
+	return l.flush(ctx)
+}
+
+// flush pushes until a push succeeds or the shutdown timeout expires
+func (l *pushLoop) flush(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cmp.Or(l.config.Load().ShutdownTimeout, DefaultPushShutdownTimeout))
+	defer cancel()
+
+	backoff := minFlushBackoff
+	for {
+		err := l.pushOnce(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxFlushBackoff)
+	}
+}
+
+// pushWithRetry pushes, retrying retryable failures with backoff according
+// to the retry policy until an attempt succeeds or ctx is done
+func (l *pushLoop) pushWithRetry(ctx context.Context) error {
+	policy := l.config.Load().Retry
+	for attempt := 1; ; attempt++ {
+		err := l.pushOnce(ctx)
+		if err == nil || attempt >= policy.MaxAttempts || !policy.retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(policy.backoff(attempt)):
+		}
+		l.retried()
+	}
+}
+
+// pushOnce pushes with the configured timeout and logs failures
+func (l *pushLoop) pushOnce(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cmp.Or(l.config.Load().Timeout, DefaultPushTimeout))
+	defer cancel()
+
+	err := l.push(ctx)
+	if err != nil {
+		l.logger.Warn("metrics push failed", logx.Err(err))
+	}
+	return err
+}
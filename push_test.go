@@ -0,0 +1,80 @@
+package metricsx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushLoop(t *testing.T) {
+	t.Run("pushes on every interval and flushes on stop", func(t *testing.T) {
+		var pushes atomic.Int64
+		loop := newPushLoop(PushConfig{Interval: 10 * time.Millisecond, FlushOnShutdown: true}, func(ctx context.Context) error {
+			pushes.Add(1)
+			return nil
+		}, getTestLogger())
+
+		loop.Start()
+		assert.Eventually(t, func() bool { return pushes.Load() >= 2 }, time.Second, 5*time.Millisecond)
+
+		require.NoError(t, loop.Stop(context.Background()))
+		stopped := pushes.Load()
+
+		time.Sleep(30 * time.Millisecond)
+		assert.Equal(t, stopped, pushes.Load())
+	})
+
+	t.Run("skips the final flush when disabled", func(t *testing.T) {
+		var pushes atomic.Int64
+		loop := newPushLoop(PushConfig{Interval: time.Hour}, func(ctx context.Context) error {
+			pushes.Add(1)
+			return nil
+		}, getTestLogger())
+
+		loop.Start()
+		require.NoError(t, loop.Stop(context.Background()))
+		assert.Zero(t, pushes.Load())
+	})
+
+	t.Run("bounds pushes by the timeout and reports flush errors", func(t *testing.T) {
+		cfg := PushConfig{Interval: time.Hour, Timeout: 10 * time.Millisecond, FlushOnShutdown: true, ShutdownTimeout: 50 * time.Millisecond}
+		loop := newPushLoop(cfg, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, getTestLogger())
+
+		loop.Start()
+		err := loop.Stop(context.Background())
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("retries the final flush within the shutdown timeout", func(t *testing.T) {
+		var pushes atomic.Int64
+		cfg := PushConfig{Interval: time.Hour, FlushOnShutdown: true, ShutdownTimeout: time.Second}
+		loop := newPushLoop(cfg, func(ctx context.Context) error {
+			if pushes.Add(1) < 3 {
+				return errors.New("gateway unavailable")
+			}
+			return nil
+		}, getTestLogger())
+
+		loop.Start()
+		require.NoError(t, loop.Stop(context.Background()))
+		assert.Equal(t, int64(3), pushes.Load())
+	})
+
+	t.Run("applies jitter on top of the interval", func(t *testing.T) {
+		cfg := PushConfig{Interval: time.Second, Jitter: 500 * time.Millisecond}
+		for range 20 {
+			interval := cfg.interval()
+			assert.GreaterOrEqual(t, interval, time.Second)
+			assert.Less(t, interval, 1500*time.Millisecond)
+		}
+		assert.Equal(t, DefaultPushInterval, PushConfig{}.interval())
+	})
+}
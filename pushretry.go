@@ -0,0 +1,120 @@
+package metricsx
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Default retry policy settings, used when the corresponding PushRetryConfig
+// field is zero
+const (
+	DefaultPushRetryInitialBackoff = 500 * time.Millisecond
+	DefaultPushRetryMaxBackoff     = 5 * time.Second
+	DefaultPushRetryMultiplier     = 2.0
+)
+
+// DefaultRetryableStatusCodes are the HTTP statuses retried when
+// PushRetryConfig.RetryableStatusCodes is empty
+var DefaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// PushRetryConfig is the retry policy shared by push providers. Network
+// errors and timeouts are always retryable, HTTP errors only with one of
+// RetryableStatusCodes.
+type PushRetryConfig struct {
+	// MaxAttempts bounds the attempts per push, including the first; 1 or
+	// less disables retries
+	MaxAttempts int `mapstructure:"max_attempts" default:"3"`
+
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" default:"500ms"`
+
+	// MaxBackoff caps the delay between retries
+	MaxBackoff time.Duration `mapstructure:"max_backoff" default:"5s"`
+
+	// Multiplier grows the delay after every retry
+	Multiplier float64 `mapstructure:"multiplier" default:"2"`
+
+	// Jitter randomizes every delay by up to this fraction, e.g. 0.2 for ±20%
+	Jitter float64 `mapstructure:"jitter" default:"0.2"`
+
+	// RetryableStatusCodes lists the HTTP statuses worth retrying. Empty uses
+	// DefaultRetryableStatusCodes.
+	RetryableStatusCodes []int `mapstructure:"retryable_status_codes"`
+}
+
+// backoff returns the delay before retry number retry, starting at 1
+func (c PushRetryConfig) backoff(retry int) time.Duration {
+	initial := cmp.Or(c.InitialBackoff, DefaultPushRetryInitialBackoff)
+	multiplier := cmp.Or(c.Multiplier, DefaultPushRetryMultiplier)
+
+	delay := min(float64(initial)*math.Pow(multiplier, float64(retry-1)), float64(cmp.Or(c.MaxBackoff, DefaultPushRetryMaxBackoff)))
+	if c.Jitter > 0 {
+		delay *= 1 + c.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// retryable reports whether a push that failed with err is worth retrying
+func (c PushRetryConfig) retryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var status *PushStatusError
+	if !errors.As(err, &status) {
+		return true
+	}
+	codes := c.RetryableStatusCodes
+	if len(codes) == 0 {
+		codes = DefaultRetryableStatusCodes
+	}
+	return slices.Contains(codes, status.StatusCode)
+}
+
+// PushStatusError is returned by push providers when the backend answers
+// with an unsuccessful HTTP status, so the retry policy can classify it
+type PushStatusError struct {
+	StatusCode int
+	URL        string
+	Body       string
+}
+
+func (e *PushStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d while pushing to %s: %s", e.StatusCode, e.URL, e.Body)
+}
+
+// maxPushErrorBody bounds the response body kept in a PushStatusError
+const maxPushErrorBody = 1 << 10
+
+// statusClient is an HTTP client returning a PushStatusError for responses
+// other than 200 and 202, the statuses a Pushgateway answers on success
+type statusClient struct {
+	client *http.Client
+}
+
+func (c statusClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusAccepted {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxPushErrorBody))
+	return nil, &PushStatusError{StatusCode: resp.StatusCode, URL: req.URL.String(), Body: string(body)}
+}
@@ -0,0 +1,118 @@
+package metricsx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushRetryConfig(t *testing.T) {
+	t.Run("grows the backoff up to the maximum", func(t *testing.T) {
+		policy := PushRetryConfig{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 3}
+		assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+		assert.Equal(t, 300*time.Millisecond, policy.backoff(2))
+		assert.Equal(t, 900*time.Millisecond, policy.backoff(3))
+		assert.Equal(t, time.Second, policy.backoff(4))
+
+		assert.Equal(t, DefaultPushRetryInitialBackoff, PushRetryConfig{}.backoff(1))
+	})
+
+	t.Run("applies jitter around the backoff", func(t *testing.T) {
+		policy := PushRetryConfig{InitialBackoff: time.Second, Jitter: 0.2}
+		for range 20 {
+			backoff := policy.backoff(1)
+			assert.GreaterOrEqual(t, backoff, 800*time.Millisecond)
+			assert.LessOrEqual(t, backoff, 1200*time.Millisecond)
+		}
+	})
+
+	t.Run("classifies errors", func(t *testing.T) {
+		policy := PushRetryConfig{}
+		assert.True(t, policy.retryable(errors.New("connection refused")))
+		assert.True(t, policy.retryable(context.DeadlineExceeded))
+		assert.False(t, policy.retryable(context.Canceled))
+		assert.True(t, policy.retryable(&PushStatusError{StatusCode: http.StatusServiceUnavailable}))
+		assert.False(t, policy.retryable(&PushStatusError{StatusCode: http.StatusBadRequest}))
+
+		policy.RetryableStatusCodes = []int{http.StatusInternalServerError}
+		assert.True(t, policy.retryable(&PushStatusError{StatusCode: http.StatusInternalServerError}))
+		assert.False(t, policy.retryable(&PushStatusError{StatusCode: http.StatusServiceUnavailable}))
+	})
+}
+
+func TestPushLoopRetry(t *testing.T) {
+	retryPolicy := PushRetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	t.Run("retries retryable failures", func(t *testing.T) {
+		var pushes, retries atomic.Int64
+		loop := newPushLoop(PushConfig{Retry: retryPolicy}, func(ctx context.Context) error {
+			if pushes.Add(1) < 3 {
+				return &PushStatusError{StatusCode: http.StatusTooManyRequests}
+			}
+			return nil
+		}, getTestLogger())
+		loop.retried = func() { retries.Add(1) }
+
+		require.NoError(t, loop.pushWithRetry(context.Background()))
+		assert.Equal(t, int64(3), pushes.Load())
+		assert.Equal(t, int64(2), retries.Load())
+	})
+
+	t.Run("gives up after the maximum attempts", func(t *testing.T) {
+		var pushes atomic.Int64
+		loop := newPushLoop(PushConfig{Retry: retryPolicy}, func(ctx context.Context) error {
+			pushes.Add(1)
+			return errors.New("connection refused")
+		}, getTestLogger())
+
+		assert.Error(t, loop.pushWithRetry(context.Background()))
+		assert.Equal(t, int64(3), pushes.Load())
+	})
+
+	t.Run("does not retry permanent failures", func(t *testing.T) {
+		var pushes atomic.Int64
+		loop := newPushLoop(PushConfig{Retry: retryPolicy}, func(ctx context.Context) error {
+			pushes.Add(1)
+			return &PushStatusError{StatusCode: http.StatusBadRequest}
+		}, getTestLogger())
+
+		assert.Error(t, loop.pushWithRetry(context.Background()))
+		assert.Equal(t, int64(1), pushes.Load())
+	})
+}
+
+func TestPushgatewayRetry(t *testing.T) {
+	var requests atomic.Int64
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gateway.Close()
+
+	res, err := NewMetrics(Params{Config: Config{
+		Enabled:     true,
+		Provider:    "pushgateway",
+		Push:        PushConfig{Interval: time.Hour, Retry: PushRetryConfig{MaxAttempts: 2, InitialBackoff: time.Millisecond}},
+		Pushgateway: PushgatewayConfig{URL: gateway.URL, Job: "nightly_import"},
+		Prometheus:  PrometheusConfig{EnableSelfMetrics: true},
+	}, Logger: getTestLogger()})
+	require.NoError(t, err)
+
+	p := res.Provider.(*pushgatewayProvider)
+	require.NoError(t, p.loop.pushWithRetry(context.Background()))
+	assert.Equal(t, int64(2), requests.Load())
+
+	body := scrape(t, p.prometheusProvider)
+	assert.Contains(t, body, "metricsx_push_retries_total 1")
+	assert.Contains(t, body, `metricsx_pushes_total{result="failure"} 1`)
+}
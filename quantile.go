@@ -0,0 +1,116 @@
+package metricsx
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/beorn7/perks/quantile"
+)
+
+// DefaultQuantileAgeBuckets is the number of staggered streams a
+// RollingQuantile rotates through, matching the Prometheus summary default
+const DefaultQuantileAgeBuckets = 5
+
+// RollingQuantile estimates quantiles of the observations of a rolling
+// window in-process, for adaptive timeouts and backpressure that cannot wait
+// for a Prometheus query. It uses the CKMS streams behind Prometheus
+// summaries: every observation goes to DefaultQuantileAgeBuckets streams
+// reset in turn, so a query covers between window - window/5 and window of
+// observations.
+//
+// Quantiles listed in the objectives are estimated within their error;
+// other quantiles are answered with lower accuracy.
+type RollingQuantile struct {
+	mu      sync.Mutex
+	width   time.Duration
+	streams []*quantile.Stream
+	head    int
+	expires time.Time
+	now     func() time.Time
+}
+
+// NewRollingQuantile creates an estimator over window targeting objectives,
+// a map of quantiles to their allowed absolute error. DefaultObjectives are
+// used when objectives is empty.
+func NewRollingQuantile(window time.Duration, objectives map[float64]float64) *RollingQuantile {
+	if len(objectives) == 0 {
+		objectives = DefaultObjectives
+	}
+
+	q := &RollingQuantile{
+		width:   max(window/DefaultQuantileAgeBuckets, 1),
+		streams: make([]*quantile.Stream, DefaultQuantileAgeBuckets),
+		now:     time.Now,
+	}
+	for i := range q.streams {
+		q.streams[i] = quantile.NewTargeted(objectives)
+	}
+	q.expires = q.now().Add(q.width)
+	return q
+}
+
+// Observe adds value to the window
+func (q *RollingQuantile) Observe(value float64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rotate()
+	for _, s := range q.streams {
+		s.Insert(value)
+	}
+}
+
+// Quantile returns the estimated value at quantile p, between 0 and 1, or
+// NaN when the window holds no observations
+func (q *RollingQuantile) Quantile(p float64) float64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rotate()
+	head := q.streams[q.head]
+	if head.Count() == 0 {
+		return math.NaN()
+	}
+	return head.Query(p)
+}
+
+// Count returns the number of observations in the window
+func (q *RollingQuantile) Count() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rotate()
+	return q.streams[q.head].Count()
+}
+
+// Reset clears the window
+func (q *RollingQuantile) Reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, s := range q.streams {
+		s.Reset()
+	}
+	q.head = 0
+	q.expires = q.now().Add(q.width)
+}
+
+// rotate resets the streams whose age bucket has passed; the caller holds mu
+func (q *RollingQuantile) rotate() {
+	now := q.now()
+	if now.Sub(q.expires) >= q.width*time.Duration(len(q.streams)) {
+		// idle for a whole window, everything expired
+		for _, s := range q.streams {
+			s.Reset()
+		}
+		q.expires = now.Add(q.width)
+		return
+	}
+
+	for !now.Before(q.expires) {
+		q.streams[q.head].Reset()
+		q.head = (q.head + 1) % len(q.streams)
+		q.expires = q.expires.Add(q.width)
+	}
+}
@@ -0,0 +1,84 @@
+package metricsx
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRollingQuantile(window time.Duration) (*RollingQuantile, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	q := NewRollingQuantile(window, nil)
+	q.now = clock.Now
+	q.Reset()
+	return q, clock
+}
+
+func TestRollingQuantile(t *testing.T) {
+	t.Run("estimates quantiles of the window", func(t *testing.T) {
+		q, _ := newTestRollingQuantile(time.Minute)
+
+		for i := 1; i <= 1000; i++ {
+			q.Observe(float64(i))
+		}
+
+		assert.Equal(t, 1000, q.Count())
+		assert.InDelta(t, 500, q.Quantile(0.5), 50)
+		assert.InDelta(t, 990, q.Quantile(0.99), 1)
+	})
+
+	t.Run("returns NaN without observations", func(t *testing.T) {
+		q, _ := newTestRollingQuantile(time.Minute)
+		assert.True(t, math.IsNaN(q.Quantile(0.99)))
+	})
+
+	t.Run("forgets observations older than the window", func(t *testing.T) {
+		q, clock := newTestRollingQuantile(time.Minute)
+
+		for range 100 {
+			q.Observe(1000)
+		}
+		clock.Advance(30 * time.Second)
+		for range 100 {
+			q.Observe(1)
+		}
+		assert.Equal(t, 200, q.Count())
+
+		clock.Advance(48 * time.Second)
+		assert.Equal(t, 100, q.Count())
+		assert.Equal(t, 1.0, q.Quantile(0.99))
+
+		clock.Advance(time.Hour)
+		assert.Zero(t, q.Count())
+	})
+
+	t.Run("keeps rotating after an idle period", func(t *testing.T) {
+		q, clock := newTestRollingQuantile(time.Minute)
+
+		clock.Advance(time.Hour)
+		q.Observe(5)
+		clock.Advance(30 * time.Second)
+		assert.Equal(t, 1, q.Count())
+		clock.Advance(time.Minute)
+		assert.Zero(t, q.Count())
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		q := NewRollingQuantile(time.Hour, map[float64]float64{0.9: 0.01})
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				for i := range 1000 {
+					q.Observe(float64(i))
+					q.Quantile(0.9)
+				}
+			})
+		}
+		wg.Wait()
+		assert.Equal(t, 8000, q.Count())
+	})
+}
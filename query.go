@@ -0,0 +1,182 @@
+package metricsx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// queryHandler answers GET ?name=<family>&labels=<k=v,...> with the matching
+// series of one family, read from the snapshot of s. Series match when they
+// carry every requested label value. With format=value the handler writes
+// the bare value of the single matching counter, gauge or untyped series, for
+// use from a shell. With range=<duration> it answers from history instead,
+// with the samples recorded over that window; history is nil when disabled.
+func queryHandler(s Snapshotter, history HistoryReader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		name := query.Get("name")
+		if name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+		matchers, err := parseLabelMatchers(query.Get("labels"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if window := query.Get("range"); window != "" {
+			writeQueryHistory(w, history, name, matchers, window)
+			return
+		}
+
+		families, err := s.Snapshot()
+		if err != nil && len(families) == 0 {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		family, ok := findFamily(families, name)
+		if !ok {
+			http.Error(w, fmt.Sprintf("metric %q not found", name), http.StatusNotFound)
+			return
+		}
+		family.Samples = matchSamples(family.Samples, matchers)
+
+		if query.Get("format") == "value" {
+			writeQueryValue(w, family)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newJSONFamily(family))
+	})
+}
+
+// parseLabelMatchers parses comma separated name=value pairs
+func parseLabelMatchers(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	matchers := make(map[string]string)
+	for pair := range strings.SplitSeq(s, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid label matcher %q, expected name=value", pair)
+		}
+		matchers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return matchers, nil
+}
+
+// findFamily returns the family called name
+func findFamily(families []FamilySnapshot, name string) (FamilySnapshot, bool) {
+	for _, f := range families {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return FamilySnapshot{}, false
+}
+
+// matchSamples returns the samples carrying every label value of matchers
+func matchSamples(samples []SampleSnapshot, matchers map[string]string) []SampleSnapshot {
+	matched := make([]SampleSnapshot, 0, len(samples))
+	for _, s := range samples {
+		if matchLabels(s.Labels, matchers) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+// matchLabels reports whether labels carry every value of matchers; a
+// matcher with an empty value also matches series without the label
+func matchLabels(labels, matchers map[string]string) bool {
+	for name, value := range matchers {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// writeQueryValue writes the value of the only sample of family as text
+func writeQueryValue(w http.ResponseWriter, family FamilySnapshot) {
+	switch {
+	case family.Type == "histogram" || family.Type == "summary":
+		http.Error(w, fmt.Sprintf("metric %q is a %s and has no single value", family.Name, family.Type), http.StatusBadRequest)
+	case len(family.Samples) != 1:
+		http.Error(w, fmt.Sprintf("metric %q has %d matching series, expected 1", family.Name, len(family.Samples)), http.StatusConflict)
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, formatFloat(family.Samples[0].Value)+"\n")
+	}
+}
+
+// jsonHistory is the JSON form of the history of one family
+type jsonHistory struct {
+	Name   string              `json:"name"`
+	Type   string              `json:"type"`
+	Series []jsonSeriesHistory `json:"series"`
+}
+
+// jsonSeriesHistory is the JSON form of a SeriesHistory
+type jsonSeriesHistory struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	Points []jsonPoint       `json:"points"`
+}
+
+// jsonPoint is the JSON form of a HistoryPoint; only the fields of the
+// family's type are set
+type jsonPoint struct {
+	Time  time.Time  `json:"time"`
+	Value *jsonFloat `json:"value,omitempty"`
+	Count *uint64    `json:"count,omitempty"`
+	Sum   *jsonFloat `json:"sum,omitempty"`
+}
+
+// writeQueryHistory writes the samples of name recorded over window
+func writeQueryHistory(w http.ResponseWriter, history HistoryReader, name string, matchers map[string]string, window string) {
+	if history == nil {
+		http.Error(w, "history is disabled, set metrics.prometheus.history.retention", http.StatusBadRequest)
+		return
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		http.Error(w, fmt.Sprintf("invalid range %q", window), http.StatusBadRequest)
+		return
+	}
+
+	series := history.History(name, matchers, d)
+	if len(series) == 0 {
+		http.Error(w, fmt.Sprintf("metric %q has no history", name), http.StatusNotFound)
+		return
+	}
+
+	body := jsonHistory{Name: name, Type: series[0].Type, Series: make([]jsonSeriesHistory, 0, len(series))}
+	for _, s := range series {
+		points := make([]jsonPoint, 0, len(s.Points))
+		for _, p := range s.Points {
+			point := jsonPoint{Time: p.Time}
+			switch s.Type {
+			case "histogram", "summary":
+				sum := jsonFloat(p.Sum)
+				point.Count, point.Sum = &p.Count, &sum
+			default:
+				value := jsonFloat(p.Value)
+				point.Value = &value
+			}
+			points = append(points, point)
+		}
+		body.Series = append(body.Series, jsonSeriesHistory{Labels: s.Labels, Points: points})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
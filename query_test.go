@@ -0,0 +1,56 @@
+package metricsx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryHandler(t *testing.T) {
+	res, err := NewMetrics(Params{Config: Config{Provider: "prometheus", Prometheus: PrometheusConfig{Path: "/metrics", QueryPath: "/metrics/query"}}, Logger: getTestLogger()})
+	require.NoError(t, err)
+
+	orders := res.Metrics.Counter("orders_total", WithLabels("region", "status"))
+	orders.Add(3, "eu", "ok")
+	orders.Add(1, "eu", "failed")
+	orders.Add(2, "us", "ok")
+	res.Metrics.Histogram("order_duration_seconds").Observe(0.2)
+
+	handler := res.Provider.(multiHandler).Handlers()["/metrics/query"]
+	require.NotNil(t, handler)
+
+	query := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	t.Run("returns the series matching the labels", func(t *testing.T) {
+		rec := query("/metrics/query?name=orders_total&labels=region=eu")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var family jsonFamily
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &family))
+		assert.Equal(t, "orders_total", family.Name)
+		assert.Len(t, family.Samples, 2)
+	})
+
+	t.Run("writes a single value as text", func(t *testing.T) {
+		rec := query("/metrics/query?name=orders_total&labels=region=us,status=ok&format=value")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2\n", rec.Body.String())
+
+		assert.Equal(t, http.StatusConflict, query("/metrics/query?name=orders_total&format=value").Code)
+		assert.Equal(t, http.StatusBadRequest, query("/metrics/query?name=order_duration_seconds&format=value").Code)
+	})
+
+	t.Run("rejects invalid queries", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, query("/metrics/query").Code)
+		assert.Equal(t, http.StatusBadRequest, query("/metrics/query?name=orders_total&labels=region").Code)
+		assert.Equal(t, http.StatusNotFound, query("/metrics/query?name=missing_total").Code)
+	})
+}
@@ -0,0 +1,90 @@
+package metricsx
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QueueTimer measures time in queue by pairing the enqueue and dequeue of
+// each item through a token such as a job ID, message ID or pointer
+type QueueTimer[K comparable] struct {
+	wait Histogram
+	now  func() time.Time
+
+	mu      sync.Mutex
+	pending map[K]time.Time
+}
+
+// NewQueueTimer creates the <name>_wait_seconds histogram of time spent in
+// the queue and the <name>_depth gauge of items enqueued but not yet
+// dequeued, read at collection time. opts apply to both, e.g. WithBuckets
+// or WithConstLabels to tell several queues apart. It panics when opts
+// declare variable labels with WithLabels, since Enqueue and Dequeue take no
+// label values.
+func NewQueueTimer[K comparable](m Metrics, name string, opts ...Option) *QueueTimer[K] {
+	if labels := applyOptions(opts...).Labels; len(labels) > 0 {
+		panic(fmt.Sprintf("metricsx: queue timer %s does not accept variable labels %v, use WithConstLabels", name, labels))
+	}
+
+	q := &QueueTimer[K]{
+		wait: m.Histogram(name+"_wait_seconds",
+			append([]Option{WithHelp("Time items spent in the queue in seconds.")}, opts...)...,
+		),
+		now:     time.Now,
+		pending: make(map[K]time.Time),
+	}
+
+	m.GaugeFunc(name+"_depth", func() float64 {
+		return float64(q.Len())
+	}, append([]Option{WithHelp("Number of items enqueued and not yet dequeued.")}, opts...)...)
+
+	return q
+}
+
+// Enqueue records that the item identified by token entered the queue.
+// Enqueueing a pending token again restarts its timer.
+func (q *QueueTimer[K]) Enqueue(token K) {
+	q.mu.Lock()
+	q.pending[token] = q.now()
+	q.mu.Unlock()
+}
+
+// Dequeue records that the item identified by token left the queue and
+// returns the time it waited. It reports false, observing nothing, when the
+// token is not pending.
+func (q *QueueTimer[K]) Dequeue(token K) (time.Duration, bool) {
+	q.mu.Lock()
+	enqueued, ok := q.pending[token]
+	delete(q.pending, token)
+	q.mu.Unlock()
+
+	if !ok {
+		return 0, false
+	}
+	wait := q.now().Sub(enqueued)
+	q.wait.Observe(wait.Seconds())
+	return wait, true
+}
+
+// Forget drops the item identified by token without observing it, e.g. when
+// it is cancelled before being processed
+func (q *QueueTimer[K]) Forget(token K) {
+	q.mu.Lock()
+	delete(q.pending, token)
+	q.mu.Unlock()
+}
+
+// ObserveSince records an item dequeued now that was enqueued at enqueued.
+// Use it for external queues where the message carries its enqueue time,
+// such as the SentTimestamp of an SQS message, and no token was recorded.
+func (q *QueueTimer[K]) ObserveSince(enqueued time.Time) {
+	q.wait.Observe(q.now().Sub(enqueued).Seconds())
+}
+
+// Len returns the number of pending items
+func (q *QueueTimer[K]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
@@ -0,0 +1,93 @@
+package metricsx
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestQueueTimer(t *testing.T) (*QueueTimer[string], *fakeClock, Provider) {
+	t.Helper()
+
+	provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+	m := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	q := NewQueueTimer[string](m, "jobs_queue", WithBuckets(1, 5, 10))
+	q.now = clock.Now
+	return q, clock, provider
+}
+
+func TestQueueTimer(t *testing.T) {
+	t.Run("pairs enqueue and dequeue by token", func(t *testing.T) {
+		q, clock, provider := newTestQueueTimer(t)
+
+		q.Enqueue("a")
+		clock.Advance(2 * time.Second)
+		q.Enqueue("b")
+		clock.Advance(4 * time.Second)
+
+		assert.Equal(t, 2, q.Len())
+		assert.Contains(t, scrape(t, provider), "jobs_queue_depth 2")
+
+		wait, ok := q.Dequeue("a")
+		assert.True(t, ok)
+		assert.Equal(t, 6*time.Second, wait)
+
+		wait, ok = q.Dequeue("b")
+		assert.True(t, ok)
+		assert.Equal(t, 4*time.Second, wait)
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, "jobs_queue_depth 0")
+		assert.Contains(t, out, `jobs_queue_wait_seconds_bucket{le="5"} 1`)
+		assert.Contains(t, out, `jobs_queue_wait_seconds_bucket{le="10"} 2`)
+		assert.Contains(t, out, "jobs_queue_wait_seconds_sum 10")
+	})
+
+	t.Run("ignores unknown and forgotten tokens", func(t *testing.T) {
+		q, _, provider := newTestQueueTimer(t)
+
+		q.Enqueue("a")
+		q.Forget("a")
+		_, ok := q.Dequeue("a")
+		assert.False(t, ok)
+		_, ok = q.Dequeue("never")
+		assert.False(t, ok)
+
+		assert.Equal(t, 0, q.Len())
+		assert.NotContains(t, scrape(t, provider), "jobs_queue_wait_seconds_count")
+	})
+
+	t.Run("observes external enqueue times", func(t *testing.T) {
+		q, clock, provider := newTestQueueTimer(t)
+
+		q.ObserveSince(clock.Now().Add(-3 * time.Second))
+
+		assert.Contains(t, scrape(t, provider), "jobs_queue_wait_seconds_sum 3")
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		q, _, _ := newTestQueueTimer(t)
+
+		var wg sync.WaitGroup
+		for i := range 50 {
+			wg.Go(func() {
+				token := string(rune('a' + i%26))
+				q.Enqueue(token)
+				q.Dequeue(token)
+			})
+		}
+		wg.Wait()
+	})
+	t.Run("rejects variable labels", func(t *testing.T) {
+		m := &metricsImpl{provider: newPrometheusProvider(PrometheusConfig{}, getTestLogger()), logger: getTestLogger()}
+
+		assert.PanicsWithValue(t,
+			"metricsx: queue timer jobs_queue does not accept variable labels [queue], use WithConstLabels",
+			func() { NewQueueTimer[string](m, "jobs_queue", WithLabels("queue")) },
+		)
+	})
+}
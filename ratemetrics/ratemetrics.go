@@ -0,0 +1,121 @@
+// Package ratemetrics instruments golang.org/x/time/rate limiters through metricsx.
+//
+//	limiter := ratemetrics.Wrap(rate.NewLimiter(100, 10), metrics, "api")
+//	if !limiter.Allow() {
+//		return errTooManyRequests
+//	}
+//
+// It records:
+//
+//	rate_limiter_requests_total{limiter, result}
+//	rate_limiter_wait_duration_seconds{limiter}
+//	rate_limiter_tokens{limiter}
+//
+// The result label is "allowed" or "rejected"; Wait calls that fail because the
+// context ends or the burst is exceeded count as rejected.
+package ratemetrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/gostratum/metricsx"
+	"golang.org/x/time/rate"
+)
+
+// Result label values
+const (
+	ResultAllowed  = "allowed"
+	ResultRejected = "rejected"
+)
+
+// Option configures the limiter metrics
+type Option func(*options)
+
+// options contains configuration for the limiter metrics
+type options struct {
+	buckets []float64
+}
+
+// WithBuckets sets the buckets for the wait duration histogram
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// Limiter wraps *rate.Limiter and records the outcome of every admission decision
+type Limiter struct {
+	*rate.Limiter
+	name string
+
+	requests metricsx.Counter
+	wait     metricsx.Histogram
+}
+
+// Wrap instruments l under the given limiter name
+func Wrap(l *rate.Limiter, m metricsx.Metrics, name string, opts ...Option) *Limiter {
+	o := &options{
+		buckets: metricsx.DefaultBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	m.GaugeFunc("rate_limiter_tokens", func() float64 {
+		return l.Tokens()
+	},
+		metricsx.WithHelp("Number of tokens currently available in the limiter."),
+		metricsx.WithConstLabels(map[string]string{"limiter": name}),
+	)
+
+	return &Limiter{
+		Limiter: l,
+		name:    name,
+		requests: m.Counter("rate_limiter_requests_total",
+			metricsx.WithHelp("Total number of rate limiter admission decisions."),
+			metricsx.WithLabels("limiter", "result"),
+		),
+		wait: m.Histogram("rate_limiter_wait_duration_seconds",
+			metricsx.WithHelp("Time spent waiting for the rate limiter in seconds."),
+			metricsx.WithLabels("limiter"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+	}
+}
+
+// Allow reports whether an event may happen now
+func (l *Limiter) Allow() bool {
+	return l.AllowN(time.Now(), 1)
+}
+
+// AllowN reports whether n events may happen at time t
+func (l *Limiter) AllowN(t time.Time, n int) bool {
+	ok := l.Limiter.AllowN(t, n)
+	l.record(ok, n)
+	return ok
+}
+
+// Wait blocks until an event may happen or ctx is done
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events may happen or ctx is done
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	start := time.Now()
+	err := l.Limiter.WaitN(ctx, n)
+
+	l.wait.Observe(time.Since(start).Seconds(), l.name)
+	l.record(err == nil, n)
+	return err
+}
+
+// record counts n admission decisions with the given outcome
+func (l *Limiter) record(allowed bool, n int) {
+	result := ResultAllowed
+	if !allowed {
+		result = ResultRejected
+	}
+	l.requests.Add(float64(n), l.name, result)
+}
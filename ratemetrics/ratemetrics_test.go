@@ -0,0 +1,49 @@
+package ratemetrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Run("counts allowed and rejected events", func(t *testing.T) {
+		m := metricstest.New()
+		limiter := Wrap(rate.NewLimiter(rate.Every(time.Hour), 2), m, "api")
+
+		assert.True(t, limiter.Allow())
+		assert.True(t, limiter.Allow())
+		assert.False(t, limiter.Allow())
+
+		assert.Equal(t, 2.0, m.CounterValue("rate_limiter_requests_total", "api", "allowed"))
+		assert.Equal(t, 1.0, m.CounterValue("rate_limiter_requests_total", "api", "rejected"))
+		assert.True(t, m.Registered("rate_limiter_tokens"))
+	})
+
+	t.Run("records wait duration", func(t *testing.T) {
+		m := metricstest.New()
+		limiter := Wrap(rate.NewLimiter(rate.Inf, 1), m, "worker")
+
+		require.NoError(t, limiter.Wait(context.Background()))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.Error(t, limiter.WaitN(ctx, 5))
+
+		assert.Equal(t, uint64(2), m.HistogramSampleCount("rate_limiter_wait_duration_seconds", "worker"))
+		assert.Equal(t, 1.0, m.CounterValue("rate_limiter_requests_total", "worker", "allowed"))
+		assert.Equal(t, 5.0, m.CounterValue("rate_limiter_requests_total", "worker", "rejected"))
+	})
+
+	t.Run("exposes the token level", func(t *testing.T) {
+		m := metricstest.New()
+		Wrap(rate.NewLimiter(rate.Every(time.Hour), 5), m, "burst")
+
+		assert.InDelta(t, 5, m.GaugeValue("rate_limiter_tokens"), 0.01)
+	})
+}
@@ -0,0 +1,187 @@
+package metricsx
+
+import (
+	"fmt"
+	"maps"
+)
+
+// Label rule actions
+const (
+	LabelActionDrop = "drop"
+	LabelActionMask = "mask"
+)
+
+// DefaultLabelMask replaces the value of masked labels when no replacement is set
+const DefaultLabelMask = "masked"
+
+// LabelRule drops or masks a label on matching metrics. Rules are applied in
+// front of the provider, so series that become identical are aggregated by
+// every backend.
+type LabelRule struct {
+	// Label is the label name the rule applies to
+	Label string `mapstructure:"label"`
+
+	// Action is "drop" to remove the label or "mask" to replace its value
+	Action string `mapstructure:"action" default:"drop"`
+
+	// Replacement is the value used by "mask" (default: "masked")
+	Replacement string `mapstructure:"replacement" default:""`
+
+	// Metrics restricts the rule to metric names matching these patterns, using
+	// the same syntax as FilterConfig. Empty applies the rule to every metric.
+	Metrics []string `mapstructure:"metrics"`
+}
+
+// labelRule is a compiled LabelRule
+type labelRule struct {
+	label       string
+	drop        bool
+	replacement string
+	metrics     []func(string) bool
+}
+
+// compileLabelRules validates and compiles rules
+func compileLabelRules(rules []LabelRule) ([]labelRule, error) {
+	compiled := make([]labelRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Label == "" {
+			return nil, fmt.Errorf("metrics label rule %d: label is required", i)
+		}
+
+		action := rule.Action
+		if action == "" {
+			action = LabelActionDrop
+		}
+		if action != LabelActionDrop && action != LabelActionMask {
+			return nil, fmt.Errorf("metrics label rule %d: unknown action %q", i, rule.Action)
+		}
+
+		metrics, err := compilePatterns(rule.Metrics)
+		if err != nil {
+			return nil, fmt.Errorf("metrics label rule %d: %w", i, err)
+		}
+
+		replacement := rule.Replacement
+		if replacement == "" {
+			replacement = DefaultLabelMask
+		}
+
+		compiled = append(compiled, labelRule{
+			label:       rule.Label,
+			drop:        action == LabelActionDrop,
+			replacement: replacement,
+			metrics:     metrics,
+		})
+	}
+	return compiled, nil
+}
+
+// matchingRules returns the rules applying to the metric name
+func matchingRules(rules []labelRule, name string) []labelRule {
+	var matched []labelRule
+	for _, rule := range rules {
+		if len(rule.metrics) == 0 || matchAny(rule.metrics, name) {
+			matched = append(matched, rule)
+		}
+	}
+	return matched
+}
+
+// sameEffect reports whether a and b drop or mask the same label the same way
+func (r labelRule) sameEffect(o labelRule) bool {
+	return r.label == o.label && r.drop == o.drop && r.replacement == o.replacement
+}
+
+// labelPlan maps the label values passed by callers to the values sent to the provider
+type labelPlan struct {
+	inputs int
+	keep   []int
+	mask   map[int]string
+}
+
+// rewriteLabels applies rules to the labels of the metric name, updating
+// options in place. It returns nil when no label is affected.
+func rewriteLabels(rules []labelRule, name string, options *Options) labelMapper {
+	if plan := planLabels(rules, name, options); plan != nil {
+		return plan.apply
+	}
+	return nil
+}
+
+// planLabels computes the label plan for the metric name, updating options in place
+func planLabels(rules []labelRule, name string, options *Options) *labelPlan {
+	matched := matchingRules(rules, name)
+	if len(matched) == 0 {
+		return nil
+	}
+
+	find := func(label string) (labelRule, bool) {
+		for _, rule := range matched {
+			if rule.label == label {
+				return rule, true
+			}
+		}
+		return labelRule{}, false
+	}
+
+	if len(options.ConstLabels) > 0 {
+		constLabels := maps.Clone(options.ConstLabels)
+		for label := range constLabels {
+			if rule, ok := find(label); ok {
+				if rule.drop {
+					delete(constLabels, label)
+				} else {
+					constLabels[label] = rule.replacement
+				}
+			}
+		}
+		options.ConstLabels = constLabels
+	}
+
+	plan := &labelPlan{inputs: len(options.Labels)}
+	labels := make([]string, 0, len(options.Labels))
+	changed := false
+	for i, label := range options.Labels {
+		rule, ok := find(label)
+		if !ok {
+			plan.keep = append(plan.keep, i)
+			labels = append(labels, label)
+			continue
+		}
+
+		changed = true
+		if rule.drop {
+			continue
+		}
+		if plan.mask == nil {
+			plan.mask = make(map[int]string)
+		}
+		plan.mask[i] = rule.replacement
+		plan.keep = append(plan.keep, i)
+		labels = append(labels, label)
+	}
+
+	if !changed {
+		return nil
+	}
+	options.Labels = labels
+	return plan
+}
+
+// apply returns the provider label values for the caller's values. Values of
+// the wrong length are passed through so the provider reports the mismatch.
+func (p *labelPlan) apply(values []string) []string {
+	if len(values) != p.inputs {
+		return values
+	}
+
+	out := make([]string, len(p.keep))
+	for i, idx := range p.keep {
+		if replacement, ok := p.mask[idx]; ok {
+			out[i] = replacement
+		} else {
+			out[i] = values[idx]
+		}
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package metricsx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelRules(t *testing.T) {
+	newMetrics := func(t *testing.T, rules ...LabelRule) (Metrics, Provider) {
+		t.Helper()
+
+		res, err := NewMetrics(Params{
+			Config: Config{
+				Enabled:    true,
+				Provider:   "prometheus",
+				LabelRules: rules,
+				Prometheus: PrometheusConfig{Path: "/metrics"},
+			},
+			Logger: getTestLogger(),
+		})
+		require.NoError(t, err)
+		return res.Metrics, res.Provider
+	}
+
+	t.Run("drops labels everywhere and aggregates series", func(t *testing.T) {
+		m, provider := newMetrics(t, LabelRule{Label: "user_id"})
+
+		counter := m.Counter("logins_total", WithLabels("user_id", "method"))
+		counter.Inc("u1", "password")
+		counter.Inc("u2", "password")
+		m.Histogram("request_seconds", WithLabels("route", "user_id")).Observe(1, "/", "u1")
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `logins_total{method="password"} 2`)
+		assert.Contains(t, out, `request_seconds_count{route="/"} 1`)
+		assert.NotContains(t, out, "user_id")
+	})
+
+	t.Run("masks labels on matching metrics only", func(t *testing.T) {
+		m, provider := newMetrics(t, LabelRule{
+			Label:   "email",
+			Action:  LabelActionMask,
+			Metrics: []string{"signup_*"},
+		})
+
+		m.Counter("signup_total", WithLabels("email")).Inc("a@example.com")
+		m.Gauge("session_count", WithLabels("email")).Set(1, "b@example.com")
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `signup_total{email="masked"} 1`)
+		assert.Contains(t, out, `session_count{email="b@example.com"} 1`)
+	})
+
+	t.Run("applies to const labels", func(t *testing.T) {
+		m, provider := newMetrics(t,
+			LabelRule{Label: "pod"},
+			LabelRule{Label: "tenant", Action: LabelActionMask, Replacement: "redacted"},
+		)
+
+		m.GaugeFunc("queue_depth", func() float64 { return 3 },
+			WithConstLabels(map[string]string{"pod": "api-1", "tenant": "acme"}))
+
+		assert.Contains(t, scrape(t, provider), `queue_depth{tenant="redacted"} 3`)
+	})
+
+	t.Run("rejects invalid rules", func(t *testing.T) {
+		for _, rule := range []LabelRule{
+			{},
+			{Label: "user_id", Action: "hash"},
+			{Label: "user_id", Metrics: []string{"re:("}},
+		} {
+			_, err := NewMetrics(Params{
+				Config: Config{Provider: "noop", LabelRules: []LabelRule{rule}},
+				Logger: getTestLogger(),
+			})
+			assert.Error(t, err)
+		}
+	})
+}
@@ -0,0 +1,206 @@
+package metricsx
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/gostratum/core/configx"
+)
+
+// ErrRestartRequired is returned by Reload when the new configuration changes
+// settings that only take effect on restart
+var ErrRestartRequired = errors.New("metrics configuration change requires restart")
+
+// Reloadable is implemented by providers that can apply configuration at runtime
+type Reloadable interface {
+	// Reload applies cfg without restarting the provider
+	Reload(cfg Config) error
+}
+
+// ReloadFrom binds a fresh Config from loader and applies it to provider. It is
+// meant to be called from a reload trigger such as a SIGHUP handler. Providers
+// that do not implement Reloadable are left unchanged.
+func ReloadFrom(loader configx.Loader, provider Provider) error {
+	r, ok := provider.(Reloadable)
+	if !ok {
+		return nil
+	}
+
+	cfg, err := NewConfig(loader)
+	if err != nil {
+		return err
+	}
+	return r.Reload(cfg)
+}
+
+// restartFields returns the names of settings that differ between current and
+// next and cannot be changed at runtime
+func restartFields(current, next PrometheusConfig) []string {
+	var fields []string
+	check := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+
+	check("namespace", current.Namespace != next.Namespace)
+	check("subsystem", current.Subsystem != next.Subsystem)
+	check("path", current.Path != next.Path)
+	check("health_path", current.HealthPath != next.HealthPath)
+	check("json_path", current.JSONPath != next.JSONPath)
+	check("query_path", current.QueryPath != next.QueryPath)
+	check("catalog_path", current.CatalogPath != next.CatalogPath)
+	check("bucket_calibration", current.BucketCalibration != next.BucketCalibration)
+	check("native_histograms", current.NativeHistograms != next.NativeHistograms)
+	check("history", current.History != next.History)
+	check("subscription_interval", current.SubscriptionInterval != next.SubscriptionInterval)
+	check("enable_pprof", current.EnablePprof != next.EnablePprof)
+	check("endpoints", !reflect.DeepEqual(current.Endpoints, next.Endpoints))
+	check("compression", current.DisableCompression != next.DisableCompression ||
+		!slices.Equal(current.Compression, next.Compression))
+	check("enable_open_metrics", current.EnableOpenMetrics != next.EnableOpenMetrics)
+	check("max_requests_in_flight", current.MaxRequestsInFlight != next.MaxRequestsInFlight)
+	check("handler_timeout", current.HandlerTimeout != next.HandlerTimeout)
+	check("error_handling", current.ErrorHandling != next.ErrorHandling)
+	check("host", current.Host != next.Host)
+	check("port", current.Port != next.Port)
+	check("read_timeout", current.ReadTimeout != next.ReadTimeout)
+	check("read_header_timeout", current.ReadHeaderTimeout != next.ReadHeaderTimeout)
+	check("write_timeout", current.WriteTimeout != next.WriteTimeout)
+	check("idle_timeout", current.IdleTimeout != next.IdleTimeout)
+	check("disable_keep_alives", current.DisableKeepAlives != next.DisableKeepAlives)
+	check("max_header_bytes", current.MaxHeaderBytes != next.MaxHeaderBytes)
+	check("disable_http2", current.DisableHTTP2 != next.DisableHTTP2)
+	check("go_metrics_rules", !slices.Equal(current.GoMetricsRules, next.GoMetricsRules))
+	check("enable_all_go_metrics", current.EnableAllGoMetrics != next.EnableAllGoMetrics)
+	check("tls", current.TLS.CertFile != next.TLS.CertFile ||
+		current.TLS.KeyFile != next.TLS.KeyFile ||
+		current.TLS.ClientCAFile != next.TLS.ClientCAFile ||
+		!slices.Equal(current.TLS.AllowedSANs, next.TLS.AllowedSANs))
+
+	return fields
+}
+
+// moduleSettings are the module-level settings applied in front of the
+// provider. They are replaced as a whole on reload.
+type moduleSettings struct {
+	labelRules  []labelRule
+	cardinality CardinalityConfig
+	overrides   map[string]metricOverride
+	events      map[string]EventConfig
+}
+
+// newModuleSettings validates and compiles the module-level settings of cfg
+func newModuleSettings(cfg Config) (*moduleSettings, error) {
+	labelRules, err := compileLabelRules(cfg.LabelRules)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := compileOverrides(cfg.Overrides)
+	if err != nil {
+		return nil, err
+	}
+	return &moduleSettings{
+		labelRules:  labelRules,
+		cardinality: cfg.Cardinality,
+		overrides:   overrides,
+		events:      cfg.Events,
+	}, nil
+}
+
+// settings returns the current module-level settings
+func (m *metricsImpl) settings() *moduleSettings {
+	if s := m.live.Load(); s != nil {
+		return s
+	}
+	return &moduleSettings{}
+}
+
+// moduleReloadable is implemented by providers that apply the module-level
+// settings of the metrics created by NewMetrics when they are reloaded
+type moduleReloadable interface {
+	setModule(m *metricsImpl)
+}
+
+// prepareReload validates the module-level settings of cfg. It returns the
+// settings that cannot change at runtime and a function swapping in the
+// rest. Label rules, overrides, cardinality limits and events apply to
+// metrics registered after the reload; changing them for a metric that is
+// already registered requires a restart, as the registered series keep their
+// labels and buckets.
+func (m *metricsImpl) prepareReload(cfg Config) ([]string, func(), error) {
+	if m == nil {
+		return nil, func() {}, nil
+	}
+
+	next, err := newModuleSettings(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m.mu.Lock()
+	fields := moduleRestartFields(m.config, cfg)
+	fields = append(fields, m.settings().changed(next, m.registered, m.events)...)
+	m.mu.Unlock()
+
+	return fields, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.live.Store(next)
+		m.config = cfg
+	}, nil
+}
+
+// moduleRestartFields returns the names of module-level settings that differ
+// between current and next and only take effect on start
+func moduleRestartFields(current, next Config) []string {
+	var fields []string
+	check := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+
+	check("dry_run", current.DryRun != next.DryRun)
+	check("lint", current.Lint != next.Lint)
+	check("labels_from_env", !maps.Equal(current.LabelsFromEnv, next.LabelsFromEnv))
+	check("add_instance_label", current.AddInstanceLabel != next.AddInstanceLabel)
+	check("instance_id", current.InstanceID != next.InstanceID)
+	check("resource", current.Resource != next.Resource)
+	check("disk_usage_paths", !slices.Equal(current.DiskUsagePaths, next.DiskUsagePaths))
+	check("expected", !slices.Equal(current.Expected, next.Expected))
+	check("watch_interval", current.WatchInterval != next.WatchInterval)
+
+	return fields
+}
+
+// changed returns the settings that differ between s and next for a metric
+// in registered or an event in events
+func (s *moduleSettings) changed(next *moduleSettings, registered map[string]struct{}, events map[string]*eventCounter) []string {
+	var labelRules, overrides, cardinality, eventsChanged bool
+	for name := range registered {
+		labelRules = labelRules || !slices.EqualFunc(matchingRules(s.labelRules, name), matchingRules(next.labelRules, name), labelRule.sameEffect)
+		overrides = overrides || !s.overrides[name].equal(next.overrides[name])
+		cardinality = cardinality || s.cardinality.limit(name, &Options{}) != next.cardinality.limit(name, &Options{})
+	}
+	for name := range events {
+		current, updated := s.events[name], next.events[name]
+		eventsChanged = eventsChanged || current.Help != updated.Help || !slices.Equal(current.Labels, updated.Labels)
+	}
+
+	var fields []string
+	check := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+
+	check("label_rules", labelRules)
+	check("overrides", overrides)
+	check("cardinality", cardinality)
+	check("events", eventsChanged)
+
+	return fields
+}
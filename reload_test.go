@@ -0,0 +1,169 @@
+package metricsx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gostratum/core/configx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubLoader binds a fixed Config
+type stubLoader struct {
+	cfg Config
+}
+
+func (l stubLoader) Bind(c configx.Configurable) error {
+	*c.(*Config) = l.cfg
+	return nil
+}
+
+func (l stubLoader) BindEnv(key string, envVars ...string) error {
+	return nil
+}
+
+func TestPrometheusReload(t *testing.T) {
+	base := Config{
+		Enabled:    true,
+		Provider:   "prometheus",
+		Prometheus: PrometheusConfig{Path: "/metrics"},
+	}
+
+	t.Run("toggles default collectors", func(t *testing.T) {
+		provider := newPrometheusProvider(base.Prometheus, getTestLogger())
+		assert.NotContains(t, scrape(t, provider), "go_goroutines")
+
+		cfg := base
+		cfg.Prometheus.EnableGoMetrics = true
+		require.NoError(t, provider.(Reloadable).Reload(cfg))
+		assert.Contains(t, scrape(t, provider), "go_goroutines")
+
+		require.NoError(t, provider.(Reloadable).Reload(base))
+		assert.NotContains(t, scrape(t, provider), "go_goroutines")
+	})
+
+	t.Run("swaps endpoint auth", func(t *testing.T) {
+		provider := newPrometheusProvider(base.Prometheus, getTestLogger())
+		handler := provider.(*prometheusProvider).Handler()
+
+		cfg := base
+		cfg.Prometheus.Auth = AuthConfig{BearerToken: "token"}
+		require.NoError(t, provider.(Reloadable).Reload(cfg))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("rejects changes that require a restart", func(t *testing.T) {
+		provider := newPrometheusProvider(base.Prometheus, getTestLogger())
+
+		cfg := base
+		cfg.Prometheus.Port = 9090
+		cfg.Prometheus.EnableGoMetrics = true
+		err := provider.(Reloadable).Reload(cfg)
+		require.ErrorIs(t, err, ErrRestartRequired)
+		assert.Contains(t, err.Error(), "port")
+		assert.NotContains(t, scrape(t, provider), "go_goroutines")
+
+		cfg = base
+		cfg.Provider = "noop"
+		assert.ErrorIs(t, provider.(Reloadable).Reload(cfg), ErrRestartRequired)
+	})
+
+	t.Run("reloads from a loader", func(t *testing.T) {
+		provider := newPrometheusProvider(base.Prometheus, getTestLogger())
+
+		cfg := base
+		cfg.Prometheus.EnableGoMetrics = true
+		require.NoError(t, ReloadFrom(stubLoader{cfg: cfg}, provider))
+		assert.Contains(t, scrape(t, provider), "go_goroutines")
+
+		assert.NoError(t, ReloadFrom(stubLoader{cfg: cfg}, newNoopProvider()))
+	})
+}
+
+func TestModuleReload(t *testing.T) {
+	base := Config{
+		Enabled:    true,
+		Provider:   "prometheus",
+		Prometheus: PrometheusConfig{Path: "/metrics"},
+	}
+	newModule := func(t *testing.T, cfg Config) Result {
+		t.Helper()
+		res, err := NewMetrics(Params{Config: cfg, Logger: getTestLogger()})
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("applies label rules to metrics registered afterwards", func(t *testing.T) {
+		res := newModule(t, base)
+		res.Metrics.Counter("orders_total", WithLabels("user_id")).Inc("42")
+
+		cfg := base
+		cfg.LabelRules = []LabelRule{{Label: "user_id", Metrics: []string{"logins_total"}}}
+		require.NoError(t, ReloadFrom(stubLoader{cfg: cfg}, res.Provider))
+
+		res.Metrics.Counter("logins_total", WithLabels("user_id")).Inc("42")
+		out := scrape(t, res.Provider)
+		assert.Contains(t, out, `orders_total{user_id="42"} 1`)
+		assert.Contains(t, out, "logins_total 1")
+	})
+
+	t.Run("requires a restart to change registered metrics", func(t *testing.T) {
+		res := newModule(t, base)
+		res.Metrics.Counter("orders_total", WithLabels("user_id"))
+		res.Metrics.Histogram("latency_seconds")
+		res.Metrics.Event("signup", nil)
+
+		cfg := base
+		cfg.LabelRules = []LabelRule{{Label: "user_id"}}
+		cfg.Overrides = map[string]MetricOverride{"latency_seconds": {Buckets: []float64{1, 2}}}
+		cfg.Cardinality = CardinalityConfig{MaxSeries: 10}
+		cfg.Events = map[string]EventConfig{"signup": {Labels: []string{"plan"}}}
+		cfg.Prometheus.EnableGoMetrics = true
+
+		err := ReloadFrom(stubLoader{cfg: cfg}, res.Provider)
+		require.ErrorIs(t, err, ErrRestartRequired)
+		assert.Contains(t, err.Error(), "label_rules, overrides, cardinality, events")
+		assert.NotContains(t, scrape(t, res.Provider), "go_goroutines")
+
+		res.Metrics.Counter("orders_total", WithLabels("user_id")).Inc("42")
+		assert.Contains(t, scrape(t, res.Provider), `orders_total{user_id="42"} 1`)
+	})
+
+	t.Run("requires a restart for startup settings", func(t *testing.T) {
+		res := newModule(t, base)
+
+		cfg := base
+		cfg.LabelsFromEnv = map[string]string{"pod": "POD_NAME"}
+		cfg.AddInstanceLabel = true
+		err := ReloadFrom(stubLoader{cfg: cfg}, res.Provider)
+		require.ErrorIs(t, err, ErrRestartRequired)
+		assert.Contains(t, err.Error(), "labels_from_env, add_instance_label")
+	})
+
+	t.Run("rejects invalid rules", func(t *testing.T) {
+		res := newModule(t, base)
+
+		cfg := base
+		cfg.LabelRules = []LabelRule{{Label: "user_id", Action: "hash"}}
+		assert.ErrorContains(t, ReloadFrom(stubLoader{cfg: cfg}, res.Provider), "unknown action")
+	})
+
+	t.Run("reloads module settings of other providers", func(t *testing.T) {
+		noop := base
+		noop.Provider = "noop"
+		res := newModule(t, noop)
+		res.Metrics.Counter("orders_total", WithLabels("user_id"))
+
+		cfg := noop
+		cfg.LabelRules = []LabelRule{{Label: "user_id"}}
+		assert.ErrorIs(t, ReloadFrom(stubLoader{cfg: cfg}, res.Provider), ErrRestartRequired)
+
+		cfg.LabelRules = []LabelRule{{Label: "user_id", Metrics: []string{"logins_total"}}}
+		assert.NoError(t, ReloadFrom(stubLoader{cfg: cfg}, res.Provider))
+	})
+}
@@ -0,0 +1,243 @@
+package metricsx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resource attribute keys
+const (
+	ResourceCloudProvider   = "cloud_provider"
+	ResourceCloudRegion     = "cloud_region"
+	ResourceCloudZone       = "cloud_zone"
+	ResourceCloudInstanceID = "cloud_instance_id"
+	ResourceK8sNamespace    = "k8s_namespace"
+	ResourceK8sPod          = "k8s_pod"
+	ResourceK8sNode         = "k8s_node"
+)
+
+// Resource describes where the process runs, as label names to values
+type Resource map[string]string
+
+// ResourceConfig controls detection of the environment the process runs in
+type ResourceConfig struct {
+	// Detect queries cloud metadata endpoints and Kubernetes environment
+	// variables at startup and exports the result as resource_info
+	Detect bool `mapstructure:"detect" default:"false"`
+
+	// Timeout bounds the time spent querying metadata endpoints
+	Timeout time.Duration `mapstructure:"timeout" default:"2s"`
+}
+
+// DefaultResourceTimeout bounds resource detection when ResourceConfig.Timeout is zero
+const DefaultResourceTimeout = 2 * time.Second
+
+// Cloud metadata endpoints
+const (
+	linkLocalMetadataURL = "http://169.254.169.254"
+	gceMetadataURL       = "http://metadata.google.internal"
+)
+
+// resourceDetector queries metadata endpoints; the URLs are fields so tests can point them at fakes
+type resourceDetector struct {
+	client   *http.Client
+	awsURL   string
+	gceURL   string
+	azureURL string
+	getenv   func(string) string
+}
+
+// DetectResource detects the cloud provider, region, zone and instance from
+// EC2, GCE or Azure metadata endpoints, and the namespace, pod and node from
+// Kubernetes environment variables. Attributes that cannot be detected before
+// ctx is done are omitted.
+func DetectResource(ctx context.Context) Resource {
+	return (&resourceDetector{
+		client:   &http.Client{},
+		awsURL:   linkLocalMetadataURL,
+		gceURL:   gceMetadataURL,
+		azureURL: linkLocalMetadataURL,
+		getenv:   os.Getenv,
+	}).detect(ctx)
+}
+
+// detect runs all detectors and merges their results
+func (d *resourceDetector) detect(ctx context.Context) Resource {
+	detectors := []func(context.Context) (Resource, error){d.aws, d.gce, d.azure}
+
+	results := make([]Resource, len(detectors))
+	var wg sync.WaitGroup
+	for i, detect := range detectors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if res, err := detect(ctx); err == nil {
+				results[i] = res
+			}
+		}()
+	}
+	wg.Wait()
+
+	resource := d.kubernetes()
+	for _, res := range results {
+		if res != nil {
+			for k, v := range res {
+				if v != "" {
+					resource[k] = v
+				}
+			}
+			break
+		}
+	}
+	return resource
+}
+
+// aws reads the EC2 instance identity document using IMDSv2
+func (d *resourceDetector) aws(ctx context.Context) (Resource, error) {
+	token, err := d.fetch(ctx, http.MethodPut, d.awsURL+"/latest/api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := d.fetch(ctx, http.MethodGet, d.awsURL+"/latest/dynamic/instance-identity/document",
+		map[string]string{"X-aws-ec2-metadata-token": string(token)})
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+		InstanceID       string `json:"instanceId"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+
+	return Resource{
+		ResourceCloudProvider:   "aws",
+		ResourceCloudRegion:     doc.Region,
+		ResourceCloudZone:       doc.AvailabilityZone,
+		ResourceCloudInstanceID: doc.InstanceID,
+	}, nil
+}
+
+// gce reads the Compute Engine instance metadata
+func (d *resourceDetector) gce(ctx context.Context) (Resource, error) {
+	body, err := d.fetch(ctx, http.MethodGet, d.gceURL+"/computeMetadata/v1/instance/?recursive=true",
+		map[string]string{"Metadata-Flavor": "Google"})
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		ID   json.Number `json:"id"`
+		Zone string      `json:"zone"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+
+	// The zone is reported as projects/<project>/zones/<zone>
+	zone := doc.Zone[strings.LastIndex(doc.Zone, "/")+1:]
+	region := zone
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		region = zone[:i]
+	}
+
+	return Resource{
+		ResourceCloudProvider:   "gcp",
+		ResourceCloudRegion:     region,
+		ResourceCloudZone:       zone,
+		ResourceCloudInstanceID: doc.ID.String(),
+	}, nil
+}
+
+// azure reads the Azure instance metadata
+func (d *resourceDetector) azure(ctx context.Context) (Resource, error) {
+	body, err := d.fetch(ctx, http.MethodGet, d.azureURL+"/metadata/instance/compute?api-version=2021-02-01",
+		map[string]string{"Metadata": "true"})
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+		VMID     string `json:"vmId"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+
+	return Resource{
+		ResourceCloudProvider:   "azure",
+		ResourceCloudRegion:     doc.Location,
+		ResourceCloudZone:       doc.Zone,
+		ResourceCloudInstanceID: doc.VMID,
+	}, nil
+}
+
+// kubernetes reads the pod environment when running in a cluster
+func (d *resourceDetector) kubernetes() Resource {
+	resource := Resource{}
+	if d.getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return resource
+	}
+
+	for key, envs := range map[string][]string{
+		ResourceK8sNamespace: {"POD_NAMESPACE"},
+		ResourceK8sPod:       {"POD_NAME", "HOSTNAME"},
+		ResourceK8sNode:      {"NODE_NAME"},
+	} {
+		for _, env := range envs {
+			if value := d.getenv(env); value != "" {
+				resource[key] = value
+				break
+			}
+		}
+	}
+	return resource
+}
+
+// fetch performs a metadata request and returns the body of a 200 response
+func (d *resourceDetector) fetch(ctx context.Context, method, url string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata request %s returned %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// registerResourceInfo exports resource as a resource_info gauge with value 1
+func registerResourceInfo(m Metrics, resource Resource) {
+	if len(resource) == 0 {
+		return
+	}
+
+	m.GaugeFunc("resource_info", func() float64 { return 1 },
+		WithHelp("Environment the process runs in, exposed as labels."),
+		WithConstLabels(resource),
+	)
+}
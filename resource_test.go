@@ -0,0 +1,111 @@
+package metricsx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectResource(t *testing.T) {
+	unavailable := httptest.NewServer(http.NotFoundHandler())
+	defer unavailable.Close()
+
+	newDetector := func(env map[string]string) *resourceDetector {
+		return &resourceDetector{
+			client:   unavailable.Client(),
+			awsURL:   unavailable.URL,
+			gceURL:   unavailable.URL,
+			azureURL: unavailable.URL,
+			getenv:   func(key string) string { return env[key] },
+		}
+	}
+
+	t.Run("detects EC2 with IMDSv2", func(t *testing.T) {
+		aws := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+				w.Write([]byte("token"))
+			case r.URL.Path == "/latest/dynamic/instance-identity/document" && r.Header.Get("X-aws-ec2-metadata-token") == "token":
+				w.Write([]byte(`{"region":"eu-west-1","availabilityZone":"eu-west-1b","instanceId":"i-0abc"}`))
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}))
+		defer aws.Close()
+
+		d := newDetector(nil)
+		d.awsURL = aws.URL
+
+		assert.Equal(t, Resource{
+			ResourceCloudProvider:   "aws",
+			ResourceCloudRegion:     "eu-west-1",
+			ResourceCloudZone:       "eu-west-1b",
+			ResourceCloudInstanceID: "i-0abc",
+		}, d.detect(context.Background()))
+	})
+
+	t.Run("detects GCE", func(t *testing.T) {
+		gce := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"id":4520031799277581759,"zone":"projects/123/zones/us-central1-a"}`))
+		}))
+		defer gce.Close()
+
+		d := newDetector(nil)
+		d.gceURL = gce.URL
+
+		assert.Equal(t, Resource{
+			ResourceCloudProvider:   "gcp",
+			ResourceCloudRegion:     "us-central1",
+			ResourceCloudZone:       "us-central1-a",
+			ResourceCloudInstanceID: "4520031799277581759",
+		}, d.detect(context.Background()))
+	})
+
+	t.Run("detects Azure and Kubernetes", func(t *testing.T) {
+		azure := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Metadata") != "true" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"location":"westeurope","zone":"","vmId":"02aab8a4"}`))
+		}))
+		defer azure.Close()
+
+		d := newDetector(map[string]string{
+			"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+			"POD_NAMESPACE":           "shop",
+			"HOSTNAME":                "api-7d9f",
+			"NODE_NAME":               "node-3",
+		})
+		d.azureURL = azure.URL
+
+		assert.Equal(t, Resource{
+			ResourceCloudProvider:   "azure",
+			ResourceCloudRegion:     "westeurope",
+			ResourceCloudInstanceID: "02aab8a4",
+			ResourceK8sNamespace:    "shop",
+			ResourceK8sPod:          "api-7d9f",
+			ResourceK8sNode:         "node-3",
+		}, d.detect(context.Background()))
+	})
+
+	t.Run("returns an empty resource outside clouds", func(t *testing.T) {
+		assert.Empty(t, newDetector(nil).detect(context.Background()))
+	})
+}
+
+func TestResourceInfo(t *testing.T) {
+	provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+	m := &metricsImpl{provider: provider, logger: getTestLogger()}
+
+	registerResourceInfo(m, Resource{ResourceCloudProvider: "aws", ResourceCloudRegion: "eu-west-1"})
+
+	assert.Contains(t, scrape(t, provider), `resource_info{cloud_provider="aws",cloud_region="eu-west-1"} 1`)
+}
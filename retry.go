@@ -0,0 +1,95 @@
+package metricsx
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRetryAttemptBuckets are the buckets for the attempts-per-operation histogram
+var DefaultRetryAttemptBuckets = []float64{1, 2, 3, 4, 5, 7, 10, 15, 20}
+
+// Retry outcome label values
+const (
+	RetryOutcomeSuccess = "success"
+	RetryOutcomeFailure = "failure"
+)
+
+// RetryObserver records metrics for retry loops: attempts per operation, final
+// outcome, and total latency including backoff
+type RetryObserver struct {
+	attempts Histogram
+	outcomes Counter
+	duration Histogram
+}
+
+// NewRetryObserver creates the retry metrics. Options apply to the duration histogram.
+func NewRetryObserver(m Metrics, opts ...Option) *RetryObserver {
+	return &RetryObserver{
+		attempts: m.Histogram("retry_attempts",
+			WithHelp("Number of attempts made per retried operation."),
+			WithLabels("operation"),
+			WithBuckets(DefaultRetryAttemptBuckets...),
+		),
+		outcomes: m.Counter("retry_operations_total",
+			WithHelp("Total number of retried operations by final outcome."),
+			WithLabels("operation", "outcome"),
+		),
+		duration: m.Histogram("retry_duration_seconds", append([]Option{
+			WithHelp("Total time spent on an operation including all retries, in seconds."),
+			WithLabels("operation"),
+		}, opts...)...),
+	}
+}
+
+// Start begins observing one execution of a retry loop for operation
+func (r *RetryObserver) Start(operation string) *RetryOperation {
+	return &RetryOperation{
+		observer:  r,
+		operation: operation,
+		start:     time.Now(),
+	}
+}
+
+// RetryOperation tracks a single execution of a retry loop
+type RetryOperation struct {
+	observer  *RetryObserver
+	operation string
+	start     time.Time
+	attempts  atomic.Int64
+	retries   atomic.Int64
+	done      atomic.Bool
+}
+
+// Attempt records that an attempt is about to be made
+func (o *RetryOperation) Attempt() {
+	o.attempts.Add(1)
+}
+
+// Notify records a retry; its signature matches backoff.Notify from
+// github.com/cenkalti/backoff
+func (o *RetryOperation) Notify(err error, next time.Duration) {
+	o.retries.Add(1)
+}
+
+// OnRetry records a retry; its signature matches retry.OnRetryFunc from
+// github.com/avast/retry-go
+func (o *RetryOperation) OnRetry(attempt uint, err error) {
+	o.retries.Add(1)
+}
+
+// Done records the final outcome of the operation. Only the first call has an effect.
+func (o *RetryOperation) Done(err error) {
+	if !o.done.CompareAndSwap(false, true) {
+		return
+	}
+
+	attempts := max(o.attempts.Load(), o.retries.Load()+1)
+	outcome := RetryOutcomeSuccess
+	if err != nil {
+		outcome = RetryOutcomeFailure
+	}
+
+	o.observer.attempts.Observe(float64(attempts), o.operation)
+	o.observer.outcomes.Inc(o.operation, outcome)
+	o.observer.duration.Observe(time.Since(o.start).Seconds(), o.operation)
+}
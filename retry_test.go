@@ -0,0 +1,55 @@
+package metricsx
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryObserver(t *testing.T) {
+	t.Run("records attempts and success", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		obs := NewRetryObserver(&metricsImpl{provider: provider, logger: getTestLogger()})
+
+		op := obs.Start("fetch_user")
+		for i := 0; i < 3; i++ {
+			op.Attempt()
+		}
+		op.Done(nil)
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `retry_attempts_sum{operation="fetch_user"} 3`)
+		assert.Contains(t, out, `retry_operations_total{operation="fetch_user",outcome="success"} 1`)
+		assert.Contains(t, out, `retry_duration_seconds_count{operation="fetch_user"} 1`)
+	})
+
+	t.Run("counts retries from library callbacks", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		obs := NewRetryObserver(&metricsImpl{provider: provider, logger: getTestLogger()})
+
+		op := obs.Start("publish")
+		op.Notify(errors.New("timeout"), time.Millisecond)
+		op.OnRetry(1, errors.New("timeout"))
+		op.Done(errors.New("gave up"))
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `retry_attempts_sum{operation="publish"} 3`)
+		assert.Contains(t, out, `retry_operations_total{operation="publish",outcome="failure"} 1`)
+	})
+
+	t.Run("done is idempotent", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics"}, getTestLogger())
+		obs := NewRetryObserver(&metricsImpl{provider: provider, logger: getTestLogger()}, WithBuckets(1, 10))
+
+		op := obs.Start("sync")
+		op.Done(nil)
+		op.Done(errors.New("late failure"))
+
+		out := scrape(t, provider)
+		assert.Contains(t, out, `retry_operations_total{operation="sync",outcome="success"} 1`)
+		assert.NotContains(t, out, `outcome="failure"`)
+		assert.Contains(t, out, `retry_duration_seconds_bucket{operation="sync",le="1"} 1`)
+	})
+}
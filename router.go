@@ -0,0 +1,56 @@
+package metricsx
+
+import (
+	"net/http"
+
+	"github.com/gostratum/core/logx"
+	"go.uber.org/fx"
+)
+
+// Router is a router the metrics handler can be mounted on when metrics are
+// exposed on the main HTTP server. *http.ServeMux and chi.Router implement it.
+// metricsx does not find the application's router by itself: mounting is
+// manual, through a Router the application provides to the fx graph.
+type Router interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// routerParams contains the dependencies for mounting the metrics handler
+type routerParams struct {
+	fx.In
+	Config   Config
+	Provider Provider
+	Logger   logx.Logger
+	Router   Router `optional:"true"`
+}
+
+// mountHandler mounts the provider's handler at the configured path on the
+// Router provided by the application when the provider runs no server of its
+// own. Without a Router in the graph it only logs that the handler must be
+// mounted by hand.
+func mountHandler(p routerParams) {
+	if p.Config.Prometheus.Port != 0 {
+		return
+	}
+
+	var handlers map[string]http.Handler
+	switch h := p.Provider.(type) {
+	case multiHandler:
+		handlers = h.Handlers()
+	case interface{ Handler() http.Handler }:
+		handlers = map[string]http.Handler{p.Config.Prometheus.Path: h.Handler()}
+	default:
+		return
+	}
+
+	if p.Router == nil {
+		p.Logger.Info("metrics handler not mounted: no metricsx.Router provided, mount Provider.Handler() on the main HTTP server",
+			logx.String("path", p.Config.Prometheus.Path))
+		return
+	}
+
+	for path, handler := range handlers {
+		p.Router.Handle(path, handler)
+		p.Logger.Info("metrics registered on main HTTP server", logx.String("path", path))
+	}
+}
@@ -0,0 +1,65 @@
+package metricsx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gostratum/core/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRegisterHandler(t *testing.T) {
+	newApp := func(cfg Config, options ...fx.Option) {
+		t.Helper()
+
+		app := fx.New(append([]fx.Option{
+			fx.NopLogger,
+			fx.Supply(cfg),
+			fx.Provide(func() logx.Logger { return getTestLogger() }, NewMetrics),
+			fx.Invoke(mountHandler),
+		}, options...)...)
+		require.NoError(t, app.Err())
+	}
+
+	cfg := Config{
+		Provider:   "prometheus",
+		Prometheus: PrometheusConfig{Path: "/internal/metrics"},
+	}
+
+	t.Run("mounts the handler on the main router", func(t *testing.T) {
+		mux := http.NewServeMux()
+		newApp(cfg, fx.Provide(func() Router { return mux }), fx.Invoke(func(m Metrics) {
+			m.Counter("orders_total").Inc()
+		}))
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/internal/metrics", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "orders_total 1")
+	})
+
+	t.Run("skips a dedicated metrics server", func(t *testing.T) {
+		mux := http.NewServeMux()
+		dedicated := cfg
+		dedicated.Prometheus.Port = 9090
+		newApp(dedicated, fx.Provide(func() Router { return mux }))
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/internal/metrics", nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("logs when there is no router", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+		newApp(cfg, fx.Decorate(func() logx.Logger { return logx.ProvideAdapter(zap.New(core)) }))
+
+		entries := logs.FilterMessageSnippet("no metricsx.Router provided").All()
+		require.Len(t, entries, 1)
+		assert.Equal(t, "/internal/metrics", entries[0].ContextMap()["path"])
+	})
+}
@@ -0,0 +1,102 @@
+package metricsx
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gostratum/core/logx"
+)
+
+// DefaultScrapeHookTimeout bounds the scrape hooks when
+// PrometheusConfig.ScrapeHookTimeout is zero
+const DefaultScrapeHookTimeout = 5 * time.Second
+
+// ScrapeHooker is implemented by providers that can refresh values right
+// before their metrics are exported
+type ScrapeHooker interface {
+	// OnScrape registers fn to run before every export
+	OnScrape(fn func(ctx context.Context) error)
+}
+
+// OnScrape registers fn to run before every export: each scrape of the
+// metrics path or an additional endpoint, each push and each Gather of
+// Gatherer(). Use it to refresh expensive gauges, such as disk usage or the
+// depth of an external queue, exactly once per scrape instead of on a timer.
+// Snapshots, and the history, subscriptions and watches built on them, read
+// the current values without running hooks. Hooks run concurrently, bounded
+// by ScrapeHookTimeout; a failing or late hook is logged and counted in
+// metricsx_scrape_hook_errors_total, and the export serves the previous
+// values.
+func (p *prometheusProvider) OnScrape(fn func(ctx context.Context) error) {
+	p.scrapeHooks.add(fn)
+}
+
+// scrapeHooks are the functions run before every export
+type scrapeHooks struct {
+	timeout atomic.Int64
+
+	mu    sync.RWMutex
+	hooks []func(ctx context.Context) error
+}
+
+// add registers fn
+func (h *scrapeHooks) add(fn func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, fn)
+}
+
+// setTimeout bounds the hooks by timeout, DefaultScrapeHookTimeout when zero
+func (h *scrapeHooks) setTimeout(timeout time.Duration) {
+	h.timeout.Store(int64(cmp.Or(timeout, DefaultScrapeHookTimeout)))
+}
+
+// run calls every hook and returns the errors they reported once all have
+// returned or the timeout expires, whichever comes first. Hooks still running
+// at the deadline are reported as timed out and left to finish in the
+// background.
+func (h *scrapeHooks) run() []error {
+	h.mu.RLock()
+	hooks := h.hooks
+	h.mu.RUnlock()
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	timeout := cmp.Or(time.Duration(h.timeout.Load()), DefaultScrapeHookTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	results := make(chan error, len(hooks))
+	for _, hook := range hooks {
+		go func() {
+			results <- hook(ctx)
+		}()
+	}
+
+	var errs []error
+	for done := 0; done < len(hooks); done++ {
+		select {
+		case err := <-results:
+			if err != nil {
+				errs = append(errs, err)
+			}
+		case <-ctx.Done():
+			late := len(hooks) - done
+			return append(errs, fmt.Errorf("%d scrape hooks still running after %s: %w", late, timeout, ctx.Err()))
+		}
+	}
+	return errs
+}
+
+// runScrapeHooks runs the scrape hooks, logging and counting their failures
+func (p *prometheusProvider) runScrapeHooks() {
+	for _, err := range p.scrapeHooks.run() {
+		p.selfCollector.scrapeHookErrors.Inc()
+		p.logger.Warn("metrics scrape hook failed", logx.Err(err))
+	}
+}
@@ -0,0 +1,111 @@
+package metricsx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnScrape(t *testing.T) {
+	t.Run("refreshes values before every gather", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger()).(*prometheusProvider)
+		usage := provider.Gauge("disk_used_bytes", applyOptions())
+
+		var refreshes atomic.Int64
+		provider.OnScrape(func(ctx context.Context) error {
+			usage.Set(float64(1000 * refreshes.Add(1)))
+			return nil
+		})
+		assert.Zero(t, refreshes.Load())
+
+		assert.Contains(t, scrape(t, provider), "disk_used_bytes 1000")
+		assert.Contains(t, scrape(t, provider), "disk_used_bytes 2000")
+		assert.Equal(t, int64(2), refreshes.Load())
+	})
+
+	t.Run("keeps gathering when a hook fails", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{EnableSelfMetrics: true}, getTestLogger()).(*prometheusProvider)
+		provider.Gauge("queue_depth", applyOptions()).Set(7)
+		provider.OnScrape(func(ctx context.Context) error {
+			return errors.New("queue unreachable")
+		})
+
+		body := scrape(t, provider)
+		assert.Contains(t, body, "queue_depth 7")
+		assert.Contains(t, body, "metricsx_scrape_hook_errors_total 1")
+	})
+
+	t.Run("bounds hooks by the timeout", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{ScrapeHookTimeout: 10 * time.Millisecond}, getTestLogger()).(*prometheusProvider)
+
+		cause := make(chan error, 1)
+		provider.OnScrape(func(ctx context.Context) error {
+			<-ctx.Done()
+			cause <- ctx.Err()
+			return ctx.Err()
+		})
+
+		scrape(t, provider)
+		assert.ErrorIs(t, <-cause, context.DeadlineExceeded)
+	})
+	t.Run("does not wait for late hooks", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{
+			EnableSelfMetrics: true,
+			ScrapeHookTimeout: 10 * time.Millisecond,
+		}, getTestLogger()).(*prometheusProvider)
+
+		release := make(chan struct{})
+		defer close(release)
+		provider.OnScrape(func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+
+		start := time.Now()
+		body := scrape(t, provider)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Contains(t, body, "metricsx_scrape_hook_errors_total 1")
+	})
+
+	t.Run("applies the timeout on reload", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger()).(*prometheusProvider)
+
+		release := make(chan struct{})
+		defer close(release)
+		provider.OnScrape(func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+
+		assert.NoError(t, provider.Reload(Config{
+			Provider:   "prometheus",
+			Prometheus: PrometheusConfig{ScrapeHookTimeout: 10 * time.Millisecond},
+		}))
+
+		start := time.Now()
+		scrape(t, provider)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("does not run on snapshots", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger()).(*prometheusProvider)
+
+		var runs atomic.Int64
+		provider.OnScrape(func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		})
+
+		_, err := provider.Snapshot()
+		assert.NoError(t, err)
+		assert.Zero(t, runs.Load())
+
+		_, err = provider.Gatherer().Gather()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), runs.Load())
+	})
+}
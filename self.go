@@ -0,0 +1,120 @@
+package metricsx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Registration error reasons of metricsx_registration_errors_total
+const (
+	RegistrationDuplicate = "duplicate"
+	RegistrationConflict  = "conflict"
+	RegistrationInvalid   = "invalid"
+)
+
+// selfMetrics describe the health of the metrics pipeline itself. Like
+// scrapeMetrics it is a single collector registered and unregistered as one.
+type selfMetrics struct {
+	registrationErrors *prometheus.CounterVec
+	expired            *prometheus.CounterVec
+	series             *prometheus.GaugeVec
+	pushes             *prometheus.CounterVec
+	pushRetries        prometheus.Counter
+	scrapeHookErrors   prometheus.Counter
+	pushDuration       prometheus.Histogram
+}
+
+// newSelfMetrics creates the pipeline metrics
+func newSelfMetrics() *selfMetrics {
+	return &selfMetrics{
+		registrationErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "metricsx_registration_errors_total",
+			Help: "Total number of metric registrations rejected by the registry, by reason.",
+		}, []string{"reason"}),
+		expired: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "metricsx_series_expired_total",
+			Help: "Total number of series deleted because they were not written within the metric TTL.",
+		}, []string{"metric"}),
+		series: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "metricsx_series",
+			Help: "Number of series per metric family as of the previous gather.",
+		}, []string{"metric"}),
+		pushes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "metricsx_pushes_total",
+			Help: "Total number of pushes by result.",
+		}, []string{"result"}),
+		pushRetries: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "metricsx_push_retries_total",
+			Help: "Total number of retries of failed pushes.",
+		}),
+		scrapeHookErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "metricsx_scrape_hook_errors_total",
+			Help: "Total number of OnScrape hooks that returned an error.",
+		}),
+		pushDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name: "metricsx_push_duration_seconds",
+			Help: "Duration of pushes in seconds.",
+		}),
+	}
+}
+
+// registrationFailed counts err returned by Registry.Register
+func (s *selfMetrics) registrationFailed(err error) {
+	reason := RegistrationInvalid
+	var already prometheus.AlreadyRegisteredError
+	switch {
+	case errors.As(err, &already):
+		reason = RegistrationDuplicate
+	case errors.Is(err, errSchemaConflict), strings.Contains(err.Error(), "previously registered descriptor"):
+		reason = RegistrationConflict
+	}
+	s.registrationErrors.WithLabelValues(reason).Inc()
+}
+
+// observeSeries records the number of series of every gathered family
+func (s *selfMetrics) observeSeries(mfs []*dto.MetricFamily) {
+	for _, mf := range mfs {
+		s.series.WithLabelValues(mf.GetName()).Set(float64(len(mf.GetMetric())))
+	}
+}
+
+// instrumentPush wraps push with the push counter and duration
+func (s *selfMetrics) instrumentPush(push func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		start := time.Now()
+		err := push(ctx)
+		s.pushDuration.Observe(time.Since(start).Seconds())
+
+		result := "success"
+		if err != nil {
+			result = "failure"
+		}
+		s.pushes.WithLabelValues(result).Inc()
+		return err
+	}
+}
+
+func (s *selfMetrics) Describe(ch chan<- *prometheus.Desc) {
+	s.registrationErrors.Describe(ch)
+	s.expired.Describe(ch)
+	s.series.Describe(ch)
+	s.pushes.Describe(ch)
+	s.pushRetries.Describe(ch)
+	s.scrapeHookErrors.Describe(ch)
+	s.pushDuration.Describe(ch)
+}
+
+func (s *selfMetrics) Collect(ch chan<- prometheus.Metric) {
+	s.registrationErrors.Collect(ch)
+	s.expired.Collect(ch)
+	s.series.Collect(ch)
+	s.pushes.Collect(ch)
+	s.pushRetries.Collect(ch)
+	s.scrapeHookErrors.Collect(ch)
+	s.pushDuration.Collect(ch)
+}
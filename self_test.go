@@ -0,0 +1,95 @@
+package metricsx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfMetrics(t *testing.T) {
+	newProvider := func(t *testing.T, enabled bool) *prometheusProvider {
+		res, err := NewMetrics(Params{
+			Config: Config{Provider: "prometheus", Prometheus: PrometheusConfig{EnableSelfMetrics: enabled}},
+			Logger: getTestLogger(),
+		})
+		require.NoError(t, err)
+		return res.Provider.(*prometheusProvider)
+	}
+
+	t.Run("counts rejected registrations", func(t *testing.T) {
+		provider := newProvider(t, true)
+
+		provider.Counter("orders_total", applyOptions(WithLabels("region")))
+		assert.Panics(t, func() {
+			provider.Counter("orders_total", applyOptions(WithLabels("country")))
+		})
+		assert.Panics(t, func() {
+			provider.Gauge("queue_depth", applyOptions(WithLabels("queue"), WithConstLabels(map[string]string{"queue": "orders"})))
+		})
+
+		body := scrape(t, provider)
+		assert.Contains(t, body, `metricsx_registration_errors_total{reason="conflict"} 1`)
+		assert.Contains(t, body, `metricsx_registration_errors_total{reason="invalid"} 1`)
+	})
+
+	t.Run("reports series per metric as of the previous gather", func(t *testing.T) {
+		provider := newProvider(t, true)
+
+		counter := provider.Counter("orders_total", applyOptions(WithLabels("region")))
+		counter.Inc("eu")
+		counter.Inc("us")
+		counter.Inc("ap")
+
+		scrape(t, provider)
+		assert.Contains(t, scrape(t, provider), `metricsx_series{metric="orders_total"} 3`)
+	})
+
+	t.Run("counts expired series", func(t *testing.T) {
+		provider := newProvider(t, true)
+		clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+
+		gauge := provider.Gauge("pod_memory_bytes", applyOptions(WithLabels("pod"), WithTTL(time.Minute)))
+		gauge.(*prometheusGaugeVec).children.expiry.now = clock.Now
+		provider.Gauge("queue_depth", applyOptions(WithLabels("queue")))
+
+		gauge.Set(1, "web-1")
+		gauge.Set(1, "web-2")
+		clock.Advance(2 * time.Minute)
+		scrape(t, provider)
+
+		body := scrape(t, provider)
+		assert.Contains(t, body, `metricsx_series_expired_total{metric="pod_memory_bytes"} 2`)
+		assert.NotContains(t, body, `metricsx_series_expired_total{metric="queue_depth"}`)
+	})
+
+	t.Run("instruments pushes", func(t *testing.T) {
+		provider := newProvider(t, true)
+
+		push := provider.selfCollector.instrumentPush(func(ctx context.Context) error {
+			return errors.New("gateway unavailable")
+		})
+		assert.Error(t, push(context.Background()))
+		push = provider.selfCollector.instrumentPush(func(ctx context.Context) error { return nil })
+		assert.NoError(t, push(context.Background()))
+
+		body := scrape(t, provider)
+		assert.Contains(t, body, `metricsx_pushes_total{result="failure"} 1`)
+		assert.Contains(t, body, `metricsx_pushes_total{result="success"} 1`)
+		assert.Contains(t, body, "metricsx_push_duration_seconds_count 2")
+	})
+
+	t.Run("can be disabled and reloaded", func(t *testing.T) {
+		provider := newProvider(t, false)
+		provider.Counter("orders_total", applyOptions()).Inc()
+
+		scrape(t, provider)
+		assert.NotContains(t, scrape(t, provider), "metricsx_series")
+
+		require.NoError(t, provider.Reload(Config{Provider: "prometheus", Prometheus: PrometheusConfig{EnableSelfMetrics: true}}))
+		assert.Contains(t, scrape(t, provider), `metricsx_series{metric="orders_total"} 1`)
+	})
+}
@@ -0,0 +1,170 @@
+package metricsx
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// counterShard is one slot of a shardedCounter, padded to its own cache line
+// so increments on different CPUs do not contend
+type counterShard struct {
+	// n counts integer increments, which avoid the compare-and-swap loop of
+	// float additions
+	n    atomic.Uint64
+	bits atomic.Uint64
+	_    [48]byte
+}
+
+// add adds value, which must not be negative
+func (s *counterShard) add(value float64) {
+	if n := uint64(value); float64(n) == value {
+		s.n.Add(n)
+		return
+	}
+	for {
+		old := s.bits.Load()
+		if s.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+value)) {
+			return
+		}
+	}
+}
+
+// value returns the sum of the increments
+func (s *counterShard) value() float64 {
+	return float64(s.n.Load()) + math.Float64frombits(s.bits.Load())
+}
+
+// shardedCounter is a counter whose increments go to a random shard. The
+// shards are only summed when the counter is collected.
+type shardedCounter struct {
+	shards []counterShard
+	labels []string
+}
+
+// newShardedCounter creates a counter with one shard per usable CPU
+func newShardedCounter(labels []string) *shardedCounter {
+	return &shardedCounter{
+		shards: make([]counterShard, runtime.GOMAXPROCS(0)),
+		labels: labels,
+	}
+}
+
+// shard returns a random shard. rand.Uint32 uses per-thread state, so
+// goroutines running on different CPUs mostly pick different shards.
+func (c *shardedCounter) shard() *counterShard {
+	return &c.shards[rand.Uint32N(uint32(len(c.shards)))]
+}
+
+func (c *shardedCounter) Inc() {
+	c.shard().n.Add(1)
+}
+
+func (c *shardedCounter) Add(value float64) {
+	if value < 0 {
+		panic("counter cannot decrease in value")
+	}
+	c.shard().add(value)
+}
+
+// value sums the shards
+func (c *shardedCounter) value() float64 {
+	var sum float64
+	for i := range c.shards {
+		sum += c.shards[i].value()
+	}
+	return sum
+}
+
+// shardedCounterVec is a collector of sharded counters partitioned by label
+// values, the sharded counterpart of prometheus.CounterVec. The counters map
+// is copied on write so lookups never write to memory shared between CPUs.
+type shardedCounterVec struct {
+	desc     *prometheus.Desc
+	labels   int
+	counters atomic.Pointer[map[string]*shardedCounter]
+
+	mu sync.Mutex
+}
+
+// newShardedCounterVec creates a vector with the variable labels described by opts
+func newShardedCounterVec(opts prometheus.CounterOpts, labels []string) *shardedCounterVec {
+	v := &shardedCounterVec{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+			opts.Help, labels, opts.ConstLabels,
+		),
+		labels: len(labels),
+	}
+	v.counters.Store(&map[string]*shardedCounter{})
+	return v
+}
+
+// WithLabelValues returns the counter for labels, creating it on first use. It
+// panics on a wrong number of label values, like prometheus.CounterVec.
+func (v *shardedCounterVec) WithLabelValues(labels ...string) *shardedCounter {
+	var buf [128]byte
+	key := appendChildKey(buf[:0], labels)
+	if c, ok := (*v.counters.Load())[string(key)]; ok {
+		return c
+	}
+
+	if len(labels) != v.labels {
+		panic(fmt.Sprintf("inconsistent label cardinality: expected %d label values but got %d", v.labels, len(labels)))
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	counters := *v.counters.Load()
+	if c, ok := counters[string(key)]; ok {
+		return c
+	}
+
+	c := newShardedCounter(slices.Clone(labels))
+	next := maps.Clone(counters)
+	next[string(key)] = c
+	v.counters.Store(&next)
+	return c
+}
+
+func (v *shardedCounterVec) Describe(ch chan<- *prometheus.Desc) {
+	ch <- v.desc
+}
+
+func (v *shardedCounterVec) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range *v.counters.Load() {
+		ch <- prometheus.MustNewConstMetric(v.desc, prometheus.CounterValue, c.value(), c.labels...)
+	}
+}
+
+// prometheusShardedCounterVec implements Counter over a shardedCounterVec
+type prometheusShardedCounterVec struct {
+	vec *shardedCounterVec
+}
+
+func (c *prometheusShardedCounterVec) Inc(labels ...string) {
+	c.vec.WithLabelValues(labels...).Inc()
+}
+
+func (c *prometheusShardedCounterVec) Add(value float64, labels ...string) {
+	c.vec.WithLabelValues(labels...).Add(value)
+}
+
+// IncCtx increments the counter. Sharded counters do not keep exemplars.
+func (c *prometheusShardedCounterVec) IncCtx(ctx context.Context, labels ...string) {
+	c.Inc(labels...)
+}
+
+// AddCtx adds value to the counter. Sharded counters do not keep exemplars.
+func (c *prometheusShardedCounterVec) AddCtx(ctx context.Context, value float64, labels ...string) {
+	c.Add(value, labels...)
+}
@@ -0,0 +1,91 @@
+package metricsx
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedCounter(t *testing.T) {
+	t.Run("sums concurrent increments at collection", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Namespace: "test"}, getTestLogger())
+		counter := provider.Counter("packets_total", &Options{Labels: []string{"iface"}, Sharded: true})
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				for range 1000 {
+					counter.Inc("eth0")
+				}
+				counter.Add(0.5, "eth0")
+				counter.Add(2, "lo")
+			})
+		}
+		wg.Wait()
+
+		body := scrape(t, provider)
+		assert.Contains(t, body, "# TYPE test_packets_total counter")
+		assert.Contains(t, body, `test_packets_total{iface="eth0"} 8004`)
+		assert.Contains(t, body, `test_packets_total{iface="lo"} 16`)
+	})
+
+	t.Run("returns the same counter for the same name", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+		options := &Options{Sharded: true}
+		assert.Same(t, provider.Counter("events_total", options), provider.Counter("events_total", options))
+	})
+
+	t.Run("creates each labelled counter once", func(t *testing.T) {
+		vec := newShardedCounterVec(counterOpts("events_total"), []string{"id"})
+		a := vec.WithLabelValues("a")
+		a.Inc()
+		assert.Same(t, a, vec.WithLabelValues("a"))
+		assert.Equal(t, 1.0, vec.WithLabelValues("a").value())
+	})
+
+	t.Run("rejects invalid updates", func(t *testing.T) {
+		vec := newShardedCounterVec(counterOpts("events_total"), []string{"kind"})
+		assert.Panics(t, func() { vec.WithLabelValues() })
+		assert.Panics(t, func() { vec.WithLabelValues("a").Add(-1) })
+	})
+
+	t.Run("records without allocating", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+		counter := provider.Counter("events_total", &Options{Labels: []string{"kind"}, Sharded: true})
+		labels := []string{"click"}
+		counter.Inc(labels...)
+
+		allocs := testing.AllocsPerRun(100, func() {
+			counter.Inc(labels...)
+			counter.Add(1.5, labels...)
+		})
+		require.Zero(t, allocs)
+	})
+}
+
+func BenchmarkShardedCounter(b *testing.B) {
+	for _, sharded := range []bool{false, true} {
+		name := "atomic"
+		if sharded {
+			name = "sharded"
+		}
+		b.Run(name, func(b *testing.B) {
+			provider := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+			counter := provider.Counter("events_total", &Options{Sharded: sharded})
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					counter.Inc()
+				}
+			})
+		})
+	}
+}
+
+// counterOpts returns the options of a counter named name
+func counterOpts(name string) prometheus.CounterOpts {
+	return prometheus.CounterOpts{Name: name}
+}
@@ -0,0 +1,112 @@
+package metricsx
+
+import (
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// metricShardCount is the number of shards the metric maps are split into
+const metricShardCount = 16
+
+// metricKeySize is the size of the stack buffer metric keys are built in;
+// longer keys spill to the heap
+const metricKeySize = 256
+
+// metricShard holds the metrics whose keys hash to it, so registrations of
+// unrelated metrics do not contend on a single lock
+type metricShard struct {
+	mu         sync.RWMutex
+	counters   map[string]Counter
+	gauges     map[string]*prometheusGaugeVec
+	histograms map[string]*prometheusHistogramVec
+	summaries  map[string]*prometheusSummaryVec
+	funcs      map[string]*prometheusValueFunc
+	schemas    map[string]MetricSchema
+}
+
+// metricShards is a fixed set of shards keyed by metric key
+type metricShards struct {
+	seed   maphash.Seed
+	shards [metricShardCount]metricShard
+
+	// claims maps fully qualified names to the type and label names they
+	// were first registered with
+	claims sync.Map
+}
+
+// errSchemaConflict is returned by conflict for a name registered with
+// another type or other label names
+var errSchemaConflict = errors.New("metric registered with another type or labels")
+
+// claim records that fqName is registered as kind with the labels in options,
+// unless it is already claimed
+func (s *metricShards) claim(fqName, kind string, options *Options) {
+	s.claims.LoadOrStore(fqName, kind+" "+labelSignature(options))
+}
+
+// conflict returns errSchemaConflict when fqName is claimed as another kind or
+// with other label names. Const label values may differ.
+func (s *metricShards) conflict(fqName, kind string, options *Options) error {
+	previous, ok := s.claims.Load(fqName)
+	if schema := kind + " " + labelSignature(options); ok && previous != schema {
+		return fmt.Errorf("%w: %s is a %s, cannot register it as %s", errSchemaConflict, fqName, previous, schema)
+	}
+	return nil
+}
+
+// labelSignature describes the label names of a metric, e.g.
+// "{method,status} const{region}"
+func labelSignature(options *Options) string {
+	signature := "{" + strings.Join(options.Labels, ",") + "}"
+	if len(options.ConstLabels) > 0 {
+		signature += " const{" + strings.Join(slices.Sorted(maps.Keys(options.ConstLabels)), ",") + "}"
+	}
+	return signature
+}
+
+// newMetricShards creates empty shards
+func newMetricShards() *metricShards {
+	s := &metricShards{seed: maphash.MakeSeed()}
+	for i := range s.shards {
+		s.shards[i] = metricShard{
+			counters:   make(map[string]Counter),
+			gauges:     make(map[string]*prometheusGaugeVec),
+			histograms: make(map[string]*prometheusHistogramVec),
+			summaries:  make(map[string]*prometheusSummaryVec),
+			funcs:      make(map[string]*prometheusValueFunc),
+			schemas:    make(map[string]MetricSchema),
+		}
+	}
+	return s
+}
+
+// get returns the shard for key
+func (s *metricShards) get(key []byte) *metricShard {
+	return &s.shards[maphash.Bytes(s.seed, key)%metricShardCount]
+}
+
+// schemas returns the schema of every metric in the shards
+func (s *metricShards) schemas() []MetricSchema {
+	var schemas []MetricSchema
+	for i := range s.shards {
+		shard := &s.shards[i]
+		shard.mu.RLock()
+		schemas = slices.AppendSeq(schemas, maps.Values(shard.schemas))
+		shard.mu.RUnlock()
+	}
+	return schemas
+}
+
+// lookup returns the metric stored under key in m, taking only the read lock
+func lookup[T any](shard *metricShard, m map[string]T, key []byte) (T, bool) {
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	v, ok := m[string(key)]
+	return v, ok
+}
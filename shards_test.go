@@ -0,0 +1,151 @@
+package metricsx
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricShards(t *testing.T) {
+	t.Run("returns the same shard for a key", func(t *testing.T) {
+		shards := newMetricShards()
+		assert.Same(t, shards.get([]byte("app__requests_total")), shards.get([]byte("app__requests_total")))
+	})
+
+	t.Run("spreads keys across shards", func(t *testing.T) {
+		shards := newMetricShards()
+		used := make(map[*metricShard]bool)
+		for i := range 1000 {
+			used[shards.get(fmt.Appendf(nil, "plugin_%d_total", i))] = true
+		}
+		assert.Len(t, used, metricShardCount)
+	})
+
+	t.Run("concurrent registration returns one metric", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{Namespace: "test"}, getTestLogger())
+
+		const goroutines = 32
+		counters := make([]Counter, goroutines)
+		var wg sync.WaitGroup
+		for i := range goroutines {
+			wg.Go(func() {
+				for j := range 50 {
+					provider.Counter(fmt.Sprintf("plugin_%d_total", j), &Options{})
+				}
+				counters[i] = provider.Counter("shared_total", &Options{Labels: []string{"plugin"}})
+			})
+		}
+		wg.Wait()
+
+		for _, c := range counters {
+			assert.Same(t, counters[0], c)
+		}
+	})
+}
+
+func TestMetricKey(t *testing.T) {
+	p := newPrometheusProvider(PrometheusConfig{Namespace: "app"}, getTestLogger()).(*prometheusProvider)
+	key := func(name string, options *Options) string {
+		return string(p.appendMetricKey(nil, name, options))
+	}
+
+	t.Run("includes the label schema", func(t *testing.T) {
+		assert.Equal(t, `app__requests_total{method,status}`, key("requests_total", &Options{Labels: []string{"method", "status"}}))
+		assert.NotEqual(t, key("requests_total", &Options{Labels: []string{"method"}}), key("requests_total", &Options{}))
+	})
+
+	t.Run("orders const labels by name", func(t *testing.T) {
+		options := &Options{Subsystem: "http", ConstLabels: map[string]string{"zone": "b", "region": `eu "west"`, "az": "1"}}
+		assert.Equal(t, `app_http_requests_total{},az="1",region="eu \"west\"",zone="b"`, key("requests_total", options))
+	})
+
+	t.Run("looks up existing metrics without allocating", func(t *testing.T) {
+		options := &Options{Labels: []string{"method", "status"}, ConstLabels: map[string]string{"region": "eu", "zone": "b"}}
+		p.Counter("requests_total", options)
+		p.Gauge("queue_depth", options)
+
+		allocs := testing.AllocsPerRun(100, func() {
+			p.Counter("requests_total", options)
+			p.Gauge("queue_depth", options)
+		})
+		assert.Zero(t, allocs)
+	})
+}
+
+func BenchmarkMetricLookup(b *testing.B) {
+	p := newPrometheusProvider(PrometheusConfig{Namespace: "app"}, getTestLogger()).(*prometheusProvider)
+
+	b.Run("labels", func(b *testing.B) {
+		options := &Options{Labels: []string{"method", "status"}}
+		p.Counter("requests_total", options)
+
+		b.ReportAllocs()
+		for b.Loop() {
+			p.Counter("requests_total", options)
+		}
+	})
+
+	b.Run("const labels", func(b *testing.B) {
+		options := &Options{Labels: []string{"method"}, ConstLabels: map[string]string{"region": "eu", "zone": "b", "az": "1"}}
+		p.Histogram("request_duration_seconds", options)
+
+		b.ReportAllocs()
+		for b.Loop() {
+			p.Histogram("request_duration_seconds", options)
+		}
+	})
+
+	b.Run("parallel", func(b *testing.B) {
+		options := &Options{Labels: []string{"method", "status"}}
+		p.Counter("requests_total", options)
+
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				p.Counter("requests_total", options)
+			}
+		})
+	})
+}
+
+func TestMetricSchemaConflict(t *testing.T) {
+	t.Run("rejects a name registered with other labels", func(t *testing.T) {
+		p := newPrometheusProvider(PrometheusConfig{Namespace: "app"}, getTestLogger())
+		p.Counter("orders_total", &Options{Labels: []string{"region"}})
+
+		assert.PanicsWithError(t, "metric registered with another type or labels: app_orders_total is a counter {region}, cannot register it as counter {country}", func() {
+			p.Counter("orders_total", &Options{Labels: []string{"country"}})
+		})
+	})
+
+	t.Run("rejects a name registered as another type", func(t *testing.T) {
+		p := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+		p.Counter("jobs", &Options{})
+
+		assert.Panics(t, func() {
+			p.Gauge("jobs", &Options{})
+		})
+	})
+
+	t.Run("allows other const label values", func(t *testing.T) {
+		p := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+		p.Gauge("pool_size", &Options{ConstLabels: map[string]string{"pool": "primary"}})
+
+		assert.NotPanics(t, func() {
+			p.Gauge("pool_size", &Options{ConstLabels: map[string]string{"pool": "replica"}})
+		})
+	})
+
+	t.Run("does not claim a name whose registration failed", func(t *testing.T) {
+		p := newPrometheusProvider(PrometheusConfig{}, getTestLogger())
+		assert.Panics(t, func() {
+			p.Gauge("queue_depth", &Options{Labels: []string{"queue"}, ConstLabels: map[string]string{"queue": "orders"}})
+		})
+
+		assert.NotPanics(t, func() {
+			p.Gauge("queue_depth", &Options{Labels: []string{"queue"}})
+		})
+	})
+}
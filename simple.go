@@ -0,0 +1,90 @@
+package metricsx
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// SimpleCounter is a counter without labels backed directly by atomics. It
+// skips the label handling of Counter for hot paths where even the cached
+// child lookup is measurable.
+type SimpleCounter struct {
+	shard counterShard
+}
+
+// NewSimpleCounter creates a SimpleCounter exported as the counter name, read
+// at collection time. Label options are ignored; const labels apply. Create
+// it once per name, as registering the name again exports the new counter.
+func NewSimpleCounter(m Metrics, name string, opts ...Option) *SimpleCounter {
+	c := &SimpleCounter{}
+	m.CounterFunc(name, c.Value, opts...)
+	return c
+}
+
+// Inc increments the counter by 1
+func (c *SimpleCounter) Inc() {
+	c.shard.n.Add(1)
+}
+
+// Add increments the counter by value. It panics if value is negative.
+func (c *SimpleCounter) Add(value float64) {
+	if value < 0 {
+		panic("counter cannot decrease in value")
+	}
+	c.shard.add(value)
+}
+
+// Value returns the current value of the counter
+func (c *SimpleCounter) Value() float64 {
+	return c.shard.value()
+}
+
+// SimpleGauge is a gauge without labels backed directly by an atomic, the
+// gauge counterpart of SimpleCounter
+type SimpleGauge struct {
+	bits atomic.Uint64
+}
+
+// NewSimpleGauge creates a SimpleGauge exported as the gauge name, read at
+// collection time. Label options are ignored; const labels apply. Create it
+// once per name, as registering the name again exports the new gauge.
+func NewSimpleGauge(m Metrics, name string, opts ...Option) *SimpleGauge {
+	g := &SimpleGauge{}
+	m.GaugeFunc(name, g.Value, opts...)
+	return g
+}
+
+// Set sets the gauge to value
+func (g *SimpleGauge) Set(value float64) {
+	g.bits.Store(math.Float64bits(value))
+}
+
+// Inc increments the gauge by 1
+func (g *SimpleGauge) Inc() {
+	g.Add(1)
+}
+
+// Dec decrements the gauge by 1
+func (g *SimpleGauge) Dec() {
+	g.Add(-1)
+}
+
+// Add adds value to the gauge
+func (g *SimpleGauge) Add(value float64) {
+	for {
+		old := g.bits.Load()
+		if g.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+value)) {
+			return
+		}
+	}
+}
+
+// Sub subtracts value from the gauge
+func (g *SimpleGauge) Sub(value float64) {
+	g.Add(-value)
+}
+
+// Value returns the current value of the gauge
+func (g *SimpleGauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
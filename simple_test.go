@@ -0,0 +1,64 @@
+package metricsx
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimpleCounter(t *testing.T) {
+	t.Run("exports the counter at collection", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: Config{Provider: "prometheus", Prometheus: PrometheusConfig{Namespace: "test"}}, Logger: getTestLogger()})
+		require.NoError(t, err)
+
+		counter := NewSimpleCounter(res.Metrics, "events_total", WithHelp("Total events"), WithConstLabels(map[string]string{"source": "kafka"}))
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				for range 1000 {
+					counter.Inc()
+				}
+				counter.Add(0.25)
+			})
+		}
+		wg.Wait()
+
+		assert.Equal(t, 8002.0, counter.Value())
+		body := scrape(t, res.Provider)
+		assert.Contains(t, body, "# TYPE test_events_total counter")
+		assert.Contains(t, body, `test_events_total{source="kafka"} 8002`)
+	})
+
+	t.Run("rejects negative increments", func(t *testing.T) {
+		counter := NewSimpleCounter(&metricsImpl{provider: newNoopProvider(), logger: getTestLogger()}, "events_total")
+		assert.Panics(t, func() { counter.Add(-1) })
+	})
+
+	t.Run("records without allocating", func(t *testing.T) {
+		counter := NewSimpleCounter(&metricsImpl{provider: newNoopProvider(), logger: getTestLogger()}, "events_total")
+		assert.Zero(t, testing.AllocsPerRun(100, func() {
+			counter.Inc()
+			counter.Add(1.5)
+		}))
+	})
+}
+
+func TestSimpleGauge(t *testing.T) {
+	res, err := NewMetrics(Params{Config: Config{Provider: "prometheus", Prometheus: PrometheusConfig{Namespace: "test"}}, Logger: getTestLogger()})
+	require.NoError(t, err)
+
+	gauge := NewSimpleGauge(res.Metrics, "workers")
+	gauge.Set(10)
+	gauge.Inc()
+	gauge.Add(2.5)
+	gauge.Dec()
+	gauge.Sub(0.5)
+
+	assert.Equal(t, 12.0, gauge.Value())
+	body := scrape(t, res.Provider)
+	assert.Contains(t, body, "# TYPE test_workers gauge")
+	assert.Contains(t, body, "test_workers 12")
+}
@@ -0,0 +1,278 @@
+// Package slo records service level objectives through metricsx and derives
+// the standard multi-window, multi-burn-rate alerts for them.
+//
+//	checkout := slo.New(metrics, slo.SLO{
+//		Name:   "checkout_availability",
+//		Target: 0.999,
+//		Window: 30 * 24 * time.Hour,
+//	})
+//	checkout.Record(err == nil)
+//
+// It records:
+//
+//	slo_events_total{slo}
+//	slo_good_events_total{slo}
+//	slo_objective_ratio{slo}
+//	slo_window_seconds{slo}
+//
+// Both counters are created with zero values so rate() over them is defined
+// from the first scrape. Alerts returns the PromQL expressions for the
+// thresholds of the Google SRE workbook; rules can also be built from
+// GoodSelector and TotalSelector directly.
+package slo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gostratum/metricsx"
+)
+
+// Metric names recorded for every SLO
+const (
+	TotalMetric     = "slo_events_total"
+	GoodMetric      = "slo_good_events_total"
+	ObjectiveMetric = "slo_objective_ratio"
+	WindowMetric    = "slo_window_seconds"
+)
+
+// DefaultWindow is the compliance window used when SLO.Window is zero
+const DefaultWindow = 30 * 24 * time.Hour
+
+// Severity label values of the standard alerts
+const (
+	SeverityPage   = "page"
+	SeverityTicket = "ticket"
+)
+
+// SLO declares a service level objective
+type SLO struct {
+	// Name identifies the SLO and is the value of the slo label
+	Name string
+
+	// Target is the fraction of good events, e.g. 0.999
+	Target float64
+
+	// Window is the compliance window the error budget is spent over
+	Window time.Duration
+
+	// Namespace is prepended to the metric names in the PromQL expressions;
+	// set it to metrics.prometheus.namespace when one is configured
+	Namespace string
+}
+
+// ErrorBudget returns the fraction of events allowed to be bad, 1 - Target
+func (s SLO) ErrorBudget() float64 {
+	return 1 - s.Target
+}
+
+// window returns the compliance window, defaulting to DefaultWindow
+func (s SLO) window() time.Duration {
+	if s.Window <= 0 {
+		return DefaultWindow
+	}
+	return s.Window
+}
+
+// validate reports declarations that cannot produce meaningful alerts
+func (s SLO) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("slo: name is required")
+	}
+	if s.Target <= 0 || s.Target >= 1 {
+		return fmt.Errorf("slo %s: target %v must be between 0 and 1", s.Name, s.Target)
+	}
+	return nil
+}
+
+// metricName returns the fully qualified name of metric
+func (s SLO) metricName(metric string) string {
+	if s.Namespace == "" {
+		return metric
+	}
+	return s.Namespace + "_" + metric
+}
+
+// GoodSelector returns the PromQL selector of the good events counter
+func (s SLO) GoodSelector() string {
+	return s.selector(GoodMetric)
+}
+
+// TotalSelector returns the PromQL selector of the total events counter
+func (s SLO) TotalSelector() string {
+	return s.selector(TotalMetric)
+}
+
+// selector returns the PromQL selector of metric for this SLO
+func (s SLO) selector(metric string) string {
+	return s.metricName(metric) + `{slo="` + s.Name + `"}`
+}
+
+// ErrorRatio returns the PromQL expression of the bad event ratio over window
+func (s SLO) ErrorRatio(window time.Duration) string {
+	w := promDuration(window)
+	return fmt.Sprintf("(1 - sum(rate(%s[%s])) / sum(rate(%s[%s])))",
+		s.GoodSelector(), w, s.TotalSelector(), w)
+}
+
+// BurnRate is the budget consumption speed at which an alert fires
+type BurnRate struct {
+	// Severity is SeverityPage or SeverityTicket
+	Severity string
+
+	// LongWindow detects the burn, ShortWindow confirms it is still going on
+	LongWindow  time.Duration
+	ShortWindow time.Duration
+
+	// BudgetConsumed is the fraction of the error budget spent over LongWindow
+	// when the alert fires
+	BudgetConsumed float64
+}
+
+// StandardBurnRates are the multi-window alerts of the Google SRE workbook:
+// 2% and 5% of a 30 day budget spent in 1h and 6h page, 10% in 3 days opens a ticket
+var StandardBurnRates = []BurnRate{
+	{Severity: SeverityPage, LongWindow: time.Hour, ShortWindow: 5 * time.Minute, BudgetConsumed: 0.02},
+	{Severity: SeverityPage, LongWindow: 6 * time.Hour, ShortWindow: 30 * time.Minute, BudgetConsumed: 0.05},
+	{Severity: SeverityTicket, LongWindow: 24 * time.Hour, ShortWindow: 2 * time.Hour, BudgetConsumed: 0.10},
+	{Severity: SeverityTicket, LongWindow: 3 * 24 * time.Hour, ShortWindow: 6 * time.Hour, BudgetConsumed: 0.10},
+}
+
+// Factor returns the burn rate multiple of the sustainable rate, e.g. 14.4
+// for 2% of a 30 day budget in one hour
+func (b BurnRate) Factor(window time.Duration) float64 {
+	return b.BudgetConsumed * window.Hours() / b.LongWindow.Hours()
+}
+
+// Alert is a burn rate alert for one SLO
+type Alert struct {
+	BurnRate
+
+	// Factor is the burn rate multiple, Threshold the error ratio it maps to
+	Factor    float64
+	Threshold float64
+
+	// Expr is the PromQL alert condition
+	Expr string
+}
+
+// Alerts returns the StandardBurnRates alerts for the SLO
+func (s SLO) Alerts() []Alert {
+	return s.AlertsFor(StandardBurnRates...)
+}
+
+// AlertsFor returns an alert for every burn rate
+func (s SLO) AlertsFor(rates ...BurnRate) []Alert {
+	alerts := make([]Alert, 0, len(rates))
+	for _, rate := range rates {
+		factor := rate.Factor(s.window())
+		threshold := factor * s.ErrorBudget()
+		t := strconv.FormatFloat(threshold, 'g', 10, 64)
+		alerts = append(alerts, Alert{
+			BurnRate:  rate,
+			Factor:    factor,
+			Threshold: threshold,
+			Expr: s.ErrorRatio(rate.LongWindow) + " > " + t +
+				" and " + s.ErrorRatio(rate.ShortWindow) + " > " + t,
+		})
+	}
+	return alerts
+}
+
+// Tracker records the events of one SLO
+type Tracker struct {
+	slo   SLO
+	total metricsx.Counter
+	good  metricsx.Counter
+}
+
+// New registers the recording metrics of s. It panics when s has no name or
+// its target is not between 0 and 1.
+func New(m metricsx.Metrics, s SLO) *Tracker {
+	if err := s.validate(); err != nil {
+		panic(err)
+	}
+
+	constLabels := metricsx.WithConstLabels(map[string]string{"slo": s.Name})
+	m.GaugeFunc(ObjectiveMetric, func() float64 {
+		return s.Target
+	},
+		metricsx.WithHelp("Target ratio of good events of the SLO."),
+		constLabels,
+	)
+	m.GaugeFunc(WindowMetric, func() float64 {
+		return s.window().Seconds()
+	},
+		metricsx.WithHelp("Compliance window of the SLO in seconds."),
+		constLabels,
+	)
+
+	t := &Tracker{
+		slo: s,
+		total: m.Counter(TotalMetric,
+			metricsx.WithHelp("Total number of events counted against SLOs."),
+			metricsx.WithLabels("slo"),
+		),
+		good: m.Counter(GoodMetric,
+			metricsx.WithHelp("Number of good events counted against SLOs."),
+			metricsx.WithLabels("slo"),
+		),
+	}
+	t.total.Add(0, s.Name)
+	t.good.Add(0, s.Name)
+	return t
+}
+
+// SLO returns the declaration the tracker records
+func (t *Tracker) SLO() SLO {
+	return t.slo
+}
+
+// Record counts one event, good or bad
+func (t *Tracker) Record(good bool) {
+	t.total.Inc(t.slo.Name)
+	if good {
+		t.good.Inc(t.slo.Name)
+	}
+}
+
+// Good counts one good event
+func (t *Tracker) Good() {
+	t.Record(true)
+}
+
+// Bad counts one bad event
+func (t *Tracker) Bad() {
+	t.Record(false)
+}
+
+// RecordLatency counts an event that is good when it took at most threshold
+func (t *Tracker) RecordLatency(d, threshold time.Duration) {
+	t.Record(d <= threshold)
+}
+
+// promDuration formats d as a PromQL duration such as 5m, 6h or 3d
+func promDuration(d time.Duration) string {
+	var b strings.Builder
+	for _, unit := range []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	} {
+		if n := d / unit.size; n > 0 {
+			b.WriteString(strconv.FormatInt(int64(n), 10))
+			b.WriteString(unit.suffix)
+			d -= n * unit.size
+		}
+	}
+	if b.Len() == 0 {
+		return "0s"
+	}
+	return b.String()
+}
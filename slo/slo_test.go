@@ -0,0 +1,108 @@
+package slo
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gostratum/core/logx"
+	"github.com/gostratum/metricsx"
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	t.Run("counts good and total events", func(t *testing.T) {
+		m := metricstest.New()
+		tracker := New(m, SLO{Name: "checkout", Target: 0.999})
+
+		tracker.Good()
+		tracker.Bad()
+		tracker.Record(true)
+		tracker.RecordLatency(50*time.Millisecond, 100*time.Millisecond)
+		tracker.RecordLatency(time.Second, 100*time.Millisecond)
+
+		assert.Equal(t, 5.0, m.CounterValue(TotalMetric, "checkout"))
+		assert.Equal(t, 3.0, m.CounterValue(GoodMetric, "checkout"))
+		assert.Equal(t, 0.999, m.GaugeValue(ObjectiveMetric))
+		assert.Equal(t, DefaultWindow.Seconds(), m.GaugeValue(WindowMetric))
+	})
+
+	t.Run("exposes zero valued series for every slo", func(t *testing.T) {
+		res, err := metricsx.NewMetrics(metricsx.Params{
+			Config: metricsx.Config{
+				Enabled:    true,
+				Provider:   "prometheus",
+				Prometheus: metricsx.PrometheusConfig{Path: "/metrics"},
+			},
+			Logger: logx.NewNoopLogger(),
+		})
+		require.NoError(t, err)
+
+		New(res.Metrics, SLO{Name: "checkout", Target: 0.999})
+		New(res.Metrics, SLO{Name: "search", Target: 0.99, Window: 7 * 24 * time.Hour})
+
+		rec := httptest.NewRecorder()
+		handler := res.Provider.(interface{ Handler() http.Handler }).Handler()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+
+		out := string(body)
+		assert.Contains(t, out, `slo_events_total{slo="checkout"} 0`)
+		assert.Contains(t, out, `slo_good_events_total{slo="search"} 0`)
+		assert.Contains(t, out, `slo_objective_ratio{slo="search"} 0.99`)
+		assert.Contains(t, out, `slo_window_seconds{slo="search"} 604800`)
+	})
+
+	t.Run("rejects invalid declarations", func(t *testing.T) {
+		assert.Panics(t, func() { New(metricstest.New(), SLO{Target: 0.99}) })
+		assert.Panics(t, func() { New(metricstest.New(), SLO{Name: "checkout", Target: 1}) })
+	})
+}
+
+func TestAlerts(t *testing.T) {
+	t.Run("derives the standard burn rates", func(t *testing.T) {
+		alerts := SLO{Name: "checkout", Target: 0.999}.Alerts()
+		require.Len(t, alerts, 4)
+
+		for i, want := range []float64{14.4, 6, 3, 1} {
+			assert.InDelta(t, want, alerts[i].Factor, 1e-9)
+			assert.InDelta(t, want*0.001, alerts[i].Threshold, 1e-12)
+		}
+		assert.Equal(t, SeverityPage, alerts[0].Severity)
+		assert.Equal(t, SeverityTicket, alerts[3].Severity)
+	})
+
+	t.Run("builds promql expressions", func(t *testing.T) {
+		s := SLO{Name: "checkout", Target: 0.99, Namespace: "shop"}
+		alert := s.AlertsFor(BurnRate{LongWindow: time.Hour, ShortWindow: 5 * time.Minute, BudgetConsumed: 0.02})[0]
+
+		assert.Equal(t,
+			`(1 - sum(rate(shop_slo_good_events_total{slo="checkout"}[1h])) / sum(rate(shop_slo_events_total{slo="checkout"}[1h]))) > 0.144`+
+				` and `+
+				`(1 - sum(rate(shop_slo_good_events_total{slo="checkout"}[5m])) / sum(rate(shop_slo_events_total{slo="checkout"}[5m]))) > 0.144`,
+			alert.Expr)
+	})
+
+	t.Run("scales factors to the window", func(t *testing.T) {
+		alerts := SLO{Name: "checkout", Target: 0.999, Window: 7 * 24 * time.Hour}.Alerts()
+		assert.InDelta(t, 3.36, alerts[0].Factor, 1e-9)
+	})
+}
+
+func TestPromDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		5 * time.Minute:               "5m",
+		6 * time.Hour:                 "6h",
+		3 * 24 * time.Hour:            "3d",
+		90 * time.Minute:              "1h30m",
+		30*time.Second + 24*time.Hour: "1d30s",
+		0:                             "0s",
+	} {
+		assert.Equal(t, want, promDuration(d))
+	}
+}
@@ -0,0 +1,212 @@
+package metricsx
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// FamilySnapshot is the current state of a metric family
+type FamilySnapshot struct {
+	// Name is the fully qualified name, including namespace and subsystem
+	Name string
+
+	// Help is the metric description
+	Help string
+
+	// Type is counter, gauge, histogram, summary or untyped
+	Type string
+
+	// Samples holds one entry per label combination
+	Samples []SampleSnapshot
+}
+
+// SampleSnapshot is the current state of a single series
+type SampleSnapshot struct {
+	// Labels are the label names and values of the series
+	Labels map[string]string
+
+	// Value of a counter, gauge or untyped series
+	Value float64
+
+	// Count and Sum of the observations of a histogram or summary
+	Count uint64
+	Sum   float64
+
+	// Buckets of a histogram, excluding the implicit +Inf bucket
+	Buckets []BucketSnapshot
+
+	// Quantiles of a summary
+	Quantiles []QuantileSnapshot
+}
+
+// BucketSnapshot is the cumulative count of observations up to UpperBound
+type BucketSnapshot struct {
+	UpperBound float64
+	Count      uint64
+}
+
+// QuantileSnapshot is the estimated value of a summary quantile
+type QuantileSnapshot struct {
+	Quantile float64
+	Value    float64
+}
+
+// Snapshotter is implemented by providers that can report current metric values
+type Snapshotter interface {
+	// Snapshot returns every exported metric family with its current values
+	Snapshot() ([]FamilySnapshot, error)
+}
+
+// Snapshot returns the current value of every exported metric, after filtering
+func (p *prometheusProvider) Snapshot() ([]FamilySnapshot, error) {
+	mfs, err := p.gatherer().Gather()
+	return snapshotFamilies(mfs), err
+}
+
+// snapshotFamilies converts gathered metric families into snapshots
+func snapshotFamilies(mfs []*dto.MetricFamily) []FamilySnapshot {
+	families := make([]FamilySnapshot, 0, len(mfs))
+	for _, mf := range mfs {
+		family := FamilySnapshot{
+			Name:    mf.GetName(),
+			Help:    mf.GetHelp(),
+			Type:    strings.ToLower(mf.GetType().String()),
+			Samples: make([]SampleSnapshot, 0, len(mf.GetMetric())),
+		}
+		for _, m := range mf.GetMetric() {
+			family.Samples = append(family.Samples, snapshotSample(m))
+		}
+		families = append(families, family)
+	}
+	return families
+}
+
+// snapshotSample converts a gathered metric into a snapshot
+func snapshotSample(m *dto.Metric) SampleSnapshot {
+	var sample SampleSnapshot
+	if len(m.GetLabel()) > 0 {
+		sample.Labels = make(map[string]string, len(m.GetLabel()))
+		for _, l := range m.GetLabel() {
+			sample.Labels[l.GetName()] = l.GetValue()
+		}
+	}
+
+	switch {
+	case m.Counter != nil:
+		sample.Value = m.GetCounter().GetValue()
+	case m.Gauge != nil:
+		sample.Value = m.GetGauge().GetValue()
+	case m.Untyped != nil:
+		sample.Value = m.GetUntyped().GetValue()
+	case m.Histogram != nil:
+		h := m.GetHistogram()
+		sample.Count, sample.Sum = h.GetSampleCount(), h.GetSampleSum()
+		for _, b := range h.GetBucket() {
+			if !math.IsInf(b.GetUpperBound(), 1) {
+				sample.Buckets = append(sample.Buckets, BucketSnapshot{UpperBound: b.GetUpperBound(), Count: b.GetCumulativeCount()})
+			}
+		}
+	case m.Summary != nil:
+		s := m.GetSummary()
+		sample.Count, sample.Sum = s.GetSampleCount(), s.GetSampleSum()
+		for _, q := range s.GetQuantile() {
+			sample.Quantiles = append(sample.Quantiles, QuantileSnapshot{Quantile: q.GetQuantile(), Value: q.GetValue()})
+		}
+	}
+	return sample
+}
+
+// jsonHandler serves the snapshot as JSON
+func (p *prometheusProvider) jsonHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		families, err := p.Snapshot()
+		if err != nil && len(families) == 0 {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		body := make([]jsonFamily, 0, len(families))
+		for _, f := range families {
+			body = append(body, newJSONFamily(f))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	})
+}
+
+// jsonFamily is the JSON form of a FamilySnapshot
+type jsonFamily struct {
+	Name    string       `json:"name"`
+	Help    string       `json:"help,omitempty"`
+	Type    string       `json:"type"`
+	Samples []jsonSample `json:"samples"`
+}
+
+// jsonSample is the JSON form of a SampleSnapshot; only the fields of the
+// family's type are set
+type jsonSample struct {
+	Labels    map[string]string    `json:"labels,omitempty"`
+	Value     *jsonFloat           `json:"value,omitempty"`
+	Count     *uint64              `json:"count,omitempty"`
+	Sum       *jsonFloat           `json:"sum,omitempty"`
+	Buckets   map[string]uint64    `json:"buckets,omitempty"`
+	Quantiles map[string]jsonFloat `json:"quantiles,omitempty"`
+}
+
+// newJSONFamily converts f to its JSON form
+func newJSONFamily(f FamilySnapshot) jsonFamily {
+	family := jsonFamily{Name: f.Name, Help: f.Help, Type: f.Type, Samples: make([]jsonSample, 0, len(f.Samples))}
+	for _, s := range f.Samples {
+		sample := jsonSample{Labels: s.Labels}
+		switch f.Type {
+		case "histogram", "summary":
+			sum := jsonFloat(s.Sum)
+			sample.Count, sample.Sum = &s.Count, &sum
+			for _, b := range s.Buckets {
+				if sample.Buckets == nil {
+					sample.Buckets = make(map[string]uint64, len(s.Buckets))
+				}
+				sample.Buckets[formatFloat(b.UpperBound)] = b.Count
+			}
+			for _, q := range s.Quantiles {
+				if sample.Quantiles == nil {
+					sample.Quantiles = make(map[string]jsonFloat, len(s.Quantiles))
+				}
+				sample.Quantiles[formatFloat(q.Quantile)] = jsonFloat(q.Value)
+			}
+		default:
+			value := jsonFloat(s.Value)
+			sample.Value = &value
+		}
+		family.Samples = append(family.Samples, sample)
+	}
+	return family
+}
+
+// jsonFloat encodes NaN and infinities as strings, which JSON numbers cannot represent
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return json.Marshal(formatFloat(v))
+	}
+	return json.Marshal(v)
+}
+
+// formatFloat formats v like the Prometheus text format
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,73 @@
+package metricsx
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	newProvider := func() *prometheusProvider {
+		provider := newPrometheusProvider(PrometheusConfig{Path: "/metrics", JSONPath: "/metrics/json"}, getTestLogger()).(*prometheusProvider)
+
+		provider.Counter("orders_total", &Options{Help: "Orders placed", Labels: []string{"status"}}).Add(3, "ok")
+		provider.Gauge("queue_depth", &Options{}).Set(7)
+		provider.Histogram("checkout_seconds", &Options{Buckets: []float64{0.5, 1}}).Observe(0.75)
+		provider.Summary("payload_bytes", &Options{Objectives: map[float64]float64{0.5: 0.05}}).Observe(100)
+		provider.Gauge("limit", &Options{}).Set(math.Inf(1))
+		return provider
+	}
+
+	t.Run("returns current values", func(t *testing.T) {
+		families, err := newProvider().Snapshot()
+		require.NoError(t, err)
+
+		byName := make(map[string]FamilySnapshot)
+		for _, f := range families {
+			byName[f.Name] = f
+		}
+
+		assert.Equal(t, FamilySnapshot{
+			Name:    "orders_total",
+			Help:    "Orders placed",
+			Type:    "counter",
+			Samples: []SampleSnapshot{{Labels: map[string]string{"status": "ok"}, Value: 3}},
+		}, byName["orders_total"])
+		assert.Equal(t, 7.0, byName["queue_depth"].Samples[0].Value)
+
+		histogram := byName["checkout_seconds"].Samples[0]
+		assert.Equal(t, uint64(1), histogram.Count)
+		assert.Equal(t, []BucketSnapshot{{UpperBound: 0.5, Count: 0}, {UpperBound: 1, Count: 1}}, histogram.Buckets)
+	})
+
+	t.Run("serves JSON", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		newProvider().Handlers()["/metrics/json"].ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics/json", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var body []map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+		byName := make(map[string]map[string]any)
+		for _, f := range body {
+			byName[f["name"].(string)] = f
+		}
+		assert.Equal(t, []any{map[string]any{"labels": map[string]any{"status": "ok"}, "value": 3.0}}, byName["orders_total"]["samples"])
+		assert.Equal(t, []any{map[string]any{"count": 1.0, "sum": 0.75, "buckets": map[string]any{"0.5": 0.0, "1": 1.0}}}, byName["checkout_seconds"]["samples"])
+		assert.Equal(t, []any{map[string]any{"count": 1.0, "sum": 100.0, "quantiles": map[string]any{"0.5": 100.0}}}, byName["payload_bytes"]["samples"])
+		assert.Equal(t, []any{map[string]any{"value": "+Inf"}}, byName["limit"]["samples"])
+	})
+
+	t.Run("rejects a JSON path that is already served", func(t *testing.T) {
+		_, err := newPrometheusProviderFromConfig(Config{
+			Prometheus: PrometheusConfig{Path: "/metrics", JSONPath: "/metrics"},
+		}, getTestLogger())
+		assert.Error(t, err)
+	})
+}
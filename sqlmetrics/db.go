@@ -0,0 +1,254 @@
+package sqlmetrics
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/gostratum/metricsx"
+)
+
+// DB wraps *sql.DB and records metrics for every statement it executes
+type DB struct {
+	*sql.DB
+	recorder *recorder
+}
+
+// Wrap decorates db so that its queries are recorded through m
+func Wrap(db *sql.DB, m metricsx.Metrics, opts ...Option) *DB {
+	return &DB{
+		DB:       db,
+		recorder: newRecorder(m, opts...),
+	}
+}
+
+// ExecContext executes a statement and records its metrics
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return db.recorder.exec(ctx, query, func() (sql.Result, error) {
+		return db.DB.ExecContext(ctx, query, args...)
+	})
+}
+
+// Exec executes a statement and records its metrics
+func (db *DB) Exec(query string, args ...any) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+// QueryContext executes a query and records its metrics
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return db.recorder.query(ctx, query, func() (*sql.Rows, error) {
+		return db.DB.QueryContext(ctx, query, args...)
+	})
+}
+
+// Query executes a query and records its metrics
+func (db *DB) Query(query string, args ...any) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRowContext executes a single-row query and records its metrics
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return db.recorder.queryRow(ctx, query, func() *sql.Row {
+		return db.DB.QueryRowContext(ctx, query, args...)
+	})
+}
+
+// QueryRow executes a single-row query and records its metrics
+func (db *DB) QueryRow(query string, args ...any) *sql.Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+// BeginTx starts a transaction whose statements are also recorded
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, recorder: db.recorder}, nil
+}
+
+// Begin starts a transaction whose statements are also recorded
+func (db *DB) Begin() (*Tx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+// PrepareContext creates a prepared statement whose executions are recorded
+func (db *DB) PrepareContext(ctx context.Context, query string) (*Stmt, error) {
+	stmt, err := db.DB.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &Stmt{Stmt: stmt, query: query, recorder: db.recorder}, nil
+}
+
+// Prepare creates a prepared statement whose executions are recorded
+func (db *DB) Prepare(query string) (*Stmt, error) {
+	return db.PrepareContext(context.Background(), query)
+}
+
+// Conn returns a single connection whose statements are also recorded
+func (db *DB) Conn(ctx context.Context) (*Conn, error) {
+	conn, err := db.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{Conn: conn, recorder: db.recorder}, nil
+}
+
+// Tx wraps *sql.Tx and records metrics for every statement it executes
+type Tx struct {
+	*sql.Tx
+	recorder *recorder
+}
+
+// ExecContext executes a statement and records its metrics
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return tx.recorder.exec(ctx, query, func() (sql.Result, error) {
+		return tx.Tx.ExecContext(ctx, query, args...)
+	})
+}
+
+// Exec executes a statement and records its metrics
+func (tx *Tx) Exec(query string, args ...any) (sql.Result, error) {
+	return tx.ExecContext(context.Background(), query, args...)
+}
+
+// QueryContext executes a query and records its metrics
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return tx.recorder.query(ctx, query, func() (*sql.Rows, error) {
+		return tx.Tx.QueryContext(ctx, query, args...)
+	})
+}
+
+// Query executes a query and records its metrics
+func (tx *Tx) Query(query string, args ...any) (*sql.Rows, error) {
+	return tx.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRowContext executes a single-row query and records its metrics
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return tx.recorder.queryRow(ctx, query, func() *sql.Row {
+		return tx.Tx.QueryRowContext(ctx, query, args...)
+	})
+}
+
+// QueryRow executes a single-row query and records its metrics
+func (tx *Tx) QueryRow(query string, args ...any) *sql.Row {
+	return tx.QueryRowContext(context.Background(), query, args...)
+}
+
+// PrepareContext creates a transaction-specific prepared statement whose
+// executions are recorded
+func (tx *Tx) PrepareContext(ctx context.Context, query string) (*Stmt, error) {
+	stmt, err := tx.Tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &Stmt{Stmt: stmt, query: query, recorder: tx.recorder}, nil
+}
+
+// Prepare creates a transaction-specific prepared statement whose executions
+// are recorded
+func (tx *Tx) Prepare(query string) (*Stmt, error) {
+	return tx.PrepareContext(context.Background(), query)
+}
+
+// StmtContext returns a transaction-specific copy of stmt whose executions
+// are recorded
+func (tx *Tx) StmtContext(ctx context.Context, stmt *Stmt) *Stmt {
+	return &Stmt{Stmt: tx.Tx.StmtContext(ctx, stmt.Stmt), query: stmt.query, recorder: tx.recorder}
+}
+
+// Stmt returns a transaction-specific copy of stmt whose executions are
+// recorded
+func (tx *Tx) Stmt(stmt *Stmt) *Stmt {
+	return tx.StmtContext(context.Background(), stmt)
+}
+
+// Stmt wraps *sql.Stmt and records metrics for every execution, labeled with
+// the operation of the statement it was prepared from
+type Stmt struct {
+	*sql.Stmt
+	query    string
+	recorder *recorder
+}
+
+// ExecContext executes the statement and records its metrics
+func (s *Stmt) ExecContext(ctx context.Context, args ...any) (sql.Result, error) {
+	return s.recorder.exec(ctx, s.query, func() (sql.Result, error) {
+		return s.Stmt.ExecContext(ctx, args...)
+	})
+}
+
+// Exec executes the statement and records its metrics
+func (s *Stmt) Exec(args ...any) (sql.Result, error) {
+	return s.ExecContext(context.Background(), args...)
+}
+
+// QueryContext executes the query and records its metrics
+func (s *Stmt) QueryContext(ctx context.Context, args ...any) (*sql.Rows, error) {
+	return s.recorder.query(ctx, s.query, func() (*sql.Rows, error) {
+		return s.Stmt.QueryContext(ctx, args...)
+	})
+}
+
+// Query executes the query and records its metrics
+func (s *Stmt) Query(args ...any) (*sql.Rows, error) {
+	return s.QueryContext(context.Background(), args...)
+}
+
+// QueryRowContext executes the single-row query and records its metrics
+func (s *Stmt) QueryRowContext(ctx context.Context, args ...any) *sql.Row {
+	return s.recorder.queryRow(ctx, s.query, func() *sql.Row {
+		return s.Stmt.QueryRowContext(ctx, args...)
+	})
+}
+
+// QueryRow executes the single-row query and records its metrics
+func (s *Stmt) QueryRow(args ...any) *sql.Row {
+	return s.QueryRowContext(context.Background(), args...)
+}
+
+// Conn wraps *sql.Conn and records metrics for every statement it executes
+type Conn struct {
+	*sql.Conn
+	recorder *recorder
+}
+
+// ExecContext executes a statement and records its metrics
+func (c *Conn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return c.recorder.exec(ctx, query, func() (sql.Result, error) {
+		return c.Conn.ExecContext(ctx, query, args...)
+	})
+}
+
+// QueryContext executes a query and records its metrics
+func (c *Conn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return c.recorder.query(ctx, query, func() (*sql.Rows, error) {
+		return c.Conn.QueryContext(ctx, query, args...)
+	})
+}
+
+// QueryRowContext executes a single-row query and records its metrics
+func (c *Conn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return c.recorder.queryRow(ctx, query, func() *sql.Row {
+		return c.Conn.QueryRowContext(ctx, query, args...)
+	})
+}
+
+// PrepareContext creates a prepared statement whose executions are recorded
+func (c *Conn) PrepareContext(ctx context.Context, query string) (*Stmt, error) {
+	stmt, err := c.Conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &Stmt{Stmt: stmt, query: query, recorder: c.recorder}, nil
+}
+
+// BeginTx starts a transaction whose statements are also recorded
+func (c *Conn) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := c.Conn.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, recorder: c.recorder}, nil
+}
@@ -0,0 +1,107 @@
+package sqlmetrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDB(t *testing.T) {
+	t.Run("records exec duration and rows affected", func(t *testing.T) {
+		m := metricstest.New()
+		db := Wrap(openFakeDB(t), m)
+
+		ctx := WithQueryName(context.Background(), "bump_visits")
+		_, err := db.ExecContext(ctx, "UPDATE users SET visits = visits + 1")
+		require.NoError(t, err)
+
+		out := m.String()
+		assert.Contains(t, out, `sql_query_duration_seconds_count{operation="update",query="bump_visits"} 1`)
+		assert.Contains(t, out, `sql_rows_affected_total{operation="update",query="bump_visits"} 2`)
+	})
+
+	t.Run("records errors", func(t *testing.T) {
+		m := metricstest.New()
+		db := Wrap(openFakeDB(t), m)
+
+		_, err := db.Exec("fail")
+		require.Error(t, err)
+		_, err = db.Query("fail")
+		require.Error(t, err)
+
+		assert.Contains(t, m.String(), `sql_query_errors_total{operation="other",query=""} 2`)
+	})
+
+	t.Run("records queries", func(t *testing.T) {
+		m := metricstest.New()
+		db := Wrap(openFakeDB(t), m, WithBuckets(0.5))
+
+		rows, err := db.Query("SELECT n FROM numbers")
+		require.NoError(t, err)
+		rows.Close()
+
+		var n int
+		require.NoError(t, db.QueryRow("SELECT n FROM numbers").Scan(&n))
+		assert.Equal(t, 1, n)
+
+		assert.Contains(t, m.String(), `sql_query_duration_seconds_bucket{operation="select",query="",le="0.5"} 2`)
+	})
+
+	t.Run("records statements inside transactions", func(t *testing.T) {
+		m := metricstest.New()
+		db := Wrap(openFakeDB(t), m)
+
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		_, err = tx.Exec("DELETE FROM sessions")
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		out := m.String()
+		assert.Contains(t, out, `sql_query_duration_seconds_count{operation="delete",query=""} 1`)
+		assert.Contains(t, out, `sql_rows_affected_total{operation="delete",query=""} 2`)
+	})
+	t.Run("records prepared statements", func(t *testing.T) {
+		m := metricstest.New()
+		db := Wrap(openFakeDB(t), m)
+
+		stmt, err := db.Prepare("INSERT INTO events VALUES ($1)")
+		require.NoError(t, err)
+		defer stmt.Close()
+		for range 3 {
+			_, err = stmt.Exec(1)
+			require.NoError(t, err)
+		}
+
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		_, err = tx.Stmt(stmt).Exec(1)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		out := m.String()
+		assert.Contains(t, out, `sql_query_duration_seconds_count{operation="insert",query=""} 4`)
+		assert.Contains(t, out, `sql_rows_affected_total{operation="insert",query=""} 8`)
+	})
+
+	t.Run("records statements on a dedicated connection", func(t *testing.T) {
+		m := metricstest.New()
+		db := Wrap(openFakeDB(t), m)
+
+		conn, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var n int
+		require.NoError(t, conn.QueryRowContext(context.Background(), "SELECT n FROM numbers").Scan(&n))
+		_, err = conn.ExecContext(context.Background(), "fail")
+		require.Error(t, err)
+
+		out := m.String()
+		assert.Contains(t, out, `sql_query_duration_seconds_count{operation="select",query=""} 1`)
+		assert.Contains(t, out, `sql_query_errors_total{operation="other",query=""} 1`)
+	})
+}
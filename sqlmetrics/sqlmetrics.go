@@ -0,0 +1,154 @@
+// Package sqlmetrics decorates database/sql handles to record query metrics through metricsx.
+//
+// Every statement executed through a wrapped DB, Tx, Conn or prepared Stmt records:
+//
+//	sql_query_duration_seconds{operation, query}
+//	sql_query_errors_total{operation, query}
+//	sql_rows_affected_total{operation, query}
+//
+// The operation label is derived from the leading SQL keyword (select, insert, ...)
+// and the query label is taken from the context via WithQueryName.
+package sqlmetrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gostratum/metricsx"
+)
+
+// Option configures the SQL metrics
+type Option func(*options)
+
+// options contains configuration for the SQL metrics
+type options struct {
+	buckets []float64
+}
+
+// WithBuckets sets the buckets for the query duration histogram
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+type queryNameKey struct{}
+
+// WithQueryName returns a context that labels queries executed with it
+func WithQueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryNameKey{}, name)
+}
+
+// queryName returns the query name stored in the context, if any
+func queryName(ctx context.Context) string {
+	name, _ := ctx.Value(queryNameKey{}).(string)
+	return name
+}
+
+// knownOperations bounds the cardinality of the operation label
+var knownOperations = map[string]struct{}{
+	"select":   {},
+	"insert":   {},
+	"update":   {},
+	"delete":   {},
+	"merge":    {},
+	"with":     {},
+	"create":   {},
+	"alter":    {},
+	"drop":     {},
+	"truncate": {},
+	"begin":    {},
+	"commit":   {},
+	"rollback": {},
+}
+
+// operation returns the operation label for a SQL statement
+func operation(query string) string {
+	query = strings.TrimLeft(query, " \t\r\n(")
+	end := strings.IndexAny(query, " \t\r\n(;")
+	if end >= 0 {
+		query = query[:end]
+	}
+
+	op := strings.ToLower(query)
+	if _, ok := knownOperations[op]; ok {
+		return op
+	}
+	return "other"
+}
+
+// recorder holds the metrics shared by wrapped handles
+type recorder struct {
+	duration     metricsx.Histogram
+	errors       metricsx.Counter
+	rowsAffected metricsx.Counter
+}
+
+// newRecorder creates the SQL metrics
+func newRecorder(m metricsx.Metrics, opts ...Option) *recorder {
+	o := &options{
+		buckets: metricsx.DefaultBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &recorder{
+		duration: m.Histogram("sql_query_duration_seconds",
+			metricsx.WithHelp("SQL query duration in seconds."),
+			metricsx.WithLabels("operation", "query"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+		errors: m.Counter("sql_query_errors_total",
+			metricsx.WithHelp("Total number of SQL queries that returned an error."),
+			metricsx.WithLabels("operation", "query"),
+		),
+		rowsAffected: m.Counter("sql_rows_affected_total",
+			metricsx.WithHelp("Total number of rows affected by SQL statements."),
+			metricsx.WithLabels("operation", "query"),
+		),
+	}
+}
+
+// observe records the outcome of a single statement
+func (r *recorder) observe(ctx context.Context, query string, start time.Time, err error) {
+	op, name := operation(query), queryName(ctx)
+
+	r.duration.Observe(time.Since(start).Seconds(), op, name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		r.errors.Inc(op, name)
+	}
+}
+
+// exec runs an Exec-style call and records its metrics
+func (r *recorder) exec(ctx context.Context, query string, fn func() (sql.Result, error)) (sql.Result, error) {
+	start := time.Now()
+	res, err := fn()
+	r.observe(ctx, query, start, err)
+
+	if err == nil {
+		if n, rerr := res.RowsAffected(); rerr == nil && n > 0 {
+			r.rowsAffected.Add(float64(n), operation(query), queryName(ctx))
+		}
+	}
+	return res, err
+}
+
+// query runs a Query-style call and records its metrics
+func (r *recorder) query(ctx context.Context, query string, fn func() (*sql.Rows, error)) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := fn()
+	r.observe(ctx, query, start, err)
+	return rows, err
+}
+
+// queryRow runs a QueryRow-style call and records its metrics
+func (r *recorder) queryRow(ctx context.Context, query string, fn func() *sql.Row) *sql.Row {
+	start := time.Now()
+	row := fn()
+	r.observe(ctx, query, start, row.Err())
+	return row
+}
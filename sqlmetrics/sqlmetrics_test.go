@@ -0,0 +1,97 @@
+package sqlmetrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errFake = errors.New("fake failure")
+
+// fakeDriver is a minimal database/sql driver; statements containing "fail" return an error
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query: query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+func (fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if query == "fail" {
+		return nil, errFake
+	}
+	return driver.RowsAffected(2), nil
+}
+
+func (fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if query == "fail" {
+		return nil, errFake
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeStmt struct{ query string }
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return fakeConn{}.ExecContext(context.Background(), s.query, nil)
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return fakeConn{}.QueryContext(context.Background(), s.query, nil)
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct{ done bool }
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func init() {
+	sql.Register("sqlmetrics_fake", fakeDriver{})
+}
+
+func openFakeDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlmetrics_fake", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestOperation(t *testing.T) {
+	assert.Equal(t, "select", operation("SELECT * FROM users"))
+	assert.Equal(t, "insert", operation("\n  insert into users values (1)"))
+	assert.Equal(t, "select", operation("(select 1)"))
+	assert.Equal(t, "with", operation("WITH x AS (SELECT 1) SELECT * FROM x"))
+	assert.Equal(t, "other", operation("VACUUM"))
+	assert.Equal(t, "other", operation(""))
+}
+
+func TestQueryName(t *testing.T) {
+	assert.Empty(t, queryName(context.Background()))
+	assert.Equal(t, "get_user", queryName(WithQueryName(context.Background(), "get_user")))
+}
@@ -0,0 +1,15 @@
+package sqsmetrics
+
+import (
+	"time"
+)
+
+// Publish records an SNS Publish or PublishBatch call of batchSize messages
+func (m *Metrics) Publish(topic string, batchSize int, fn func() error) error {
+	m.snsBatchSize.Observe(float64(batchSize), topic)
+
+	start := time.Now()
+	err := fn()
+	m.snsDuration.Observe(time.Since(start).Seconds(), topic, status(err))
+	return err
+}
@@ -0,0 +1,23 @@
+package sqsmetrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublish(t *testing.T) {
+	m := metricstest.New()
+	sm := New(m)
+
+	require.NoError(t, sm.Publish("events", 5, func() error { return nil }))
+	require.Error(t, sm.Publish("events", 1, func() error { return errors.New("denied") }))
+
+	out := m.String()
+	assert.Contains(t, out, `aws_sns_publish_duration_seconds_count{status="ok",topic="events"} 1`)
+	assert.Contains(t, out, `aws_sns_publish_duration_seconds_count{status="error",topic="events"} 1`)
+	assert.Contains(t, out, `aws_sns_batch_size_sum{topic="events"} 6`)
+}
@@ -0,0 +1,199 @@
+// Package sqsmetrics provides helpers that record AWS SQS and SNS client metrics through metricsx.
+//
+// The helpers wrap calls made with any SDK version, so this package does not
+// depend on the AWS SDK:
+//
+//	sm := sqsmetrics.New(metrics)
+//
+//	err := sm.Send("orders", 1, func() error {
+//		_, err := client.SendMessage(ctx, input)
+//		return err
+//	})
+//
+// It records:
+//
+//	aws_sqs_operation_duration_seconds{queue, operation, status}
+//	aws_sqs_batch_size{queue, operation}
+//	aws_sqs_approximate_messages{queue, state}
+//	aws_sqs_visibility_extensions_total{queue}
+//	aws_sns_publish_duration_seconds{topic, status}
+//	aws_sns_batch_size{topic}
+package sqsmetrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/gostratum/metricsx"
+)
+
+// Operation label values
+const (
+	OperationSend             = "send"
+	OperationReceive          = "receive"
+	OperationDelete           = "delete"
+	OperationChangeVisibility = "change_visibility"
+)
+
+// DefaultBatchBuckets matches the SQS and SNS limit of 10 messages per batch
+var DefaultBatchBuckets = []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+// Option configures the SQS/SNS metrics
+type Option func(*options)
+
+// options contains configuration for the SQS/SNS metrics
+type options struct {
+	buckets      []float64
+	batchBuckets []float64
+}
+
+// WithBuckets sets the buckets for the operation duration histograms
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// WithBatchBuckets sets the buckets for the batch size histograms
+func WithBatchBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.batchBuckets = buckets
+	}
+}
+
+// Metrics records SQS and SNS client metrics
+type Metrics struct {
+	sqsDuration   metricsx.Histogram
+	sqsBatchSize  metricsx.Histogram
+	queueDepth    metricsx.Gauge
+	visibilityExt metricsx.Counter
+	snsDuration   metricsx.Histogram
+	snsBatchSize  metricsx.Histogram
+}
+
+// New creates the SQS/SNS metrics
+func New(m metricsx.Metrics, opts ...Option) *Metrics {
+	o := &options{
+		buckets:      metricsx.DefaultBuckets,
+		batchBuckets: DefaultBatchBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Metrics{
+		sqsDuration: m.Histogram("aws_sqs_operation_duration_seconds",
+			metricsx.WithHelp("Duration of SQS API calls in seconds."),
+			metricsx.WithLabels("queue", "operation", "status"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+		sqsBatchSize: m.Histogram("aws_sqs_batch_size",
+			metricsx.WithHelp("Number of messages per SQS send, receive or delete call."),
+			metricsx.WithLabels("queue", "operation"),
+			metricsx.WithBuckets(o.batchBuckets...),
+		),
+		queueDepth: m.Gauge("aws_sqs_approximate_messages",
+			metricsx.WithHelp("Approximate number of messages in the queue by state, from GetQueueAttributes."),
+			metricsx.WithLabels("queue", "state"),
+		),
+		visibilityExt: m.Counter("aws_sqs_visibility_extensions_total",
+			metricsx.WithHelp("Total number of visibility timeout extensions."),
+			metricsx.WithLabels("queue"),
+		),
+		snsDuration: m.Histogram("aws_sns_publish_duration_seconds",
+			metricsx.WithHelp("Duration of SNS publish calls in seconds."),
+			metricsx.WithLabels("topic", "status"),
+			metricsx.WithBuckets(o.buckets...),
+		),
+		snsBatchSize: m.Histogram("aws_sns_batch_size",
+			metricsx.WithHelp("Number of messages per SNS publish call."),
+			metricsx.WithLabels("topic"),
+			metricsx.WithBuckets(o.batchBuckets...),
+		),
+	}
+}
+
+// Send records a SendMessage or SendMessageBatch call of batchSize messages
+func (m *Metrics) Send(queue string, batchSize int, fn func() error) error {
+	m.sqsBatchSize.Observe(float64(batchSize), queue, OperationSend)
+	return m.observe(queue, OperationSend, fn)
+}
+
+// Receive records a ReceiveMessage call; fn returns the number of messages received
+func (m *Metrics) Receive(queue string, fn func() (int, error)) error {
+	var received int
+	err := m.observe(queue, OperationReceive, func() error {
+		var err error
+		received, err = fn()
+		return err
+	})
+
+	if err == nil {
+		m.sqsBatchSize.Observe(float64(received), queue, OperationReceive)
+	}
+	return err
+}
+
+// Delete records a DeleteMessage or DeleteMessageBatch call of batchSize messages
+func (m *Metrics) Delete(queue string, batchSize int, fn func() error) error {
+	m.sqsBatchSize.Observe(float64(batchSize), queue, OperationDelete)
+	return m.observe(queue, OperationDelete, fn)
+}
+
+// ExtendVisibility records a ChangeMessageVisibility call that extends a message's timeout
+func (m *Metrics) ExtendVisibility(queue string, fn func() error) error {
+	err := m.observe(queue, OperationChangeVisibility, fn)
+	if err == nil {
+		m.visibilityExt.Inc(queue)
+	}
+	return err
+}
+
+// observe times fn and records it under the given operation
+func (m *Metrics) observe(queue, operation string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	m.sqsDuration.Observe(time.Since(start).Seconds(), queue, operation, status(err))
+	return err
+}
+
+// QueueDepth holds the approximate message counts returned by GetQueueAttributes
+type QueueDepth struct {
+	// Visible is ApproximateNumberOfMessages
+	Visible int64
+
+	// InFlight is ApproximateNumberOfMessagesNotVisible
+	InFlight int64
+
+	// Delayed is ApproximateNumberOfMessagesDelayed
+	Delayed int64
+}
+
+// PollQueueDepth calls fetch every interval and exports the result as gauges.
+// It blocks until ctx is cancelled; failed fetches leave the previous values in place.
+func (m *Metrics) PollQueueDepth(ctx context.Context, queue string, interval time.Duration, fetch func(ctx context.Context) (QueueDepth, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if depth, err := fetch(ctx); err == nil {
+			m.queueDepth.Set(float64(depth.Visible), queue, "visible")
+			m.queueDepth.Set(float64(depth.InFlight), queue, "in_flight")
+			m.queueDepth.Set(float64(depth.Delayed), queue, "delayed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// status returns the status label for an error
+func status(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
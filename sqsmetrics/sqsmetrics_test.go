@@ -0,0 +1,62 @@
+package sqsmetrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQSOperations(t *testing.T) {
+	m := metricstest.New()
+	sm := New(m)
+
+	require.NoError(t, sm.Send("orders", 10, func() error { return nil }))
+	require.NoError(t, sm.Receive("orders", func() (int, error) { return 3, nil }))
+	require.NoError(t, sm.Delete("orders", 3, func() error { return nil }))
+	require.Error(t, sm.Send("orders", 1, func() error { return errors.New("throttled") }))
+	require.NoError(t, sm.ExtendVisibility("orders", func() error { return nil }))
+	require.Error(t, sm.ExtendVisibility("orders", func() error { return errors.New("receipt expired") }))
+
+	out := m.String()
+	assert.Contains(t, out, `aws_sqs_operation_duration_seconds_count{operation="send",queue="orders",status="ok"} 1`)
+	assert.Contains(t, out, `aws_sqs_operation_duration_seconds_count{operation="send",queue="orders",status="error"} 1`)
+	assert.Contains(t, out, `aws_sqs_operation_duration_seconds_count{operation="receive",queue="orders",status="ok"} 1`)
+	assert.Contains(t, out, `aws_sqs_operation_duration_seconds_count{operation="delete",queue="orders",status="ok"} 1`)
+	assert.Contains(t, out, `aws_sqs_batch_size_sum{operation="send",queue="orders"} 11`)
+	assert.Contains(t, out, `aws_sqs_batch_size_sum{operation="receive",queue="orders"} 3`)
+	assert.Contains(t, out, `aws_sqs_visibility_extensions_total{queue="orders"} 1`)
+}
+
+func TestPollQueueDepth(t *testing.T) {
+	m := metricstest.New()
+	sm := New(m)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sm.PollQueueDepth(ctx, "orders", 10*time.Millisecond, func(context.Context) (QueueDepth, error) {
+			return QueueDepth{Visible: 42, InFlight: 5, Delayed: 1}, nil
+		})
+	}()
+
+	assert.Eventually(t, func() bool {
+		out := m.String()
+		return strings.Contains(out, `aws_sqs_approximate_messages{queue="orders",state="visible"} 42`) &&
+			strings.Contains(out, `aws_sqs_approximate_messages{queue="orders",state="in_flight"} 5`) &&
+			strings.Contains(out, `aws_sqs_approximate_messages{queue="orders",state="delayed"} 1`)
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("PollQueueDepth did not return after cancellation")
+	}
+}
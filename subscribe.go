@@ -0,0 +1,208 @@
+package metricsx
+
+import (
+	"cmp"
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultSubscriptionInterval is how often subscribed metrics are compared
+// when PrometheusConfig.SubscriptionInterval is zero
+const DefaultSubscriptionInterval = time.Second
+
+// subscriptionBuffer is the capacity of every subscription channel
+const subscriptionBuffer = 256
+
+// MetricUpdate is the new state of a series that appeared or changed
+type MetricUpdate struct {
+	// Name is the fully qualified family name
+	Name string
+
+	// Type is counter, gauge, histogram, summary or untyped
+	Type string
+
+	// Labels are the label names and values of the series
+	Labels map[string]string
+
+	// Value of a counter, gauge or untyped series
+	Value float64
+
+	// Count and Sum of the observations of a histogram or summary
+	Count uint64
+	Sum   float64
+
+	// Time the change was detected
+	Time time.Time
+}
+
+// Subscriber is implemented by providers that stream metric changes
+type Subscriber interface {
+	// Subscribe returns a channel receiving an update whenever a series of a
+	// metric allowed by filter appears or changes. It panics when a pattern
+	// of filter does not compile.
+	Subscribe(filter FilterConfig) <-chan MetricUpdate
+
+	// Unsubscribe stops the updates of ch and closes it
+	Unsubscribe(ch <-chan MetricUpdate)
+}
+
+// Subscribe streams the changes of the metrics allowed by filter. Series are
+// compared every SubscriptionInterval; the first comparison sends every
+// matching series. When the subscriber falls behind, updates are not queued
+// but resent with the latest value once the channel has room, so a slow
+// reader skips intermediate values rather than blocking the provider. The
+// channel is closed on Unsubscribe and when the provider stops.
+func (p *prometheusProvider) Subscribe(filter FilterConfig) <-chan MetricUpdate {
+	return p.subscriptions.subscribe(filter)
+}
+
+// Unsubscribe stops the updates of ch and closes it
+func (p *prometheusProvider) Unsubscribe(ch <-chan MetricUpdate) {
+	p.subscriptions.unsubscribe(ch)
+}
+
+// subscriptions compares the snapshot of a provider on every interval and
+// sends the changed series to each subscriber
+type subscriptions struct {
+	source   Snapshotter
+	interval time.Duration
+	now      func() time.Time
+
+	mu   sync.Mutex
+	subs map[<-chan MetricUpdate]*subscription
+	stop chan struct{}
+	done chan struct{}
+}
+
+// subscription is one subscriber and the series values it was last sent
+type subscription struct {
+	filter *metricFilter
+	ch     chan MetricUpdate
+	sent   map[string]MetricUpdate
+}
+
+// newSubscriptions creates the subscriptions of source
+func newSubscriptions(source Snapshotter, interval time.Duration) *subscriptions {
+	return &subscriptions{
+		source:   source,
+		interval: cmp.Or(interval, DefaultSubscriptionInterval),
+		now:      time.Now,
+		subs:     make(map[<-chan MetricUpdate]*subscription),
+	}
+}
+
+// subscribe adds a subscriber and starts comparing on the first one
+func (s *subscriptions) subscribe(filter FilterConfig) <-chan MetricUpdate {
+	f, err := newMetricFilter(filter)
+	if err != nil {
+		panic(err)
+	}
+
+	sub := &subscription{filter: f, ch: make(chan MetricUpdate, subscriptionBuffer), sent: make(map[string]MetricUpdate)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs[sub.ch] = sub
+	if s.stop == nil {
+		s.stop, s.done = make(chan struct{}), make(chan struct{})
+		go s.run(s.stop, s.done)
+	}
+	return sub.ch
+}
+
+// unsubscribe removes the subscriber of ch and closes it, ending the
+// comparisons with the last subscriber
+func (s *subscriptions) unsubscribe(ch <-chan MetricUpdate) {
+	s.mu.Lock()
+	// publish sends under the lock, so ch is never closed during a send
+	if sub, ok := s.subs[ch]; ok {
+		delete(s.subs, ch)
+		close(sub.ch)
+	}
+	var stop, done chan struct{}
+	if len(s.subs) == 0 {
+		stop, done = s.stop, s.done
+		s.stop, s.done = nil, nil
+	}
+	s.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// close ends the comparisons and closes every subscription
+func (s *subscriptions) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch, sub := range s.subs {
+		delete(s.subs, ch)
+		close(sub.ch)
+	}
+	if s.stop != nil {
+		// the loop exits on its own; publish finds no subscribers meanwhile
+		close(s.stop)
+		s.stop, s.done = nil, nil
+	}
+}
+
+// run compares the snapshot on every interval until stop is closed
+func (s *subscriptions) run(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.publish()
+		}
+	}
+}
+
+// publish sends every series that changed since it was last sent to each
+// subscriber, skipping subscribers whose channel is full
+func (s *subscriptions) publish() {
+	families, _ := s.source.Snapshot()
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.subs {
+		for _, f := range families {
+			if !sub.filter.allowed(f.Name) {
+				continue
+			}
+			for _, sample := range f.Samples {
+				update := MetricUpdate{Name: f.Name, Type: f.Type, Labels: sample.Labels, Value: sample.Value, Count: sample.Count, Sum: sample.Sum}
+				key := historyKey(f.Name, sample.Labels)
+				if last, ok := sub.sent[key]; ok && sameValue(last, update) {
+					continue
+				}
+
+				update.Time = now
+				select {
+				case sub.ch <- update:
+					sub.sent[key] = update
+				default:
+				}
+			}
+		}
+	}
+}
+
+// sameValue reports whether a and b hold the same value, ignoring the time;
+// NaN equals NaN so a NaN gauge is not resent on every comparison
+func sameValue(a, b MetricUpdate) bool {
+	return sameFloat(a.Value, b.Value) && a.Count == b.Count && sameFloat(a.Sum, b.Sum)
+}
+
+func sameFloat(a, b float64) bool {
+	return a == b || (math.IsNaN(a) && math.IsNaN(b))
+}
@@ -0,0 +1,114 @@
+package metricsx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptions(t *testing.T) {
+	newTestSubscriptions := func(t *testing.T) (Metrics, *subscriptions) {
+		t.Helper()
+		res, err := NewMetrics(Params{Config: Config{Provider: "prometheus", Prometheus: PrometheusConfig{SubscriptionInterval: time.Hour}}, Logger: getTestLogger()})
+		require.NoError(t, err)
+
+		s := res.Provider.(*prometheusProvider).subscriptions
+		t.Cleanup(s.close)
+		return res.Metrics, s
+	}
+
+	receive := func(t *testing.T, ch <-chan MetricUpdate) []MetricUpdate {
+		t.Helper()
+		var updates []MetricUpdate
+		for {
+			select {
+			case u := <-ch:
+				updates = append(updates, u)
+			default:
+				return updates
+			}
+		}
+	}
+
+	t.Run("sends matching series that changed", func(t *testing.T) {
+		m, s := newTestSubscriptions(t)
+		queue := m.Gauge("queue_depth", WithLabels("queue"))
+		m.Counter("requests_total").Inc()
+		queue.Set(3, "emails")
+		queue.Set(7, "sms")
+
+		ch := s.subscribe(FilterConfig{Allow: []string{"queue_*"}})
+		s.publish()
+		updates := receive(t, ch)
+		assert.Len(t, updates, 2, "the first comparison sends every series")
+
+		s.publish()
+		assert.Empty(t, receive(t, ch))
+
+		queue.Set(4, "emails")
+		s.publish()
+		updates = receive(t, ch)
+		require.Len(t, updates, 1)
+		assert.Equal(t, "queue_depth", updates[0].Name)
+		assert.Equal(t, "gauge", updates[0].Type)
+		assert.Equal(t, map[string]string{"queue": "emails"}, updates[0].Labels)
+		assert.Equal(t, 4.0, updates[0].Value)
+	})
+
+	t.Run("resends the latest value to slow subscribers", func(t *testing.T) {
+		m, s := newTestSubscriptions(t)
+		gauge := m.Gauge("temperature_celsius")
+
+		ch := s.subscribe(FilterConfig{})
+		for i := range subscriptionBuffer + 10 {
+			gauge.Set(float64(i))
+			s.publish()
+		}
+		assert.Len(t, receive(t, ch), subscriptionBuffer)
+
+		s.publish()
+		updates := receive(t, ch)
+		require.Len(t, updates, 1)
+		assert.Equal(t, float64(subscriptionBuffer+9), updates[0].Value)
+	})
+
+	t.Run("closes channels on unsubscribe and stop", func(t *testing.T) {
+		_, s := newTestSubscriptions(t)
+
+		first := s.subscribe(FilterConfig{})
+		second := s.subscribe(FilterConfig{})
+		s.unsubscribe(first)
+		_, open := <-first
+		assert.False(t, open)
+
+		s.close()
+		for range second {
+		}
+		assert.Empty(t, s.subs)
+	})
+
+	t.Run("streams on the interval", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: Config{Provider: "prometheus", Prometheus: PrometheusConfig{SubscriptionInterval: time.Millisecond}}, Logger: getTestLogger()})
+		require.NoError(t, err)
+		p := res.Provider.(*prometheusProvider)
+		t.Cleanup(p.subscriptions.close)
+
+		ch := p.Subscribe(FilterConfig{Allow: []string{"jobs_total"}})
+		res.Metrics.Counter("jobs_total").Add(2)
+
+		select {
+		case u := <-ch:
+			assert.Equal(t, 2.0, u.Value)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no update received")
+		}
+		p.Unsubscribe(ch)
+	})
+
+	t.Run("panics on invalid filters", func(t *testing.T) {
+		_, s := newTestSubscriptions(t)
+		assert.Panics(t, func() { s.subscribe(FilterConfig{Allow: []string{"["}}) })
+	})
+}
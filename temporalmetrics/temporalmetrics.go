@@ -0,0 +1,189 @@
+// Package temporalmetrics implements Temporal's client.MetricsHandler on top of
+// metricsx so workflow and activity metrics land in the application registry.
+//
+//	c, err := client.Dial(client.Options{
+//		MetricsHandler: temporalmetrics.NewHandler(metrics),
+//	})
+//
+// SDK counters and gauges keep their names; timers become histograms with a
+// "_seconds" suffix, e.g. temporal_activity_execution_latency_seconds. Handler
+// tags become labels. Prometheus requires a fixed label set per metric, so the
+// label names of a metric are fixed the first time it is used: the tags present
+// then plus the well-known namespace, task_queue, workflow_type and
+// activity_type keys, which the SDK adds in varying order. Missing tags are
+// empty; later tags outside that set are dropped and counted in
+// temporal_metrics_dropped_tags_total{metric, tag}.
+package temporalmetrics
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gostratum/metricsx"
+	"go.temporal.io/sdk/client"
+)
+
+// Option configures the handler
+type Option func(*options)
+
+// options contains configuration for the handler
+type options struct {
+	buckets []float64
+}
+
+// WithBuckets sets the buckets for timer histograms
+func WithBuckets(buckets ...float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// knownTags are the SDK tag keys declared on every metric, so a metric first
+// used before its handler was tagged with them does not drop them later
+var knownTags = []string{"activity_type", "namespace", "task_queue", "workflow_type"}
+
+// registry tracks the label names each SDK metric was first created with
+type registry struct {
+	metrics metricsx.Metrics
+	buckets []float64
+	dropped metricsx.Counter
+
+	mu     sync.Mutex
+	labels map[string][]string
+}
+
+// handler implements client.MetricsHandler for one tag set
+type handler struct {
+	registry *registry
+	tags     map[string]string
+}
+
+// NewHandler creates a client.MetricsHandler that records through m
+func NewHandler(m metricsx.Metrics, opts ...Option) client.MetricsHandler {
+	o := &options{
+		buckets: metricsx.DefaultBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &handler{
+		registry: &registry{
+			metrics: m,
+			buckets: o.buckets,
+			dropped: m.Counter("temporal_metrics_dropped_tags_total",
+				metricsx.WithHelp("Number of Temporal SDK metrics obtained with a tag outside their fixed label set."),
+				metricsx.WithLabels("metric", "tag"),
+			),
+			labels: make(map[string][]string),
+		},
+	}
+}
+
+// WithTags returns a handler whose metrics carry tags in addition to the current ones
+func (h *handler) WithTags(tags map[string]string) client.MetricsHandler {
+	merged := maps.Clone(h.tags)
+	if merged == nil {
+		merged = make(map[string]string, len(tags))
+	}
+	maps.Copy(merged, tags)
+	return &handler{registry: h.registry, tags: merged}
+}
+
+// Counter obtains a counter for the given name
+func (h *handler) Counter(name string) client.MetricsCounter {
+	labels, values := h.labelValues(name)
+	return &counter{
+		counter: h.registry.metrics.Counter(name,
+			metricsx.WithHelp("Temporal SDK counter "+name+"."),
+			metricsx.WithLabels(labels...),
+		),
+		values: values,
+	}
+}
+
+// Gauge obtains a gauge for the given name
+func (h *handler) Gauge(name string) client.MetricsGauge {
+	labels, values := h.labelValues(name)
+	return &gauge{
+		gauge: h.registry.metrics.Gauge(name,
+			metricsx.WithHelp("Temporal SDK gauge "+name+"."),
+			metricsx.WithLabels(labels...),
+		),
+		values: values,
+	}
+}
+
+// Timer obtains a timer for the given name
+func (h *handler) Timer(name string) client.MetricsTimer {
+	name += "_seconds"
+	labels, values := h.labelValues(name)
+	return &timer{
+		histogram: h.registry.metrics.Histogram(name,
+			metricsx.WithHelp("Temporal SDK timer "+name+"."),
+			metricsx.WithLabels(labels...),
+			metricsx.WithBuckets(h.registry.buckets...),
+		),
+		values: values,
+	}
+}
+
+// labelValues returns the label names fixed for name and the handler's values
+// for them, counting the handler's tags that are not among them
+func (h *handler) labelValues(name string) ([]string, []string) {
+	h.registry.mu.Lock()
+	labels, ok := h.registry.labels[name]
+	if !ok {
+		labels = slices.AppendSeq(slices.Clone(knownTags), maps.Keys(h.tags))
+		slices.Sort(labels)
+		labels = slices.Compact(labels)
+		h.registry.labels[name] = labels
+	}
+	h.registry.mu.Unlock()
+
+	values := make([]string, len(labels))
+	for i, label := range labels {
+		values[i] = h.tags[label]
+	}
+	for tag := range h.tags {
+		if _, found := slices.BinarySearch(labels, tag); !found {
+			h.registry.dropped.Inc(name, tag)
+		}
+	}
+	return labels, values
+}
+
+// counter implements client.MetricsCounter
+type counter struct {
+	counter metricsx.Counter
+	values  []string
+}
+
+// Inc increments the counter value
+func (c *counter) Inc(delta int64) {
+	c.counter.Add(float64(delta), c.values...)
+}
+
+// gauge implements client.MetricsGauge
+type gauge struct {
+	gauge  metricsx.Gauge
+	values []string
+}
+
+// Update sets the gauge value
+func (g *gauge) Update(value float64) {
+	g.gauge.Set(value, g.values...)
+}
+
+// timer implements client.MetricsTimer
+type timer struct {
+	histogram metricsx.Histogram
+	values    []string
+}
+
+// Record observes the duration in seconds
+func (t *timer) Record(d time.Duration) {
+	t.histogram.Observe(d.Seconds(), t.values...)
+}
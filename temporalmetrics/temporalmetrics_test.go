@@ -0,0 +1,58 @@
+package temporalmetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gostratum/metricsx/metricstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	t.Run("records counters, gauges and timers with tags", func(t *testing.T) {
+		m := metricstest.New()
+		h := NewHandler(m).WithTags(map[string]string{"namespace": "default"})
+		wh := h.WithTags(map[string]string{"workflow_type": "Checkout"})
+
+		wh.Counter("temporal_workflow_completed").Inc(2)
+		h.Gauge("temporal_num_pollers").Update(4)
+		wh.Timer("temporal_workflow_endtoend_latency").Record(1500 * time.Millisecond)
+
+		out := m.String()
+		assert.Contains(t, out, `temporal_workflow_completed{activity_type="",namespace="default",task_queue="",workflow_type="Checkout"} 2`)
+		assert.Contains(t, out, `temporal_num_pollers{activity_type="",namespace="default",task_queue="",workflow_type=""} 4`)
+		assert.Contains(t, out, `temporal_workflow_endtoend_latency_seconds_sum{activity_type="",namespace="default",task_queue="",workflow_type="Checkout"} 1.5`)
+	})
+
+	t.Run("overrides existing tags", func(t *testing.T) {
+		m := metricstest.New()
+		h := NewHandler(m).WithTags(map[string]string{"task_queue": "a"})
+
+		h.WithTags(map[string]string{"task_queue": "b"}).Counter("temporal_request").Inc(1)
+
+		assert.Equal(t, 1.0, m.CounterValue("temporal_request", "", "", "b", ""))
+	})
+
+	t.Run("keeps the label set of the first use", func(t *testing.T) {
+		m := metricstest.New()
+		h := NewHandler(m)
+
+		h.WithTags(map[string]string{"namespace": "default", "operation": "Poll"}).Counter("temporal_request_failure").Inc(1)
+		h.WithTags(map[string]string{"namespace": "other", "extra": "dropped"}).Counter("temporal_request_failure").Inc(1)
+
+		assert.Equal(t, 1.0, m.CounterValue("temporal_request_failure", "", "default", "Poll", "", ""))
+		assert.Equal(t, 1.0, m.CounterValue("temporal_request_failure", "", "other", "", "", ""))
+		assert.Equal(t, 1.0, m.CounterValue("temporal_metrics_dropped_tags_total", "temporal_request_failure", "extra"))
+	})
+
+	t.Run("declares well-known tags added after the first use", func(t *testing.T) {
+		m := metricstest.New()
+		h := NewHandler(m).WithTags(map[string]string{"namespace": "default"})
+
+		h.Counter("temporal_request").Inc(1)
+		h.WithTags(map[string]string{"task_queue": "orders"}).Counter("temporal_request").Inc(1)
+
+		assert.Equal(t, 1.0, m.CounterValue("temporal_request", "", "default", "orders", ""))
+		assert.NotContains(t, m.String(), `tag="task_queue"`)
+	})
+}
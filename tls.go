@@ -0,0 +1,93 @@
+package metricsx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// TLSConfig serves the standalone metrics server over TLS, optionally requiring
+// client certificates signed by ClientCAFile
+type TLSConfig struct {
+	// CertFile is the PEM encoded server certificate
+	CertFile string `mapstructure:"cert_file" default:""`
+
+	// KeyFile is the PEM encoded server private key
+	KeyFile string `mapstructure:"key_file" default:""`
+
+	// ClientCAFile is a PEM bundle of CAs used to verify client certificates.
+	// When set, clients must present a valid certificate.
+	ClientCAFile string `mapstructure:"client_ca_file" default:""`
+
+	// AllowedSANs restricts client certificates to those carrying one of these
+	// DNS, IP, URI or email subject alternative names. Empty allows any
+	// certificate signed by ClientCAFile.
+	AllowedSANs []string `mapstructure:"allowed_sans"`
+}
+
+// Enabled reports whether TLS is configured
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// serverConfig builds the tls.Config for the metrics server
+func (c TLSConfig) serverConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load metrics server certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if c.ClientCAFile == "" {
+		if len(c.AllowedSANs) > 0 {
+			return nil, errors.New("metrics tls allowed_sans requires client_ca_file")
+		}
+		return config, nil
+	}
+
+	bundle, err := os.ReadFile(c.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read metrics client CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, fmt.Errorf("no certificates found in metrics client CA bundle %s", c.ClientCAFile)
+	}
+
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	config.ClientCAs = pool
+	if len(c.AllowedSANs) > 0 {
+		config.VerifyConnection = c.verifySAN
+	}
+	return config, nil
+}
+
+// verifySAN rejects client certificates without an allowed subject alternative name
+func (c TLSConfig) verifySAN(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("client certificate required")
+	}
+
+	leaf := state.PeerCertificates[0]
+	sans := slices.Concat(leaf.DNSNames, leaf.EmailAddresses)
+	for _, ip := range leaf.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, uri := range leaf.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	for _, san := range sans {
+		if slices.Contains(c.AllowedSANs, san) {
+			return nil
+		}
+	}
+	return fmt.Errorf("client certificate SANs %v are not allowed", sans)
+}
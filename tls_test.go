@@ -0,0 +1,163 @@
+package metricsx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA issues certificates for TLS tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// newTestCA creates a self-signed CA
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "metricsx test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue creates a leaf certificate with the given DNS names
+func (ca *testCA) issue(t *testing.T, usage x509.ExtKeyUsage, dnsNames ...string) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "metricsx test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     dnsNames,
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// writeKeyPair writes cert as PEM files and returns their paths
+func writeKeyPair(t *testing.T, cert tls.Certificate) (string, string) {
+	t.Helper()
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestPrometheusTLS(t *testing.T) {
+	ca := newTestCA(t)
+	certFile, keyFile := writeKeyPair(t, ca.issue(t, x509.ExtKeyUsageServerAuth, "localhost"))
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0o600))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	get := func(url string, certs ...tls.Certificate) (*http.Response, error) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+		return client.Get(url)
+	}
+
+	t.Run("requires client certificates with an allowed SAN", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{
+			Port: 19092,
+			Path: "/metrics",
+			TLS: TLSConfig{
+				CertFile:     certFile,
+				KeyFile:      keyFile,
+				ClientCAFile: caFile,
+				AllowedSANs:  []string{"prometheus.monitoring.svc"},
+			},
+		}, getTestLogger())
+
+		ctx := context.Background()
+		require.NoError(t, provider.Start(ctx))
+		defer provider.Stop(ctx)
+		time.Sleep(100 * time.Millisecond)
+
+		const url = "https://localhost:19092/metrics"
+
+		_, err := get(url)
+		assert.Error(t, err)
+
+		_, err = get(url, ca.issue(t, x509.ExtKeyUsageClientAuth, "intruder.example.com"))
+		assert.Error(t, err)
+
+		resp, err := get(url, ca.issue(t, x509.ExtKeyUsageClientAuth, "prometheus.monitoring.svc"))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("rejects invalid configuration on start", func(t *testing.T) {
+		provider := newPrometheusProvider(PrometheusConfig{
+			Port: 19093,
+			Path: "/metrics",
+			TLS: TLSConfig{
+				CertFile:    certFile,
+				KeyFile:     keyFile,
+				AllowedSANs: []string{"prometheus"},
+			},
+		}, getTestLogger())
+		assert.Error(t, provider.Start(context.Background()))
+
+		provider = newPrometheusProvider(PrometheusConfig{
+			Port: 19093,
+			Path: "/metrics",
+			TLS:  TLSConfig{CertFile: "missing.pem", KeyFile: "missing.key"},
+		}, getTestLogger())
+		assert.Error(t, provider.Start(context.Background()))
+	})
+}
@@ -0,0 +1,108 @@
+package metricsx
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// seriesExpiry tracks when each series of a metric vector was last written
+// and deletes the ones idle for longer than ttl
+type seriesExpiry struct {
+	ttl    time.Duration
+	remove func(key string, labels []string)
+	now    func() time.Time
+
+	mu     sync.RWMutex
+	series map[string]*expiringSeries
+}
+
+// expiringSeries is a tracked series and the time of its last write in Unix nanoseconds
+type expiringSeries struct {
+	labels  []string
+	written atomic.Int64
+}
+
+// newSeriesExpiry creates an expiry calling remove for every expired series
+func newSeriesExpiry(ttl time.Duration, remove func(key string, labels []string)) *seriesExpiry {
+	return &seriesExpiry{
+		ttl:    ttl,
+		remove: remove,
+		now:    time.Now,
+		series: make(map[string]*expiringSeries),
+	}
+}
+
+// touch records a write to the series with the given child key and labels
+func (e *seriesExpiry) touch(key []byte, labels []string) {
+	now := e.now().UnixNano()
+
+	e.mu.RLock()
+	s, ok := e.series[string(key)]
+	e.mu.RUnlock()
+	if ok {
+		s.written.Store(now)
+		return
+	}
+
+	e.mu.Lock()
+	if s, ok = e.series[string(key)]; !ok {
+		s = &expiringSeries{labels: slices.Clone(labels)}
+		e.series[string(key)] = s
+	}
+	s.written.Store(now)
+	e.mu.Unlock()
+}
+
+// expire removes every series not written within ttl
+func (e *seriesExpiry) expire() {
+	deadline := e.now().Add(-e.ttl).UnixNano()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key, s := range e.series {
+		if s.written.Load() < deadline {
+			delete(e.series, key)
+			e.remove(key, s.labels)
+		}
+	}
+}
+
+// deletableVec is a metric vector whose series can be deleted
+type deletableVec interface {
+	prometheus.Collector
+	DeleteLabelValues(labels ...string) bool
+}
+
+// expiringCollector expires stale series before every collection
+type expiringCollector struct {
+	deletableVec
+	expiry *seriesExpiry
+}
+
+// Collect deletes the expired series and collects the rest
+func (c *expiringCollector) Collect(ch chan<- prometheus.Metric) {
+	c.expiry.expire()
+	c.deletableVec.Collect(ch)
+}
+
+// expiring returns the collector to register for vec. With a positive ttl,
+// writes through children are tracked and series idle for longer than ttl are
+// deleted from vec and children when the metric is collected, counting each
+// in expired.
+func expiring[T any](vec deletableVec, children *childCache[T], ttl time.Duration, expired prometheus.Counter) prometheus.Collector {
+	if ttl <= 0 {
+		return vec
+	}
+
+	children.expiry = newSeriesExpiry(ttl, func(key string, labels []string) {
+		children.remove(key)
+		vec.DeleteLabelValues(labels...)
+		expired.Inc()
+	})
+	return &expiringCollector{deletableVec: vec, expiry: children.expiry}
+}
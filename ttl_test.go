@@ -0,0 +1,93 @@
+package metricsx
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTL(t *testing.T) {
+	newProvider := func(t *testing.T) Provider {
+		res, err := NewMetrics(Params{Config: Config{Provider: "prometheus"}, Logger: getTestLogger()})
+		require.NoError(t, err)
+		return res.Provider
+	}
+
+	t.Run("deletes series not written within the ttl", func(t *testing.T) {
+		provider := newProvider(t)
+		clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+
+		counter := provider.Counter("sessions_total", applyOptions(WithLabels("session"), WithTTL(time.Minute)))
+		counter.(*prometheusCounterVec).children.expiry.now = clock.Now
+
+		counter.Inc("a")
+		counter.Inc("b")
+		clock.Advance(45 * time.Second)
+		counter.Inc("b")
+
+		body := scrape(t, provider)
+		assert.Contains(t, body, `sessions_total{session="a"} 1`)
+		assert.Contains(t, body, `sessions_total{session="b"} 2`)
+
+		clock.Advance(30 * time.Second)
+		body = scrape(t, provider)
+		assert.NotContains(t, body, `session="a"`)
+		assert.Contains(t, body, `sessions_total{session="b"} 2`)
+
+		counter.Inc("a")
+		assert.Contains(t, scrape(t, provider), `sessions_total{session="a"} 1`)
+	})
+
+	t.Run("expires gauges, histograms and summaries", func(t *testing.T) {
+		provider := newProvider(t)
+		clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+
+		gauge := provider.Gauge("pod_memory_bytes", applyOptions(WithLabels("pod"), WithTTL(time.Minute)))
+		gauge.(*prometheusGaugeVec).children.expiry.now = clock.Now
+		histogram := provider.Histogram("pod_latency_seconds", applyOptions(WithLabels("pod"), WithTTL(time.Minute)))
+		histogram.(*prometheusHistogramVec).children.expiry.now = clock.Now
+		summary := provider.Summary("pod_size_bytes", applyOptions(WithLabels("pod"), WithTTL(time.Minute)))
+		summary.(*prometheusSummaryVec).children.expiry.now = clock.Now
+
+		gauge.Set(1, "web-1")
+		histogram.Observe(0.1, "web-1")
+		summary.Observe(10, "web-1")
+		assert.Contains(t, scrape(t, provider), `pod="web-1"`)
+
+		clock.Advance(2 * time.Minute)
+		assert.NotContains(t, scrape(t, provider), `pod="web-1"`)
+	})
+
+	t.Run("keeps series without a ttl", func(t *testing.T) {
+		provider := newProvider(t)
+
+		counter := provider.Counter("requests_total", applyOptions(WithLabels("method")))
+		assert.Nil(t, counter.(*prometheusCounterVec).children.expiry)
+
+		counter.Inc("GET")
+		assert.Contains(t, scrape(t, provider), `requests_total{method="GET"} 1`)
+	})
+
+	t.Run("is safe during collection", func(t *testing.T) {
+		provider := newProvider(t)
+		counter := provider.Counter("events_total", applyOptions(WithLabels("id"), WithTTL(time.Nanosecond)))
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Go(func() {
+				for range 200 {
+					counter.Inc("a")
+				}
+			})
+		}
+		wg.Go(func() {
+			for range 20 {
+				scrape(t, provider)
+			}
+		})
+		wg.Wait()
+	})
+}
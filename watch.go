@@ -0,0 +1,199 @@
+package metricsx
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gostratum/core/logx"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+)
+
+// DefaultWatchInterval is the evaluation interval used when
+// Config.WatchInterval is zero
+const DefaultWatchInterval = 10 * time.Second
+
+// Sample is the value of one series, as passed to Watch callbacks and sent
+// by RegisterFunc functions
+type Sample struct {
+	// Name is the fully qualified family name in Watch callbacks, and the
+	// name before namespace and subsystem in RegisterFunc
+	Name string
+
+	// Labels are the label names and values of the series
+	Labels map[string]string
+
+	// Value of the counter, gauge or untyped series
+	Value float64
+
+	// Time the value was read; optional in RegisterFunc
+	Time time.Time
+}
+
+// watch is a predicate on the series of one metric
+type watch struct {
+	names     []string
+	predicate func(float64) bool
+	cb        func(Sample)
+
+	// firing holds the series the predicate held for at the last evaluation
+	firing map[string]bool
+}
+
+// watcher evaluates watches against the provider snapshot on every interval
+type watcher struct {
+	source    Snapshotter
+	interval  time.Duration
+	namespace string
+	subsystem string
+	logger    logx.Logger
+	now       func() time.Time
+
+	mu      sync.Mutex
+	watches []*watch
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// newWatcher creates a watcher of the provider; source is nil when the
+// provider cannot snapshot its metrics
+func newWatcher(provider Provider, cfg Config, logger logx.Logger) *watcher {
+	source, _ := provider.(Snapshotter)
+	return &watcher{
+		source:    source,
+		interval:  cmp.Or(cfg.WatchInterval, DefaultWatchInterval),
+		namespace: cfg.Prometheus.Namespace,
+		subsystem: cfg.Prometheus.Subsystem,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Watch calls cb with the sample of every counter, gauge or untyped series of
+// the metric name for which predicate becomes true. The series are read every
+// Config.WatchInterval; cb runs once when the predicate starts holding for a
+// series and again only after it stopped holding at an evaluation. name is
+// the name the metric was created with, or its fully qualified name.
+// Callbacks run on the watcher goroutine and should return quickly. Watches
+// need a provider that supports snapshots, such as prometheus.
+func (m *metricsImpl) Watch(name string, predicate func(float64) bool, cb func(Sample)) {
+	m.watcher.add(name, predicate, cb)
+}
+
+// add registers a watch and starts the evaluation loop on first use
+func (w *watcher) add(name string, predicate func(float64) bool, cb func(Sample)) {
+	if w.source == nil {
+		w.logger.Warn("metrics provider does not support watches", logx.String("metric", name))
+		return
+	}
+
+	names := []string{name}
+	if fqName := prometheus.BuildFQName(w.namespace, w.subsystem, name); fqName != name {
+		names = append(names, fqName)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.watches = append(w.watches, &watch{names: names, predicate: predicate, cb: cb})
+	if w.stop == nil {
+		w.stop, w.done = make(chan struct{}), make(chan struct{})
+		go w.run(w.stop, w.done)
+	}
+}
+
+// run evaluates the watches on every interval until stop is closed
+func (w *watcher) run(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			w.evaluate()
+		}
+	}
+}
+
+// close stops the evaluation loop; watches added afterwards start it again
+func (w *watcher) close() {
+	w.mu.Lock()
+	stop, done := w.stop, w.done
+	w.stop, w.done = nil, nil
+	w.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// evaluate reads the snapshot once and calls the callbacks of the watches
+// whose predicate started holding
+func (w *watcher) evaluate() {
+	families, _ := w.source.Snapshot()
+	now := w.now()
+
+	type firing struct {
+		cb     func(Sample)
+		sample Sample
+	}
+	var fired []firing
+
+	w.mu.Lock()
+	for _, wt := range w.watches {
+		next := make(map[string]bool)
+		for _, f := range families {
+			if !slices.Contains(wt.names, f.Name) || f.Type == "histogram" || f.Type == "summary" {
+				continue
+			}
+			for _, s := range f.Samples {
+				if !wt.predicate(s.Value) {
+					continue
+				}
+				key := historyKey(f.Name, s.Labels)
+				next[key] = true
+				if !wt.firing[key] {
+					fired = append(fired, firing{cb: wt.cb, sample: Sample{Name: f.Name, Labels: s.Labels, Value: s.Value, Time: now}})
+				}
+			}
+		}
+		wt.firing = next
+	}
+	w.mu.Unlock()
+
+	// callbacks run without the lock so they may add watches themselves
+	for _, f := range fired {
+		w.call(f.cb, f.sample)
+	}
+}
+
+// call runs cb, logging instead of crashing the watcher goroutine on panic
+func (w *watcher) call(cb func(Sample), sample Sample) {
+	defer func() {
+		if r := recover(); r != nil {
+			w.logger.Error("metrics watch callback panicked", logx.String("metric", sample.Name), logx.Any("panic", r))
+		}
+	}()
+	cb(sample)
+}
+
+// registerWatches stops evaluating watches when the application stops
+func registerWatches(lc fx.Lifecycle, metrics Metrics) {
+	m, ok := metrics.(*metricsImpl)
+	if !ok {
+		return
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			m.watcher.close()
+			return nil
+		},
+	})
+}
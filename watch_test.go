@@ -0,0 +1,95 @@
+package metricsx
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	newTestWatch := func(t *testing.T, cfg Config) (*metricsImpl, *[]Sample) {
+		t.Helper()
+		cfg.Provider = "prometheus"
+		res, err := NewMetrics(Params{Config: cfg, Logger: getTestLogger()})
+		require.NoError(t, err)
+
+		m := res.Metrics.(*metricsImpl)
+		t.Cleanup(m.watcher.close)
+
+		var fired []Sample
+		m.Watch("queue_depth", func(v float64) bool { return v > 100 }, func(s Sample) {
+			fired = append(fired, s)
+		})
+		return m, &fired
+	}
+
+	t.Run("fires when the predicate starts holding", func(t *testing.T) {
+		m, fired := newTestWatch(t, Config{WatchInterval: time.Hour})
+		queue := m.Gauge("queue_depth", WithLabels("queue"))
+
+		queue.Set(150, "emails")
+		queue.Set(5, "sms")
+		m.watcher.evaluate()
+		m.watcher.evaluate()
+		require.Len(t, *fired, 1)
+		assert.Equal(t, "queue_depth", (*fired)[0].Name)
+		assert.Equal(t, map[string]string{"queue": "emails"}, (*fired)[0].Labels)
+		assert.Equal(t, 150.0, (*fired)[0].Value)
+
+		queue.Set(50, "emails")
+		m.watcher.evaluate()
+		queue.Set(120, "emails")
+		queue.Set(101, "sms")
+		m.watcher.evaluate()
+		assert.Len(t, *fired, 3)
+	})
+
+	t.Run("matches the namespaced name", func(t *testing.T) {
+		m, fired := newTestWatch(t, Config{WatchInterval: time.Hour, Prometheus: PrometheusConfig{Namespace: "app"}})
+		m.Gauge("queue_depth").Set(500)
+
+		m.watcher.evaluate()
+		require.Len(t, *fired, 1)
+		assert.Equal(t, "app_queue_depth", (*fired)[0].Name)
+	})
+
+	t.Run("survives panicking callbacks", func(t *testing.T) {
+		m, fired := newTestWatch(t, Config{WatchInterval: time.Hour})
+		m.Watch("queue_depth", func(float64) bool { return true }, func(Sample) { panic("boom") })
+		m.Gauge("queue_depth").Set(500)
+
+		m.watcher.evaluate()
+		assert.Len(t, *fired, 1)
+	})
+
+	t.Run("evaluates on the interval", func(t *testing.T) {
+		res, err := NewMetrics(Params{Config: Config{Provider: "prometheus", WatchInterval: time.Millisecond}, Logger: getTestLogger()})
+		require.NoError(t, err)
+		m := res.Metrics.(*metricsImpl)
+		t.Cleanup(m.watcher.close)
+
+		var once sync.Once
+		done := make(chan Sample)
+		m.Gauge("temperature_celsius").Set(90)
+		m.Watch("temperature_celsius", func(v float64) bool { return v >= 80 }, func(s Sample) {
+			once.Do(func() { done <- s })
+		})
+
+		select {
+		case s := <-done:
+			assert.Equal(t, 90.0, s.Value)
+		case <-time.After(5 * time.Second):
+			t.Fatal("watch did not fire")
+		}
+	})
+
+	t.Run("is ignored without snapshots", func(t *testing.T) {
+		m := &metricsImpl{provider: newNoopProvider(), logger: getTestLogger()}
+		m.watcher = newWatcher(m.provider, Config{}, m.logger)
+		m.Watch("queue_depth", func(float64) bool { return true }, func(Sample) {})
+		assert.Empty(t, m.watcher.watches)
+	})
+}
@@ -0,0 +1,104 @@
+package metricsx
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultWindowBuckets is the number of buckets a WindowedCounter divides its
+// window into when none are given
+const DefaultWindowBuckets = 60
+
+// WindowedCounter counts events over a rolling window, e.g. the last 5
+// minutes, for components that decide locally on recent rates such as
+// throttles and circuit breakers. It is not exported by itself; register
+// Sum or Rate with GaugeFunc to expose it.
+//
+// The window is split into buckets that expire whole, so Sum covers between
+// window - window/buckets and window of history.
+type WindowedCounter struct {
+	mu      sync.Mutex
+	width   time.Duration
+	buckets []windowBucket
+	now     func() time.Time
+}
+
+// windowBucket holds the count of one slot of the window
+type windowBucket struct {
+	slot  int64
+	value float64
+}
+
+// NewWindowedCounter creates a counter over the given window split into
+// buckets, DefaultWindowBuckets when buckets is not positive
+func NewWindowedCounter(window time.Duration, buckets int) *WindowedCounter {
+	if buckets <= 0 {
+		buckets = DefaultWindowBuckets
+	}
+	width := max(window/time.Duration(buckets), 1)
+	return &WindowedCounter{
+		width:   width,
+		buckets: make([]windowBucket, buckets),
+		now:     time.Now,
+	}
+}
+
+// Window returns the length of the window
+func (c *WindowedCounter) Window() time.Duration {
+	return c.width * time.Duration(len(c.buckets))
+}
+
+// Inc increments the counter by 1
+func (c *WindowedCounter) Inc() {
+	c.Add(1)
+}
+
+// Add increments the counter by value. It panics if value is negative.
+func (c *WindowedCounter) Add(value float64) {
+	if value < 0 {
+		panic("counter cannot decrease in value")
+	}
+
+	slot := c.slot()
+	c.mu.Lock()
+	b := &c.buckets[slot%int64(len(c.buckets))]
+	if b.slot != slot {
+		b.slot, b.value = slot, 0
+	}
+	b.value += value
+	c.mu.Unlock()
+}
+
+// Sum returns the count within the window
+func (c *WindowedCounter) Sum() float64 {
+	slot := c.slot()
+	oldest := slot - int64(len(c.buckets))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var sum float64
+	for _, b := range c.buckets {
+		if b.slot > oldest && b.slot <= slot {
+			sum += b.value
+		}
+	}
+	return sum
+}
+
+// Rate returns the per-second rate within the window
+func (c *WindowedCounter) Rate() float64 {
+	return c.Sum() / c.Window().Seconds()
+}
+
+// Reset clears the window
+func (c *WindowedCounter) Reset() {
+	c.mu.Lock()
+	clear(c.buckets)
+	c.mu.Unlock()
+}
+
+// slot returns the index of the bucket period containing the current time
+func (c *WindowedCounter) slot() int64 {
+	return c.now().UnixNano() / int64(c.width)
+}
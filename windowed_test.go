@@ -0,0 +1,99 @@
+package metricsx
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a manually advanced time source
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func newTestWindowedCounter(window time.Duration, buckets int) (*WindowedCounter, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	c := NewWindowedCounter(window, buckets)
+	c.now = clock.Now
+	return c, clock
+}
+
+func TestWindowedCounter(t *testing.T) {
+	t.Run("counts events within the window", func(t *testing.T) {
+		c, clock := newTestWindowedCounter(time.Minute, 6)
+
+		c.Inc()
+		c.Add(2)
+		clock.Advance(30 * time.Second)
+		c.Inc()
+
+		assert.Equal(t, 4.0, c.Sum())
+		assert.InDelta(t, 4.0/60, c.Rate(), 1e-9)
+	})
+
+	t.Run("expires old buckets", func(t *testing.T) {
+		c, clock := newTestWindowedCounter(time.Minute, 6)
+
+		c.Add(5)
+		clock.Advance(30 * time.Second)
+		c.Add(3)
+		clock.Advance(35 * time.Second)
+		assert.Equal(t, 3.0, c.Sum())
+
+		clock.Advance(time.Minute)
+		assert.Zero(t, c.Sum())
+	})
+
+	t.Run("reuses buckets after the window wraps", func(t *testing.T) {
+		c, clock := newTestWindowedCounter(time.Minute, 6)
+
+		c.Add(5)
+		clock.Advance(time.Minute)
+		c.Inc()
+		assert.Equal(t, 1.0, c.Sum())
+	})
+
+	t.Run("defaults the number of buckets", func(t *testing.T) {
+		c := NewWindowedCounter(5*time.Minute, 0)
+		assert.Len(t, c.buckets, DefaultWindowBuckets)
+		assert.Equal(t, 5*time.Minute, c.Window())
+	})
+
+	t.Run("resets and rejects negative increments", func(t *testing.T) {
+		c, _ := newTestWindowedCounter(time.Minute, 6)
+		c.Add(3)
+		c.Reset()
+		assert.Zero(t, c.Sum())
+		assert.Panics(t, func() { c.Add(-1) })
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		c := NewWindowedCounter(time.Hour, 10)
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				for range 1000 {
+					c.Inc()
+				}
+			})
+		}
+		wg.Wait()
+		assert.Equal(t, 8000.0, c.Sum())
+	})
+}